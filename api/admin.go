@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/loadshed"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// resyncTimeout longest time a resync requested by an admin runs
+const resyncTimeout = 2 * time.Minute
+
+// maxArchiveDelay longest time archive building waits while CPU load is shed,
+// before it starts and between batches of rounds
+const maxArchiveDelay = time.Minute
+
+// errArchiveOverloaded archive was shed under CPU load
+var errArchiveOverloaded = errors.New("node is under CPU load and does not build archives, retry later")
+
+// Relayer relayer that admins can pause
+type Relayer interface {
+	Pause()
+	Resume()
+	Paused() bool
+}
+
+// AdminOps operations of node that admins run at /admin, an operation that is
+// nil answers 404
+type AdminOps struct {
+	// Config effective config of node, secrets must be left out
+	Config func() map[string]interface{}
+	// Resync fetch missing rounds from peers, return the latest round in store
+	Resync func(ctx context.Context) (uint64, error)
+	// RotateLogs start a new log file
+	RotateLogs  func() error
+	BanPeer     func(id peer.ID, duration time.Duration) error
+	UnbanPeer   func(id peer.ID) error
+	BannedPeers func() []network.Ban
+	Relayer     Relayer
+	// Archive write stored rounds from round from to round to into an
+	// archive, calling pace between batches of rounds
+	Archive func(w io.Writer, from uint64, to uint64, pace func() error) (int, error)
+}
+
+// AdminPeers connected and banned peers of node
+type AdminPeers struct {
+	Connected []network.PeerInfo `json:"connected"`
+	Banned    []network.Ban      `json:"banned"`
+}
+
+// BanRequest JSON body that bans a peer
+type BanRequest struct {
+	// Duration seconds the ban lasts, 0 bans peer until node restarts
+	Duration uint64 `json:"duration"`
+}
+
+// SetAdminToken serve management endpoints under /admin to requests bearing
+// token, they are disabled if it's empty. It must be called before Start
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// SetAdmin serve operations of node under /admin, it must be called before
+// Start
+func (s *Server) SetAdmin(ops *AdminOps) {
+	s.ops = ops
+}
+
+// SetAdminAddress serve /admin on its own listener instead of the API
+// listener, e.g. on a loopback or private address. It must be called before
+// Start
+func (s *Server) SetAdminAddress(bindHost string, bindPort uint) {
+	s.adminServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", bindHost, bindPort),
+		Handler: s.authorized(routesAdmin, s.serveAdmin),
+	}
+}
+
+// startAdmin listen on admin address if it's set
+func (s *Server) startAdmin() error {
+	if s.adminServer == nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.adminServer.Addr)
+	if err != nil {
+		return err
+	}
+	s.configure(s.adminServer, false)
+	log.Infof("Admin API listen on: %s, TLS: %v", listener.Addr(), s.tls != nil)
+	go func() {
+		if err := serve(s.adminServer, listener, s.tls); err != nil && err != http.ErrServerClosed {
+			log.Errorf("Admin API stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// handleAdmin serve /admin on API listener unless it has its own address or
+// unix socket
+func (s *Server) handleAdmin(w http.ResponseWriter, r *http.Request) {
+	if s.adminServer != nil || s.unix.Admin != "" {
+		http.NotFound(w, r)
+		return
+	}
+	s.serveAdmin(w, r)
+}
+
+// admin serve handler only to requests bearing admin token
+func (s *Server) admin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			WriteError(w, http.StatusNotFound, errors.New("admin API is disabled"))
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			log.Warnf("Admin request %s %s from %s with invalid token", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			WriteError(w, http.StatusUnauthorized, errors.New("invalid admin token"))
+			return
+		}
+		if r.Method != http.MethodGet {
+			log.Infof("Admin request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		}
+		handler(w, r)
+	}
+}
+
+// operation check method of request and that node runs operation, write an
+// error and return false otherwise
+func (s *Server) operation(w http.ResponseWriter, r *http.Request, method string, available bool) bool {
+	if r.Method != method {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return false
+	}
+	if s.ops == nil || !available {
+		WriteError(w, http.StatusNotFound, errors.New("node does not run this operation"))
+		return false
+	}
+	return true
+}
+
+// handleConfig dump effective config of node
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodGet, s.ops != nil && s.ops.Config != nil) {
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.ops.Config())
+}
+
+// handleResync fetch missing rounds from peers and answer once it's done
+func (s *Server) handleResync(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodPost, s.ops != nil && s.ops.Resync != nil) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), resyncTimeout)
+	defer cancel()
+	latest, err := s.ops.Resync(ctx)
+	if err != nil {
+		WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]uint64{"latest": latest})
+}
+
+// handleArchive stream an archive of stored rounds, it's deferred while CPU
+// load is shed and cut off if load stays high
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodGet, s.ops != nil && s.ops.Archive != nil) {
+		return
+	}
+	query := r.URL.Query()
+	from, to := uint64(1), uint64(0)
+	var err error
+	if value := query.Get("from"); value != "" {
+		if from, err = strconv.ParseUint(value, 10, 64); err != nil || from == 0 {
+			WriteError(w, http.StatusBadRequest, errors.New("invalid from round"))
+			return
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = strconv.ParseUint(value, 10, 64); err != nil {
+			WriteError(w, http.StatusBadRequest, errors.New("invalid to round"))
+			return
+		}
+	}
+	if to == 0 {
+		latest, err := s.latest()
+		if err != nil {
+			writeRoundError(w, err)
+			return
+		}
+		to = latest.Round
+	}
+	if from > to {
+		WriteError(w, http.StatusBadRequest, errors.New("first round is after last round"))
+		return
+	}
+	if !s.shedder.Wait(r.Context(), loadshed.WorkArchive, maxArchiveDelay) {
+		w.Header().Set("Retry-After", strconv.Itoa(overloadedRetryAfter))
+		WriteError(w, http.StatusServiceUnavailable, errArchiveOverloaded)
+		return
+	}
+	pace := func() error {
+		if !s.shedder.Wait(r.Context(), loadshed.WorkArchive, maxArchiveDelay) {
+			return errArchiveOverloaded
+		}
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	count, err := s.ops.Archive(w, from, to, pace)
+	if err != nil {
+		log.Warnf("Archive of rounds %d to %d stopped after %d rounds: %v", from, to, count, err)
+		// Abort response so client sees a truncated archive as an error
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// handleRotateLogs start a new log file
+func (s *Server) handleRotateLogs(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodPost, s.ops != nil && s.ops.RotateLogs != nil) {
+		return
+	}
+	if err := s.ops.RotateLogs(); err != nil {
+		WriteError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminPeers list connected and banned peers
+func (s *Server) handleAdminPeers(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodGet, s.ops != nil && s.ops.BannedPeers != nil) {
+		return
+	}
+	peers := &AdminPeers{Connected: []network.PeerInfo{}, Banned: s.ops.BannedPeers()}
+	if s.peers != nil {
+		peers.Connected = s.peers()
+	}
+	WriteJSON(w, http.StatusOK, peers)
+}
+
+// handleBan ban peer at /admin/peers/<id>/ban with POST and unban it with
+// DELETE
+func (s *Server) handleBan(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/admin/peers/")
+	if !strings.HasSuffix(rest, "/ban") {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := peer.Decode(strings.TrimSuffix(rest, "/ban"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, errors.New("invalid peer ID"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if !s.operation(w, r, http.MethodPost, s.ops != nil && s.ops.BanPeer != nil) {
+			return
+		}
+		body := new(BanRequest)
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(body); err != nil {
+				WriteError(w, http.StatusBadRequest, errors.New("invalid request body"))
+				return
+			}
+		}
+		if err := s.ops.BanPeer(id, time.Duration(body.Duration)*time.Second); err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if !s.operation(w, r, http.MethodDelete, s.ops != nil && s.ops.UnbanPeer != nil) {
+			return
+		}
+		if err := s.ops.UnbanPeer(id); err == network.ErrNotBanned {
+			WriteError(w, http.StatusNotFound, err)
+			return
+		} else if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// handleRelayer show whether relayer is paused
+func (s *Server) handleRelayer(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodGet, s.ops != nil && s.ops.Relayer != nil) {
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]bool{"paused": s.ops.Relayer.Paused()})
+}
+
+// handlePauseRelayer stop relaying rounds and calls until relayer is resumed
+func (s *Server) handlePauseRelayer(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodPost, s.ops != nil && s.ops.Relayer != nil) {
+		return
+	}
+	s.ops.Relayer.Pause()
+	WriteJSON(w, http.StatusOK, map[string]bool{"paused": true})
+}
+
+// handleResumeRelayer relay rounds and calls again
+func (s *Server) handleResumeRelayer(w http.ResponseWriter, r *http.Request) {
+	if !s.operation(w, r, http.MethodPost, s.ops != nil && s.ops.Relayer != nil) {
+		return
+	}
+	s.ops.Relayer.Resume()
+	WriteJSON(w, http.StatusOK, map[string]bool{"paused": false})
+}
@@ -0,0 +1,484 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/loadshed"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/orochi-network/orochimaru/store"
+	"github.com/orochi-network/orochimaru/vrf"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Source provide rounds served by API
+type Source interface {
+	// Latest get latest round
+	Latest() (*beacon.RoundResult, error)
+	// Get round by its number
+	Get(round uint64) (*beacon.RoundResult, error)
+}
+
+// Round JSON representation of a round
+type Round struct {
+	Round             uint64 `json:"round"`
+	Randomness        string `json:"randomness"`
+	Signature         string `json:"signature"`
+	PreviousSignature string `json:"previousSignature,omitempty"`
+}
+
+// Info JSON representation of chain info
+type Info struct {
+	PublicKey   string `json:"publicKey,omitempty"`
+	Period      uint64 `json:"period"`
+	GenesisTime int64  `json:"genesisTime"`
+	Mode        string `json:"mode"`
+	Scheme      string `json:"scheme"`
+	Hash        string `json:"hash,omitempty"`
+}
+
+// DefaultRoundsCount number of rounds returned by /public when count is not given
+const DefaultRoundsCount = 100
+
+// MaxRoundsCount largest number of rounds returned by /public at once
+const MaxRoundsCount = 1000
+
+// Server HTTP API server
+type Server struct {
+	BindHost string
+	BindPort uint
+	source   Source
+	drng     *beacon.Beacon
+	server   *http.Server
+	mux      *http.ServeMux
+	hub      *hub
+	checks   []ReadinessCheck
+	// beacons other beacons of node by id, served under /beacons/<id>/
+	beacons   map[string]*Server
+	dkgStatus DKGStatus
+	peers     PeerList
+	resources ResourceUsage
+	requests  *vrf.Service
+	// keys API keys that randomness endpoints are metered by, nil if they are
+	// not metered
+	keys       *apiKeys
+	adminToken string
+	// adminMux endpoints under /admin, served on adminServer if it's set and
+	// on API listener otherwise
+	adminMux    *http.ServeMux
+	adminServer *http.Server
+	ops         *AdminOps
+	// tls config of HTTPS, API is served over plain HTTP if it's nil
+	tls     *tls.Config
+	auth    *auth
+	options HTTPOptions
+	// cache latest and recent rounds with their encoded responses
+	cache *roundCache
+	// grpcService RandomnessService, served on grpcAddr if it's set
+	grpcService *grpcService
+	grpcAddr    string
+	grpcServer  *grpc.Server
+	// syncProgress last sync progress, shown by dashboard
+	syncProgress syncStatus
+	// unix sockets that API, admin endpoints and gRPC are served on
+	unix           UnixSockets
+	unixServers    []*http.Server
+	grpcUnixServer *grpc.Server
+	// shedder history queries are refused while it sheds load, nil if they
+	// are never refused
+	shedder *loadshed.Shedder
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("api")
+	metrics.Registry.MustRegister(keyRequests, roundCacheRequests)
+}
+
+// New create API server serving rounds of given source and chain info of given beacon
+func New(bindHost string, bindPort uint, source Source, drng *beacon.Beacon) *Server {
+	s := &Server{
+		BindHost: bindHost,
+		BindPort: bindPort,
+		source:   source,
+		drng:     drng,
+		mux:      http.NewServeMux(),
+		hub:      newHub(),
+		beacons:  make(map[string]*Server),
+		adminMux: http.NewServeMux(),
+		cache:    new(roundCache),
+	}
+	s.grpcService = &grpcService{server: s, streams: make(map[chan *beacon.RoundResult]bool)}
+	s.mux.HandleFunc("/public", s.authorized(RoutesPublic, s.metered(s.handleRounds)))
+	s.mux.HandleFunc("/public/latest", s.authorized(RoutesPublic, s.metered(s.handleLatest)))
+	s.mux.HandleFunc("/public/", s.authorized(RoutesPublic, s.metered(s.handleRound)))
+	s.mux.HandleFunc("/info", s.authorized(RoutesPublic, s.handleInfo))
+	s.mux.HandleFunc("/stats", s.authorized(RoutesStatus, s.handleStats))
+	s.mux.HandleFunc("/participation", s.authorized(RoutesStatus, s.handleParticipation))
+	s.mux.HandleFunc("/ws", s.authorized(RoutesPublic, s.metered(s.handleWebSocket)))
+	s.mux.HandleFunc("/rpc", s.authorized(RoutesPublic, s.metered(s.handleRPC)))
+	s.mux.HandleFunc("/healthz", s.authorized(routesHealth, s.handleHealth))
+	s.mux.HandleFunc("/readyz", s.authorized(routesHealth, s.handleReady))
+	s.mux.HandleFunc("/beacons", s.authorized(RoutesPublic, s.handleBeacons))
+	s.mux.HandleFunc("/dkg", s.authorized(RoutesStatus, s.handleDKG))
+	s.mux.HandleFunc("/peers", s.authorized(RoutesStatus, s.handlePeers))
+	s.mux.HandleFunc("/resources", s.authorized(RoutesStatus, s.handleResources))
+	s.mux.HandleFunc("/vrf", s.authorized(RoutesPublic, s.metered(s.handleVRFRequest)))
+	s.mux.HandleFunc("/vrf/", s.authorized(RoutesPublic, s.metered(s.handleVRFOutput)))
+	s.mux.HandleFunc("/admin/", s.authorized(routesAdmin, s.handleAdmin))
+	s.mux.Handle("/metrics", s.authorized(RoutesStatus, metrics.Handler().ServeHTTP))
+	s.adminMux.HandleFunc("/admin/apikeys", s.handleAPIKeys)
+	s.adminMux.HandleFunc("/admin/apikeys/", s.handleAPIKey)
+	s.adminMux.HandleFunc("/admin/config", s.handleConfig)
+	s.adminMux.HandleFunc("/admin/resync", s.handleResync)
+	s.adminMux.HandleFunc("/admin/archive", s.handleArchive)
+	s.adminMux.HandleFunc("/admin/logs/rotate", s.handleRotateLogs)
+	s.adminMux.HandleFunc("/admin/peers", s.handleAdminPeers)
+	s.adminMux.HandleFunc("/admin/peers/", s.handleBan)
+	s.adminMux.HandleFunc("/admin/relayer", s.handleRelayer)
+	s.adminMux.HandleFunc("/admin/relayer/pause", s.handlePauseRelayer)
+	s.adminMux.HandleFunc("/admin/relayer/resume", s.handleResumeRelayer)
+	s.adminMux.HandleFunc(dashboardStatePath, s.handleDashboardState)
+	s.server = &http.Server{Handler: s.mux}
+	return s
+}
+
+// Start listen and serve in background, API is only served on its unix
+// socket if BindPort is 0 and a socket is set
+func (s *Server) Start() error {
+	var listener net.Listener
+	if s.BindPort > 0 || s.unix.API == "" {
+		var err error
+		listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", s.BindHost, s.BindPort))
+		if err != nil {
+			return err
+		}
+	}
+	// fail close listeners started before err, at once
+	fail := func(err error) error {
+		if listener != nil {
+			listener.Close()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		s.Stop(ctx)
+		return err
+	}
+	s.configure(s.server, true)
+	if err := s.startAdmin(); err != nil {
+		return fail(err)
+	}
+	if err := s.startGRPC(); err != nil {
+		return fail(err)
+	}
+	if err := s.startUnix(); err != nil {
+		return fail(err)
+	}
+	if s.keys != nil {
+		s.keys.run()
+	}
+	if listener == nil {
+		return nil
+	}
+	log.Infof("API server listen on: %s, TLS: %v", listener.Addr(), s.tls != nil)
+	go func() {
+		if err := serve(s.server, listener, s.tls); err != nil && err != http.ErrServerClosed {
+			log.Errorf("API server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// serve accept connections of listener, over TLS if config is set
+func serve(server *http.Server, listener net.Listener, config *tls.Config) error {
+	if config == nil {
+		return server.Serve(listener)
+	}
+	server.TLSConfig = config
+	return server.ServeTLS(listener, "", "")
+}
+
+// Handler HTTP handler of API, useful to mount API into another server
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Stop server gracefully, usage of API keys is stored
+func (s *Server) Stop(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+	if s.adminServer != nil {
+		if adminErr := s.adminServer.Shutdown(ctx); err == nil {
+			err = adminErr
+		}
+	}
+	for _, server := range s.unixServers {
+		if unixErr := server.Shutdown(ctx); err == nil {
+			err = unixErr
+		}
+	}
+	s.stopGRPC(ctx)
+	if s.keys != nil {
+		s.keys.close()
+	}
+	return err
+}
+
+// NewRound JSON representation of given round result
+func NewRound(result *beacon.RoundResult) *Round {
+	return &Round{
+		Round:             result.Round,
+		Randomness:        hex.EncodeToString(result.Randomness),
+		Signature:         hex.EncodeToString(result.Signature),
+		PreviousSignature: hex.EncodeToString(result.PreviousSignature),
+	}
+}
+
+// Result decode hex fields of round
+func (r *Round) Result() (*beacon.RoundResult, error) {
+	randomness, err := hex.DecodeString(r.Randomness)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return nil, err
+	}
+	previousSignature, err := hex.DecodeString(r.PreviousSignature)
+	if err != nil {
+		return nil, err
+	}
+	return &beacon.RoundResult{
+		Round:             r.Round,
+		Randomness:        randomness,
+		Signature:         signature,
+		PreviousSignature: previousSignature,
+	}, nil
+}
+
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	s.writeLatest(w, r)
+}
+
+func (s *Server) handleRound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	round, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/public/"), 10, 64)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, errors.New("invalid round number"))
+		return
+	}
+	// Round 0 is an alias of latest round
+	if round == 0 {
+		s.writeLatest(w, r)
+		return
+	}
+	cached, err := s.round(round)
+	if err != nil {
+		writeRoundError(w, err)
+		return
+	}
+	s.writeCached(w, r, cached.body, cached.etag, ImmutableMaxAge)
+}
+
+// writeLatest write latest round, clients may cache it until the next round
+// is due
+func (s *Server) writeLatest(w http.ResponseWriter, r *http.Request) {
+	latest, err := s.latestRound()
+	if err != nil {
+		writeRoundError(w, err)
+		return
+	}
+	s.writeCached(w, r, latest.body, latest.etag, s.latestMaxAge(latest.result.Round))
+}
+
+// handleRounds serve up to count sequential rounds from start, rounds that
+// were skipped are left out and the list stops at latest round
+func (s *Server) handleRounds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	query := r.URL.Query()
+	start, err := strconv.ParseUint(query.Get("start"), 10, 64)
+	if err != nil || start == 0 {
+		WriteError(w, http.StatusBadRequest, errors.New("invalid start round"))
+		return
+	}
+	count := uint64(DefaultRoundsCount)
+	if value := query.Get("count"); value != "" {
+		if count, err = strconv.ParseUint(value, 10, 64); err != nil || count == 0 || count > MaxRoundsCount {
+			WriteError(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", MaxRoundsCount))
+			return
+		}
+	}
+	rounds, latest, err := s.rounds(start, count)
+	if err != nil {
+		writeRoundError(w, err)
+		return
+	}
+	body, err := json.Marshal(rounds)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	body = append(body, '\n')
+	sum := sha256.Sum256(body)
+	// Missing rounds may still be synced, so lists are not immutable
+	s.writeCached(w, r, body, `"`+hex.EncodeToString(sum[:16])+`"`, s.latestMaxAge(latest))
+}
+
+// rounds get up to count stored rounds from start and the latest round
+func (s *Server) rounds(start uint64, count uint64) ([]*Round, uint64, error) {
+	rounds := make([]*Round, 0)
+	latest, err := s.latest()
+	if err == store.ErrNotFound {
+		return rounds, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if !s.shedder.Allow(loadshed.WorkHistory) {
+		return nil, 0, errOverloaded
+	}
+	end := start + count - 1
+	if end > latest.Round || end < start {
+		end = latest.Round
+	}
+	for round := start; round <= end && round >= start; round++ {
+		result, err := s.source.Get(round)
+		if err == store.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		rounds = append(rounds, NewRound(result))
+	}
+	return rounds, latest.Round, nil
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.info())
+}
+
+// info JSON representation of chain info of beacon
+func (s *Server) info() *Info {
+	chainInfo := s.drng.ChainInfo()
+	info := &Info{
+		PublicKey:   hex.EncodeToString(chainInfo.PublicKey),
+		Period:      uint64(chainInfo.Period.Seconds()),
+		GenesisTime: chainInfo.GenesisTime.Unix(),
+		Mode:        chainInfo.Mode,
+		Scheme:      chainInfo.Scheme,
+	}
+	if chainInfo.PublicKey != nil {
+		info.Hash = hex.EncodeToString(chainInfo.Hash())
+	}
+	return info
+}
+
+// ChainInfo decode chain info, hash is not trusted and must be recomputed.
+// Nodes that don't report a scheme sign with BLS
+func (i *Info) ChainInfo() (beacon.ChainInfo, error) {
+	publicKey, err := hex.DecodeString(i.PublicKey)
+	if err != nil {
+		return beacon.ChainInfo{}, err
+	}
+	scheme := i.Scheme
+	if scheme == "" {
+		scheme = keypair.SchemeBLS
+	}
+	return beacon.ChainInfo{
+		PublicKey:   publicKey,
+		Period:      time.Duration(i.Period) * time.Second,
+		GenesisTime: time.Unix(i.GenesisTime, 0),
+		Mode:        i.Mode,
+		Scheme:      scheme,
+	}, nil
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	stats := s.drng.Stats()
+	WriteJSON(w, http.StatusOK, &stats)
+}
+
+// handleParticipation list heartbeats and missed rounds of each member
+func (s *Server) handleParticipation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.drng.Scoreboard())
+}
+
+// errOverloaded history query was shed under CPU load
+var errOverloaded = errors.New("node is under CPU load and does not serve history queries, retry later")
+
+// overloadedRetryAfter seconds clients wait before retrying a shed query
+const overloadedRetryAfter = 5
+
+// SetShedder refuse history queries, reads of rounds that are not cached,
+// while shedder sheds load. Latest and recent rounds are always served. It
+// must be called before Start
+func (s *Server) SetShedder(shedder *loadshed.Shedder) {
+	s.shedder = shedder
+	for _, sub := range s.beacons {
+		sub.shedder = shedder
+	}
+}
+
+func writeRoundError(w http.ResponseWriter, err error) {
+	if err == store.ErrNotFound {
+		WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err == errOverloaded {
+		w.Header().Set("Retry-After", strconv.Itoa(overloadedRetryAfter))
+		WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	WriteError(w, http.StatusInternalServerError, err)
+}
+
+// WriteJSON write v as JSON response with status
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debugf("Unable to write response: %v", err)
+	}
+}
+
+// WriteError write JSON response with status and error message
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, map[string]string{"error": err.Error()})
+}
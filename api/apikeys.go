@@ -0,0 +1,490 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/orochi-network/orochimaru/store"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// APIKeyHeader header carrying API key of a consumer, keys are also accepted
+// in the api_key query parameter for clients that cannot set headers
+const APIKeyHeader = "X-API-Key"
+
+// keyCacheTTL time a key is served from memory before it's read again, so
+// changes made by nodes sharing a database apply
+const keyCacheTTL = 30 * time.Second
+
+// usageFlushInterval interval that usage counted in memory is added to store at
+const usageFlushInterval = 10 * time.Second
+
+// maxKeyName longest name of a key
+const maxKeyName = 64
+
+// Outcomes of requests made with a key
+const (
+	keyServed         = "served"
+	keyRateLimited    = "rate_limited"
+	keyQuotaExhausted = "quota_exhausted"
+)
+
+var (
+	errInvalidKey  = errors.New("invalid API key")
+	errKeyRequired = errors.New("API key required, send it in the " + APIKeyHeader + " header")
+	errKeyDisabled = errors.New("API key is disabled")
+	errRateLimited = errors.New("API key exceeds its rate limit")
+	errQuota       = errors.New("API key exhausted its quota")
+)
+
+var keyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "api",
+	Name:      "key_requests_total",
+	Help:      "Requests made with API keys, by key ID and outcome",
+}, []string{"key", "outcome"})
+
+// APIKeyInfo JSON representation of an API key, requests include usage not
+// added to store yet
+type APIKeyInfo struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Rate     float64    `json:"rate"`
+	Burst    int        `json:"burst"`
+	Quota    uint64     `json:"quota"`
+	Requests uint64     `json:"requests"`
+	Rejected uint64     `json:"rejected"`
+	Disabled bool       `json:"disabled"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+	// Key secret key of consumer, only returned when key is created
+	Key string `json:"key,omitempty"`
+}
+
+// APIKeyRequest JSON body that creates or changes an API key, fields that
+// are not set are left unchanged
+type APIKeyRequest struct {
+	Name     *string  `json:"name"`
+	Rate     *float64 `json:"rate"`
+	Burst    *int     `json:"burst"`
+	Quota    *uint64  `json:"quota"`
+	Disabled *bool    `json:"disabled"`
+	// ResetUsage set counters of key to zero, e.g. when a billing period starts
+	ResetUsage bool `json:"resetUsage"`
+}
+
+// keyState key as last read from store with its token bucket and usage that
+// is not added to store yet
+type keyState struct {
+	key    *store.APIKey
+	loaded time.Time
+	tokens float64
+	last   time.Time
+	usage  store.Usage
+}
+
+// apiKeys check API keys of requests against their limits and count usage
+type apiKeys struct {
+	store    store.KeyStore
+	required bool
+	states   map[string]*keyState
+	mutex    sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// SetAPIKeys meter randomness endpoints by API keys kept in keys, requests
+// without a key are refused when required is set. It must be called before
+// Start
+func (s *Server) SetAPIKeys(keys store.KeyStore, required bool) {
+	s.keys = &apiKeys{store: keys, required: required, states: make(map[string]*keyState)}
+	for _, sub := range s.beacons {
+		sub.keys = s.keys
+	}
+}
+
+// metered serve handler only to requests within limits of their API key
+func (s *Server) metered(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.keys == nil {
+			handler(w, r)
+			return
+		}
+		raw := r.Header.Get(APIKeyHeader)
+		if raw == "" {
+			raw = r.URL.Query().Get("api_key")
+		}
+		if raw == "" {
+			if s.keys.required {
+				WriteError(w, http.StatusUnauthorized, errKeyRequired)
+				return
+			}
+			handler(w, r)
+			return
+		}
+		status, retry, err := s.keys.allow(raw, time.Now())
+		if err != nil {
+			if retry > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			}
+			WriteError(w, status, err)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// allow check key of a request and count it, return HTTP status and wait
+// before a retry may succeed if request is refused
+func (k *apiKeys) allow(raw string, now time.Time) (int, time.Duration, error) {
+	id, secret, ok := splitKey(raw)
+	if !ok {
+		return http.StatusUnauthorized, 0, errInvalidKey
+	}
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	state, err := k.state(id, now)
+	if err == store.ErrKeyNotFound {
+		return http.StatusUnauthorized, 0, errInvalidKey
+	}
+	if err != nil {
+		log.Errorf("Unable to read API key %s: %v", id, err)
+		return http.StatusServiceUnavailable, 0, errors.New("API keys are unavailable")
+	}
+	hash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(hash[:], state.key.SecretHash) != 1 {
+		return http.StatusUnauthorized, 0, errInvalidKey
+	}
+	if state.key.Disabled {
+		return http.StatusForbidden, 0, errKeyDisabled
+	}
+	if state.key.Quota > 0 && state.key.Requests+state.usage.Requests >= state.key.Quota {
+		state.usage.Rejected++
+		keyRequests.WithLabelValues(id, keyQuotaExhausted).Inc()
+		return http.StatusTooManyRequests, 0, errQuota
+	}
+	if rate := state.key.Rate; rate > 0 {
+		burst := float64(state.key.Burst)
+		state.tokens = math.Min(burst, state.tokens+now.Sub(state.last).Seconds()*rate)
+		state.last = now
+		if state.tokens < 1 {
+			state.usage.Rejected++
+			keyRequests.WithLabelValues(id, keyRateLimited).Inc()
+			return http.StatusTooManyRequests, time.Duration((1 - state.tokens) / rate * float64(time.Second)), errRateLimited
+		}
+		state.tokens--
+	}
+	state.usage.Requests++
+	state.usage.LastUsed = now
+	keyRequests.WithLabelValues(id, keyServed).Inc()
+	return http.StatusOK, 0, nil
+}
+
+// state key of ID read from store unless it was read recently, caller must
+// hold mutex
+func (k *apiKeys) state(id string, now time.Time) (*keyState, error) {
+	state, ok := k.states[id]
+	if ok && now.Sub(state.loaded) < keyCacheTTL {
+		return state, nil
+	}
+	key, err := k.store.GetKey(id)
+	if err == store.ErrKeyNotFound && ok {
+		delete(k.states, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		state = &keyState{tokens: float64(key.Burst), last: now}
+		k.states[id] = state
+	}
+	state.key = key
+	state.loaded = now
+	return state, nil
+}
+
+// forget drop key from memory, so it's read again on next use. Its usage is
+// added to store first
+func (k *apiKeys) forget(id string) {
+	k.flush()
+	k.mutex.Lock()
+	delete(k.states, id)
+	k.mutex.Unlock()
+}
+
+// flush add usage counted in memory to store, usage is kept for the next
+// flush if store fails
+func (k *apiKeys) flush() {
+	k.mutex.Lock()
+	usage := make(map[string]store.Usage)
+	for id, state := range k.states {
+		if state.usage.Requests > 0 || state.usage.Rejected > 0 {
+			usage[id] = state.usage
+			state.usage = store.Usage{}
+		}
+	}
+	k.mutex.Unlock()
+	if len(usage) == 0 {
+		return
+	}
+	err := k.store.AddUsage(usage)
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	for id, u := range usage {
+		state, ok := k.states[id]
+		if !ok {
+			continue
+		}
+		if err != nil {
+			state.usage.Requests += u.Requests
+			state.usage.Rejected += u.Rejected
+			if u.LastUsed.After(state.usage.LastUsed) {
+				state.usage.LastUsed = u.LastUsed
+			}
+			continue
+		}
+		state.key.Requests += u.Requests
+		state.key.Rejected += u.Rejected
+		if u.LastUsed.After(state.key.LastUsed) {
+			state.key.LastUsed = u.LastUsed
+		}
+	}
+	if err != nil {
+		log.Warnf("Unable to store usage of API keys: %v", err)
+	}
+}
+
+// run flush usage every usageFlushInterval until keys are stopped
+func (k *apiKeys) run() {
+	k.stop = make(chan struct{})
+	k.done = make(chan struct{})
+	go func() {
+		defer close(k.done)
+		ticker := time.NewTicker(usageFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				k.flush()
+			case <-k.stop:
+				k.flush()
+				return
+			}
+		}
+	}()
+}
+
+// close stop flushing, usage left is added to store
+func (k *apiKeys) close() {
+	if k.stop != nil {
+		close(k.stop)
+		<-k.done
+	}
+}
+
+// info JSON representation of key with usage counted in memory
+func (k *apiKeys) info(key *store.APIKey) *APIKeyInfo {
+	info := &APIKeyInfo{
+		ID:       key.ID,
+		Name:     key.Name,
+		Rate:     key.Rate,
+		Burst:    key.Burst,
+		Quota:    key.Quota,
+		Requests: key.Requests,
+		Rejected: key.Rejected,
+		Disabled: key.Disabled,
+		Created:  key.Created,
+	}
+	lastUsed := key.LastUsed
+	k.mutex.Lock()
+	if state, ok := k.states[key.ID]; ok {
+		info.Requests += state.usage.Requests
+		info.Rejected += state.usage.Rejected
+		if state.usage.LastUsed.After(lastUsed) {
+			lastUsed = state.usage.LastUsed
+		}
+	}
+	k.mutex.Unlock()
+	if !lastUsed.IsZero() {
+		info.LastUsed = &lastUsed
+	}
+	return info
+}
+
+// splitKey split a key of form <id>.<secret>
+func splitKey(raw string) (string, string, bool) {
+	i := strings.IndexByte(raw, '.')
+	if i <= 0 || i == len(raw)-1 {
+		return "", "", false
+	}
+	return raw[:i], raw[i+1:], true
+}
+
+// newKeySecret random ID and secret of a new key
+func newKeySecret() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(b[:8]), hex.EncodeToString(b[8:]), nil
+}
+
+// handleAPIKeys list keys or create a key, secret of a created key is only
+// returned in its response
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if s.keys == nil {
+		WriteError(w, http.StatusNotFound, errors.New("store does not keep API keys"))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := s.keys.store.ListKeys()
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		infos := make([]*APIKeyInfo, 0, len(keys))
+		for _, key := range keys {
+			infos = append(infos, s.keys.info(key))
+		}
+		WriteJSON(w, http.StatusOK, infos)
+	case http.MethodPost:
+		body, err := decodeKeyRequest(w, r)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		id, secret, err := newKeySecret()
+		if err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		hash := sha256.Sum256([]byte(secret))
+		key := &store.APIKey{ID: id, SecretHash: hash[:], Created: time.Now().UTC()}
+		body.apply(key)
+		if err := s.keys.store.PutKey(key); err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		log.Infof("API key %s created for %q", id, key.Name)
+		info := s.keys.info(key)
+		info.Key = id + "." + secret
+		WriteJSON(w, http.StatusCreated, info)
+	default:
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// handleAPIKey show, change or delete key of ID at /admin/apikeys/<id>
+func (s *Server) handleAPIKey(w http.ResponseWriter, r *http.Request) {
+	if s.keys == nil {
+		WriteError(w, http.StatusNotFound, errors.New("store does not keep API keys"))
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/admin/apikeys/")
+	switch r.Method {
+	case http.MethodGet:
+		key, err := s.keys.store.GetKey(id)
+		if err != nil {
+			writeKeyError(w, err)
+			return
+		}
+		WriteJSON(w, http.StatusOK, s.keys.info(key))
+	case http.MethodPatch:
+		body, err := decodeKeyRequest(w, r)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		// Usage counted so far is added before key is read, so it's not lost
+		// when key is written back
+		s.keys.flush()
+		key, err := s.keys.store.GetKey(id)
+		if err != nil {
+			writeKeyError(w, err)
+			return
+		}
+		body.apply(key)
+		if body.ResetUsage {
+			key.Requests, key.Rejected = 0, 0
+		}
+		if err := s.keys.store.PutKey(key); err != nil {
+			WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.keys.forget(id)
+		WriteJSON(w, http.StatusOK, s.keys.info(key))
+	case http.MethodDelete:
+		if err := s.keys.store.DeleteKey(id); err != nil {
+			writeKeyError(w, err)
+			return
+		}
+		s.keys.forget(id)
+		log.Infof("API key %s deleted", id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func writeKeyError(w http.ResponseWriter, err error) {
+	if err == store.ErrKeyNotFound {
+		WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	WriteError(w, http.StatusInternalServerError, err)
+}
+
+// decodeKeyRequest decode and validate body of a key request
+func decodeKeyRequest(w http.ResponseWriter, r *http.Request) (*APIKeyRequest, error) {
+	body := new(APIKeyRequest)
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	if body.Name != nil && len(*body.Name) > maxKeyName {
+		return nil, errors.New("name is longer than 64 characters")
+	}
+	if body.Rate != nil && (*body.Rate < 0 || math.IsInf(*body.Rate, 0) || math.IsNaN(*body.Rate)) {
+		return nil, errors.New("rate must not be negative")
+	}
+	if body.Burst != nil && *body.Burst < 0 {
+		return nil, errors.New("burst must not be negative")
+	}
+	return body, nil
+}
+
+// apply set fields of request on key, a rate without burst gets a burst of
+// one second of requests
+func (req *APIKeyRequest) apply(key *store.APIKey) {
+	if req.Name != nil {
+		key.Name = *req.Name
+	}
+	if req.Rate != nil {
+		key.Rate = *req.Rate
+	}
+	if req.Burst != nil {
+		key.Burst = *req.Burst
+	}
+	if req.Quota != nil {
+		key.Quota = *req.Quota
+	}
+	if req.Disabled != nil {
+		key.Disabled = *req.Disabled
+	}
+	if key.Rate > 0 && key.Burst < 1 {
+		key.Burst = int(math.Max(1, math.Ceil(key.Rate)))
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth modes of API, client certificates are checked against the client CA
+// of TLS config
+const (
+	// AuthNone every request is served
+	AuthNone = "none"
+	// AuthToken route groups with a token need it as bearer token
+	AuthToken = "token"
+	// AuthMTLS requests need a verified client certificate
+	AuthMTLS = "mtls"
+	// AuthMTLSToken requests need a verified client certificate and the
+	// bearer token of their route group
+	AuthMTLSToken = "mtls+token"
+)
+
+// Route groups that tokens are given for
+const (
+	// RoutesPublic rounds, chain info, randomness requests, JSON-RPC and
+	// websocket
+	RoutesPublic = "public"
+	// RoutesStatus stats, participation, DKG, peers, resources and metrics
+	RoutesStatus = "status"
+	// routesHealth health and readiness, served without auth for probes
+	routesHealth = "health"
+	// routesAdmin admin endpoints, their token is the admin token
+	routesAdmin = "admin"
+)
+
+// AuthModes every auth mode
+func AuthModes() []string {
+	return []string{AuthNone, AuthToken, AuthMTLS, AuthMTLSToken}
+}
+
+var (
+	errCertRequired = errors.New("client certificate required")
+	errInvalidToken = errors.New("invalid bearer token")
+)
+
+// auth checks of requests by route group
+type auth struct {
+	mode string
+	// tokens bearer token of each route group, groups without a token are
+	// open in token modes
+	tokens map[string]string
+}
+
+// SetTLS serve API over TLS with given config, client certificates are
+// verified against its ClientCAs. It must be called before Start
+func (s *Server) SetTLS(config *tls.Config) {
+	s.tls = config
+}
+
+// SetAuth check requests by auth mode, tokens are bearer tokens of route
+// groups. It must be called after SetTLS and before Start
+func (s *Server) SetAuth(mode string, tokens map[string]string) error {
+	switch mode {
+	case AuthNone, AuthToken:
+	case AuthMTLS, AuthMTLSToken:
+		if s.tls == nil || s.tls.ClientCAs == nil {
+			return errors.New("auth mode " + mode + " needs TLS with a client CA")
+		}
+	default:
+		return errors.New("unknown auth mode: " + mode)
+	}
+	s.auth = &auth{mode: mode, tokens: tokens}
+	for _, sub := range s.beacons {
+		sub.auth = s.auth
+	}
+	return nil
+}
+
+// authorized serve handler only to requests that pass auth of route group
+func (s *Server) authorized(group string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth != nil && group != routesHealth {
+			if err := s.auth.check(group, r.TLS, r.Header.Get("Authorization"), overUnixSocket(r)); err != nil {
+				if err == errInvalidToken {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				WriteError(w, http.StatusUnauthorized, err)
+				return
+			}
+		}
+		handler(w, r)
+	}
+}
+
+// check request of given TLS state and Authorization header against auth
+// mode, admin tokens are checked by admin endpoints. Local requests came in
+// over a unix socket, whose permissions stand in for client certificates
+func (a *auth) check(group string, state *tls.ConnectionState, authorization string, local bool) error {
+	if (a.mode == AuthMTLS || a.mode == AuthMTLSToken) && !local {
+		if state == nil || len(state.VerifiedChains) == 0 {
+			return errCertRequired
+		}
+	}
+	if (a.mode == AuthToken || a.mode == AuthMTLSToken) && group != routesAdmin {
+		token, ok := a.tokens[group]
+		if !ok || token == "" {
+			return nil
+		}
+		bearer := strings.TrimPrefix(authorization, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			return errInvalidToken
+		}
+	}
+	return nil
+}
+
+// certificate key pair of server read again when its files change, so
+// renewed certificates apply without a restart
+type certificate struct {
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modified time.Time
+	mutex    sync.Mutex
+}
+
+// get current key pair, files are read again if certificate file changed
+func (c *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	info, err := os.Stat(c.certFile)
+	if err != nil && c.cert != nil {
+		return c.cert, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.cert != nil && !info.ModTime().After(c.modified) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if c.cert != nil {
+			log.Errorf("Keep TLS certificate, unable to load %s: %v", c.certFile, err)
+			return c.cert, nil
+		}
+		return nil, err
+	}
+	if c.cert != nil {
+		log.Infof("TLS certificate %s reloaded", c.certFile)
+	}
+	c.cert = &cert
+	c.modified = info.ModTime()
+	return c.cert, nil
+}
+
+// NewTLSConfig TLS config of a certificate and key in PEM files, client
+// certificates are verified against certificate authorities of clientCAFile
+// if it's set
+func NewTLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+	cert := &certificate{certFile: certFile, keyFile: keyFile}
+	if _, err := cert.get(nil); err != nil {
+		return nil, err
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: cert.get}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", clientCAFile)
+		}
+		// Health endpoints are served without a certificate, so it's checked
+		// by route group rather than in handshake
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
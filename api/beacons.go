@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/orochi-network/orochimaru/beacon"
+)
+
+// AddBeacon serve another beacon of node under /beacons/<id>/ with the same
+// endpoints as the default beacon, e.g. /beacons/<id>/public/latest. Return
+// server of beacon, its rounds are broadcast to websocket clients with it.
+// Beacons must be added before server starts
+func (s *Server) AddBeacon(id string, source Source, drng *beacon.Beacon) *Server {
+	sub := New(s.BindHost, s.BindPort, source, drng)
+	sub.keys = s.keys
+	sub.auth = s.auth
+	sub.shedder = s.shedder
+	prefix := "/beacons/" + id
+	s.mux.Handle(prefix+"/", http.StripPrefix(prefix, sub.Handler()))
+	s.beacons[id] = sub
+	return sub
+}
+
+// handleBeacons list chain info of beacons added to server by id
+func (s *Server) handleBeacons(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	beacons := make(map[string]*Info, len(s.beacons))
+	for id, sub := range s.beacons {
+		beacons[id] = sub.info()
+	}
+	WriteJSON(w, http.StatusOK, beacons)
+}
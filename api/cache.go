@@ -0,0 +1,232 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/loadshed"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recentRounds number of rounds kept in cache besides latest round, clients
+// following the chain mostly ask for the last few rounds
+const recentRounds = 64
+
+// ImmutableMaxAge seconds clients may cache a stored round, a round never
+// changes once it's signed
+const ImmutableMaxAge = 365 * 24 * 60 * 60
+
+var roundCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "api",
+	Name:      "round_cache_requests_total",
+	Help:      "Reads of rounds by API, by whether they were served from cache",
+}, []string{"result"})
+
+// cachedRound round with its encoded response
+type cachedRound struct {
+	result *beacon.RoundResult
+	body   []byte
+	etag   string
+}
+
+// newCachedRound encode round the way WriteJSON does, ETag is a hash of the
+// encoded response so it's strong
+func newCachedRound(result *beacon.RoundResult) (*cachedRound, error) {
+	body, err := json.Marshal(NewRound(result))
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, '\n')
+	return &cachedRound{result: result, body: body, etag: ETag(body)}, nil
+}
+
+// ETag strong validator of a response body, a hash of it
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// roundCache latest round and recent rounds of a beacon, it's refreshed by
+// finalized rounds so reads of them don't touch the store
+type roundCache struct {
+	mutex  sync.RWMutex
+	latest *cachedRound
+	// checked current round when store was last read for latest round, rounds
+	// fetched by sync are stored without an event
+	checked uint64
+	recent  [recentRounds]*cachedRound
+}
+
+func (c *roundCache) put(round *cachedRound) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.recent[round.result.Round%recentRounds] = round
+	if c.latest == nil || round.result.Round > c.latest.result.Round {
+		c.latest = round
+	}
+}
+
+func (c *roundCache) get(round uint64) *cachedRound {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if cached := c.recent[round%recentRounds]; cached != nil && cached.result.Round == round {
+		return cached
+	}
+	return nil
+}
+
+// Cache put a finalized round in cache of latest rounds
+func (s *Server) Cache(result *beacon.RoundResult) {
+	cached, err := newCachedRound(result)
+	if err != nil {
+		log.Errorf("Unable to cache round %d: %v", result.Round, err)
+		return
+	}
+	s.cache.put(cached)
+}
+
+// latestRound latest round from cache, store is read at most once per round
+// period unless a newer round is finalized
+func (s *Server) latestRound() (*cachedRound, error) {
+	current := s.drng.CurrentRound(time.Now())
+	s.cache.mutex.RLock()
+	latest, checked := s.cache.latest, s.cache.checked
+	s.cache.mutex.RUnlock()
+	if latest != nil && (latest.result.Round >= current || checked == current) {
+		roundCacheRequests.WithLabelValues("hit").Inc()
+		return latest, nil
+	}
+	roundCacheRequests.WithLabelValues("miss").Inc()
+	result, err := s.source.Latest()
+	if err != nil {
+		return nil, err
+	}
+	cached, err := newCachedRound(result)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(cached)
+	s.cache.mutex.Lock()
+	s.cache.checked = current
+	latest = s.cache.latest
+	s.cache.mutex.Unlock()
+	return latest, nil
+}
+
+// latest latest round, see latestRound
+func (s *Server) latest() (*beacon.RoundResult, error) {
+	cached, err := s.latestRound()
+	if err != nil {
+		return nil, err
+	}
+	return cached.result, nil
+}
+
+// round stored round from cache, rounds close to latest round are cached
+// once they're read. Reading rounds that are not cached is shed under load
+func (s *Server) round(round uint64) (*cachedRound, error) {
+	if cached := s.cache.get(round); cached != nil {
+		roundCacheRequests.WithLabelValues("hit").Inc()
+		return cached, nil
+	}
+	roundCacheRequests.WithLabelValues("miss").Inc()
+	if !s.shedder.Allow(loadshed.WorkHistory) {
+		return nil, errOverloaded
+	}
+	result, err := s.source.Get(round)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := newCachedRound(result)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.mutex.RLock()
+	recent := s.cache.latest != nil && round+recentRounds > s.cache.latest.result.Round
+	s.cache.mutex.RUnlock()
+	if recent {
+		s.cache.put(cached)
+	}
+	return cached, nil
+}
+
+// latestMaxAge seconds until a round newer than latest is due, 0 if it's
+// already being produced
+func (s *Server) latestMaxAge(latest uint64) int {
+	now := time.Now()
+	if latest < s.drng.CurrentRound(now) {
+		return 0
+	}
+	return int(s.drng.RoundTime(latest + 1).Sub(now).Seconds())
+}
+
+// cacheScope public if shared caches such as CDNs may keep rounds, private if
+// rounds are only served to authenticated or metered clients
+func (s *Server) cacheScope() string {
+	if s.keys != nil && s.keys.required {
+		return "private"
+	}
+	if s.auth != nil {
+		switch s.auth.mode {
+		case AuthMTLS, AuthMTLSToken:
+			return "private"
+		case AuthToken:
+			if s.auth.tokens[RoutesPublic] != "" {
+				return "private"
+			}
+		}
+	}
+	return "public"
+}
+
+func (s *Server) writeCached(w http.ResponseWriter, r *http.Request, body []byte, etag string, maxAge int) {
+	WriteCached(w, r, body, etag, CacheControl(s.cacheScope(), maxAge))
+}
+
+// CacheControl Cache-Control header of a response clients may keep for maxAge
+// seconds, scope is public or private. Responses kept for ImmutableMaxAge are
+// immutable
+func CacheControl(scope string, maxAge int) string {
+	cacheControl := fmt.Sprintf("%s, max-age=%d", scope, maxAge)
+	if maxAge == ImmutableMaxAge {
+		cacheControl += ", immutable"
+	}
+	return cacheControl
+}
+
+// WriteCached write body with its ETag, or 304 if client has it already
+func WriteCached(w http.ResponseWriter, r *http.Request, body []byte, etag string, cacheControl string) {
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", cacheControl)
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Debugf("Unable to write response: %v", err)
+	}
+}
+
+// etagMatch whether If-None-Match header lists etag, weak validators match
+// their strong form
+func etagMatch(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// dashboardPath page of dashboard, it holds no data of node and reads
+// dashboardStatePath with the admin token that operator enters
+const dashboardPath = "/admin/dashboard"
+
+// dashboardStatePath state of node shown by dashboard
+const dashboardStatePath = "/admin/dashboard/state"
+
+// dashboardRounds number of latest rounds shown by dashboard
+const dashboardRounds = 20
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// DashboardState snapshot of node that dashboard shows
+type DashboardState struct {
+	Time time.Time `json:"time"`
+	// CurrentRound round being produced now
+	CurrentRound uint64 `json:"currentRound"`
+	// Period seconds between rounds
+	Period float64          `json:"period"`
+	Stats  beacon.Stats     `json:"stats"`
+	Rounds []DashboardRound `json:"rounds"`
+	// Peers connected peers, empty if node does not serve peers
+	Peers  []network.PeerInfo `json:"peers"`
+	Sync   DashboardSync      `json:"sync"`
+	Errors []logger.Entry     `json:"errors"`
+}
+
+// DashboardRound stored round with the time it was due
+type DashboardRound struct {
+	Round      uint64    `json:"round"`
+	Time       time.Time `json:"time"`
+	Randomness string    `json:"randomness"`
+}
+
+// DashboardSync how far store is behind the chain and the last sync progress
+type DashboardSync struct {
+	// Latest round in store, 0 if store is empty
+	Latest uint64 `json:"latest"`
+	// Behind number of rounds before the current round that are not stored
+	Behind uint64 `json:"behind"`
+	// Peer that last sync fetched rounds from, empty for gateways
+	Peer   peer.ID `json:"peer,omitempty"`
+	Target uint64  `json:"target,omitempty"`
+	// Updated time of last sync progress, nil if node has not synced
+	Updated *time.Time `json:"updated,omitempty"`
+}
+
+// syncStatus last sync progress published on event bus
+type syncStatus struct {
+	mutex    sync.Mutex
+	progress events.SyncProgress
+	updated  time.Time
+}
+
+func (s *syncStatus) set(progress events.SyncProgress) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.progress = progress
+	s.updated = time.Now()
+}
+
+func (s *syncStatus) get() (events.SyncProgress, time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.progress, s.updated
+}
+
+// serveAdmin serve dashboard page to anyone who reaches admin endpoints and
+// other admin endpoints to requests bearing admin token
+func (s *Server) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == dashboardPath {
+		s.handleDashboard(w, r)
+		return
+	}
+	s.admin(s.adminMux.ServeHTTP)(w, r)
+}
+
+// handleDashboard serve page of dashboard, it's disabled with admin API
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if s.adminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	header := w.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	header.Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'")
+	header.Set("X-Frame-Options", "DENY")
+	w.Write(dashboardPage)
+}
+
+// handleDashboardState snapshot of rounds, peers, sync and errors of node
+func (s *Server) handleDashboardState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	now := time.Now()
+	state := &DashboardState{
+		Time:         now,
+		CurrentRound: s.drng.CurrentRound(now),
+		Period:       s.drng.Period.Seconds(),
+		Stats:        s.drng.Stats(),
+		Rounds:       []DashboardRound{},
+		Peers:        []network.PeerInfo{},
+		Errors:       logger.RecentErrors(),
+	}
+	if s.peers != nil {
+		state.Peers = s.peers()
+	}
+	if latest, err := s.latest(); err == nil {
+		state.Sync.Latest = latest.Round
+		state.Rounds = append(state.Rounds, s.dashboardRound(latest))
+		for round := latest.Round - 1; round > 0 && latest.Round-round < dashboardRounds; round-- {
+			cached, err := s.round(round)
+			if err != nil {
+				continue
+			}
+			state.Rounds = append(state.Rounds, s.dashboardRound(cached.result))
+		}
+	}
+	if state.CurrentRound > state.Sync.Latest+1 {
+		state.Sync.Behind = state.CurrentRound - state.Sync.Latest - 1
+	}
+	if progress, updated := s.syncProgress.get(); !updated.IsZero() {
+		state.Sync.Peer = progress.Peer
+		state.Sync.Target = progress.Target
+		state.Sync.Updated = &updated
+	}
+	WriteJSON(w, http.StatusOK, state)
+}
+
+func (s *Server) dashboardRound(result *beacon.RoundResult) DashboardRound {
+	return DashboardRound{
+		Round:      result.Round,
+		Time:       s.drng.RoundTime(result.Round),
+		Randomness: hex.EncodeToString(result.Randomness),
+	}
+}
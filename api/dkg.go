@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/orochi-network/orochimaru/dkg"
+)
+
+// DKGStatus provide status of a DKG ceremony
+type DKGStatus func() *dkg.Status
+
+// SetDKGStatus serve status of a DKG ceremony at /dkg, it must be called
+// before Start
+func (s *Server) SetDKGStatus(status DKGStatus) {
+	s.dkgStatus = status
+}
+
+// NewDKG create API server that serves only /dkg and /healthz, for commands
+// that run a ceremony without a beacon
+func NewDKG(bindHost string, bindPort uint, status DKGStatus) *Server {
+	s := &Server{
+		BindHost:  bindHost,
+		BindPort:  bindPort,
+		mux:       http.NewServeMux(),
+		dkgStatus: status,
+	}
+	s.mux.HandleFunc("/dkg", s.handleDKG)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.server = &http.Server{Handler: s.mux}
+	return s
+}
+
+// handleDKG report phase, participants seen and complaints of ceremony
+func (s *Server) handleDKG(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if s.dkgStatus == nil {
+		WriteError(w, http.StatusNotFound, errors.New("no DKG ceremony is running"))
+		return
+	}
+	status := s.dkgStatus()
+	if status == nil {
+		WriteError(w, http.StatusNotFound, errors.New("DKG ceremony has not started"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, status)
+}
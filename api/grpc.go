@@ -0,0 +1,273 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/orochi-network/orochimaru/api/proto"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	grpcPeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// grpcStreamBuffer rounds buffered for each StreamRounds call, calls that
+// fall further behind are ended
+const grpcStreamBuffer = 16
+
+// grpcService RandomnessService over rounds and chain info of server
+type grpcService struct {
+	proto.UnimplementedRandomnessServiceServer
+	server  *Server
+	streams map[chan *beacon.RoundResult]bool
+	mutex   sync.Mutex
+}
+
+// SetGRPCAddress serve RandomnessService over gRPC on given address as well,
+// with TLS and auth of API. It must be called before Start
+func (s *Server) SetGRPCAddress(bindHost string, bindPort uint) {
+	s.grpcAddr = fmt.Sprintf("%s:%d", bindHost, bindPort)
+}
+
+// startGRPC listen on gRPC address if it's set, server reflection lets
+// clients such as grpcurl discover the service
+func (s *Server) startGRPC() error {
+	if s.grpcAddr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.grpcAddr)
+	if err != nil {
+		return err
+	}
+	options := []grpc.ServerOption{grpc.UnaryInterceptor(s.grpcUnary), grpc.StreamInterceptor(s.grpcStream)}
+	if s.tls != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+	s.grpcServer = grpc.NewServer(options...)
+	proto.RegisterRandomnessServiceServer(s.grpcServer, s.grpcService)
+	reflection.Register(s.grpcServer)
+	log.Infof("gRPC API listen on: %s, TLS: %v", listener.Addr(), s.tls != nil)
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			log.Errorf("gRPC API stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// stopGRPC stop gRPC servers gracefully, calls still running when ctx is
+// done are canceled
+func (s *Server) stopGRPC(ctx context.Context) {
+	for _, server := range []*grpc.Server{s.grpcServer, s.grpcUnixServer} {
+		if server == nil {
+			continue
+		}
+		server := server
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			server.Stop()
+		}
+	}
+}
+
+// grpcUnary check auth of API before unary calls
+func (s *Server) grpcUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.grpcAuthorized(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// grpcStream check auth of API before streaming calls
+func (s *Server) grpcStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.grpcAuthorized(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// grpcAuthorized check call against auth mode, calls belong to public route
+// group and carry bearer token in authorization metadata
+func (s *Server) grpcAuthorized(ctx context.Context) error {
+	if s.auth == nil {
+		return nil
+	}
+	var state *tls.ConnectionState
+	local := false
+	if p, ok := grpcPeer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+		local = p.Addr != nil && p.Addr.Network() == "unix"
+	}
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+	if err := s.auth.check(RoutesPublic, state, authorization, local); err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return nil
+}
+
+// newProtoRound protobuf representation of given round result
+func newProtoRound(result *beacon.RoundResult) *proto.Round {
+	return &proto.Round{
+		Round:             result.Round,
+		Randomness:        result.Randomness,
+		Signature:         result.Signature,
+		PreviousSignature: result.PreviousSignature,
+	}
+}
+
+// grpcRoundError status of an error reading a round
+func grpcRoundError(err error) error {
+	if err == store.ErrNotFound {
+		return status.Error(codes.NotFound, "round not found")
+	}
+	if err == errOverloaded {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	log.Errorf("Unable to read round: %v", err)
+	return status.Error(codes.Internal, "unable to read round")
+}
+
+// GetLatest get latest round
+func (g *grpcService) GetLatest(ctx context.Context, req *proto.GetLatestRequest) (*proto.Round, error) {
+	latest, err := g.server.latestRound()
+	if err != nil {
+		return nil, grpcRoundError(err)
+	}
+	return newProtoRound(latest.result), nil
+}
+
+// GetRound get round by its number, round 0 is an alias of latest round
+func (g *grpcService) GetRound(ctx context.Context, req *proto.GetRoundRequest) (*proto.Round, error) {
+	if req.Round == 0 {
+		return g.GetLatest(ctx, nil)
+	}
+	cached, err := g.server.round(req.Round)
+	if err != nil {
+		return nil, grpcRoundError(err)
+	}
+	return newProtoRound(cached.result), nil
+}
+
+// GetRounds stream up to count sequential rounds from start, count defaults
+// to DefaultRoundsCount. Rounds that were skipped are left out and the stream
+// stops at latest round
+func (g *grpcService) GetRounds(req *proto.GetRoundsRequest, stream proto.RandomnessService_GetRoundsServer) error {
+	if req.Start == 0 {
+		return status.Error(codes.InvalidArgument, "invalid start round")
+	}
+	count := req.Count
+	if count == 0 {
+		count = DefaultRoundsCount
+	}
+	if count > MaxRoundsCount {
+		return status.Errorf(codes.InvalidArgument, "count must be between 1 and %d", MaxRoundsCount)
+	}
+	latest, err := g.server.latest()
+	if err == store.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return grpcRoundError(err)
+	}
+	end := req.Start + count - 1
+	if end > latest.Round || end < req.Start {
+		end = latest.Round
+	}
+	for round := req.Start; round <= end && round >= req.Start; round++ {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		cached, err := g.server.round(round)
+		if err == store.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return grpcRoundError(err)
+		}
+		if err := stream.Send(newProtoRound(cached.result)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamRounds send rounds as they are finalized until client cancels
+func (g *grpcService) StreamRounds(req *proto.StreamRoundsRequest, stream proto.RandomnessService_StreamRoundsServer) error {
+	rounds := make(chan *beacon.RoundResult, grpcStreamBuffer)
+	g.mutex.Lock()
+	g.streams[rounds] = true
+	g.mutex.Unlock()
+	defer g.remove(rounds)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case result, ok := <-rounds:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "client is too slow")
+			}
+			if err := stream.Send(newProtoRound(result)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ChainInfo get public parameters of beacon chain
+func (g *grpcService) ChainInfo(ctx context.Context, req *proto.ChainInfoRequest) (*proto.ChainInfoResponse, error) {
+	chainInfo := g.server.drng.ChainInfo()
+	return &proto.ChainInfoResponse{
+		PublicKey:   chainInfo.PublicKey,
+		Period:      uint64(chainInfo.Period.Seconds()),
+		GenesisTime: chainInfo.GenesisTime.Unix(),
+		Mode:        chainInfo.Mode,
+		Scheme:      chainInfo.Scheme,
+	}, nil
+}
+
+// broadcast push round to StreamRounds calls, calls that are behind by
+// grpcStreamBuffer rounds are ended
+func (g *grpcService) broadcast(result *beacon.RoundResult) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for rounds := range g.streams {
+		select {
+		case rounds <- result:
+		default:
+			log.Debug("Drop slow gRPC stream")
+			delete(g.streams, rounds)
+			close(rounds)
+		}
+	}
+}
+
+// remove stream from broadcast if it's still there
+func (g *grpcService) remove(rounds chan *beacon.RoundResult) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.streams[rounds] {
+		delete(g.streams, rounds)
+		close(rounds)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// StaleRounds number of periods latest round may lag behind before node or
+// gateway is not ready
+const StaleRounds = 2
+
+// Check readiness condition, it returns an error describing why node is not ready
+type Check func() error
+
+// ReadinessCheck named readiness condition, name is its key in checks of /readyz
+type ReadinessCheck struct {
+	Name string
+	Run  Check
+}
+
+// Health JSON representation of health and readiness
+type Health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// AddReadinessCheck add a condition to /readyz, it must be called before Start
+func (s *Server) AddReadinessCheck(name string, run Check) {
+	s.checks = append(s.checks, ReadinessCheck{Name: name, Run: run})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ServeHealth(w, r)
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	checks := append([]ReadinessCheck{{Name: "round", Run: s.checkLatestRound}}, s.checks...)
+	ServeReady(w, r, checks)
+}
+
+// ServeHealth report process is up
+func ServeHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, &Health{Status: "ok"})
+}
+
+// ServeReady report whether checks pass, it responds 503 if any check fails
+func ServeReady(w http.ResponseWriter, r *http.Request, checks []ReadinessCheck) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	health := &Health{Status: "ready", Checks: make(map[string]string)}
+	status := http.StatusOK
+	for _, c := range checks {
+		if err := c.Run(); err != nil {
+			health.Checks[c.Name] = err.Error()
+			health.Status = "not ready"
+			status = http.StatusServiceUnavailable
+		} else {
+			health.Checks[c.Name] = "ok"
+		}
+	}
+	WriteJSON(w, status, health)
+}
+
+// checkLatestRound latest round must be within StaleRounds periods of current round
+func (s *Server) checkLatestRound() error {
+	current := s.drng.CurrentRound(time.Now())
+	if current <= StaleRounds {
+		// Chain has not started or is just starting
+		return nil
+	}
+	latest, err := s.source.Latest()
+	if err == store.ErrNotFound {
+		return errors.New("no round yet")
+	}
+	if err != nil {
+		return err
+	}
+	if latest.Round+StaleRounds < current {
+		return fmt.Errorf("latest round %d is behind current round %d", latest.Round, current)
+	}
+	return nil
+}
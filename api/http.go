@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxBodyBytes largest request body when HTTPOptions do not set one
+const DefaultMaxBodyBytes = 1 << 20
+
+// corsMaxAge seconds browsers cache answers of preflight requests
+const corsMaxAge = 600
+
+// HTTPOptions settings of HTTP servers of API, a zero value keeps defaults of
+// net/http and serves no CORS headers
+type HTTPOptions struct {
+	// CORSOrigins origins that browsers may query API from, "*" allows every
+	// origin. Admin endpoints never answer CORS requests
+	CORSOrigins []string
+	// TrustedProxies reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers give client address of a request
+	TrustedProxies []*net.IPNet
+	// MaxBodyBytes largest request body, DefaultMaxBodyBytes if it's 0
+	MaxBodyBytes   int64
+	MaxHeaderBytes int
+	// ReadTimeout time to read a request with its headers, WriteTimeout time
+	// to write a response and IdleTimeout time a keep-alive connection waits
+	// for the next request. A timeout of 0 is no timeout
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// AccessLog log every request with its client address, status and duration
+	AccessLog bool
+}
+
+// ParseTrustedProxies parse IP addresses and CIDR ranges of proxies, e.g.
+// 10.0.0.1 or 172.16.0.0/12
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.New("invalid trusted proxy: " + entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.New("invalid trusted proxy: " + entry)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+// SetHTTPOptions apply options to API listener and admin listener, it must be
+// called before Start
+func (s *Server) SetHTTPOptions(options HTTPOptions) {
+	s.options = options
+}
+
+// configure set timeouts and limits of options on server and wrap its
+// handler, cors is false for servers that do not answer CORS requests
+func (s *Server) configure(server *http.Server, cors bool) {
+	server.ReadTimeout = s.options.ReadTimeout
+	server.ReadHeaderTimeout = s.options.ReadTimeout
+	server.WriteTimeout = s.options.WriteTimeout
+	server.IdleTimeout = s.options.IdleTimeout
+	server.MaxHeaderBytes = s.options.MaxHeaderBytes
+	handler := server.Handler
+	if cors && len(s.options.CORSOrigins) > 0 {
+		handler = s.cors(handler)
+	}
+	server.Handler = s.frontend(handler)
+}
+
+// frontend resolve client address of requests forwarded by trusted proxies,
+// limit request bodies and log requests if access log is on
+func (s *Server) frontend(next http.Handler) http.Handler {
+	maxBody := s.options.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBodyBytes
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.options.TrustedProxies) > 0 {
+			r.RemoteAddr = s.clientAddr(r)
+		}
+		if s.options.AccessLog {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				log.Infof("%s %s %s %d %s", r.RemoteAddr, r.Method, r.URL.RequestURI(), recorder.status, time.Since(start).Round(time.Microsecond))
+			}()
+			w = recorder
+		}
+		if r.ContentLength > maxBody {
+			WriteError(w, http.StatusRequestEntityTooLarge, errors.New("request body is too large"))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr address of client that sent request, the rightmost address of
+// X-Forwarded-For that is not a trusted proxy if request comes from one
+func (s *Server) clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !s.isTrustedProxy(host) {
+		return r.RemoteAddr
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if !s.isTrustedProxy(hop) || i == 0 {
+				return hop
+			}
+		}
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return r.RemoteAddr
+}
+
+func (s *Server) isTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range s.options.TrustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// cors answer preflight requests and allow listed origins to read responses
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || strings.HasPrefix(r.URL.Path, "/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		allowed := s.allowedOrigin(origin)
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if allowed != "" {
+			header.Set("Access-Control-Allow-Origin", allowed)
+			header.Set("Access-Control-Expose-Headers", "ETag, Location, Retry-After")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed != "" {
+				header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match, "+APIKeyHeader)
+				header.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedOrigin value of Access-Control-Allow-Origin for origin, empty if
+// origin is not allowed
+func (s *Server) allowedOrigin(origin string) string {
+	for _, allowed := range s.options.CORSOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// statusRecorder remember status of a response for access log, websocket
+// upgrades hijack the connection through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// JSON-RPC 2.0 methods served on /rpc
+const (
+	MethodGetLatest = "drng_getLatest"
+	MethodGetRound  = "drng_getRound"
+	MethodChainInfo = "drng_chainInfo"
+)
+
+// JSON-RPC 2.0 error codes, rpcErrNotFound and rpcErrOverloaded are in the
+// range left to applications
+const (
+	rpcErrParse          = -32700
+	rpcErrInvalidRequest = -32600
+	rpcErrMethodNotFound = -32601
+	rpcErrInvalidParams  = -32602
+	rpcErrInternal       = -32603
+	rpcErrNotFound       = -32001
+	rpcErrOverloaded     = -32005
+)
+
+// maxRPCBody limit of request body size
+const maxRPCBody = 1 << 20
+
+// rpcRequest JSON-RPC 2.0 request, requests without id are notifications
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse JSON-RPC 2.0 response
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError JSON-RPC 2.0 error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRPCBody))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	body = bytes.TrimSpace(body)
+	// Batch requests are answered with an array of responses, notifications
+	// get no response
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			WriteJSON(w, http.StatusOK, rpcFailure(nil, rpcErrParse, "parse error"))
+			return
+		}
+		if len(batch) == 0 {
+			WriteJSON(w, http.StatusOK, rpcFailure(nil, rpcErrInvalidRequest, "empty batch"))
+			return
+		}
+		responses := make([]*rpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if response := s.callRPC(raw); response != nil {
+				responses = append(responses, response)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		WriteJSON(w, http.StatusOK, responses)
+		return
+	}
+	response := s.callRPC(body)
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// callRPC answer a single request, nil for notifications
+func (s *Server) callRPC(raw json.RawMessage) *rpcResponse {
+	request := new(rpcRequest)
+	if err := json.Unmarshal(raw, request); err != nil {
+		return rpcFailure(nil, rpcErrParse, "parse error")
+	}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		return rpcFailure(request.ID, rpcErrInvalidRequest, "invalid request")
+	}
+	result, rpcErr := s.dispatchRPC(request.Method, request.Params)
+	if len(request.ID) == 0 {
+		return nil
+	}
+	if rpcErr != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: request.ID, Error: rpcErr}
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: request.ID, Result: result}
+}
+
+func (s *Server) dispatchRPC(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case MethodGetLatest:
+		return rpcRound(s.latest())
+	case MethodGetRound:
+		round, err := roundParam(params)
+		if err != nil {
+			return nil, &rpcError{Code: rpcErrInvalidParams, Message: err.Error()}
+		}
+		if round == 0 {
+			return rpcRound(s.latest())
+		}
+		cached, err := s.round(round)
+		if err != nil {
+			return rpcRound(nil, err)
+		}
+		return rpcRound(cached.result, nil)
+	case MethodChainInfo:
+		return s.info(), nil
+	default:
+		return nil, &rpcError{Code: rpcErrMethodNotFound, Message: "method not found"}
+	}
+}
+
+func rpcRound(result *beacon.RoundResult, err error) (interface{}, *rpcError) {
+	if err == store.ErrNotFound {
+		return nil, &rpcError{Code: rpcErrNotFound, Message: err.Error()}
+	}
+	if err == errOverloaded {
+		return nil, &rpcError{Code: rpcErrOverloaded, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, &rpcError{Code: rpcErrInternal, Message: err.Error()}
+	}
+	return NewRound(result), nil
+}
+
+// roundParam round number of params given by position or by name, either as
+// a number or a decimal or 0x prefixed hex string
+func roundParam(params json.RawMessage) (uint64, error) {
+	var value json.RawMessage
+	var positional []json.RawMessage
+	named := struct {
+		Round json.RawMessage `json:"round"`
+	}{}
+	if err := json.Unmarshal(params, &positional); err == nil && len(positional) > 0 {
+		value = positional[0]
+	} else if err := json.Unmarshal(params, &named); err == nil && named.Round != nil {
+		value = named.Round
+	} else {
+		return 0, errors.New("round number is required")
+	}
+	var number uint64
+	if err := json.Unmarshal(value, &number); err == nil {
+		return number, nil
+	}
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return 0, errors.New("invalid round number")
+	}
+	if strings.HasPrefix(text, "0x") {
+		number, err := strconv.ParseUint(text[2:], 16, 64)
+		if err != nil {
+			return 0, errors.New("invalid round number")
+		}
+		return number, nil
+	}
+	number, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid round number")
+	}
+	return number, nil
+}
+
+func rpcFailure(id json.RawMessage, code int, message string) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
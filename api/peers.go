@@ -0,0 +1,53 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// PeerList provide connected peers of node
+type PeerList func() []network.PeerInfo
+
+// ResourceUsage provide resources that node uses
+type ResourceUsage func() network.ResourceUsage
+
+// SetPeers serve connected peers at /peers, it must be called before Start
+func (s *Server) SetPeers(peers PeerList) {
+	s.peers = peers
+}
+
+// handlePeers list connected peers with their addresses, latency, topics and
+// group membership
+func (s *Server) handlePeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if s.peers == nil {
+		WriteError(w, http.StatusNotFound, errors.New("node does not serve peers"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.peers())
+}
+
+// SetResources serve resource usage of node at /resources, it must be called
+// before Start
+func (s *Server) SetResources(resources ResourceUsage) {
+	s.resources = resources
+}
+
+// handleResources show connections, streams and memory in use with their
+// limits
+func (s *Server) handleResources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if s.resources == nil {
+		WriteError(w, http.StatusNotFound, errors.New("node does not serve resources"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.resources())
+}
@@ -0,0 +1,6 @@
+// Package proto holds the protobuf definition of RandomnessService, the gRPC
+// API of nodes, and Go code generated from it by protoc-gen-go and
+// protoc-gen-go-grpc
+package proto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative randomness.proto
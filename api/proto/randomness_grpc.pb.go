@@ -0,0 +1,312 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// RandomnessServiceClient is the client API for RandomnessService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RandomnessServiceClient interface {
+	// GetLatest get latest round
+	GetLatest(ctx context.Context, in *GetLatestRequest, opts ...grpc.CallOption) (*Round, error)
+	// GetRound get round by its number, round 0 is an alias of latest round
+	GetRound(ctx context.Context, in *GetRoundRequest, opts ...grpc.CallOption) (*Round, error)
+	// GetRounds stream up to count sequential stored rounds from start, used to
+	// backfill history, skipped rounds are left out
+	GetRounds(ctx context.Context, in *GetRoundsRequest, opts ...grpc.CallOption) (RandomnessService_GetRoundsClient, error)
+	// StreamRounds stream rounds as they are produced
+	StreamRounds(ctx context.Context, in *StreamRoundsRequest, opts ...grpc.CallOption) (RandomnessService_StreamRoundsClient, error)
+	// ChainInfo get public parameters of beacon chain
+	ChainInfo(ctx context.Context, in *ChainInfoRequest, opts ...grpc.CallOption) (*ChainInfoResponse, error)
+}
+
+type randomnessServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRandomnessServiceClient(cc grpc.ClientConnInterface) RandomnessServiceClient {
+	return &randomnessServiceClient{cc}
+}
+
+func (c *randomnessServiceClient) GetLatest(ctx context.Context, in *GetLatestRequest, opts ...grpc.CallOption) (*Round, error) {
+	out := new(Round)
+	err := c.cc.Invoke(ctx, "/orochi.drng.v1.RandomnessService/GetLatest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *randomnessServiceClient) GetRound(ctx context.Context, in *GetRoundRequest, opts ...grpc.CallOption) (*Round, error) {
+	out := new(Round)
+	err := c.cc.Invoke(ctx, "/orochi.drng.v1.RandomnessService/GetRound", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *randomnessServiceClient) GetRounds(ctx context.Context, in *GetRoundsRequest, opts ...grpc.CallOption) (RandomnessService_GetRoundsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RandomnessService_ServiceDesc.Streams[0], "/orochi.drng.v1.RandomnessService/GetRounds", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &randomnessServiceGetRoundsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RandomnessService_GetRoundsClient interface {
+	Recv() (*Round, error)
+	grpc.ClientStream
+}
+
+type randomnessServiceGetRoundsClient struct {
+	grpc.ClientStream
+}
+
+func (x *randomnessServiceGetRoundsClient) Recv() (*Round, error) {
+	m := new(Round)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *randomnessServiceClient) StreamRounds(ctx context.Context, in *StreamRoundsRequest, opts ...grpc.CallOption) (RandomnessService_StreamRoundsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RandomnessService_ServiceDesc.Streams[1], "/orochi.drng.v1.RandomnessService/StreamRounds", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &randomnessServiceStreamRoundsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RandomnessService_StreamRoundsClient interface {
+	Recv() (*Round, error)
+	grpc.ClientStream
+}
+
+type randomnessServiceStreamRoundsClient struct {
+	grpc.ClientStream
+}
+
+func (x *randomnessServiceStreamRoundsClient) Recv() (*Round, error) {
+	m := new(Round)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *randomnessServiceClient) ChainInfo(ctx context.Context, in *ChainInfoRequest, opts ...grpc.CallOption) (*ChainInfoResponse, error) {
+	out := new(ChainInfoResponse)
+	err := c.cc.Invoke(ctx, "/orochi.drng.v1.RandomnessService/ChainInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RandomnessServiceServer is the server API for RandomnessService service.
+// All implementations must embed UnimplementedRandomnessServiceServer
+// for forward compatibility
+type RandomnessServiceServer interface {
+	// GetLatest get latest round
+	GetLatest(context.Context, *GetLatestRequest) (*Round, error)
+	// GetRound get round by its number, round 0 is an alias of latest round
+	GetRound(context.Context, *GetRoundRequest) (*Round, error)
+	// GetRounds stream up to count sequential stored rounds from start, used to
+	// backfill history, skipped rounds are left out
+	GetRounds(*GetRoundsRequest, RandomnessService_GetRoundsServer) error
+	// StreamRounds stream rounds as they are produced
+	StreamRounds(*StreamRoundsRequest, RandomnessService_StreamRoundsServer) error
+	// ChainInfo get public parameters of beacon chain
+	ChainInfo(context.Context, *ChainInfoRequest) (*ChainInfoResponse, error)
+	mustEmbedUnimplementedRandomnessServiceServer()
+}
+
+// UnimplementedRandomnessServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRandomnessServiceServer struct {
+}
+
+func (UnimplementedRandomnessServiceServer) GetLatest(context.Context, *GetLatestRequest) (*Round, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLatest not implemented")
+}
+func (UnimplementedRandomnessServiceServer) GetRound(context.Context, *GetRoundRequest) (*Round, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRound not implemented")
+}
+func (UnimplementedRandomnessServiceServer) GetRounds(*GetRoundsRequest, RandomnessService_GetRoundsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetRounds not implemented")
+}
+func (UnimplementedRandomnessServiceServer) StreamRounds(*StreamRoundsRequest, RandomnessService_StreamRoundsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamRounds not implemented")
+}
+func (UnimplementedRandomnessServiceServer) ChainInfo(context.Context, *ChainInfoRequest) (*ChainInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChainInfo not implemented")
+}
+func (UnimplementedRandomnessServiceServer) mustEmbedUnimplementedRandomnessServiceServer() {}
+
+// UnsafeRandomnessServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RandomnessServiceServer will
+// result in compilation errors.
+type UnsafeRandomnessServiceServer interface {
+	mustEmbedUnimplementedRandomnessServiceServer()
+}
+
+func RegisterRandomnessServiceServer(s grpc.ServiceRegistrar, srv RandomnessServiceServer) {
+	s.RegisterService(&RandomnessService_ServiceDesc, srv)
+}
+
+func _RandomnessService_GetLatest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLatestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RandomnessServiceServer).GetLatest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orochi.drng.v1.RandomnessService/GetLatest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RandomnessServiceServer).GetLatest(ctx, req.(*GetLatestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RandomnessService_GetRound_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRoundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RandomnessServiceServer).GetRound(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orochi.drng.v1.RandomnessService/GetRound",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RandomnessServiceServer).GetRound(ctx, req.(*GetRoundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RandomnessService_GetRounds_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRoundsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RandomnessServiceServer).GetRounds(m, &randomnessServiceGetRoundsServer{stream})
+}
+
+type RandomnessService_GetRoundsServer interface {
+	Send(*Round) error
+	grpc.ServerStream
+}
+
+type randomnessServiceGetRoundsServer struct {
+	grpc.ServerStream
+}
+
+func (x *randomnessServiceGetRoundsServer) Send(m *Round) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RandomnessService_StreamRounds_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRoundsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RandomnessServiceServer).StreamRounds(m, &randomnessServiceStreamRoundsServer{stream})
+}
+
+type RandomnessService_StreamRoundsServer interface {
+	Send(*Round) error
+	grpc.ServerStream
+}
+
+type randomnessServiceStreamRoundsServer struct {
+	grpc.ServerStream
+}
+
+func (x *randomnessServiceStreamRoundsServer) Send(m *Round) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RandomnessService_ChainInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChainInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RandomnessServiceServer).ChainInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orochi.drng.v1.RandomnessService/ChainInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RandomnessServiceServer).ChainInfo(ctx, req.(*ChainInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RandomnessService_ServiceDesc is the grpc.ServiceDesc for RandomnessService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RandomnessService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orochi.drng.v1.RandomnessService",
+	HandlerType: (*RandomnessServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLatest",
+			Handler:    _RandomnessService_GetLatest_Handler,
+		},
+		{
+			MethodName: "GetRound",
+			Handler:    _RandomnessService_GetRound_Handler,
+		},
+		{
+			MethodName: "ChainInfo",
+			Handler:    _RandomnessService_ChainInfo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetRounds",
+			Handler:       _RandomnessService_GetRounds_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamRounds",
+			Handler:       _RandomnessService_StreamRounds_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "randomness.proto",
+}
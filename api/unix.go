@@ -0,0 +1,129 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/orochi-network/orochimaru/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// DefaultSocketMode permissions of unix sockets, owner and group may connect
+const DefaultSocketMode os.FileMode = 0660
+
+// UnixSockets paths of unix domain sockets that API, admin endpoints and gRPC
+// are served on, a path that is empty is not served. Sockets are served over
+// plain HTTP and gRPC, and who may connect is set by their file permissions
+type UnixSockets struct {
+	API   string
+	Admin string
+	GRPC  string
+	// Mode permissions of socket files, DefaultSocketMode if it's 0
+	Mode os.FileMode
+}
+
+// ParseSocketMode parse octal permissions of socket files, e.g. 0660
+func ParseSocketMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value == 0 || value > 0777 {
+		return 0, fmt.Errorf("invalid socket mode %q, it must be octal permissions such as 0660", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// SetUnixSockets serve API, admin endpoints and gRPC on unix sockets as well,
+// so co-located applications need no TCP port. Admin endpoints on a socket
+// are not served on the API listener. It must be called before Start
+func (s *Server) SetUnixSockets(sockets UnixSockets) {
+	if sockets.Mode == 0 {
+		sockets.Mode = DefaultSocketMode
+	}
+	s.unix = sockets
+}
+
+// startUnix listen on unix sockets that are set
+func (s *Server) startUnix() error {
+	if s.unix.API != "" {
+		server := &http.Server{Handler: s.mux}
+		if err := s.serveUnix(server, s.unix.API, true, "API"); err != nil {
+			return err
+		}
+		s.unixServers = append(s.unixServers, server)
+	}
+	if s.unix.Admin != "" {
+		server := &http.Server{Handler: s.authorized(routesAdmin, s.serveAdmin)}
+		if err := s.serveUnix(server, s.unix.Admin, false, "Admin API"); err != nil {
+			return err
+		}
+		s.unixServers = append(s.unixServers, server)
+	}
+	if s.unix.GRPC != "" {
+		listener, err := listenUnix(s.unix.GRPC, s.unix.Mode)
+		if err != nil {
+			return err
+		}
+		s.grpcUnixServer = grpc.NewServer(grpc.UnaryInterceptor(s.grpcUnary), grpc.StreamInterceptor(s.grpcStream))
+		proto.RegisterRandomnessServiceServer(s.grpcUnixServer, s.grpcService)
+		reflection.Register(s.grpcUnixServer)
+		log.Infof("gRPC API listen on unix socket: %s", s.unix.GRPC)
+		go func() {
+			if err := s.grpcUnixServer.Serve(listener); err != nil {
+				log.Errorf("gRPC API on unix socket stopped: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// serveUnix serve HTTP server on unix socket at path
+func (s *Server) serveUnix(server *http.Server, path string, cors bool, name string) error {
+	listener, err := listenUnix(path, s.unix.Mode)
+	if err != nil {
+		return err
+	}
+	s.configure(server, cors)
+	log.Infof("%s listen on unix socket: %s", name, path)
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("%s on unix socket stopped: %v", name, err)
+		}
+	}()
+	return nil
+}
+
+// listenUnix listen on unix socket at path with given permissions, a socket
+// that a previous run left behind is removed. The socket file is removed when
+// listener is closed
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a unix socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("unix socket %s is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// overUnixSocket whether request came in over a unix socket
+func overUnixSocket(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/orochi-network/orochimaru/vrf"
+)
+
+// maxVRFRequestBody largest body of a randomness request, a hex seed of
+// vrf.MaxSeedSize bytes fits with room to spare
+const maxVRFRequestBody = 4 << 10
+
+// VRFRequest JSON body of a randomness request
+type VRFRequest struct {
+	// Seed hex encoded seed
+	Seed string `json:"seed"`
+}
+
+// VRFOutput JSON representation of a randomness request, randomness and
+// signature are empty while it's pending
+type VRFOutput struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Seed       string `json:"seed,omitempty"`
+	Randomness string `json:"randomness,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+}
+
+// Status of randomness requests
+const (
+	VRFPending   = "pending"
+	VRFFulfilled = "fulfilled"
+)
+
+// NewVRFOutput JSON representation of output of a fulfilled request
+func NewVRFOutput(output *vrf.Output) *VRFOutput {
+	return &VRFOutput{
+		ID:         hex.EncodeToString(output.ID),
+		Status:     VRFFulfilled,
+		Seed:       hex.EncodeToString(output.Seed),
+		Randomness: hex.EncodeToString(output.Randomness),
+		Signature:  hex.EncodeToString(output.Signature),
+	}
+}
+
+// SetVRF serve on-demand randomness of service, requests are submitted to
+// POST /vrf and looked up at /vrf/<id>. It must be called before Start
+func (s *Server) SetVRF(service *vrf.Service) {
+	s.requests = service
+}
+
+// handleVRFRequest submit a seed to group, response is the output when seed
+// was signed before and 202 with ID of request otherwise
+func (s *Server) handleVRFRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if s.requests == nil {
+		WriteError(w, http.StatusNotFound, errors.New("node does not serve on-demand randomness"))
+		return
+	}
+	body := new(VRFRequest)
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxVRFRequestBody)).Decode(body); err != nil {
+		WriteError(w, http.StatusBadRequest, errors.New("invalid request body"))
+		return
+	}
+	seed, err := hex.DecodeString(strings.TrimPrefix(body.Seed, "0x"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, errors.New("seed must be hex encoded"))
+		return
+	}
+	if len(seed) == 0 || len(seed) > vrf.MaxSeedSize {
+		WriteError(w, http.StatusBadRequest, errors.New("seed must be between 1 and 256 bytes"))
+		return
+	}
+	id, err := s.requests.Request(seed)
+	if err != nil {
+		WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	s.writeVRFOutput(w, id)
+}
+
+// handleVRFOutput look up a request by its hex ID
+func (s *Server) handleVRFOutput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if s.requests == nil {
+		WriteError(w, http.StatusNotFound, errors.New("node does not serve on-demand randomness"))
+		return
+	}
+	id, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, "/vrf/"))
+	if err != nil || len(id) != vrf.IDSize {
+		WriteError(w, http.StatusBadRequest, errors.New("invalid request ID"))
+		return
+	}
+	s.writeVRFOutput(w, id)
+}
+
+func (s *Server) writeVRFOutput(w http.ResponseWriter, id []byte) {
+	output, pending := s.requests.Lookup(id)
+	switch {
+	case output != nil:
+		WriteJSON(w, http.StatusOK, NewVRFOutput(output))
+	case pending:
+		w.Header().Set("Location", "/vrf/"+hex.EncodeToString(id))
+		WriteJSON(w, http.StatusAccepted, &VRFOutput{ID: hex.EncodeToString(id), Status: VRFPending})
+	default:
+		WriteError(w, http.StatusNotFound, errors.New("unknown or expired request"))
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/tracing"
+)
+
+const (
+	// wsSendBuffer rounds queued per client, a client that falls further behind is dropped
+	wsSendBuffer = 16
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+// hub WebSocket clients that new rounds are pushed to
+type hub struct {
+	clients map[*wsClient]bool
+	mutex   sync.Mutex
+}
+
+// wsClient connection of a WebSocket client
+type wsClient struct {
+	conn *websocket.Conn
+	send chan *Round
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Rounds are public, any origin may subscribe
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func newHub() *hub {
+	return &hub{clients: make(map[*wsClient]bool)}
+}
+
+// Broadcast push a finalized round to all WebSocket clients and gRPC streams
+func (s *Server) Broadcast(result *beacon.RoundResult) {
+	s.grpcService.broadcast(result)
+	round := NewRound(result)
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	for client := range s.hub.clients {
+		select {
+		case client.send <- round:
+		default:
+			log.Debugf("Drop slow WebSocket client: %s", client.conn.RemoteAddr())
+			delete(s.hub.clients, client)
+			close(client.send)
+		}
+	}
+}
+
+// Subscribe cache rounds finalized on bus and push them to WebSocket clients
+// of their beacon, rounds of beacons that are not served are left out. Sync
+// progress of default beacon is kept for dashboard
+func (s *Server) Subscribe(bus *events.Bus) *events.Subscription {
+	return bus.Subscribe("api", func(event events.Event) {
+		if progress, ok := event.(events.SyncProgress); ok {
+			if progress.Beacon == "" {
+				s.syncProgress.set(progress)
+			}
+			return
+		}
+		finalized := event.(events.RoundFinalized)
+		server := s
+		if finalized.Beacon != "" {
+			if server = s.beacons[finalized.Beacon]; server == nil {
+				return
+			}
+		}
+		result := finalized.Result
+		tracing.Run(server.drng.RoundContext(result.Round), "api.broadcast", func() error {
+			server.Cache(&result)
+			server.Broadcast(&result)
+			return nil
+		})
+	}, events.TypeRoundFinalized, events.TypeSyncProgress)
+}
+
+func (h *hub) remove(client *wsClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.clients[client] {
+		delete(h.clients, client)
+		close(client.send)
+	}
+}
+
+// handleWebSocket stream each new round as JSON until client disconnects
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrader has already replied with an error
+		log.Debugf("Unable to upgrade WebSocket connection: %v", err)
+		return
+	}
+	client := &wsClient{conn: conn, send: make(chan *Round, wsSendBuffer)}
+	s.hub.mutex.Lock()
+	s.hub.clients[client] = true
+	s.hub.mutex.Unlock()
+	go client.writeLoop()
+	client.readLoop()
+	s.hub.remove(client)
+}
+
+// readLoop discard client messages and keep connection alive with pongs, it
+// returns once connection is closed
+func (c *wsClient) readLoop() {
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := c.conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// writeLoop write queued rounds and pings until send channel is closed
+func (c *wsClient) writeLoop() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case round, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteJSON(round); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
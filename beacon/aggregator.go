@@ -0,0 +1,525 @@
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PartialTopic pubsub topic of partial signatures
+const PartialTopic = "beacon-partial"
+
+// MessagePartial message type of partial signatures
+const MessagePartial = "partial"
+
+// maxPendingPartials partials kept for a round whose message is not known yet
+const maxPendingPartials = 256
+
+// maxCheckedPartials partials of a round whose verification by Check is cached
+const maxCheckedPartials = 256
+
+// Progress aggregation progress of a round
+type Progress struct {
+	Round     uint64
+	Index     uint32
+	Received  int
+	Threshold int
+	Recovered bool
+}
+
+// aggregation partial signatures collected for one round
+type aggregation struct {
+	message  *keypair.HashedMessage
+	pending  [][]byte
+	verified map[uint32][]byte
+	// verifying partials handed to workers and not verified yet
+	verifying map[string]bool
+	// checked messages that partials were verified against by Check, so
+	// they are not verified again once they are added
+	checked   map[string][]byte
+	done      chan []byte
+	recovered bool
+}
+
+// Aggregator collect partial signatures and recover group signatures.
+// Partials are verified on a pool of workers, pairing checks of a large
+// group would take most of a round on one goroutine
+type Aggregator struct {
+	scheme     keypair.ThresholdScheme
+	pubPoly    *keypair.PubPoly
+	rounds     map[uint64]*aggregation
+	onProgress func(Progress)
+	// Message provides message of a round before it's expected, so partials
+	// that arrive early are verified right away. Nil if it's not known yet
+	Message func(round uint64) *keypair.HashedMessage
+	// Context provides context carrying span of a round that recovery of its
+	// group signature is traced in, recovery is not traced if it's nil
+	Context func(round uint64) context.Context
+	// workers slots of verifications running at once
+	workers chan struct{}
+	mutex   sync.Mutex
+}
+
+// NewAggregator create an aggregator verifying partials of given scheme against
+// given public polynomial on GOMAXPROCS workers
+func NewAggregator(scheme keypair.ThresholdScheme, pubPoly *keypair.PubPoly, onProgress func(Progress)) *Aggregator {
+	return newAggregator(scheme, pubPoly, runtime.GOMAXPROCS(0), onProgress)
+}
+
+func newAggregator(scheme keypair.ThresholdScheme, pubPoly *keypair.PubPoly, workers int, onProgress func(Progress)) *Aggregator {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Aggregator{
+		scheme:     scheme,
+		pubPoly:    pubPoly,
+		rounds:     make(map[uint64]*aggregation),
+		onProgress: onProgress,
+		workers:    make(chan struct{}, workers),
+	}
+}
+
+func (a *Aggregator) get(round uint64) *aggregation {
+	state, ok := a.rounds[round]
+	if !ok {
+		state = &aggregation{
+			verified:  make(map[uint32][]byte),
+			verifying: make(map[string]bool),
+			checked:   make(map[string][]byte),
+			done:      make(chan []byte, 1),
+		}
+		a.rounds[round] = state
+	}
+	return state
+}
+
+// message message of a round, nil if it's neither expected nor provided by
+// Message. It must be called without holding mutex
+func (a *Aggregator) message(round uint64) *keypair.HashedMessage {
+	a.mutex.Lock()
+	state, ok := a.rounds[round]
+	if ok && state.message != nil {
+		message := state.message
+		a.mutex.Unlock()
+		return message
+	}
+	a.mutex.Unlock()
+	if a.Message == nil {
+		return nil
+	}
+	return a.Message(round)
+}
+
+// Expect set message of a round, recovered group signature is sent to returned channel
+func (a *Aggregator) Expect(round uint64, message []byte) <-chan []byte {
+	hashed, err := a.scheme.HashMessage(message)
+	if err != nil {
+		// Hash to curve fails with negligible probability, round times out
+		log.Errorf("Unable to hash message of round %d: %v", round, err)
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		return a.get(round).done
+	}
+	return a.ExpectHashed(round, hashed)
+}
+
+// ExpectHashed set message of a round that was hashed ahead, like Expect
+func (a *Aggregator) ExpectHashed(round uint64, message *keypair.HashedMessage) <-chan []byte {
+	a.mutex.Lock()
+	state := a.get(round)
+	if state.message != nil && !bytes.Equal(state.message.Data, message.Data) {
+		// Message provided early turned out wrong, verify partials again
+		for _, partial := range state.verified {
+			state.pending = append(state.pending, partial)
+		}
+		state.verified = make(map[uint32][]byte)
+	}
+	state.message = message
+	pending := state.pending
+	state.pending = nil
+	jobs := make([][]byte, 0, len(pending))
+	for _, partial := range pending {
+		if a.claim(state, partial) {
+			jobs = append(jobs, partial)
+		}
+	}
+	a.mutex.Unlock()
+	for _, partial := range jobs {
+		a.dispatch(round, message, partial)
+	}
+	return state.done
+}
+
+// Add a partial signature of a round, it's verified once message of round is
+// known. It blocks while every worker is busy
+func (a *Aggregator) Add(round uint64, partial []byte) {
+	message := a.message(round)
+	a.mutex.Lock()
+	state := a.get(round)
+	if state.message == nil && message != nil {
+		state.message = message
+	}
+	message = state.message
+	if message == nil {
+		if len(state.pending) < maxPendingPartials {
+			state.pending = append(state.pending, partial)
+		}
+		a.mutex.Unlock()
+		return
+	}
+	claimed := a.claim(state, partial)
+	a.mutex.Unlock()
+	if claimed {
+		a.dispatch(round, message, partial)
+	}
+}
+
+// errUnknownRound message of round is not known yet, its partials can not be
+// verified
+var errUnknownRound = errors.New("message of round is not known yet")
+
+// Check verify a partial signature, errUnknownRound is returned if message of
+// its round is not known yet. Valid partials are cached so Add does not verify
+// them again
+func (a *Aggregator) Check(round uint64, partial []byte) error {
+	index, err := keypair.PartialIndex(partial)
+	if err != nil {
+		return err
+	}
+	message := a.message(round)
+	if message == nil {
+		return errUnknownRound
+	}
+	a.mutex.Lock()
+	if state, ok := a.rounds[round]; ok {
+		if verified, ok := state.verified[index]; ok && bytes.Equal(verified, partial) {
+			a.mutex.Unlock()
+			return nil
+		}
+		if checked, ok := state.checked[string(partial)]; ok && bytes.Equal(checked, message.Data) {
+			a.mutex.Unlock()
+			return nil
+		}
+	}
+	a.mutex.Unlock()
+	if ok, err := a.scheme.VerifyPartial(a.pubPoly, message, partial); err != nil || !ok {
+		return errors.New("invalid partial signature")
+	}
+	a.mutex.Lock()
+	state := a.get(round)
+	if state.message == nil {
+		state.message = message
+	}
+	if len(state.checked) < maxCheckedPartials {
+		state.checked[string(partial)] = message.Data
+	}
+	a.mutex.Unlock()
+	return nil
+}
+
+// Received share indexes whose partials of given round were verified, in order
+func (a *Aggregator) Received(round uint64) []uint32 {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	indexes := make([]uint32, 0)
+	if state, ok := a.rounds[round]; ok {
+		for index := range state.verified {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// Forget drop state of rounds before given round
+func (a *Aggregator) Forget(before uint64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	for round := range a.rounds {
+		if round < before {
+			delete(a.rounds, round)
+		}
+	}
+}
+
+// Remove drop state of a round, for aggregations that are not keyed by
+// increasing round numbers
+func (a *Aggregator) Remove(round uint64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	delete(a.rounds, round)
+}
+
+// claim mark a partial as being verified, false if it needs no verification.
+// Caller must hold mutex
+func (a *Aggregator) claim(state *aggregation, partial []byte) bool {
+	if state.recovered || state.verifying[string(partial)] {
+		return false
+	}
+	index, err := keypair.PartialIndex(partial)
+	if err != nil {
+		return false
+	}
+	if _, ok := state.verified[index]; ok {
+		return false
+	}
+	state.verifying[string(partial)] = true
+	return true
+}
+
+// dispatch verify a claimed partial on a worker, it blocks until a worker
+// is free
+func (a *Aggregator) dispatch(round uint64, message *keypair.HashedMessage, partial []byte) {
+	a.workers <- struct{}{}
+	go func() {
+		defer func() { <-a.workers }()
+		a.verify(round, message, partial)
+	}()
+}
+
+// verify check a claimed partial against message and recover group
+// signature once threshold partials are verified
+func (a *Aggregator) verify(round uint64, message *keypair.HashedMessage, partial []byte) {
+	index, _ := keypair.PartialIndex(partial)
+	a.mutex.Lock()
+	var cached bool
+	if state, ok := a.rounds[round]; ok {
+		checked, ok := state.checked[string(partial)]
+		cached = ok && bytes.Equal(checked, message.Data)
+	}
+	a.mutex.Unlock()
+	valid := cached
+	if !cached {
+		ok, err := a.scheme.VerifyPartial(a.pubPoly, message, partial)
+		valid = err == nil && ok
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	state, ok := a.rounds[round]
+	if !ok {
+		return
+	}
+	delete(state.verifying, string(partial))
+	if state.message == nil || !bytes.Equal(state.message.Data, message.Data) {
+		// Message changed while partial was verified, Expect queued it again
+		return
+	}
+	if !valid {
+		log.Warnf("Invalid partial signature from share %d in round %d", index, round)
+		return
+	}
+	if _, ok := state.verified[index]; ok || state.recovered {
+		return
+	}
+	state.verified[index] = partial
+	threshold := a.pubPoly.Threshold()
+	progress := Progress{Round: round, Index: index, Received: len(state.verified), Threshold: threshold}
+	if len(state.verified) >= threshold {
+		partials := make([][]byte, 0, len(state.verified))
+		for _, p := range state.verified {
+			partials = append(partials, p)
+		}
+		span := trace.SpanFromContext(context.Background())
+		if a.Context != nil {
+			_, span = tracing.Start(a.Context(round), "beacon.aggregate", trace.WithAttributes(attribute.Int("partials", len(partials))))
+		}
+		signature, err := a.scheme.RecoverSignature(partials, threshold)
+		if err == nil {
+			var ok bool
+			if ok, err = a.scheme.Verify(a.pubPoly.PublicKey(), state.message.Data, signature); ok {
+				state.recovered = true
+				progress.Recovered = true
+				state.done <- signature
+			} else if err == nil {
+				err = errors.New("recovered group signature is invalid")
+			}
+		}
+		tracing.End(span, err)
+	}
+	if a.onProgress != nil {
+		a.onProgress(progress)
+	}
+}
+
+// SetThreshold make beacon produce rounds with threshold signatures of Scheme
+// with given share
+func (b *Beacon) SetThreshold(share *keypair.PriShare, pubPoly *keypair.PubPoly) error {
+	scheme, err := keypair.GetThresholdScheme(b.Scheme)
+	if err != nil {
+		return err
+	}
+	b.scheme = scheme
+	b.share = share
+	b.pubPoly = pubPoly
+	b.aggregator = NewAggregator(scheme, pubPoly, func(progress Progress) {
+		b.traceProgress(progress)
+		if b.OnProgress != nil {
+			b.OnProgress(progress)
+		}
+	})
+	b.aggregator.Message = b.expectedMessage
+	b.aggregator.Context = b.RoundContext
+	return nil
+}
+
+// Threshold number of partial signatures a round needs, 0 if beacon is not threshold
+func (b *Beacon) Threshold() int {
+	if b.pubPoly == nil {
+		return 0
+	}
+	return b.pubPoly.Threshold()
+}
+
+// ThresholdKeys share of node and public polynomial of group, nil if beacon
+// is not threshold
+func (b *Beacon) ThresholdKeys() (*keypair.PriShare, *keypair.PubPoly) {
+	return b.share, b.pubPoly
+}
+
+// GroupKey group public key of threshold beacon or of followed chain, nil otherwise
+func (b *Beacon) GroupKey() []byte {
+	if b.pubPoly == nil {
+		return b.groupKey
+	}
+	return b.pubPoly.PublicKey()
+}
+
+// validatePartial reject invalid partial signatures before they are propagated
+func (b *Beacon) validatePartial(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	e := network.EnvelopeOf(msg)
+	if e == nil || e.Type != MessagePartial || len(e.Payload) != b.scheme.PartialSize() {
+		return pubsub.ValidationReject
+	}
+	current := b.CurrentRound(b.Clock.Now())
+	if e.Round+1 < current || e.Round > current+1 {
+		return pubsub.ValidationIgnore
+	}
+	switch err := b.aggregator.Check(e.Round, e.Payload); {
+	case errors.Is(err, errUnknownRound):
+		// Unverified partials are not relayed, aggregator keeps them until
+		// message of round is known
+		b.aggregator.Add(e.Round, e.Payload)
+		return pubsub.ValidationIgnore
+	case err != nil:
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationAccept
+}
+
+// validateResult reject rounds that are not signed by the group
+func (b *Beacon) validateResult(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	e := network.EnvelopeOf(msg)
+	if e == nil || e.Type != MessageResult {
+		return pubsub.ValidationReject
+	}
+	result := new(RoundResult)
+	if err := network.DecodePayload(e.Payload, result); err != nil || result.Round != e.Round {
+		return pubsub.ValidationReject
+	}
+	if err := b.VerifyRound(result, nil); err != nil {
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationAccept
+}
+
+// handlePartial feed partial signatures of peers to aggregator
+func (b *Beacon) handlePartial(e *network.Envelope) {
+	current := b.CurrentRound(b.Clock.Now())
+	if e.Round+1 < current || e.Round > current+1 {
+		return
+	}
+	b.aggregator.Add(e.Round, e.Payload)
+}
+
+// handleResult adopt verified rounds of peers, so a restarted node can rejoin the chain
+func (b *Beacon) handleResult(e *network.Envelope) {
+	result := new(RoundResult)
+	if err := network.DecodePayload(e.Payload, result); err != nil || result.Round != e.Round {
+		return
+	}
+	if err := b.VerifyRound(result, nil); err != nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if result.Round > b.lastRound {
+		b.lastRound = result.Round
+		b.lastSignature = result.Signature
+	}
+}
+
+// produceThreshold broadcast our partial signature and wait until group
+// signature is recovered, ctx carries span of round
+func (b *Beacon) produceThreshold(ctx context.Context, round uint64) (*RoundResult, error) {
+	b.mutex.Lock()
+	previousSignature, err := b.previousSignature(round)
+	b.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	message := Message(round, previousSignature)
+	var partial []byte
+	var done <-chan []byte
+	entry := b.precomputedRound(round, message)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("precomputed", entry != nil))
+	if entry != nil {
+		partial = entry.partial
+		done = b.aggregator.ExpectHashed(round, entry.message)
+	} else {
+		_, span := tracing.Start(ctx, "beacon.partial.sign")
+		hashed, err := b.scheme.HashMessage(message)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, err
+		}
+		partial = b.scheme.SignPartial(b.share, hashed)
+		done = b.aggregator.ExpectHashed(round, hashed)
+	}
+	_, span := tracing.Start(ctx, "beacon.partial.broadcast")
+	b.aggregator.Add(round, partial)
+	err = b.net.Send(b.TopicName(PartialTopic), MessagePartial, round, partial)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	defer b.aggregator.Forget(round)
+
+	_, span = tracing.Start(ctx, "beacon.partials.collect")
+	timer := b.Clock.NewTimer(b.roundDeadline(round).Sub(b.Clock.Now()))
+	defer timer.Stop()
+	select {
+	case <-b.context.Done():
+		tracing.End(span, b.context.Err())
+		return nil, b.context.Err()
+	case <-timer.C():
+		span.SetAttributes(attribute.Int("received", len(b.aggregator.Received(round))))
+		tracing.End(span, errThresholdNotReached)
+		b.skip(round)
+		return nil, errThresholdNotReached
+	case signature := <-done:
+		span.SetAttributes(attribute.Int("received", len(b.aggregator.Received(round))))
+		span.End()
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.lastRound = round
+		b.lastSignature = signature
+		return &RoundResult{
+			Round:             round,
+			Randomness:        Randomness(signature),
+			Signature:         signature,
+			PreviousSignature: previousSignature,
+		}, nil
+	}
+}
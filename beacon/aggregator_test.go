@@ -0,0 +1,92 @@
+package beacon
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// BenchmarkAggregation time recovering a group signature of 10 members with
+// threshold 7 from partials that arrived before their message was known
+func BenchmarkAggregation(b *testing.B) {
+	const members, threshold = 10, 7
+	scheme, err := keypair.GetThresholdScheme(keypair.SchemeBLS)
+	if err != nil {
+		b.Fatal(err)
+	}
+	poly, err := keypair.NewPriPoly(threshold, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	message := Message(1, nil)
+	hashed, err := scheme.HashMessage(message)
+	if err != nil {
+		b.Fatal(err)
+	}
+	partials := make([][]byte, 0, members)
+	for _, share := range poly.Shares(members) {
+		partials = append(partials, scheme.SignPartial(share, hashed))
+	}
+	pubPoly := poly.Commit()
+	workerCounts := []int{1}
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		workerCounts = append(workerCounts, procs)
+	}
+	for _, workers := range workerCounts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				aggregator := newAggregator(scheme, pubPoly, workers, nil)
+				for _, partial := range partials {
+					aggregator.Add(1, partial)
+				}
+				<-aggregator.ExpectHashed(1, hashed)
+			}
+		})
+	}
+}
+
+// BenchmarkPrecompute time getting a round ready to publish its partial and
+// verify partials of peers, with and without precomputation
+func BenchmarkPrecompute(b *testing.B) {
+	scheme, err := keypair.GetThresholdScheme(keypair.SchemeBLS)
+	if err != nil {
+		b.Fatal(err)
+	}
+	poly, err := keypair.NewPriPoly(1, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	share := poly.Shares(1)[0]
+	pubPoly := poly.Commit()
+	b.Run("signed", func(b *testing.B) {
+		aggregator := newAggregator(scheme, pubPoly, 1, nil)
+		for i := 0; i < b.N; i++ {
+			round := uint64(i + 1)
+			hashed, err := scheme.HashMessage(Message(round, nil))
+			if err != nil {
+				b.Fatal(err)
+			}
+			scheme.SignPartial(share, hashed)
+			aggregator.ExpectHashed(round, hashed)
+			aggregator.Remove(round)
+		}
+	})
+	b.Run("precomputed", func(b *testing.B) {
+		drng := newPrecomputeBeacon(scheme, share)
+		aggregator := newAggregator(scheme, pubPoly, 1, nil)
+		for i := 0; i < b.N; i++ {
+			round := uint64(i + 1)
+			b.StopTimer()
+			drng.precompute(round)
+			b.StartTimer()
+			entry := drng.precomputedRound(round, Message(round, nil))
+			if entry == nil {
+				b.Fatal("round was not precomputed")
+			}
+			aggregator.ExpectHashed(round, entry.message)
+			aggregator.Remove(round)
+		}
+	})
+}
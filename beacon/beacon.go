@@ -0,0 +1,498 @@
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/orochi-network/orochimaru/clock"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/tracing"
+	"go.uber.org/zap"
+)
+
+// Topic pubsub topic where round results are published
+const Topic = "beacon"
+
+// MessageResult message type of round results
+const MessageResult = "result"
+
+// validatorBeacon key of beacon validators
+const validatorBeacon = "beacon"
+
+var errThresholdNotReached = errors.New("threshold was not reached before round deadline")
+
+// errChainUnknown previous round of a chained round is neither known nor stored,
+// round can't be signed until chain is synced
+var errChainUnknown = errors.New("previous round is unknown, chain must be synced first")
+
+// RoundStore stored rounds, beacon resumes its chain from the latest one when
+// it doesn't know the previous round
+type RoundStore interface {
+	Latest() (*RoundResult, error)
+}
+
+// Beacon modes
+const (
+	// ModeChained each round is the signature over previous round
+	ModeChained = "chained"
+	// ModeUnchained each round is the signature over its round number only, so
+	// the message of a future round is known in advance, as timelock encryption needs
+	ModeUnchained = "unchained"
+	// ModeCommitReveal each round aggregates secrets committed and revealed by nodes
+	ModeCommitReveal = "commit-reveal"
+)
+
+// RoundResult output of a beacon round
+type RoundResult struct {
+	Round             uint64 `json:"round"`
+	Randomness        []byte `json:"randomness"`
+	Signature         []byte `json:"signature"`
+	PreviousSignature []byte `json:"previousSignature"`
+}
+
+// ChainInfo public parameters of a beacon chain
+type ChainInfo struct {
+	PublicKey   []byte
+	Period      time.Duration
+	GenesisTime time.Time
+	Mode        string
+	// Scheme name of signature scheme rounds are signed with
+	Scheme string
+}
+
+// Beacon drive fixed-period randomness rounds
+type Beacon struct {
+	Period  time.Duration
+	Genesis time.Time
+	Mode    string
+	// Scheme name of threshold signature scheme of group, it must be set
+	// before SetThreshold, see keypair.GetThresholdScheme
+	Scheme string
+	// OnProgress called whenever a partial signature is verified in threshold mode
+	OnProgress func(Progress)
+	// OnSkip called when a threshold round misses its deadline
+	OnSkip func(Skip)
+	// OnChainUnknown called when a chained round can't be signed as the
+	// previous round is neither known nor stored, chain should be synced
+	OnChainUnknown func(round uint64)
+	// Halted rounds are skipped without signing while it returns true, as
+	// after an emergency halt of governance
+	Halted func() bool
+	// TimeoutRatio fraction of period partials of a round may take to arrive
+	TimeoutRatio float64
+	// Namespace separates topics of beacons sharing a network, it's empty for
+	// the default beacon of a node
+	Namespace string
+	// Clock schedules rounds and their timeouts, a mock clock lets tests
+	// advance rounds without waiting
+	Clock clock.Clock
+	// PrecomputeDepth upcoming rounds whose message is hashed to curve and
+	// signed with share of node ahead of time, none if it's 0
+	PrecomputeDepth int
+	// Members of group in share order, used to name members that missed a round
+	Members       []peer.ID
+	stats         Stats
+	net           *network.Network
+	nodeKey       keypair.Signer
+	results       chan RoundResult
+	lastSignature []byte
+	lastRound     uint64
+	store         RoundStore
+	commitReveal  map[uint64]*commitRevealRound
+	scheme        keypair.ThresholdScheme
+	share         *keypair.PriShare
+	pubPoly       *keypair.PubPoly
+	groupKey      []byte
+	aggregator    *Aggregator
+	rounds        sync.WaitGroup
+	context       context.Context
+	cancel        context.CancelFunc
+	mutex         sync.Mutex
+	// precomputed upcoming rounds by number, see precompute
+	precomputed     map[uint64]*precomputed
+	precomputeMutex sync.Mutex
+	// liveness latest heartbeat of each member, see StartHeartbeat
+	liveness          map[peer.ID]*liveness
+	heartbeatInterval time.Duration
+	// traces contexts carrying spans of recent rounds, see RoundContext
+	traces     map[uint64]context.Context
+	traceMutex sync.Mutex
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("beacon")
+	network.SetPayloadLimit(MessagePartial, keypair.PartialSignatureSize)
+	network.SetPayloadLimit(MessageResult, 1024)
+	network.SetPayloadLimit(MessageCommit, sha256.Size)
+	network.SetPayloadLimit(MessageReveal, 32)
+	network.SetPayloadLimit(MessageHeartbeat, 256)
+}
+
+// New create a beacon with given period, genesis is Unix epoch until it's changed
+func New(net *network.Network, nodeKey keypair.Signer, period time.Duration) *Beacon {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Beacon{
+		Period:       period,
+		Genesis:      time.Unix(0, 0),
+		Mode:         ModeChained,
+		Scheme:       keypair.SchemeBLS,
+		TimeoutRatio: DefaultTimeoutRatio,
+		Clock:        clock.New(),
+		stats:        Stats{Missed: make(map[uint32]uint64)},
+		net:          net,
+		nodeKey:      nodeKey,
+		results:      make(chan RoundResult, 16),
+		commitReveal: make(map[uint64]*commitRevealRound),
+		context:      ctx,
+		cancel:       cancel,
+		// Next round is prepared while waiting for it
+		PrecomputeDepth: DefaultPrecomputeDepth,
+		precomputed:     make(map[uint64]*precomputed),
+		// Spans of recent rounds, see RoundContext
+		traces: make(map[uint64]context.Context),
+	}
+}
+
+// TopicName name of given beacon topic in namespace of beacon
+func (b *Beacon) TopicName(topic string) string {
+	return NamespacedTopic(b.Namespace, topic)
+}
+
+// NamespacedTopic name of a beacon topic in given namespace
+func NamespacedTopic(namespace string, topic string) string {
+	if namespace == "" {
+		return topic
+	}
+	return namespace + "/" + topic
+}
+
+// CheckPeriod period must be a positive number of seconds, chain info hashes
+// and serves it in seconds
+func CheckPeriod(period time.Duration) error {
+	if period < time.Second || period%time.Second != 0 {
+		return errors.New("period must be a positive number of seconds")
+	}
+	return nil
+}
+
+// Hash identify a chain by its public parameters, so clients can pin a chain
+// with a single value. Period is hashed in seconds, see CheckPeriod
+func (c ChainInfo) Hash() []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, uint64(c.Period.Seconds()))
+	binary.BigEndian.PutUint64(buf[8:], uint64(c.GenesisTime.Unix()))
+	h := sha256.New()
+	h.Write(c.PublicKey)
+	h.Write(buf)
+	h.Write([]byte(c.Mode))
+	h.Write([]byte(c.Scheme))
+	return h.Sum(nil)
+}
+
+// ChainInfo public parameters of this beacon, a beacon that is neither
+// threshold nor following a chain signs rounds with scheme of node key
+func (b *Beacon) ChainInfo() ChainInfo {
+	scheme := b.Scheme
+	if b.GroupKey() == nil && b.nodeKey != nil {
+		if name, err := keypair.SchemeName(int(b.nodeKey.GetPublicKey().Type())); err == nil {
+			scheme = name
+		}
+	}
+	return ChainInfo{
+		PublicKey:   b.GroupKey(),
+		Period:      b.Period,
+		GenesisTime: b.Genesis,
+		Mode:        b.Mode,
+		Scheme:      scheme,
+	}
+}
+
+// Resume chain next rounds to given round, used to continue the chain after a restart
+func (b *Beacon) Resume(last *RoundResult) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if last.Round > b.lastRound {
+		b.lastRound = last.Round
+		b.lastSignature = last.Signature
+	}
+}
+
+// SetStore set store that chain is resumed from when previous round is
+// unknown, such as after a restart or once rounds are synced
+func (b *Beacon) SetStore(store RoundStore) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.store = store
+}
+
+// Results channel of produced rounds
+func (b *Beacon) Results() <-chan RoundResult {
+	return b.results
+}
+
+// CurrentRound round number at given time, round 1 starts at genesis
+func (b *Beacon) CurrentRound(t time.Time) uint64 {
+	if t.Before(b.Genesis) {
+		return 0
+	}
+	return uint64(t.Sub(b.Genesis)/b.Period) + 1
+}
+
+// RoundTime time when given round starts
+func (b *Beacon) RoundTime(round uint64) time.Time {
+	if round == 0 {
+		return b.Genesis
+	}
+	return b.Genesis.Add(time.Duration(round-1) * b.Period)
+}
+
+// GenesisSeed previous signature of round 1
+func (b *Beacon) GenesisSeed() []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(b.Genesis.Unix()))
+	seed := sha256.Sum256(buf)
+	return seed[:]
+}
+
+// Message message to be signed in given round, previous signature is nil in unchained mode
+func Message(round uint64, previousSignature []byte) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, round)
+	h := sha256.New()
+	h.Write(previousSignature)
+	h.Write(buf)
+	return h.Sum(nil)
+}
+
+// Randomness derive randomness from round signature
+func Randomness(signature []byte) []byte {
+	randomness := sha256.Sum256(signature)
+	return randomness[:]
+}
+
+// VerifyRound verify group signature and randomness of a round, when previous
+// round is given the round must be chained to it
+func (b *Beacon) VerifyRound(result *RoundResult, previous *RoundResult) error {
+	groupKey := b.GroupKey()
+	if groupKey == nil {
+		return errors.New("rounds can only be verified by threshold beacon")
+	}
+	if b.Mode == ModeUnchained {
+		if len(result.PreviousSignature) != 0 {
+			return errors.New("unchained round must not carry previous signature")
+		}
+		return Verify(b.Scheme, groupKey, result)
+	}
+	if result.Round == 1 && !bytes.Equal(result.PreviousSignature, b.GenesisSeed()) {
+		return errors.New("round 1 is not chained to genesis seed")
+	}
+	if len(result.PreviousSignature) == 0 {
+		return errors.New("chained round must carry previous signature")
+	}
+	if previous != nil && previous.Round+1 == result.Round &&
+		!bytes.Equal(result.PreviousSignature, previous.Signature) {
+		return errors.New("round is not chained to previous round")
+	}
+	return Verify(b.Scheme, groupKey, result)
+}
+
+// Verify check randomness and group signature of a round against group public
+// key of given scheme, chaining to previous rounds is not checked
+func Verify(scheme string, groupKey []byte, result *RoundResult) error {
+	if !bytes.Equal(result.Randomness, Randomness(result.Signature)) {
+		return errors.New("randomness does not match signature")
+	}
+	s, err := keypair.GetScheme(scheme)
+	if err != nil {
+		return err
+	}
+	ok, err := s.Verify(groupKey, Message(result.Round, result.PreviousSignature), result.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid group signature")
+	}
+	return nil
+}
+
+// Start produce rounds in background until beacon is stopped
+func (b *Beacon) Start() {
+	if b.Mode == ModeCommitReveal {
+		b.handle(b.TopicName(CommitRevealTopic), MessageCommit, b.handleCommitReveal)
+		b.handle(b.TopicName(CommitRevealTopic), MessageReveal, b.handleCommitReveal)
+	} else if b.aggregator != nil {
+		b.handle(b.TopicName(PartialTopic), MessagePartial, b.handlePartial)
+		b.handle(b.TopicName(Topic), MessageResult, b.handleResult)
+		b.handle(b.TopicName(ResultsTopic), MessageResult, b.handleResult)
+		b.validate(b.TopicName(PartialTopic), b.validatePartial)
+		b.validate(b.TopicName(Topic), b.validateResult)
+		b.validate(b.TopicName(ResultsTopic), b.validateResult)
+	}
+	go b.run()
+}
+
+// Stop producing rounds
+func (b *Beacon) Stop() {
+	b.cancel()
+	b.net.RemoveHandler(b.TopicName(CommitRevealTopic), MessageCommit)
+	b.net.RemoveHandler(b.TopicName(CommitRevealTopic), MessageReveal)
+	b.net.RemoveHandler(b.TopicName(PartialTopic), MessagePartial)
+	b.net.RemoveHandler(b.TopicName(Topic), MessageResult)
+	b.net.RemoveHandler(b.TopicName(ResultsTopic), MessageResult)
+	b.net.Topics().RemoveValidator(b.TopicName(PartialTopic), validatorBeacon)
+	b.net.Topics().RemoveValidator(b.TopicName(Topic), validatorBeacon)
+	b.net.Topics().RemoveValidator(b.TopicName(ResultsTopic), validatorBeacon)
+}
+
+func (b *Beacon) handle(topicName string, msgType string, handler network.Handler) {
+	if err := b.net.Handle(topicName, msgType, handler); err != nil {
+		log.Errorf("Unable to handle %s messages of %s: %v", msgType, topicName, err)
+	}
+}
+
+// validate add beacon validator of a topic, it runs after envelope validator
+func (b *Beacon) validate(topicName string, validator pubsub.ValidatorEx) {
+	if err := b.net.Topics().AddValidator(topicName, validatorBeacon, validator); err != nil {
+		log.Errorf("Unable to validate messages of %s: %v", topicName, err)
+	}
+}
+
+func (b *Beacon) run() {
+	defer func() {
+		b.rounds.Wait()
+		close(b.results)
+	}()
+	for {
+		nextRound := b.CurrentRound(b.Clock.Now()) + 1
+		if b.aggregator != nil {
+			go b.precompute(nextRound)
+		}
+		timer := b.Clock.NewTimer(b.RoundTime(nextRound).Sub(b.Clock.Now()))
+		select {
+		case <-b.context.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		if b.Halted != nil && b.Halted() {
+			log.Warnf("Skip round %d: signing is halted", nextRound)
+			continue
+		}
+		if b.Mode == ModeCommitReveal {
+			b.rounds.Add(1)
+			go func(round uint64) {
+				defer b.rounds.Done()
+				b.runCommitReveal(round)
+			}(nextRound)
+			continue
+		}
+		var result *RoundResult
+		var err error
+		ctx := b.startRound(nextRound)
+		if b.aggregator != nil {
+			result, err = b.produceThreshold(ctx, nextRound)
+		} else {
+			result, err = b.produce(nextRound)
+		}
+		if err == errThresholdNotReached {
+			endRound(ctx, err)
+			continue
+		}
+		if err == errChainUnknown {
+			log.Warnf("Skip round %d: %v", nextRound, err)
+			endRound(ctx, err)
+			if b.OnChainUnknown != nil {
+				b.OnChainUnknown(nextRound)
+			}
+			continue
+		}
+		if err != nil {
+			log.Errorf("Unable to produce round %d: %v", nextRound, err)
+			endRound(ctx, err)
+			continue
+		}
+		b.mutex.Lock()
+		b.stats.Produced++
+		b.mutex.Unlock()
+		_, span := tracing.Start(ctx, "beacon.publish")
+		b.publish(result)
+		span.End()
+		endRound(ctx, nil)
+	}
+}
+
+// produce sign given round and chain it to the previous one
+func (b *Beacon) produce(round uint64) (*RoundResult, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	previousSignature, err := b.previousSignature(round)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := b.nodeKey.Sign(Message(round, previousSignature))
+	if err != nil {
+		return nil, err
+	}
+	b.lastRound = round
+	b.lastSignature = signature
+	return &RoundResult{
+		Round:             round,
+		Randomness:        Randomness(signature),
+		Signature:         signature,
+		PreviousSignature: previousSignature,
+	}, nil
+}
+
+// previousSignature signature that given round is chained to, caller must hold
+// mutex. Rounds are chained to the last produced round, skipped rounds in
+// between are left out of the chain. Latest stored round is loaded if beacon
+// doesn't know any round yet, errChainUnknown if there's none before round
+func (b *Beacon) previousSignature(round uint64) ([]byte, error) {
+	if b.Mode == ModeUnchained {
+		return nil, nil
+	}
+	if round == 1 {
+		return b.GenesisSeed(), nil
+	}
+	if b.lastRound == 0 && b.store != nil {
+		if last, err := b.store.Latest(); err == nil && last.Round < round {
+			b.lastRound = last.Round
+			b.lastSignature = last.Signature
+		}
+	}
+	if b.lastRound == 0 || b.lastRound >= round {
+		return nil, errChainUnknown
+	}
+	return b.lastSignature, nil
+}
+
+// publish result to local consumers and to the network
+func (b *Beacon) publish(result *RoundResult) {
+	select {
+	case b.results <- *result:
+	default:
+		log.Warnf("Result channel is full, round %d was not delivered locally", result.Round)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if err := b.net.Send(b.TopicName(Topic), MessageResult, result.Round, data); err != nil {
+		log.Warnf("Unable to publish round %d: %v", result.Round, err)
+	}
+	b.relay(data, result.Round)
+}
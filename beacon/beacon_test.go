@@ -0,0 +1,106 @@
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/orochi-network/orochimaru/clock"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// newMockBeacon single node beacon on an in-memory host whose rounds are
+// scheduled on a mock clock, genesis is one period after start of clock
+func newMockBeacon(t *testing.T, period time.Duration) (*Beacon, *clock.Mock) {
+	key, err := keypair.NewEd25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := multiaddr.NewMultiaddr("/ip6/100::1/tcp/4001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := mocknet.New(context.Background()).AddPeer(key.GetPrivateKey(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net := network.NewWithHost(host, network.Identifier{Name: network.Devnet}, key)
+	t.Cleanup(func() { net.Close() })
+	mock := clock.NewMock(time.Unix(1600000000, 0))
+	b := New(net, key, period)
+	b.Genesis = mock.Now().Add(period)
+	b.Clock = mock
+	return b, mock
+}
+
+// nextResult round that beacon produces once mock clock is advanced to the
+// start of next round
+func nextResult(t *testing.T, b *Beacon, mock *clock.Mock) RoundResult {
+	mock.WaitForTimers(1)
+	select {
+	case result := <-b.Results():
+		t.Fatalf("round %d was produced before its time", result.Round)
+	default:
+	}
+	mock.Set(b.RoundTime(b.CurrentRound(mock.Now()) + 1))
+	select {
+	case result := <-b.Results():
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("no round was produced after mock clock was advanced")
+	}
+	return RoundResult{}
+}
+
+func TestScheduleRounds(t *testing.T) {
+	b, mock := newMockBeacon(t, 30*time.Second)
+	b.Start()
+	defer b.Stop()
+
+	previous := b.GenesisSeed()
+	for round := uint64(1); round <= 3; round++ {
+		result := nextResult(t, b, mock)
+		if result.Round != round {
+			t.Fatalf("got round %d, want round %d", result.Round, round)
+		}
+		if !bytes.Equal(result.PreviousSignature, previous) {
+			t.Fatalf("round %d is not chained to the round before it", round)
+		}
+		if !bytes.Equal(result.Randomness, Randomness(result.Signature)) {
+			t.Fatalf("randomness of round %d does not match its signature", round)
+		}
+		ok, err := b.nodeKey.GetPublicKey().Verify(Message(round, previous), result.Signature)
+		if err != nil || !ok {
+			t.Fatalf("signature of round %d does not verify: %v", round, err)
+		}
+		previous = result.Signature
+	}
+	if produced := b.Stats().Produced; produced != 3 {
+		t.Fatalf("stats count %d produced rounds, want 3", produced)
+	}
+}
+
+func TestScheduleHalted(t *testing.T) {
+	b, mock := newMockBeacon(t, 30*time.Second)
+	b.Halted = func() bool {
+		return b.CurrentRound(mock.Now()) == 2
+	}
+	b.Start()
+	defer b.Stop()
+
+	first := nextResult(t, b, mock)
+	// Round 2 is skipped, its timer fires without a result
+	mock.WaitForTimers(1)
+	mock.Set(b.RoundTime(2))
+	third := nextResult(t, b, mock)
+	if first.Round != 1 || third.Round != 3 {
+		t.Fatalf("got rounds %d and %d, want rounds 1 and 3", first.Round, third.Round)
+	}
+	if !bytes.Equal(third.PreviousSignature, first.Signature) {
+		t.Fatal("round after a halted round is not chained to the last produced round")
+	}
+}
@@ -0,0 +1,141 @@
+package beacon
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// Commit-reveal mode, every node commits to a random secret at round start,
+// reveals it a third of a period later and the round output is the hash of
+// all secrets revealed within two thirds of a period. Nodes that committed
+// but did not reveal in time are left out of the round.
+
+// CommitRevealTopic pubsub topic of commit-reveal messages
+const CommitRevealTopic = "beacon-commit-reveal"
+
+// Message types of commit-reveal topic
+const (
+	MessageCommit = "commit"
+	MessageReveal = "reveal"
+)
+
+// commitRevealRound commitments and reveals received in a round
+type commitRevealRound struct {
+	commits map[peer.ID][]byte
+	reveals map[peer.ID][]byte
+}
+
+// commitment of a secret, it binds round and sender
+func commitment(round uint64, sender peer.ID, secret []byte) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, round)
+	h := sha256.New()
+	h.Write(buf)
+	h.Write([]byte(sender))
+	h.Write(secret)
+	return h.Sum(nil)
+}
+
+// handleCommitReveal collect commitments and reveals of peers
+func (b *Beacon) handleCommitReveal(e *network.Envelope) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	current := b.CurrentRound(b.Clock.Now())
+	// Only accept messages of current round, with one round of clock skew
+	if e.Round+1 < current || e.Round > current+1 {
+		return
+	}
+	state, ok := b.commitReveal[e.Round]
+	if !ok {
+		state = &commitRevealRound{commits: make(map[peer.ID][]byte), reveals: make(map[peer.ID][]byte)}
+		b.commitReveal[e.Round] = state
+	}
+	switch e.Type {
+	case MessageCommit:
+		if _, ok := state.commits[e.Sender]; !ok {
+			state.commits[e.Sender] = e.Payload
+		}
+	case MessageReveal:
+		if commit, ok := state.commits[e.Sender]; ok && bytes.Equal(commit, commitment(e.Round, e.Sender, e.Payload)) {
+			state.reveals[e.Sender] = e.Payload
+		}
+	}
+}
+
+// runCommitReveal run commit, reveal and aggregation of given round
+func (b *Beacon) runCommitReveal(round uint64) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Errorf("Unable to generate secret of round %d: %v", round, err)
+		return
+	}
+	start := b.RoundTime(round)
+	if err := b.net.Send(b.TopicName(CommitRevealTopic), MessageCommit, round, commitment(round, b.net.NodeID, secret)); err != nil {
+		log.Warnf("Unable to publish commitment of round %d: %v", round, err)
+		return
+	}
+	if !b.sleepUntil(start.Add(b.Period / 3)) {
+		return
+	}
+	if err := b.net.Send(b.TopicName(CommitRevealTopic), MessageReveal, round, secret); err != nil {
+		log.Warnf("Unable to publish reveal of round %d: %v", round, err)
+	}
+	if !b.sleepUntil(start.Add(b.Period * 2 / 3)) {
+		return
+	}
+
+	b.mutex.Lock()
+	state := b.commitReveal[round]
+	delete(b.commitReveal, round)
+	for r := range b.commitReveal {
+		if r < round {
+			delete(b.commitReveal, r)
+		}
+	}
+	b.mutex.Unlock()
+	if state == nil || len(state.reveals) == 0 {
+		log.Warnf("No secret was revealed in round %d", round)
+		return
+	}
+
+	senders := make([]string, 0, len(state.reveals))
+	for sender := range state.reveals {
+		senders = append(senders, string(sender))
+	}
+	sort.Strings(senders)
+	h := sha256.New()
+	for _, sender := range senders {
+		h.Write(state.reveals[peer.ID(sender)])
+	}
+	randomness := h.Sum(nil)
+	for sender := range state.commits {
+		if _, ok := state.reveals[sender]; !ok {
+			log.Warnf("Peer %s committed but did not reveal in round %d", sender.Pretty(), round)
+		}
+	}
+	signature, err := b.nodeKey.Sign(Message(round, randomness))
+	if err != nil {
+		log.Errorf("Unable to sign round %d: %v", round, err)
+		return
+	}
+	b.publish(&RoundResult{Round: round, Randomness: randomness, Signature: signature})
+}
+
+// sleepUntil wait until given time, return false if beacon was stopped
+func (b *Beacon) sleepUntil(t time.Time) bool {
+	timer := b.Clock.NewTimer(t.Sub(b.Clock.Now()))
+	defer timer.Stop()
+	select {
+	case <-b.context.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
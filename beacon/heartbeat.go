@@ -0,0 +1,156 @@
+package beacon
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// HeartbeatTopic pubsub topic that members publish liveness statements on,
+// only members may publish on it
+const HeartbeatTopic = "heartbeat"
+
+// MessageHeartbeat message type of liveness statements
+const MessageHeartbeat = "heartbeat"
+
+// heartbeatsMissed number of heartbeat intervals without a heartbeat before a
+// member is considered offline
+const heartbeatsMissed = 3
+
+// Heartbeat liveness statement of a member, its envelope is signed by member
+// and carries the round that was current when it was sent
+type Heartbeat struct {
+	// LatestRound latest round that member has
+	LatestRound uint64 `json:"latestRound"`
+	// Time of sending in Unix nanoseconds, it tells heartbeats of a round
+	// apart so they are not dropped as replays
+	Time int64 `json:"time"`
+}
+
+// Participation entry of participation scoreboard of a member
+type Participation struct {
+	ID    peer.ID `json:"id"`
+	Index uint32  `json:"index"`
+	// Online heartbeat of member was received within three intervals
+	Online bool `json:"online"`
+	// LastSeenRound round that was current when latest heartbeat was sent,
+	// 0 if member was never seen
+	LastSeenRound uint64    `json:"lastSeenRound"`
+	LastSeen      time.Time `json:"lastSeen"`
+	// LatestRound latest round that member reported having
+	LatestRound uint64 `json:"latestRound"`
+	Heartbeats  uint64 `json:"heartbeats"`
+	// Missed number of skipped rounds that member did not contribute to
+	Missed uint64 `json:"missed"`
+}
+
+// liveness latest heartbeat of a member
+type liveness struct {
+	round      uint64
+	seen       time.Time
+	latest     uint64
+	heartbeats uint64
+}
+
+// StartHeartbeat publish a heartbeat every interval while node is a member
+// and track heartbeats of other members until beacon is stopped
+func (b *Beacon) StartHeartbeat(interval time.Duration) error {
+	b.mutex.Lock()
+	b.heartbeatInterval = interval
+	b.liveness = make(map[peer.ID]*liveness)
+	b.mutex.Unlock()
+	if err := b.net.Handle(b.TopicName(HeartbeatTopic), MessageHeartbeat, b.handleHeartbeat); err != nil {
+		return err
+	}
+	go func() {
+		ticker := b.Clock.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			b.sendHeartbeat()
+			select {
+			case <-ticker.C():
+			case <-b.context.Done():
+				b.net.RemoveHandler(b.TopicName(HeartbeatTopic), MessageHeartbeat)
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// sendHeartbeat publish latest round of node, nodes outside of group stay quiet
+func (b *Beacon) sendHeartbeat() {
+	if indexOf(b.Members, b.net.NodeID) == 0 {
+		return
+	}
+	b.mutex.Lock()
+	data, err := json.Marshal(Heartbeat{LatestRound: b.lastRound, Time: b.Clock.Now().UnixNano()})
+	b.mutex.Unlock()
+	if err != nil {
+		log.Errorf("Unable to encode heartbeat: %v", err)
+		return
+	}
+	if err := b.net.Send(b.TopicName(HeartbeatTopic), MessageHeartbeat, b.CurrentRound(b.Clock.Now()), data); err != nil {
+		log.Warnf("Unable to send heartbeat: %v", err)
+	}
+}
+
+// handleHeartbeat record heartbeat of a member, heartbeats of another round
+// than the current one are replays or come from a skewed clock
+func (b *Beacon) handleHeartbeat(e *network.Envelope) {
+	heartbeat := new(Heartbeat)
+	if indexOf(b.Members, e.Sender) == 0 || network.DecodePayload(e.Payload, heartbeat) != nil {
+		return
+	}
+	current := b.CurrentRound(b.Clock.Now())
+	if e.Round+1 < current || e.Round > current+1 || heartbeat.LatestRound > e.Round {
+		log.Debugf("Drop heartbeat of round %d from %s at round %d", e.Round, e.Sender, current)
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	member := b.liveness[e.Sender]
+	if member == nil {
+		member = new(liveness)
+		b.liveness[e.Sender] = member
+	}
+	if e.Round >= member.round {
+		member.round = e.Round
+		member.seen = b.Clock.Now()
+		member.latest = heartbeat.LatestRound
+	}
+	member.heartbeats++
+}
+
+// Scoreboard participation of each member in share order, members are only
+// online while heartbeats are tracked
+func (b *Beacon) Scoreboard() []Participation {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	scoreboard := make([]Participation, 0, len(b.Members))
+	for i, id := range b.Members {
+		index := uint32(i + 1)
+		entry := Participation{ID: id, Index: index, Missed: b.stats.Missed[index]}
+		if member := b.liveness[id]; member != nil {
+			entry.Online = b.Clock.Now().Sub(member.seen) < heartbeatsMissed*b.heartbeatInterval
+			entry.LastSeenRound = member.round
+			entry.LastSeen = member.seen
+			entry.LatestRound = member.latest
+			entry.Heartbeats = member.heartbeats
+		}
+		scoreboard = append(scoreboard, entry)
+	}
+	return scoreboard
+}
+
+// indexOf share index of member, 0 if it's not a member
+func indexOf(members []peer.ID, id peer.ID) uint32 {
+	for i, member := range members {
+		if member == id {
+			return uint32(i + 1)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package beacon
+
+import (
+	"errors"
+	"time"
+
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// AggregationMeasurement time an aggregator took to recover a group signature
+// from partials of every member
+type AggregationMeasurement struct {
+	Members   int           `json:"members"`
+	Threshold int           `json:"threshold"`
+	Workers   int           `json:"workers"`
+	Elapsed   time.Duration `json:"elapsed"`
+	// PartialsPerSecond partials verified per second until threshold was reached
+	PartialsPerSecond float64 `json:"partialsPerSecond"`
+}
+
+// MeasureAggregation sign a round with every share of a random group, hand
+// the partials to an aggregator with given number of workers before their
+// message is known, as partials arriving early are, and time how long
+// recovering the group signature takes once the message is expected
+func MeasureAggregation(members int, threshold int, workers int) (*AggregationMeasurement, error) {
+	if threshold < 1 || threshold > members {
+		return nil, errors.New("threshold must be between 1 and number of members")
+	}
+	poly, err := keypair.NewPriPoly(threshold, nil)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := keypair.GetThresholdScheme(keypair.SchemeBLS)
+	if err != nil {
+		return nil, err
+	}
+	message := Message(1, nil)
+	hashed, err := scheme.HashMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	aggregator := newAggregator(scheme, poly.Commit(), workers, nil)
+	for _, share := range poly.Shares(members) {
+		aggregator.Add(1, scheme.SignPartial(share, hashed))
+	}
+	start := time.Now()
+	<-aggregator.Expect(1, message)
+	elapsed := time.Since(start)
+	return &AggregationMeasurement{
+		Members:           members,
+		Threshold:         threshold,
+		Workers:           cap(aggregator.workers),
+		Elapsed:           elapsed,
+		PartialsPerSecond: float64(threshold) / elapsed.Seconds(),
+	}, nil
+}
+
+// PrecomputeMeasurement mean time from start of a round until the partial
+// signature of node is ready to publish and its message ready to verify
+// partials of peers
+type PrecomputeMeasurement struct {
+	Rounds int `json:"rounds"`
+	// Signed message is hashed and signed when round starts
+	Signed time.Duration `json:"signed"`
+	// Precomputed message was hashed and signed while waiting for round
+	Precomputed time.Duration `json:"precomputed"`
+}
+
+// MeasurePrecompute start given number of unchained rounds with and
+// without precomputation and time each until it's ready
+func MeasurePrecompute(rounds int) (*PrecomputeMeasurement, error) {
+	if rounds < 1 {
+		return nil, errors.New("rounds must be positive")
+	}
+	poly, err := keypair.NewPriPoly(1, nil)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := keypair.GetThresholdScheme(keypair.SchemeBLS)
+	if err != nil {
+		return nil, err
+	}
+	b := newPrecomputeBeacon(scheme, poly.Shares(1)[0])
+	aggregator := newAggregator(scheme, poly.Commit(), 1, nil)
+	var signed, precomputed time.Duration
+	for round := uint64(1); round <= uint64(rounds); round++ {
+		start := time.Now()
+		message := Message(round, nil)
+		hashed, err := scheme.HashMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		scheme.SignPartial(b.share, hashed)
+		aggregator.ExpectHashed(round, hashed)
+		signed += time.Since(start)
+
+		b.precompute(round + uint64(rounds))
+		start = time.Now()
+		message = Message(round+uint64(rounds), nil)
+		entry := b.precomputedRound(round+uint64(rounds), message)
+		if entry == nil {
+			return nil, errors.New("round was not precomputed")
+		}
+		aggregator.ExpectHashed(round+uint64(rounds), entry.message)
+		precomputed += time.Since(start)
+	}
+	return &PrecomputeMeasurement{
+		Rounds:      rounds,
+		Signed:      signed / time.Duration(rounds),
+		Precomputed: precomputed / time.Duration(rounds),
+	}, nil
+}
+
+// newPrecomputeBeacon unchained beacon signing with share that precomputes
+// one round ahead, it has no network and is only used to time rounds
+func newPrecomputeBeacon(scheme keypair.ThresholdScheme, share *keypair.PriShare) *Beacon {
+	return &Beacon{
+		Mode:            ModeUnchained,
+		Scheme:          keypair.SchemeBLS,
+		scheme:          scheme,
+		PrecomputeDepth: 1,
+		precomputed:     make(map[uint64]*precomputed),
+		share:           share,
+	}
+}
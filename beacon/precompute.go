@@ -0,0 +1,96 @@
+package beacon
+
+import (
+	"bytes"
+
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// DefaultPrecomputeDepth rounds whose partial signature is prepared ahead
+const DefaultPrecomputeDepth = 1
+
+// precomputed message of an upcoming round hashed to curve and partial
+// signature of node on it
+type precomputed struct {
+	message *keypair.HashedMessage
+	partial []byte
+}
+
+// knownMessage message of a round before the round is produced, it's
+// known in advance for unchained rounds and once the previous round is done
+// for chained rounds. Nil if it's not known
+func (b *Beacon) knownMessage(round uint64) []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.Mode == ModeUnchained || round == 1 || (b.lastRound > 0 && b.lastRound+1 == round) {
+		if previousSignature, err := b.previousSignature(round); err == nil {
+			return Message(round, previousSignature)
+		}
+	}
+	return nil
+}
+
+// expectedMessage hashed message of a round before the round is produced,
+// nil if it's not known
+func (b *Beacon) expectedMessage(round uint64) *keypair.HashedMessage {
+	message := b.knownMessage(round)
+	if message == nil {
+		return nil
+	}
+	if entry := b.precomputedRound(round, message); entry != nil {
+		return entry.message
+	}
+	hashed, err := b.scheme.HashMessage(message)
+	if err != nil {
+		return nil
+	}
+	return hashed
+}
+
+// precompute hash messages of upcoming rounds to curve and sign them with
+// share of node, so a round only has to publish its partial when it starts.
+// Up to PrecomputeDepth rounds from given one are prepared, as far as their
+// messages are known: a single round in chained mode
+func (b *Beacon) precompute(from uint64) {
+	if b.PrecomputeDepth < 1 || b.share == nil {
+		return
+	}
+	b.precomputeMutex.Lock()
+	for round := range b.precomputed {
+		if round < from {
+			delete(b.precomputed, round)
+		}
+	}
+	b.precomputeMutex.Unlock()
+	for round := from; round < from+uint64(b.PrecomputeDepth); round++ {
+		message := b.knownMessage(round)
+		if message == nil {
+			return
+		}
+		if b.precomputedRound(round, message) != nil {
+			continue
+		}
+		hashed, err := b.scheme.HashMessage(message)
+		if err != nil {
+			log.Warnf("Unable to precompute round %d: %v", round, err)
+			return
+		}
+		entry := &precomputed{message: hashed, partial: b.scheme.SignPartial(b.share, hashed)}
+		b.precomputeMutex.Lock()
+		b.precomputed[round] = entry
+		b.precomputeMutex.Unlock()
+		log.Debugf("Precomputed round %d", round)
+	}
+}
+
+// precomputedRound precomputed round whose message matches, nil if there is
+// none. A chained round is precomputed again once its message changes
+func (b *Beacon) precomputedRound(round uint64, message []byte) *precomputed {
+	b.precomputeMutex.Lock()
+	defer b.precomputeMutex.Unlock()
+	entry, ok := b.precomputed[round]
+	if !ok || !bytes.Equal(entry.message.Data, message) {
+		return nil
+	}
+	return entry
+}
@@ -0,0 +1,77 @@
+package beacon
+
+import (
+	"errors"
+
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// ResultsTopic pubsub topic of finalized rounds, unlike Topic it's open to
+// every peer so nodes outside of group can follow the chain without a share.
+// Rounds carry the group signature, so they are verified whoever relays them
+const ResultsTopic = "results"
+
+// SetGroupKey set group public key of a node that verifies rounds without
+// holding a share
+func (b *Beacon) SetGroupKey(groupKey []byte) {
+	b.groupKey = groupKey
+}
+
+// Follow receive finalized rounds from ResultsTopic instead of producing them.
+// Verified rounds newer than the last one are passed to Results until beacon
+// is stopped
+func (b *Beacon) Follow() error {
+	if b.GroupKey() == nil {
+		return errors.New("group key is required to follow a chain")
+	}
+	// Envelope validator is added by Handle and must run before beacon validator
+	if err := b.net.Handle(b.TopicName(ResultsTopic), MessageResult, b.handleFollowed); err != nil {
+		return err
+	}
+	b.validate(b.TopicName(ResultsTopic), b.validateResult)
+	go func() {
+		<-b.context.Done()
+		// Handler has returned once it's removed, results can be closed
+		b.net.RemoveHandler(b.TopicName(ResultsTopic), MessageResult)
+		b.net.Topics().RemoveValidator(b.TopicName(ResultsTopic), validatorBeacon)
+		close(b.results)
+	}()
+	return nil
+}
+
+// handleFollowed adopt a verified round, rounds are verified again as they
+// may arrive before beacon validator is added
+func (b *Beacon) handleFollowed(e *network.Envelope) {
+	result := new(RoundResult)
+	if err := network.DecodePayload(e.Payload, result); err != nil || result.Round != e.Round {
+		return
+	}
+	if err := b.VerifyRound(result, nil); err != nil {
+		log.Debugf("Drop invalid round %d from %s: %v", result.Round, e.Sender, err)
+		return
+	}
+	b.mutex.Lock()
+	if result.Round <= b.lastRound {
+		b.mutex.Unlock()
+		return
+	}
+	b.lastRound = result.Round
+	b.lastSignature = result.Signature
+	b.mutex.Unlock()
+	select {
+	case b.results <- *result:
+	default:
+		log.Warnf("Result channel is full, round %d was not delivered locally", result.Round)
+	}
+}
+
+// relay publish a finalized round on ResultsTopic, rounds without a group
+// signature can not be verified by other nodes and are not relayed
+func (b *Beacon) relay(data []byte, round uint64) {
+	if b.GroupKey() == nil {
+		return
+	}
+	if err := b.net.Send(b.TopicName(ResultsTopic), MessageResult, round, data); err != nil {
+		log.Warnf("Unable to relay round %d: %v", round, err)
+	}
+}
@@ -0,0 +1,92 @@
+package beacon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultTimeoutRatio fraction of period partials of a round may take to arrive,
+// the rest of period leaves time to deliver the round before the next one starts
+const DefaultTimeoutRatio = 0.8
+
+// Skip a threshold round that did not collect enough partials before its deadline.
+// Next round is chained to the last produced round, so every member that knows
+// the chain signs the same message and the chain continues
+type Skip struct {
+	Round     uint64
+	Threshold int
+	// Received share indexes whose partials arrived
+	Received []uint32
+	// Missing share indexes that did not contribute, empty if members are unknown
+	Missing []uint32
+}
+
+// Stats counters of rounds since beacon started
+type Stats struct {
+	Produced uint64 `json:"produced"`
+	Skipped  uint64 `json:"skipped"`
+	// Missed number of skipped rounds each share index did not contribute to
+	Missed map[uint32]uint64 `json:"missed"`
+}
+
+// roundDeadline time partials of given round must arrive by
+func (b *Beacon) roundDeadline(round uint64) time.Time {
+	ratio := b.TimeoutRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	return b.RoundTime(round).Add(time.Duration(float64(b.Period) * ratio))
+}
+
+// skip record a round whose threshold was not reached and explain who was missing
+func (b *Beacon) skip(round uint64) {
+	skip := Skip{Round: round, Threshold: b.Threshold(), Received: b.aggregator.Received(round)}
+	received := make(map[uint32]bool, len(skip.Received))
+	for _, index := range skip.Received {
+		received[index] = true
+	}
+	missing := make([]string, 0)
+	for i, member := range b.Members {
+		index := uint32(i + 1)
+		if !received[index] {
+			skip.Missing = append(skip.Missing, index)
+			missing = append(missing, shortID(member))
+		}
+	}
+	b.mutex.Lock()
+	b.stats.Skipped++
+	for _, index := range skip.Missing {
+		b.stats.Missed[index]++
+	}
+	b.mutex.Unlock()
+	if len(missing) > 0 {
+		log.Warnf("Round %d skipped with %d of %d partials, missing: %s", round, len(skip.Received), skip.Threshold, strings.Join(missing, ", "))
+	} else {
+		log.Warnf("Round %d skipped with %d of %d partials", round, len(skip.Received), skip.Threshold)
+	}
+	if b.OnSkip != nil {
+		b.OnSkip(skip)
+	}
+}
+
+// Stats snapshot of round counters
+func (b *Beacon) Stats() Stats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	stats := b.stats
+	stats.Missed = make(map[uint32]uint64, len(b.stats.Missed))
+	for index, count := range b.stats.Missed {
+		stats.Missed[index] = count
+	}
+	return stats
+}
+
+func shortID(id peer.ID) string {
+	s := id.Pretty()
+	if len(s) > 12 {
+		return s[len(s)-12:]
+	}
+	return s
+}
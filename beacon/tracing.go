@@ -0,0 +1,61 @@
+package beacon
+
+import (
+	"context"
+
+	"github.com/orochi-network/orochimaru/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxTracedRounds rounds whose span is kept for later stages, like storing a
+// round, to join trace of round
+const maxTracedRounds = 16
+
+// startRound start span of a round, it ends once round is published or skipped
+func (b *Beacon) startRound(round uint64) context.Context {
+	ctx, _ := tracing.Start(context.Background(), "beacon.round", trace.WithAttributes(
+		attribute.Int64("round", int64(round)),
+		attribute.String("mode", b.Mode),
+		attribute.String("namespace", b.Namespace),
+	))
+	b.traceMutex.Lock()
+	defer b.traceMutex.Unlock()
+	b.traces[round] = ctx
+	for traced := range b.traces {
+		if traced+maxTracedRounds <= round {
+			delete(b.traces, traced)
+		}
+	}
+	return ctx
+}
+
+// endRound end span of round carried by ctx, a non-nil err marks round failed
+func endRound(ctx context.Context, err error) {
+	tracing.End(trace.SpanFromContext(ctx), err)
+}
+
+// RoundContext context carrying span of a recent round, stages after beacon
+// start their spans from it so a round is traced until it's served. It's a
+// background context if round was not traced
+func (b *Beacon) RoundContext(round uint64) context.Context {
+	b.traceMutex.Lock()
+	defer b.traceMutex.Unlock()
+	if ctx, ok := b.traces[round]; ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// traceProgress record a verified partial on span of its round
+func (b *Beacon) traceProgress(progress Progress) {
+	span := trace.SpanFromContext(b.RoundContext(progress.Round))
+	span.AddEvent("partial", trace.WithAttributes(
+		attribute.Int64("index", int64(progress.Index)),
+		attribute.Int("received", progress.Received),
+		attribute.Int("threshold", progress.Threshold),
+	))
+	if progress.Recovered {
+		span.AddEvent("recovered")
+	}
+}
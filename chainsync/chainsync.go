@@ -0,0 +1,227 @@
+package chainsync
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/client"
+	"github.com/orochi-network/orochimaru/loadshed"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/store"
+	"go.uber.org/zap"
+)
+
+// Protocol libp2p stream protocol of chain sync, beacons with a namespace
+// sync over /orochi/sync/<namespace>/1.0.0
+const Protocol = "/orochi/sync/1.0.0"
+
+// MaxRounds largest number of rounds served in one request
+const MaxRounds = 1000
+
+// streamTimeout deadline of a sync stream
+const streamTimeout = time.Minute
+
+// maxRequestSize largest sync request that is read from a peer
+const maxRequestSize = 1 << 10
+
+// maxServeDelay longest time a sync request waits while CPU load is shed
+const maxServeDelay = 5 * time.Second
+
+// request ask a peer for rounds from From to To inclusive
+type request struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// Progress rounds fetched from a peer while syncing
+type Progress struct {
+	// Peer that rounds were fetched from, empty for gateways
+	Peer peer.ID
+	// Latest round in store
+	Latest uint64
+	// Target round that sync catches up to
+	Target uint64
+}
+
+// Syncer serve stored rounds to peers and fetch missing rounds from them
+type Syncer struct {
+	// OnProgress called after rounds were fetched from a peer
+	OnProgress func(Progress)
+	// Shedder sync requests of peers wait while it sheds load, and are
+	// refused if load stays high
+	Shedder  *loadshed.Shedder
+	net      *network.Network
+	store    *store.Store
+	drng     *beacon.Beacon
+	gateways *client.Client
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("chainsync")
+}
+
+// New create a syncer of given store, fetched rounds are verified by given beacon
+func New(net *network.Network, rounds *store.Store, drng *beacon.Beacon) *Syncer {
+	return &Syncer{net: net, store: rounds, drng: drng}
+}
+
+// Serve answer sync requests of peers
+func (s *Syncer) Serve() {
+	s.net.SetStreamHandler(s.protocol(), s.handleStream)
+}
+
+// protocol stream protocol of chain of syncer
+func (s *Syncer) protocol() string {
+	if s.drng.Namespace == "" {
+		return Protocol
+	}
+	return "/orochi/sync/" + s.drng.Namespace + "/1.0.0"
+}
+
+func (s *Syncer) handleStream(stream p2pNetwork.Stream) {
+	defer stream.Close()
+	deadline := time.Now().Add(streamTimeout)
+	stream.SetDeadline(deadline)
+	req := new(request)
+	if err := json.NewDecoder(io.LimitReader(stream, maxRequestSize)).Decode(req); err != nil {
+		log.Debugf("Invalid sync request from %s: %v", stream.Conn().RemotePeer(), err)
+		stream.Reset()
+		return
+	}
+	if s.Shedder.Overloaded() {
+		ctx, cancel := watchReset(stream, deadline)
+		served := s.Shedder.Wait(ctx, loadshed.WorkSync, maxServeDelay)
+		cancel()
+		if !served {
+			log.Debugf("Refused sync request from %s under CPU load", stream.Conn().RemotePeer())
+			stream.Reset()
+			return
+		}
+	}
+	writer := bufio.NewWriter(stream)
+	encoder := json.NewEncoder(writer)
+	cursor := s.store.Cursor()
+	count := 0
+	for result, err := cursor.Seek(req.From); result != nil || err != nil; result, err = cursor.Next() {
+		if err != nil {
+			log.Errorf("Unable to read store: %v", err)
+			break
+		}
+		if result.Round > req.To || count == MaxRounds {
+			break
+		}
+		if err := encoder.Encode(result); err != nil {
+			stream.Reset()
+			return
+		}
+		count++
+	}
+	if err := writer.Flush(); err != nil {
+		stream.Reset()
+	}
+}
+
+// watchReset context that ends at deadline of stream, or early once peer
+// resets stream. Peers close their side after the request, so a read fails
+// with an error other than EOF only once stream is reset
+func watchReset(stream p2pNetwork.Stream, deadline time.Time) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	go func() {
+		var b [1]byte
+		if _, err := stream.Read(b[:]); err != nil && err != io.EOF {
+			cancel()
+		}
+	}()
+	return ctx, cancel
+}
+
+// Sync fetch missing rounds up to given round from connected peers, rounds
+// that no peer serves are fetched from gateways if any is set, return the
+// latest round in store
+func (s *Syncer) Sync(ctx context.Context, upTo uint64) (uint64, error) {
+	latest, err := s.store.Latest()
+	if err != nil && err != store.ErrNotFound {
+		return 0, err
+	}
+	for _, peerID := range s.net.Peers() {
+		for latest == nil || latest.Round < upTo {
+			from := uint64(1)
+			if latest != nil {
+				from = latest.Round + 1
+			}
+			last, err := s.fetch(ctx, peerID, from, upTo, latest)
+			if err != nil {
+				log.Debugf("Unable to sync from %s: %v", peerID, err)
+			}
+			if last == nil || (latest != nil && last.Round == latest.Round) {
+				break
+			}
+			latest = last
+			if s.OnProgress != nil {
+				s.OnProgress(Progress{Peer: peerID, Latest: latest.Round, Target: upTo})
+			}
+		}
+		if latest != nil && latest.Round >= upTo {
+			break
+		}
+	}
+	if s.gateways != nil && (latest == nil || latest.Round < upTo) {
+		last, err := s.syncGateways(ctx, latest, upTo)
+		if err != nil {
+			log.Warnf("Unable to sync from gateways: %v", err)
+		}
+		latest = last
+	}
+	if latest == nil {
+		return 0, errors.New("no round was synced")
+	}
+	s.drng.Resume(latest)
+	return latest.Round, nil
+}
+
+// fetch request rounds of a peer and store the ones that verify, return the
+// last stored round
+func (s *Syncer) fetch(ctx context.Context, peerID peer.ID, from uint64, to uint64, previous *beacon.RoundResult) (*beacon.RoundResult, error) {
+	stream, err := s.net.NewStream(ctx, peerID, s.protocol())
+	if err != nil {
+		return previous, err
+	}
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(streamTimeout))
+	if err := json.NewEncoder(stream).Encode(&request{From: from, To: to}); err != nil {
+		return previous, err
+	}
+	if err := stream.CloseWrite(); err != nil {
+		return previous, err
+	}
+	decoder := json.NewDecoder(bufio.NewReader(stream))
+	for {
+		result := new(beacon.RoundResult)
+		if err := decoder.Decode(result); err != nil {
+			if err == io.EOF {
+				return previous, nil
+			}
+			return previous, err
+		}
+		if result.Round < from || result.Round > to || (previous != nil && result.Round <= previous.Round) {
+			return previous, errors.New("peer sent round out of requested range")
+		}
+		if err := s.drng.VerifyRound(result, previous); err != nil {
+			return previous, err
+		}
+		if err := s.store.Put(result); err != nil {
+			return previous, err
+		}
+		previous = result
+	}
+}
@@ -0,0 +1,109 @@
+package chainsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/client"
+)
+
+// gatewayBatch number of rounds requested from gateways at a time
+const gatewayBatch = MaxRounds
+
+// ValidateGateway gateway must be an https URL
+func ValidateGateway(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid gateway %q: %v", endpoint, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("gateway %q is not an https URL", endpoint)
+	}
+	return nil
+}
+
+// SetGateways fetch rounds from HTTP API of given https gateways when peers
+// can't serve them, rounds of gateways are verified against group key of
+// beacon before they are stored so gateways don't need to be trusted
+func (s *Syncer) SetGateways(gateways ...string) error {
+	if len(gateways) == 0 {
+		s.gateways = nil
+		return nil
+	}
+	for _, gateway := range gateways {
+		if err := ValidateGateway(gateway); err != nil {
+			return err
+		}
+	}
+	c, err := client.New(gateways...)
+	if err != nil {
+		return err
+	}
+	c.CacheSize = 0
+	s.gateways = c
+	return nil
+}
+
+// pinGateways pin chain info of gateways to the one of beacon, the group key
+// is only known once DKG finished
+func (s *Syncer) pinGateways() error {
+	groupKey := s.drng.GroupKey()
+	if groupKey == nil {
+		return errors.New("rounds can only be fetched from gateways by threshold beacon")
+	}
+	s.gateways.GroupKey = groupKey
+	s.gateways.ChainHash = s.drng.ChainInfo().Hash()
+	return nil
+}
+
+// syncGateways fetch rounds after latest up to given round from gateways and
+// store the ones that verify, return the last stored round
+func (s *Syncer) syncGateways(ctx context.Context, latest *beacon.RoundResult, upTo uint64) (*beacon.RoundResult, error) {
+	if err := s.pinGateways(); err != nil {
+		return latest, err
+	}
+	for latest == nil || latest.Round < upTo {
+		from := uint64(1)
+		if latest != nil {
+			from = latest.Round + 1
+		}
+		count := upTo - from + 1
+		if count > gatewayBatch {
+			count = gatewayBatch
+		}
+		rounds, err := s.gateways.Range(ctx, from, count)
+		if err != nil {
+			return latest, err
+		}
+		if len(rounds) == 0 {
+			return latest, nil
+		}
+		if uint64(len(rounds)) > count {
+			return latest, fmt.Errorf("gateway sent %d rounds, %d were requested", len(rounds), count)
+		}
+		// Gateways are not trusted, a skipped or reordered round would leave a
+		// hole in store that chaining can't catch, so the batch is refused
+		for i := range rounds {
+			if rounds[i].Round != from+uint64(i) {
+				return latest, fmt.Errorf("gateway sent round %d instead of round %d", rounds[i].Round, from+uint64(i))
+			}
+		}
+		for i := range rounds {
+			result := &rounds[i]
+			if err := s.drng.VerifyRound(result, latest); err != nil {
+				return latest, fmt.Errorf("gateway round %d is invalid: %v", result.Round, err)
+			}
+			if err := s.store.Put(result); err != nil {
+				return latest, err
+			}
+			latest = result
+		}
+		if s.OnProgress != nil {
+			s.OnProgress(Progress{Latest: latest.Round, Target: upTo})
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,83 @@
+package chainsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// ProblemKind kind of problem found in stored chain
+type ProblemKind string
+
+const (
+	// ProblemInvalid stored round does not verify against group key or is not
+	// chained to the round before it
+	ProblemInvalid ProblemKind = "invalid"
+	// ProblemMissing rounds are missing, the round after them is chained to a
+	// round that is not stored
+	ProblemMissing ProblemKind = "missing"
+	// ProblemGap rounds of an unchained beacon are not stored, they may have
+	// been skipped by group
+	ProblemGap ProblemKind = "gap"
+)
+
+// Problem rounds From to To inclusive that are invalid or missing
+type Problem struct {
+	Kind ProblemKind
+	From uint64
+	To   uint64
+	Err  error
+}
+
+func (p Problem) String() string {
+	rounds := fmt.Sprintf("round %d", p.From)
+	if p.To != p.From {
+		rounds = fmt.Sprintf("rounds %d to %d", p.From, p.To)
+	}
+	if p.Err != nil {
+		return fmt.Sprintf("%s %s: %v", rounds, p.Kind, p.Err)
+	}
+	return fmt.Sprintf("%s %s", rounds, p.Kind)
+}
+
+// Verify walk stored rounds in order and check each of them against group key
+// and the round before it, problems are passed to report. Rounds before the
+// first stored round are not reported as they may have been pruned. Return
+// number of checked rounds
+func Verify(rounds *store.Store, drng *beacon.Beacon, report func(Problem)) (int, error) {
+	if drng.GroupKey() == nil {
+		return 0, errors.New("group key is required to verify rounds")
+	}
+	count := 0
+	// previous is the last valid round, last the last stored one
+	var previous *beacon.RoundResult
+	var last uint64
+	cursor := rounds.Cursor()
+	for result, err := cursor.First(); result != nil || err != nil; result, err = cursor.Next() {
+		if err != nil {
+			return count, err
+		}
+		count++
+		if count > 1 && result.Round > last+1 {
+			gap := Problem{From: last + 1, To: result.Round - 1}
+			if drng.Mode == beacon.ModeUnchained {
+				gap.Kind = ProblemGap
+				report(gap)
+			} else if previous == nil || previous.Round != last || !bytes.Equal(result.PreviousSignature, previous.Signature) {
+				// Round after skipped rounds is chained to the last produced round
+				gap.Kind = ProblemMissing
+				report(gap)
+			}
+		}
+		last = result.Round
+		if err := drng.VerifyRound(result, previous); err != nil {
+			report(Problem{Kind: ProblemInvalid, From: result.Round, To: result.Round, Err: err})
+			continue
+		}
+		previous = result
+	}
+	return count, nil
+}
@@ -0,0 +1,313 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Cache store of verified rounds of a client, it must be safe for concurrent
+// use. Rounds a client puts are checked to be chained to cached neighbours
+type Cache interface {
+	// Get cached round, nil if it's not cached
+	Get(round uint64) *Round
+	// Put keep a verified round, the cache may evict other rounds
+	Put(result *Round)
+	// Remove drop a round from cache
+	Remove(round uint64)
+}
+
+// InfoCache cache that also keeps chain info, so a client can verify cached
+// rounds without asking a node for chain info
+type InfoCache interface {
+	Cache
+	// Info cached chain info, nil if there is none
+	Info() *api.Info
+	// SetInfo keep chain info, cached rounds of another chain are dropped
+	SetInfo(info *api.Info)
+}
+
+// MemoryCache cache keeping a bounded number of rounds in memory, the least
+// recently used round is evicted first
+type MemoryCache struct {
+	size    int
+	rounds  map[uint64]*list.Element
+	recency *list.List
+	mutex   sync.Mutex
+}
+
+// NewMemoryCache create cache of at most size rounds
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, rounds: make(map[uint64]*list.Element), recency: list.New()}
+}
+
+// Get cached round, it becomes the most recently used one
+func (m *MemoryCache) Get(round uint64) *Round {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	element := m.rounds[round]
+	if element == nil {
+		return nil
+	}
+	m.recency.MoveToFront(element)
+	return element.Value.(*Round)
+}
+
+// Put keep round, least recently used rounds are evicted beyond size
+func (m *MemoryCache) Put(result *Round) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if element := m.rounds[result.Round]; element != nil {
+		element.Value = result
+		m.recency.MoveToFront(element)
+		return
+	}
+	m.rounds[result.Round] = m.recency.PushFront(result)
+	for m.recency.Len() > m.size {
+		oldest := m.recency.Back()
+		m.recency.Remove(oldest)
+		delete(m.rounds, oldest.Value.(*Round).Round)
+	}
+}
+
+// Remove drop round from cache
+func (m *MemoryCache) Remove(round uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if element := m.rounds[round]; element != nil {
+		m.recency.Remove(element)
+		delete(m.rounds, round)
+	}
+}
+
+// Len number of cached rounds
+func (m *MemoryCache) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.recency.Len()
+}
+
+var (
+	fileRoundsBucket = []byte("rounds")
+	fileInfoBucket   = []byte("info")
+	fileInfoKey      = []byte("info")
+)
+
+// FileCache cache keeping rounds and chain info in a BoltDB file, so they
+// outlive the client. Beyond size rounds the lowest rounds are evicted first
+type FileCache struct {
+	db   *bolt.DB
+	size int
+	// count number of cached rounds, it's only changed by update transactions
+	// which bolt runs one at a time
+	count int
+}
+
+// OpenFileCache open or create cache file of at most size rounds
+func OpenFileCache(path string, size int) (*FileCache, error) {
+	if size <= 0 {
+		return nil, errors.New("cache size must be positive")
+	}
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	f := &FileCache{db: db, size: size}
+	err = db.Update(func(tx *bolt.Tx) error {
+		rounds, err := tx.CreateBucketIfNotExists(fileRoundsBucket)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(fileInfoBucket); err != nil {
+			return err
+		}
+		return rounds.ForEach(func(k, v []byte) error {
+			f.count++
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Close close cache file
+func (f *FileCache) Close() error {
+	return f.db.Close()
+}
+
+func fileKey(round uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, round)
+	return key
+}
+
+// Get cached round, nil if it's not cached or can't be read
+func (f *FileCache) Get(round uint64) *Round {
+	var result *Round
+	err := f.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(fileRoundsBucket).Get(fileKey(round))
+		if value == nil {
+			return nil
+		}
+		result = new(Round)
+		return json.Unmarshal(value, result)
+	})
+	if err != nil {
+		log.Warnf("Unable to read cached round %d: %v", round, err)
+		return nil
+	}
+	return result
+}
+
+// Put keep round, lowest rounds are evicted beyond size
+func (f *FileCache) Put(result *Round) {
+	value, err := json.Marshal(result)
+	if err == nil {
+		err = f.db.Update(func(tx *bolt.Tx) error {
+			rounds := tx.Bucket(fileRoundsBucket)
+			key := fileKey(result.Round)
+			if rounds.Get(key) == nil {
+				f.count++
+			}
+			if err := rounds.Put(key, value); err != nil {
+				return err
+			}
+			cursor := rounds.Cursor()
+			for f.count > f.size {
+				if key, _ := cursor.First(); key == nil {
+					break
+				}
+				if err := cursor.Delete(); err != nil {
+					return err
+				}
+				f.count--
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		log.Warnf("Unable to cache round %d: %v", result.Round, err)
+	}
+}
+
+// Remove drop round from cache
+func (f *FileCache) Remove(round uint64) {
+	err := f.db.Update(func(tx *bolt.Tx) error {
+		rounds := tx.Bucket(fileRoundsBucket)
+		key := fileKey(round)
+		if rounds.Get(key) == nil {
+			return nil
+		}
+		f.count--
+		return rounds.Delete(key)
+	})
+	if err != nil {
+		log.Warnf("Unable to remove cached round %d: %v", round, err)
+	}
+}
+
+// Info cached chain info, nil if there is none
+func (f *FileCache) Info() *api.Info {
+	var info *api.Info
+	err := f.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(fileInfoBucket).Get(fileInfoKey)
+		if value == nil {
+			return nil
+		}
+		info = new(api.Info)
+		return json.Unmarshal(value, info)
+	})
+	if err != nil {
+		log.Warnf("Unable to read cached chain info: %v", err)
+		return nil
+	}
+	return info
+}
+
+// SetInfo keep chain info, cached rounds are dropped if it's the info of
+// another chain
+func (f *FileCache) SetInfo(info *api.Info) {
+	value, err := json.Marshal(info)
+	if err == nil {
+		err = f.db.Update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(fileInfoBucket)
+			if previous := bucket.Get(fileInfoKey); previous != nil && !sameChain(previous, info) {
+				if err := tx.DeleteBucket(fileRoundsBucket); err != nil {
+					return err
+				}
+				if _, err := tx.CreateBucket(fileRoundsBucket); err != nil {
+					return err
+				}
+				f.count = 0
+			}
+			return bucket.Put(fileInfoKey, value)
+		})
+	}
+	if err != nil {
+		log.Warnf("Unable to cache chain info: %v", err)
+	}
+}
+
+// sameChain whether encoded chain info is the info of chain of info
+func sameChain(encoded []byte, info *api.Info) bool {
+	previous := new(api.Info)
+	if err := json.Unmarshal(encoded, previous); err != nil {
+		return false
+	}
+	a, err := previous.ChainInfo()
+	if err != nil {
+		return false
+	}
+	b, err := info.ChainInfo()
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a.Hash(), b.Hash())
+}
+
+// fill cache a verified round after checking it against the cached rounds
+// next to it. A verified round is authentic, so a cached neighbour it's not
+// chained to, or a cached copy with another signature, is dropped
+func fill(cache Cache, info *api.Info, result *Round) {
+	if cached := cache.Get(result.Round); cached != nil {
+		if bytes.Equal(cached.Signature, result.Signature) {
+			return
+		}
+		log.Warnf("Cached round %d differs from verified round, dropping it", result.Round)
+	}
+	if info.Mode != beacon.ModeUnchained {
+		if previous := cache.Get(result.Round - 1); previous != nil && !bytes.Equal(result.PreviousSignature, previous.Signature) {
+			log.Warnf("Round %d is not chained to cached round %d, dropping it", result.Round, previous.Round)
+			cache.Remove(previous.Round)
+		}
+		if next := cache.Get(result.Round + 1); next != nil && !bytes.Equal(next.PreviousSignature, result.Signature) {
+			log.Warnf("Cached round %d is not chained to round %d, dropping it", next.Round, result.Round)
+			cache.Remove(next.Round)
+		}
+	}
+	cache.Put(result)
+}
+
+// checkCached verify a round read from cache, rounds of caches that outlive
+// the client may have changed since they were put
+func checkCached(cache Cache, info *api.Info, result *Round) error {
+	if _, ok := cache.(*MemoryCache); ok {
+		return nil
+	}
+	if err := Verify(info, result); err != nil {
+		cache.Remove(result.Round)
+		return fmt.Errorf("cached %v", err)
+	}
+	return nil
+}
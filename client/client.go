@@ -0,0 +1,407 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/logger"
+	"go.uber.org/zap"
+)
+
+// Round verified round of a beacon chain
+type Round = beacon.RoundResult
+
+// DefaultCacheSize number of verified rounds kept in memory
+const DefaultCacheSize = 256
+
+// ErrNotFound round is not published by any node
+var ErrNotFound = errors.New("round not found")
+
+// Client fetch rounds from HTTP API of beacon nodes, fail over to next node
+// when a node is unreachable and verify every round against chain info before
+// returning it
+type Client struct {
+	// GroupKey trusted group public key, when nil the key reported by the first
+	// node that answers is trusted
+	GroupKey []byte
+	// ChainHash trusted chain hash, chain info of nodes must hash to it
+	ChainHash []byte
+	// HTTPClient used for requests
+	HTTPClient *http.Client
+	// CacheSize number of verified rounds kept in memory when Cache is nil, 0
+	// disables caching
+	CacheSize int
+	// Cache store of verified rounds, e.g. a FileCache that keeps them across
+	// restarts. Rounds are kept in a MemoryCache of CacheSize if it's nil
+	Cache Cache
+	// APIKey key sent with every request to nodes that meter requests by API
+	// keys, no key is sent if it's empty
+	APIKey string
+	// Token bearer token sent with every request to nodes that authenticate
+	// requests by tokens, no token is sent if it's empty
+	Token     string
+	urls      []string
+	preferred int
+	info      *api.Info
+	memory    *MemoryCache
+	mutex     sync.Mutex
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("client")
+}
+
+// New create a client of given node endpoints, e.g. http://127.0.0.1:8080
+func New(urls ...string) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("at least one node endpoint is required")
+	}
+	c := &Client{
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		CacheSize:  DefaultCacheSize,
+	}
+	for _, url := range urls {
+		c.urls = append(c.urls, strings.TrimRight(url, "/"))
+	}
+	return c, nil
+}
+
+// Info chain info of beacon, fetched once and checked against GroupKey and
+// ChainHash, the first chain info fetched is pinned for the life of client.
+// Chain info kept by an InfoCache is used without asking a node if it passes
+// the same checks
+func (c *Client) Info(ctx context.Context) (*api.Info, error) {
+	c.mutex.Lock()
+	info := c.info
+	c.mutex.Unlock()
+	if info != nil {
+		return info, nil
+	}
+	infoCache, _ := c.Cache.(InfoCache)
+	if infoCache != nil {
+		if cached := infoCache.Info(); cached != nil && c.checkInfo(cached) == nil {
+			c.mutex.Lock()
+			c.info = cached
+			c.mutex.Unlock()
+			return cached, nil
+		}
+	}
+	info = new(api.Info)
+	if err := c.fetch(ctx, "/info", info); err != nil {
+		return nil, err
+	}
+	if err := c.checkInfo(info); err != nil {
+		return nil, err
+	}
+	if infoCache != nil {
+		infoCache.SetInfo(info)
+	}
+	c.mutex.Lock()
+	c.info = info
+	c.mutex.Unlock()
+	return info, nil
+}
+
+// checkInfo check chain info against GroupKey and ChainHash
+func (c *Client) checkInfo(info *api.Info) error {
+	chainInfo, err := info.ChainInfo()
+	if err != nil || len(chainInfo.PublicKey) == 0 {
+		return errors.New("node does not report a group public key")
+	}
+	if c.GroupKey != nil && !bytes.Equal(chainInfo.PublicKey, c.GroupKey) {
+		return errors.New("node reports a different group public key")
+	}
+	if c.ChainHash != nil && !bytes.Equal(chainInfo.Hash(), c.ChainHash) {
+		return errors.New("chain info of node does not match chain hash")
+	}
+	return nil
+}
+
+// Get verified round by its number, cached rounds are served without asking
+// a node
+func (c *Client) Get(ctx context.Context, round uint64) (*Round, error) {
+	if round == 0 {
+		return c.Latest(ctx)
+	}
+	if cache := c.roundCache(); cache != nil {
+		if cached := cache.Get(round); cached != nil {
+			info, err := c.Info(ctx)
+			if err != nil {
+				return nil, err
+			}
+			err = checkCached(cache, info, cached)
+			if err == nil {
+				return cached, nil
+			}
+			log.Warnf("Dropped round from cache: %v", err)
+		}
+	}
+	return c.get(ctx, fmt.Sprintf("/public/%d", round), round)
+}
+
+// Latest verified latest round
+func (c *Client) Latest(ctx context.Context) (*Round, error) {
+	return c.get(ctx, "/public/latest", 0)
+}
+
+// Watch stream new rounds in order until context is done, rounds skipped by
+// the group are not sent
+func (c *Client) Watch(ctx context.Context) <-chan Round {
+	rounds := make(chan Round)
+	go func() {
+		defer close(rounds)
+		var last uint64
+		for {
+			info, err := c.Info(ctx)
+			if err == nil {
+				var latest *Round
+				if latest, err = c.Latest(ctx); err == nil {
+					last = c.catchUp(ctx, rounds, last, latest)
+				}
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Debugf("Unable to watch rounds: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.untilNextRound(info, last)):
+			}
+		}
+	}()
+	return rounds
+}
+
+// catchUp send rounds after last up to latest, return number of last sent round
+func (c *Client) catchUp(ctx context.Context, rounds chan<- Round, last uint64, latest *Round) uint64 {
+	if latest.Round <= last {
+		return last
+	}
+	// The first round watched is the latest one, rounds in between are sent
+	// only when a node was missed for some rounds
+	if last > 0 {
+		for round := last + 1; round < latest.Round; round++ {
+			result, err := c.Get(ctx, round)
+			if err != nil {
+				if err != ErrNotFound {
+					log.Debugf("Unable to fetch round %d: %v", round, err)
+				}
+				continue
+			}
+			select {
+			case rounds <- *result:
+			case <-ctx.Done():
+				return last
+			}
+		}
+	}
+	select {
+	case rounds <- *latest:
+		return latest.Round
+	case <-ctx.Done():
+		return last
+	}
+}
+
+// untilNextRound time to wait before polling for the round after last
+func (c *Client) untilNextRound(info *api.Info, last uint64) time.Duration {
+	if info == nil || info.Period == 0 {
+		return time.Second
+	}
+	period := time.Duration(info.Period) * time.Second
+	// Round 1 starts at genesis
+	next := time.Unix(info.GenesisTime, 0).Add(time.Duration(last) * period)
+	wait := time.Until(next)
+	// Poll again soon when the round is due but not published yet
+	if wait < period/10 {
+		wait = period / 10
+	}
+	if wait < 500*time.Millisecond {
+		wait = 500 * time.Millisecond
+	}
+	return wait
+}
+
+// get fetch and verify a round, expected is 0 for latest round
+func (c *Client) get(ctx context.Context, path string, expected uint64) (*Round, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apiRound := new(api.Round)
+	if err := c.fetch(ctx, path, apiRound); err != nil {
+		return nil, err
+	}
+	result, err := apiRound.Result()
+	if err != nil {
+		return nil, err
+	}
+	if expected > 0 && result.Round != expected {
+		return nil, fmt.Errorf("node returned round %d instead of %d", result.Round, expected)
+	}
+	if err := Verify(info, result); err != nil {
+		return nil, err
+	}
+	c.remember(info, result)
+	return result, nil
+}
+
+// Range verified rounds numbered from start to start + count - 1 in ascending
+// order, rounds skipped by the group and rounds after latest are left out
+func (c *Client) Range(ctx context.Context, start uint64, count uint64) ([]Round, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := c.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if start == 0 {
+		start = 1
+	}
+	end := start + count
+	if end > latest.Round+1 || end < start {
+		end = latest.Round + 1
+	}
+	var rounds []Round
+	for from := start; from < end; from += api.MaxRoundsCount {
+		batch := end - from
+		if batch > api.MaxRoundsCount {
+			batch = api.MaxRoundsCount
+		}
+		var apiRounds []api.Round
+		if err := c.fetch(ctx, fmt.Sprintf("/public?start=%d&count=%d", from, batch), &apiRounds); err != nil {
+			return nil, err
+		}
+		for _, apiRound := range apiRounds {
+			result, err := apiRound.Result()
+			if err != nil {
+				return nil, err
+			}
+			if result.Round < from || result.Round >= from+batch {
+				return nil, fmt.Errorf("node returned round %d out of requested range", result.Round)
+			}
+			if err := Verify(info, result); err != nil {
+				return nil, err
+			}
+			c.remember(info, result)
+			rounds = append(rounds, *result)
+		}
+	}
+	return rounds, nil
+}
+
+// Verify check round against chain info
+func Verify(info *api.Info, result *Round) error {
+	if info.Mode == beacon.ModeUnchained && len(result.PreviousSignature) != 0 {
+		return errors.New("unchained round must not carry previous signature")
+	}
+	if info.Mode == beacon.ModeChained && len(result.PreviousSignature) == 0 {
+		return errors.New("chained round must carry previous signature")
+	}
+	chainInfo, err := info.ChainInfo()
+	if err != nil {
+		return err
+	}
+	if err := beacon.Verify(chainInfo.Scheme, chainInfo.PublicKey, result); err != nil {
+		return fmt.Errorf("round %d is invalid: %v", result.Round, err)
+	}
+	return nil
+}
+
+// remember cache a verified round, see fill
+func (c *Client) remember(info *api.Info, result *Round) {
+	if cache := c.roundCache(); cache != nil {
+		fill(cache, info, result)
+	}
+}
+
+// roundCache cache of client, nil if caching is disabled
+func (c *Client) roundCache() Cache {
+	if c.Cache != nil {
+		return c.Cache
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.CacheSize <= 0 {
+		return nil
+	}
+	if c.memory == nil || c.memory.size != c.CacheSize {
+		c.memory = NewMemoryCache(c.CacheSize)
+	}
+	return c.memory
+}
+
+// fetch decode JSON response of path, nodes are tried in turn starting with
+// the one that answered last, ErrNotFound is returned only if no node has it
+func (c *Client) fetch(ctx context.Context, path string, v interface{}) error {
+	c.mutex.Lock()
+	preferred := c.preferred
+	c.mutex.Unlock()
+	var errs []string
+	notFound := false
+	for i := range c.urls {
+		index := (preferred + i) % len(c.urls)
+		err := c.fetchFrom(ctx, c.urls[index]+path, v)
+		if err == nil {
+			c.mutex.Lock()
+			c.preferred = index
+			c.mutex.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// A node that lags behind may miss a round others have
+		if err == ErrNotFound {
+			notFound = true
+			continue
+		}
+		errs = append(errs, err.Error())
+	}
+	if notFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("no node answered: %s", strings.Join(errs, "; "))
+}
+
+func (c *Client) fetchFrom(ctx context.Context, url string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if c.APIKey != "" {
+		request.Header.Set(api.APIKeyHeader, c.APIKey)
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiError := struct {
+			Error string `json:"error"`
+		}{}
+		json.NewDecoder(resp.Body).Decode(&apiError)
+		return fmt.Errorf("%s: %s %s", url, resp.Status, apiError.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,69 @@
+// Package clock abstracts time so schedulers can run on a mock clock, rounds
+// then progress when a test advances time instead of after real sleeps
+package clock
+
+import "time"
+
+// Clock source of current time and of timers
+type Clock interface {
+	Now() time.Time
+	// NewTimer timer that fires once after d
+	NewTimer(d time.Duration) Timer
+	// NewTicker ticker that fires every d
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer fires once, like time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker fires periodically, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// New clock of real time
+func New() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,151 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Mock clock that only moves when it's advanced, timers and tickers fire in
+// order of their deadline while time passes them
+type Mock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*mockTimer
+	// waiters are notified whenever a timer is created
+	waiters []chan struct{}
+}
+
+// mockTimer timer or ticker of a mock clock, period is 0 for timers
+type mockTimer struct {
+	mock     *Mock
+	deadline time.Time
+	period   time.Duration
+	c        chan time.Time
+}
+
+// NewMock mock clock starting at given time
+func NewMock(start time.Time) *Mock {
+	return &Mock{now: start}
+}
+
+// Now current time of mock clock
+func (m *Mock) Now() time.Time {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.now
+}
+
+// NewTimer timer that fires once mock clock is advanced by d
+func (m *Mock) NewTimer(d time.Duration) Timer {
+	return m.add(d, 0)
+}
+
+// NewTicker ticker that fires each time mock clock passes another d
+func (m *Mock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return mockTicker{m.add(d, d)}
+}
+
+func (m *Mock) add(d time.Duration, period time.Duration) *mockTimer {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	t := &mockTimer{mock: m, deadline: m.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		t.c <- m.now
+		return t
+	}
+	m.timers = append(m.timers, t)
+	for _, waiter := range m.waiters {
+		close(waiter)
+	}
+	m.waiters = nil
+	return t
+}
+
+// Add advance mock clock by d and fire every timer whose deadline is passed,
+// tickers fire once per period passed
+func (m *Mock) Add(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set move mock clock to t, it never goes backwards
+func (m *Mock) Set(t time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for {
+		sort.Slice(m.timers, func(i, j int) bool { return m.timers[i].deadline.Before(m.timers[j].deadline) })
+		if len(m.timers) == 0 || m.timers[0].deadline.After(t) {
+			break
+		}
+		next := m.timers[0]
+		if next.deadline.After(m.now) {
+			m.now = next.deadline
+		}
+		// Like time package, a tick is dropped if the previous one was not read
+		select {
+		case next.c <- m.now:
+		default:
+		}
+		if next.period > 0 {
+			next.deadline = next.deadline.Add(next.period)
+		} else {
+			m.timers = m.timers[1:]
+		}
+	}
+	if t.After(m.now) {
+		m.now = t
+	}
+}
+
+// Timers number of timers and tickers that have not fired or been stopped
+func (m *Mock) Timers() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.timers)
+}
+
+// WaitForTimers block until at least n timers and tickers are pending, so a
+// test advances time only once the code under test waits on it
+func (m *Mock) WaitForTimers(n int) {
+	for {
+		m.mutex.Lock()
+		if len(m.timers) >= n {
+			m.mutex.Unlock()
+			return
+		}
+		waiter := make(chan struct{})
+		m.waiters = append(m.waiters, waiter)
+		m.mutex.Unlock()
+		<-waiter
+	}
+}
+
+func (t *mockTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop remove timer from mock clock, false if it already fired or was stopped
+func (t *mockTimer) Stop() bool {
+	m := t.mock
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i, pending := range m.timers {
+		if pending == t {
+			m.timers = append(m.timers[:i], m.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// mockTicker ticker of a mock clock, Stop of a ticker returns nothing
+type mockTicker struct {
+	*mockTimer
+}
+
+func (t mockTicker) Stop() {
+	t.mockTimer.Stop()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/chainsync"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/relayer"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// dsnPassword password of a key=value connection string
+var dsnPassword = regexp.MustCompile(`password=('[^']*'|\S+)`)
+
+// adminOps operations of a running node served under /admin
+func adminOps(net *network.Network, syncer *chainsync.Syncer, drng *beacon.Beacon, rounds *store.Store, evmRelayer *relayer.Relayer) *api.AdminOps {
+	ops := &api.AdminOps{
+		Config: effectiveConfig,
+		Resync: func(ctx context.Context) (uint64, error) {
+			return syncer.Sync(ctx, drng.CurrentRound(time.Now())-1)
+		},
+		RotateLogs:  logger.Rotate,
+		BanPeer:     net.BanPeer,
+		UnbanPeer:   net.UnbanPeer,
+		BannedPeers: net.BannedPeers,
+		Archive: func(w io.Writer, from uint64, to uint64, pace func() error) (int, error) {
+			return rounds.ExportPaced(w, drng.ChainInfo(), from, to, pace)
+		},
+	}
+	if evmRelayer != nil {
+		ops.Relayer = evmRelayer
+	}
+	return ops
+}
+
+// effectiveConfig values of every config key, the password of store::dsn is
+// redacted. Other secrets are read from environment variables that config
+// only names
+func effectiveConfig() map[string]interface{} {
+	values := AppConfig.cfg.Values()
+	if dsn, ok := values["store::dsn"].(string); ok && dsn != "" {
+		values["store::dsn"] = redactDSN(dsn)
+	}
+	return values
+}
+
+// redactDSN connection string without its password, both URL and key=value
+// forms are redacted
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password=xxxxx")
+}
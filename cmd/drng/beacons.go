@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/chainsync"
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/store"
+	"github.com/orochi-network/orochimaru/tracing"
+)
+
+// Files of an extra beacon directory
+const (
+	extraGroupFile = "group.json"
+	extraShareFile = "share.json"
+)
+
+// extraBeacon beacon that node takes part in besides its default beacon, it
+// has its own group, share, topics, store namespace and API path
+type extraBeacon struct {
+	ID  string
+	Dir string
+}
+
+// runningBeacon extra beacon opened by start
+type runningBeacon struct {
+	extraBeacon
+	drng     *beacon.Beacon
+	rounds   *store.Store
+	syncer   *chainsync.Syncer
+	observer bool
+}
+
+// parseExtraBeacons parse id=directory pairs, ids name topics, store
+// namespaces and API paths so they must be unique
+func parseExtraBeacons(items []string) ([]extraBeacon, error) {
+	var beacons []extraBeacon
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("extra beacon must be id=directory: %s", item)
+		}
+		if !store.ValidNamespace(parts[0]) {
+			return nil, fmt.Errorf("beacon id must be 1 to 32 lowercase letters, digits and underscores: %s", parts[0])
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("beacon id is used twice: %s", parts[0])
+		}
+		seen[parts[0]] = true
+		beacons = append(beacons, extraBeacon{ID: parts[0], Dir: parts[1]})
+	}
+	return beacons, nil
+}
+
+// extraBeaconTopics topics of extra beacons that members publish on
+func extraBeaconTopics(beacons []extraBeacon) []string {
+	var topics []string
+	for _, b := range beacons {
+		for _, topic := range []string{beacon.Topic, beacon.PartialTopic, beacon.CommitRevealTopic, beacon.HeartbeatTopic, beacon.ResultsTopic} {
+			topics = append(topics, beacon.NamespacedTopic(b.ID, topic))
+		}
+	}
+	return topics
+}
+
+// openExtraBeacons open every extra beacon of configuration with its store
+func openExtraBeacons(net *network.Network, nodeKey keypair.Signer) []*runningBeacon {
+	beacons, err := AppConfig.GetExtraBeacons()
+	if err != nil {
+		log.Fatal(err)
+	}
+	opened := make([]*runningBeacon, 0, len(beacons))
+	for _, b := range beacons {
+		running, err := openExtraBeacon(net, nodeKey, b)
+		if err != nil {
+			log.Fatalf("Unable to open beacon %s: %v", b.ID, err)
+		}
+		opened = append(opened, running)
+	}
+	return opened
+}
+
+// openExtraBeacon create beacon of group file in directory of extra beacon,
+// node is an observer of it if there is no share file
+func openExtraBeacon(net *network.Network, nodeKey keypair.Signer, b extraBeacon) (*runningBeacon, error) {
+	g, err := group.LoadVerifiedFromFile(filepath.Join(b.Dir, extraGroupFile))
+	if err != nil {
+		return nil, fmt.Errorf("invalid group file: %v", err)
+	}
+	drng := beacon.New(net, nodeKey, g.Period)
+	drng.Namespace = b.ID
+	drng.Genesis = g.GenesisTime
+	drng.TimeoutRatio = AppConfig.GetBeaconTimeoutRatio()
+	drng.PrecomputeDepth = AppConfig.GetBeaconPrecomputeDepth()
+	drng.Members = g.CurrentMembers()
+	if g.Mode != "" {
+		drng.Mode = g.Mode
+	}
+	topics := []string{drng.TopicName(beacon.Topic), drng.TopicName(beacon.PartialTopic), drng.TopicName(beacon.CommitRevealTopic), drng.TopicName(beacon.HeartbeatTopic)}
+	if err := net.RestrictTopics(drng.Members, topics...); err != nil {
+		return nil, err
+	}
+	net.SetGroupMembers(b.ID, drng.Members)
+
+	running := &runningBeacon{extraBeacon: b, drng: drng}
+	shareFile := filepath.Join(b.Dir, extraShareFile)
+	if _, err := os.Stat(shareFile); AppConfig.GetNodeMode() == nodeModeObserver || os.IsNotExist(err) {
+		if g.PublicKey == nil {
+			return nil, errors.New("observer needs a group file with group public key")
+		}
+		drng.SetGroupKey(g.PublicKey)
+		running.observer = true
+	} else {
+		share, err := dkg.LoadResultFromFile(shareFile)
+		if err != nil {
+			return nil, err
+		}
+		if g.PublicKey != nil && !bytes.Equal(g.PublicKey, share.GroupKey()) {
+			return nil, errors.New("group key of share file does not match group file")
+		}
+		if err := drng.SetThreshold(share.Share, share.PubPoly); err != nil {
+			return nil, err
+		}
+	}
+
+	driver := AppConfig.GetStoreDriver()
+	if running.rounds, err = store.OpenNamespace(driver, storeSource(driver, AppConfig.GetStoreFile(), AppConfig.GetStoreDSN()), b.ID); err != nil {
+		return nil, err
+	}
+	drng.SetStore(running.rounds)
+	if last, err := running.rounds.Latest(); err == nil {
+		drng.Resume(last)
+	}
+	running.syncer = chainsync.New(net, running.rounds, drng)
+	running.syncer.Shedder = shedder
+	publishSyncProgress(running.syncer, b.ID)
+	running.syncer.Serve()
+	syncOnUnknownChain(&backgroundSync{syncer: running.syncer, drng: drng})
+	log.Infof("Beacon %s of %d members, threshold: %d observer: %t", b.ID, len(g.Members), g.Threshold, running.observer)
+	return running, nil
+}
+
+// run produce or follow rounds of extra beacon, store them and publish them
+// on event bus of node
+func (b *runningBeacon) run() {
+	defer b.rounds.Close()
+	go pruneStore(b.rounds)
+	syncChain(b.syncer, b.drng)
+	startHeartbeat(b.drng)
+	if b.observer {
+		if err := b.drng.Follow(); err != nil {
+			log.Errorf("Unable to follow beacon %s: %v", b.ID, err)
+			return
+		}
+	} else {
+		b.drng.Start()
+	}
+	for result := range b.drng.Results() {
+		log.Infof("Beacon: %s round: %d randomness: %x", b.ID, result.Round, result.Randomness)
+		ctx := b.drng.RoundContext(result.Round)
+		if err := tracing.Run(ctx, "store.put", func() error { return b.rounds.Put(&result) }); err != nil {
+			log.Errorf("Unable to store round %d of beacon %s: %v", result.Round, b.ID, err)
+		}
+		nodeEvents.Publish(events.RoundFinalized{Beacon: b.ID, Result: result})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// genBindingsCommand write Go bindings of a contract ABI, they are the
+// bindings that abigen of go-ethereum writes
+func genBindingsCommand(args []string) {
+	flags := flag.NewFlagSet("gen-bindings", flag.ExitOnError)
+	abiFile := flags.String("abi", "", "ABI JSON file of contract, as written by solc --abi")
+	typeName := flags.String("type", "", "Go type of contract (default ABI file name up to the first dot)")
+	pkg := flags.String("pkg", "contracts", "Go package of bindings")
+	out := flags.String("out", "", "Output Go file (default stdout)")
+	flags.Parse(args)
+
+	if *abiFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	raw, err := os.ReadFile(*abiFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	name := *typeName
+	if name == "" {
+		base := filepath.Base(*abiFile)
+		name = base[:strings.IndexByte(base+".", '.')]
+	}
+	source, err := bind.Bind([]string{name}, []string{string(raw)}, []string{""}, nil, *pkg, bind.LangGo, nil, nil)
+	if err != nil {
+		log.Fatalf("Unable to generate bindings of %s: %v", *abiFile, err)
+	}
+	if *out == "" {
+		os.Stdout.WriteString(source)
+		return
+	}
+	if err := os.WriteFile(*out, []byte(source), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
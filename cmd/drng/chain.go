@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// chainCommand dispatch chain subcommands
+func chainCommand(args []string) {
+	if len(args) > 0 && args[0] == "dump" {
+		chainDump(args[1:])
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng chain dump [flags]")
+	os.Exit(1)
+}
+
+// chainDump stream stored rounds as CSV or JSON lines, for loading history
+// into spreadsheets and data warehouses
+func chainDump(args []string) {
+	flags := flag.NewFlagSet("chain dump", flag.ExitOnError)
+	storeFile := flags.String("store-file", "rounds.db", "File that rounds are persisted to")
+	driver := flags.String("store-driver", store.DriverBolt, "Store driver: bolt or postgres")
+	dsn := flags.String("store-dsn", "", "Connection string of database drivers")
+	format := flags.String("format", store.DumpJSONL, "Output format: csv or jsonl")
+	fields := flags.String("fields", "round,randomness,signature,previous_signature", "Comma-separated fields of each round: "+strings.Join(store.DumpFields, ", "))
+	groupFile := flags.String("group-file", "", "Group file holding genesis time and period, needed by the time field")
+	from := flags.Uint64("from", 1, "First round to dump")
+	to := flags.Uint64("to", 0, "Last round to dump, latest round if it's 0")
+	out := flags.String("out", "", "File to write, standard output if it's empty")
+	flags.Parse(args)
+
+	opts := store.DumpOptions{Format: *format, From: *from, To: *to}
+	for _, field := range strings.Split(*fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opts.Fields = append(opts.Fields, field)
+		}
+	}
+	if *groupFile != "" {
+		g, err := group.LoadVerifiedFromFile(*groupFile)
+		if err != nil {
+			log.Fatalf("Invalid group file: %v", err)
+		}
+		drng := beacon.New(nil, nil, g.Period)
+		drng.Genesis = g.GenesisTime
+		opts.RoundTime = drng.RoundTime
+	}
+	if *driver == store.DriverBolt {
+		// Opening a missing file would create an empty store
+		if _, err := os.Stat(*storeFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	rounds, err := store.OpenDriver(*driver, storeSource(*driver, *storeFile, *dsn))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rounds.Close()
+
+	output := os.Stdout
+	if *out != "" {
+		if output, err = os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	count, err := rounds.Dump(output, opts)
+	if err == nil && *out != "" {
+		err = output.Sync()
+	}
+	if *out != "" {
+		output.Close()
+		if err != nil {
+			os.Remove(*out)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Dump failed after %d rounds: %v", count, err)
+	}
+	if *out != "" {
+		fmt.Printf("Dumped %d rounds to %s\n", count, *out)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/simnet"
+)
+
+// devnetCommand run a group of in-process nodes, run DKG among them and
+// print rounds they produce until interrupted or enough rounds were printed
+func devnetCommand(args []string) {
+	flags := flag.NewFlagSet("devnet", flag.ExitOnError)
+	nodes := flags.Int("nodes", 5, "Number of nodes")
+	threshold := flags.Int("threshold", 3, "Number of partial signatures needed per round")
+	period := flags.Duration("period", 5*time.Second, "Round period, nodes share CPU of one machine so it must leave time to verify every partial")
+	mode := flags.String("mode", beacon.ModeChained, "Beacon mode: chained or unchained")
+	phaseTimeout := flags.Duration("phase-timeout", 2*time.Second, "Duration of each DKG phase")
+	rounds := flags.Uint64("rounds", 0, "Exit after this many rounds, 0 runs until interrupted")
+	logLevel := flags.String("log-level", "warn", "Log level of nodes, e.g. info or info,beacon=debug")
+	flags.Parse(args)
+
+	if err := logger.SetLevel(*logLevel); err != nil {
+		log.Fatalf("Invalid log level: %v", err)
+	}
+	sim, err := simnet.New(simnet.Config{
+		Nodes:        *nodes,
+		Threshold:    *threshold,
+		Period:       *period,
+		Mode:         *mode,
+		PhaseTimeout: *phaseTimeout,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer sim.Stop()
+	for i, id := range sim.Members() {
+		fmt.Printf("Node %d: %s\n", i+1, id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6**phaseTimeout)
+	err = sim.RunDKG(ctx)
+	cancel()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Group key: %x\n", sim.GroupKey())
+	// Genesis is ahead of start, so every node signs round 1 that begins the chain
+	if err := sim.Start(time.Now().Add(time.Second)); err != nil {
+		log.Fatal(err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	var printed uint64
+	for {
+		select {
+		case result, ok := <-sim.Results():
+			if !ok {
+				return
+			}
+			fmt.Printf("Round: %d randomness: %x\n", result.Round, result.Randomness)
+			if printed++; *rounds > 0 && printed >= *rounds {
+				return
+			}
+		case <-interrupt:
+			return
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// dkgCommand dispatch dkg subcommands
+func dkgCommand(args []string) {
+	if len(args) > 0 && args[0] == "init" {
+		dkgInit(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "join" {
+		dkgJoin(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "recover" {
+		dkgRecover(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "propose" {
+		dkgPropose(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "accept" {
+		dkgAccept(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "status" {
+		dkgStatus(args[1:])
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng dkg init|join|recover|propose|accept|status [flags]")
+	os.Exit(1)
+}
+
+// dkgInit write an unsigned group file listing participants of a ceremony
+func dkgInit(args []string) {
+	flags := flag.NewFlagSet("dkg init", flag.ExitOnError)
+	groupFile := flags.String("group-file", "", "Group file to write")
+	members := flags.String("members", "", "Comma-separated peer IDs of members")
+	threshold := flags.Int("threshold", 0, "Number of partial signatures needed per round")
+	period := flags.Duration("period", 30*time.Second, "Round period")
+	genesis := flags.Int64("genesis", 0, "Genesis time as Unix timestamp")
+	mode := flags.String("mode", group.ModeChained, "Beacon mode: chained or unchained")
+	publicKey := flags.String("public-key", "", "Hex encoded group public key produced by DKG")
+	flags.Parse(args)
+
+	if *groupFile == "" || *members == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	var ids []peer.ID
+	for _, member := range strings.Split(*members, ",") {
+		id, err := peer.Decode(strings.TrimSpace(member))
+		if err != nil {
+			log.Fatalf("Invalid member %s: %v", member, err)
+		}
+		ids = append(ids, id)
+	}
+	g, err := group.New(ids, *threshold, *period, time.Unix(*genesis, 0), *mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *publicKey != "" {
+		if g.PublicKey, err = hex.DecodeString(*publicKey); err != nil {
+			log.Fatalf("Invalid group public key: %v", err)
+		}
+	}
+	if _, err := g.SaveToFile(*groupFile); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Group file: %s\nMembers: %d threshold: %d\n", *groupFile, len(ids), *threshold)
+}
+
+// dkgJoin take part in the DKG ceremony of a group file and save share of this node
+func dkgJoin(args []string) {
+	parseNodeFlags("dkg join", args)
+	groupFile := AppConfig.GetGroupFile()
+	shareFile := AppConfig.GetBeaconShareFile()
+	if groupFile == "" || shareFile == "" && AppConfig.GetKeystoreDir() == "" {
+		log.Fatal("DKG needs a group file and a share file or keystore to write to")
+	}
+	// Members sign group file once its public key is known
+	g, err := group.LoadFromFile(groupFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	nodeKey := openNodeKey()
+	net := openNetwork(nodeKey)
+	runCeremony(net, g, groupFile, AppConfig.GetDKGPhaseTimeout())
+}
+
+// runCeremony take part in DKG ceremony of group, save share of this node and
+// write group public key into group file. Status of ceremony is served at
+// /dkg if API is enabled
+func runCeremony(net *network.Network, g *group.Group, groupFile string, phaseTimeout time.Duration) {
+	protocol, err := dkg.New(net, dkg.Config{
+		Participants: g.CurrentMembers(),
+		Threshold:    g.Threshold,
+		PhaseTimeout: phaseTimeout,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	protocol.OnPhase = func(phase dkg.Phase) {
+		log.Infof("DKG phase: %s", phase)
+		nodeEvents.Publish(events.DKGPhaseChanged{Phase: string(phase)})
+	}
+	startDKGServer(protocol.Status)
+	waitForMembers(net, g.CurrentMembers(), 6*phaseTimeout)
+	result, err := protocol.Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if shareFile := AppConfig.GetBeaconShareFile(); shareFile != "" {
+		_, err = result.SaveToFile(shareFile)
+	} else {
+		err = saveShareToKeystore(result)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if g.PublicKey == nil && len(g.Signatures) == 0 {
+		g.PublicKey = result.GroupKey()
+		if _, err := g.SaveToFile(groupFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Printf("Share: %d qualified dealers: %v\nGroup key: %x\n", result.Share.Index, result.Qualified, result.GroupKey())
+	// Contracts take the group key uncompressed
+	if contractKey, err := keypair.EncodeG2EVM(result.GroupKey()); err == nil {
+		fmt.Printf("Contract group key: %x\n", contractKey)
+	}
+}
+
+// startDKGServer serve status of a ceremony at /dkg if API is enabled
+func startDKGServer(status api.DKGStatus) {
+	if AppConfig.GetAPIBindPort() == 0 {
+		return
+	}
+	if err := api.NewDKG(AppConfig.GetAPIBindHost(), AppConfig.GetAPIBindPort(), status).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// dkgRecover get share of this node back from threshold other members after
+// it was lost, node must be stopped while its share is recovered
+func dkgRecover(args []string) {
+	parseNodeFlags("dkg recover", args)
+	groupFile := AppConfig.GetGroupFile()
+	shareFile := AppConfig.GetBeaconShareFile()
+	if groupFile == "" || shareFile == "" && AppConfig.GetKeystoreDir() == "" {
+		log.Fatal("Share recovery needs a group file and a share file or keystore to write to")
+	}
+	if shareFile != "" {
+		if _, err := os.Stat(shareFile); err == nil {
+			log.Fatalf("Share file %s already exists", shareFile)
+		}
+	}
+	g, err := group.LoadVerifiedFromFile(groupFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if g.PublicKey == nil {
+		log.Fatal("Group file has no group public key")
+	}
+
+	nodeKey := openNodeKey()
+	net := openNetwork(nodeKey)
+	members := g.CurrentMembers()
+	timeout := 6 * AppConfig.GetDKGPhaseTimeout()
+	waitForMembers(net, members, AppConfig.GetDKGPhaseTimeout())
+	result, err := dkg.Recover(context.Background(), net, dkg.RecoverConfig{
+		Members:   members,
+		GroupKey:  g.PublicKey,
+		Threshold: g.Threshold,
+		Timeout:   timeout,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if shareFile != "" {
+		_, err = result.SaveToFile(shareFile)
+	} else {
+		err = saveShareToKeystore(result)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Share: %d\nGroup key: %x\n", result.Share.Index, result.GroupKey())
+}
+
+// waitForMembers wait until all other members are connected or timeout expires
+func waitForMembers(net *network.Network, members []peer.ID, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for {
+		connected := make(map[peer.ID]bool)
+		for _, id := range net.Peers() {
+			connected[id] = true
+		}
+		missing := 0
+		for _, member := range members {
+			if member != net.NodeID && !connected[member] {
+				missing++
+			}
+		}
+		if missing == 0 || time.Now().After(deadline) {
+			if missing > 0 {
+				log.Warnf("Start DKG with %d members not connected", missing)
+			}
+			return
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// proposalRepeat interval that proposal, acceptance and start messages are
+// repeated at until they are answered
+const proposalRepeat = 2 * time.Second
+
+// dkgPropose propose a group to its members, tell them to start the ceremony
+// once all of them accepted and follow the ceremony until it finishes
+func dkgPropose(args []string) {
+	flags := flag.NewFlagSet("dkg propose", flag.ExitOnError)
+	membersFile := flags.String("members", "", "File listing peer IDs of members, one per line")
+	threshold := flags.Int("threshold", 0, "Number of partial signatures needed per round")
+	period := flags.Duration("period", 30*time.Second, "Round period")
+	genesis := flags.Int64("genesis", 0, "Genesis time as Unix timestamp")
+	mode := flags.String("mode", group.ModeChained, "Beacon mode: chained or unchained")
+	wait := flags.Duration("wait", 10*time.Minute, "Time members have to accept proposal")
+	parseNodeFlags("dkg propose", splitFlags(flags, args))
+	groupFile := AppConfig.GetGroupFile()
+	if *membersFile == "" || groupFile == "" {
+		log.Fatal("Proposal needs -members and a group file to write the proposed group to")
+	}
+	members, err := loadMembersFile(*membersFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g, err := group.New(members, *threshold, *period, time.Unix(*genesis, 0), *mode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	proposal, err := group.NewProposal(g, AppConfig.GetDKGPhaseTimeout())
+	if err != nil {
+		log.Fatal(err)
+	}
+	id, err := proposal.ID()
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := proposal.Marshal()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := g.SaveToFile(groupFile); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Proposal: %s\nMembers: %d threshold: %d\nMembers accept it with: drng dkg accept [flags] %s\n", id, len(members), g.Threshold, id)
+
+	nodeKey := openNodeKey()
+	net := openNetwork(nodeKey)
+	if g.IsMember(net.NodeID) {
+		log.Fatal("Coordinator must not be a member, members run dkg accept with their own node key")
+	}
+	monitor := dkg.NewMonitor(net, dkg.Config{Participants: members, Threshold: g.Threshold, PhaseTimeout: proposal.PhaseTimeout})
+	if err := monitor.Start(); err != nil {
+		log.Fatal(err)
+	}
+	defer monitor.Stop()
+	startDKGServer(monitor.Status)
+
+	accepted := make(chan peer.ID, len(members))
+	err = net.Handle(group.ProposalTopic, group.AcceptanceMessage, func(e *network.Envelope) {
+		acceptance := new(group.Acceptance)
+		if network.DecodePayload(e.Payload, acceptance) != nil || acceptance.ID != id || !g.IsMember(e.Sender) {
+			return
+		}
+		select {
+		case accepted <- e.Sender:
+		default:
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	start, err := json.Marshal(group.Acceptance{ID: id})
+	if err != nil {
+		log.Fatal(err)
+	}
+	ticker := time.NewTicker(proposalRepeat)
+	defer ticker.Stop()
+	deadline := time.After(*wait)
+	acceptedBy := make(map[peer.ID]bool)
+	for len(acceptedBy) < len(members) {
+		select {
+		case member := <-accepted:
+			if !acceptedBy[member] {
+				acceptedBy[member] = true
+				fmt.Printf("Accepted by %s (%d of %d)\n", member, len(acceptedBy), len(members))
+			}
+		case <-ticker.C:
+			if err := net.Send(group.ProposalTopic, group.ProposalMessage, 0, data); err != nil {
+				log.Warnf("Unable to send proposal: %v", err)
+			}
+		case <-deadline:
+			for _, member := range members {
+				if !acceptedBy[member] {
+					fmt.Printf("Not accepted by %s\n", member)
+				}
+			}
+			log.Fatalf("Only %d of %d members accepted proposal", len(acceptedBy), len(members))
+		}
+	}
+
+	// Members start on the first start message, it's repeated until the
+	// ceremony is seen dealing
+	fmt.Println("All members accepted, starting ceremony")
+	phase := dkg.Phase("")
+	for range ticker.C {
+		status := monitor.Status()
+		if status.Phase != phase {
+			phase = status.Phase
+			fmt.Printf("Phase: %s\n", phase)
+		}
+		if phase == dkg.PhaseFinished {
+			printDKGStatus(status)
+			return
+		}
+		if phase == "" || phase == dkg.PhaseAnnounce {
+			if err := net.Send(group.ProposalTopic, group.StartMessage, 0, start); err != nil {
+				log.Warnf("Unable to send start: %v", err)
+			}
+		}
+	}
+}
+
+// dkgAccept wait for the proposal of given ID, accept it and take part in its
+// ceremony once coordinator starts it
+func dkgAccept(args []string) {
+	rest := parseNodeFlags("dkg accept", args)
+	groupFile := AppConfig.GetGroupFile()
+	if len(rest) != 1 || groupFile == "" || AppConfig.GetBeaconShareFile() == "" && AppConfig.GetKeystoreDir() == "" {
+		log.Fatal("Usage: drng dkg accept [flags] <proposal ID>, a group file and a share file or keystore to write to are needed")
+	}
+	id := rest[0]
+
+	nodeKey := openNodeKey()
+	net := openNetwork(nodeKey)
+	type proposed struct {
+		proposal    *group.Proposal
+		coordinator peer.ID
+	}
+	proposals := make(chan proposed, 1)
+	err := net.Handle(group.ProposalTopic, group.ProposalMessage, func(e *network.Envelope) {
+		proposal, err := group.UnmarshalProposal(e.Payload)
+		if err != nil {
+			log.Debugf("Invalid proposal from %s: %v", e.Sender, err)
+			return
+		}
+		if proposalID, err := proposal.ID(); err != nil || proposalID != id {
+			return
+		}
+		select {
+		case proposals <- proposed{proposal: proposal, coordinator: e.Sender}:
+		default:
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("Waiting for proposal: %s", id)
+	p := <-proposals
+	net.RemoveHandler(group.ProposalTopic, group.ProposalMessage)
+	g := p.proposal.Group
+	if !g.IsMember(net.NodeID) {
+		log.Fatal("This node is not a member of proposed group")
+	}
+	fmt.Printf("Proposal of %s\nMembers: %d threshold: %d period: %s genesis: %d\n", p.coordinator, len(g.Members), g.Threshold, g.Period, g.GenesisTime.Unix())
+	if _, err := g.SaveToFile(groupFile); err != nil {
+		log.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	var once sync.Once
+	err = net.Handle(group.ProposalTopic, group.StartMessage, func(e *network.Envelope) {
+		acceptance := new(group.Acceptance)
+		if e.Sender == p.coordinator && network.DecodePayload(e.Payload, acceptance) == nil && acceptance.ID == id {
+			once.Do(func() { close(started) })
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	acceptance, err := json.Marshal(group.Acceptance{ID: id})
+	if err != nil {
+		log.Fatal(err)
+	}
+	ticker := time.NewTicker(proposalRepeat)
+wait:
+	for {
+		if err := net.Send(group.ProposalTopic, group.AcceptanceMessage, 0, acceptance); err != nil {
+			log.Warnf("Unable to send acceptance: %v", err)
+		}
+		select {
+		case <-started:
+			break wait
+		case <-ticker.C:
+		}
+	}
+	ticker.Stop()
+	net.RemoveHandler(group.ProposalTopic, group.StartMessage)
+	log.Info("Coordinator started ceremony")
+	runCeremony(net, g, groupFile, p.proposal.PhaseTimeout)
+}
+
+// dkgStatus print status of the ceremony that a node runs or follows
+func dkgStatus(args []string) {
+	flags := flag.NewFlagSet("dkg status", flag.ExitOnError)
+	node := flags.String("node", "", "HTTP API of node running dkg join, accept or propose")
+	jsonOutput := flags.Bool("json", false, "Print status as JSON")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of request")
+	flags.Parse(args)
+
+	if *node == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	status := new(dkg.Status)
+	if err := fetchNodeJSON(*node, "/dkg", *timeout, status); err != nil {
+		log.Fatal(err)
+	}
+	if *jsonOutput {
+		printJSON(status)
+		return
+	}
+	printDKGStatus(status)
+}
+
+// printDKGStatus print phase, phases seen from each participant and complaints
+func printDKGStatus(status *dkg.Status) {
+	fmt.Printf("Session: %s\nPhase: %s\nThreshold: %d\n\n", status.Session, status.Phase, status.Threshold)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "INDEX\tID\tSEEN\tDISQUALIFIED")
+	for _, p := range status.Participants {
+		seen := make([]string, 0, len(p.Seen))
+		for _, phase := range p.Seen {
+			seen = append(seen, string(phase))
+		}
+		if len(seen) == 0 {
+			seen = append(seen, "-")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%v\n", p.Index, p.ID, strings.Join(seen, ","), p.Disqualified)
+	}
+	w.Flush()
+	if len(status.Complaints) == 0 {
+		fmt.Println("\nNo complaints")
+		return
+	}
+	fmt.Println("\nComplaints:")
+	for _, c := range status.Complaints {
+		fmt.Printf("  participant %d complained about dealer %d\n", c.Complainer, c.Dealer)
+	}
+}
+
+// loadMembersFile read peer IDs of members, one per line, empty lines and
+// lines starting with # are skipped
+func loadMembersFile(fileName string) ([]peer.ID, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var members []peer.ID
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := peer.Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid member %s: %v", line, err)
+		}
+		members = append(members, id)
+	}
+	return members, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/chainsync"
+	"github.com/orochi-network/orochimaru/consumer"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// nodeEvents event bus of node, subsystems publish what happens in node to it
+// and API, relayer and metrics subscribe to it
+var nodeEvents = events.New()
+
+// publishPeerEvents publish peers connecting and disconnecting
+func publishPeerEvents(net *network.Network) {
+	net.NotifyPeers(func(id peer.ID, direction string) {
+		nodeEvents.Publish(events.PeerConnected{Peer: id, Direction: direction})
+	}, func(id peer.ID) {
+		nodeEvents.Publish(events.PeerDisconnected{Peer: id})
+	})
+}
+
+// publishSyncProgress publish progress of syncer of given beacon, empty for
+// the default beacon
+func publishSyncProgress(syncer *chainsync.Syncer, beaconID string) {
+	syncer.OnProgress = func(progress chainsync.Progress) {
+		nodeEvents.Publish(events.SyncProgress{Beacon: beaconID, Peer: progress.Peer, Latest: progress.Latest, Target: progress.Target})
+	}
+}
+
+// attachConsumers hand finalized rounds to consumers of node::consumers, to
+// webhooks of webhook::urls and to Kafka and NATS if they are configured
+func attachConsumers() {
+	consumer.SetWebhookDefaults(consumer.WebhookConfig{
+		Secret:      []byte(os.Getenv(AppConfig.GetWebhookSecretEnv())),
+		MaxAttempts: AppConfig.GetWebhookMaxAttempts(),
+		Backoff:     AppConfig.GetWebhookBackoff(),
+		DeadLetter:  AppConfig.GetWebhookDeadLetterFile(),
+	})
+	entries := AppConfig.GetConsumers()
+	for _, url := range AppConfig.GetWebhookURLs() {
+		entries = append(entries, "webhook:"+url)
+	}
+	for _, entry := range entries {
+		name, c, err := consumer.Open(entry)
+		if err != nil {
+			log.Fatal(err)
+		}
+		consumer.Attach(nodeEvents, name, c)
+		log.Infof("Rounds are handed to consumer: %s", name)
+		if name == "webhook" && os.Getenv(AppConfig.GetWebhookSecretEnv()) == "" {
+			log.Warnf("Webhook requests are not signed, %s is not set", AppConfig.GetWebhookSecretEnv())
+		}
+	}
+	if brokers := AppConfig.GetKafkaBrokers(); len(brokers) > 0 {
+		c, err := consumer.NewKafka(consumer.KafkaConfig{
+			Brokers:  brokers,
+			Topic:    AppConfig.GetKafkaTopic(),
+			User:     AppConfig.GetKafkaUser(),
+			Password: os.Getenv(AppConfig.GetKafkaPasswordEnv()),
+			TLS:      AppConfig.GetKafkaTLS(),
+			CAFile:   AppConfig.GetKafkaCAFile(),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		consumer.Attach(nodeEvents, "kafka", c)
+		log.Infof("Rounds are published to Kafka topic %s", AppConfig.GetKafkaTopic())
+	}
+	if url := AppConfig.GetNATSURL(); url != "" {
+		c, err := consumer.NewNATS(consumer.NATSConfig{
+			URL:       url,
+			Subject:   AppConfig.GetNATSSubject(),
+			CredsFile: AppConfig.GetNATSCredsFile(),
+			Token:     os.Getenv(AppConfig.GetNATSTokenEnv()),
+			CAFile:    AppConfig.GetNATSCAFile(),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		consumer.Attach(nodeEvents, "nats", c)
+		log.Infof("Rounds are published to NATS subject %s", AppConfig.GetNATSSubject())
+	}
+}
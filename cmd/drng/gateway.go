@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/orochi-network/orochimaru/gateway"
+)
+
+// gatewayCommand serve public API of a beacon from several upstream nodes,
+// every read goes to all upstreams and the first verified answer is served
+func gatewayCommand(args []string) {
+	flags := flag.NewFlagSet("gateway", flag.ExitOnError)
+	upstreams := flags.String("upstreams", "", "HTTP API of upstream beacon or observer nodes separated by commas, e.g. http://node-a:8080,http://node-b:8080")
+	bindHost := flags.String("bind-host", "127.0.0.1", "Bind host of gateway")
+	bindPort := flags.Uint("bind-port", 8080, "Bind port of gateway")
+	groupKey := flags.String("group-key", "", "Hex encoded group public key that rounds are verified against")
+	groupFile := flags.String("group-file", "", "Group file holding group public key, used if -group-key is not given")
+	chainHash := flags.String("chain-hash", "", "Hex encoded chain hash that chain info of upstreams must match")
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout of request to an upstream")
+	cacheSize := flags.Int("cache-size", gateway.DefaultCacheSize, "Number of verified rounds kept in memory")
+	apiKeyEnv := flags.String("upstream-api-key-env", "OROCHI_UPSTREAM_API_KEY", "Environment variable holding API key sent to upstreams")
+	tokenEnv := flags.String("upstream-token-env", "OROCHI_UPSTREAM_TOKEN", "Environment variable holding bearer token sent to upstreams")
+	flags.Parse(args)
+
+	if *upstreams == "" {
+		fmt.Fprintln(os.Stderr, "Gateway needs -upstreams")
+		flags.Usage()
+		os.Exit(1)
+	}
+	g, err := gateway.New(splitList(*upstreams)...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if g.GroupKey, g.ChainHash, err = loadPins(*groupKey, *groupFile, *chainHash); err != nil {
+		log.Fatal(err)
+	}
+	g.HTTPClient.Timeout = *timeout
+	g.CacheSize = *cacheSize
+	g.APIKey = os.Getenv(*apiKeyEnv)
+	g.Token = os.Getenv(*tokenEnv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	if info, err := g.Info(ctx); err != nil {
+		// Chain info is asked again on the next request
+		log.Warnf("Unable to get chain info from upstreams: %v", err)
+	} else {
+		log.Infof("Gateway of chain %s, mode: %s, period: %ds", info.Hash, info.Mode, info.Period)
+	}
+	cancel()
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", *bindHost, *bindPort),
+		Handler:           g.Handler(),
+		ReadHeaderTimeout: 30 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+	log.Infof("Gateway listen on: %s, upstreams: %s", server.Addr, *upstreams)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/client"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// getCommand print a round, latest round if no round is given. Rounds
+// are read from local store, or fetched from remote nodes and verified
+// against group key or chain hash when -node is given
+func getCommand(args []string) {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	storeFile := flags.String("store-file", "rounds.db", "File that rounds are persisted to")
+	driver := flags.String("store-driver", store.DriverBolt, "Store driver: bolt or postgres")
+	dsn := flags.String("store-dsn", "", "Connection string of database drivers")
+	node := flags.String("node", "", "HTTP API of remote nodes separated by commas, e.g. http://127.0.0.1:8080")
+	groupKey := flags.String("group-key", "", "Hex encoded group public key that remote rounds are verified against")
+	groupFile := flags.String("group-file", "", "Group file holding group public key, used if -group-key is not given")
+	chainHash := flags.String("chain-hash", "", "Hex encoded chain hash that chain info of remote nodes must match")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of request to remote node")
+	jsonOutput := flags.Bool("json", false, "Print round as JSON")
+	flags.Usage = func() {
+		flags.Output().Write([]byte("Usage: drng get [flags] [round]\n"))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var round uint64
+	if flags.NArg() > 0 {
+		var err error
+		if round, err = strconv.ParseUint(flags.Arg(0), 10, 64); err != nil {
+			flags.Usage()
+			os.Exit(1)
+		}
+	}
+	if *node != "" {
+		c, err := newClient(*node, *groupKey, *groupFile, *chainHash, *timeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		result, err := c.Get(context.Background(), round)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printRound(result, *jsonOutput)
+		return
+	}
+
+	if *driver == store.DriverBolt {
+		// Opening a missing file would create an empty store
+		if _, err := os.Stat(*storeFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	rounds, err := store.OpenDriver(*driver, storeSource(*driver, *storeFile, *dsn))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rounds.Close()
+	var result *beacon.RoundResult
+	if round == 0 {
+		result, err = rounds.Latest()
+	} else {
+		result, err = rounds.Get(round)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	printRound(result, *jsonOutput)
+}
+
+// printRound print round, as the JSON object that API serves rounds as with
+// jsonOutput
+func printRound(result *beacon.RoundResult, jsonOutput bool) {
+	round := api.NewRound(result)
+	if jsonOutput {
+		printJSON(round)
+		return
+	}
+	fmt.Printf("Round: %d\nRandomness: %s\nSignature: %s\n", round.Round, round.Randomness, round.Signature)
+	if round.PreviousSignature != "" {
+		fmt.Printf("Previous signature: %s\n", round.PreviousSignature)
+	}
+}
+
+// loadGroupKey group public key given in hex or read from group file
+func loadGroupKey(groupKey string, groupFile string) ([]byte, error) {
+	if groupKey != "" {
+		return hex.DecodeString(groupKey)
+	}
+	if groupFile == "" {
+		return nil, errors.New("remote rounds need -group-key, -group-file or -chain-hash to be verified")
+	}
+	g, err := group.LoadVerifiedFromFile(groupFile)
+	if err != nil {
+		return nil, err
+	}
+	if g.PublicKey == nil {
+		return nil, errors.New("group file has no group public key")
+	}
+	return g.PublicKey, nil
+}
+
+// newClient client of comma separated node endpoints that verifies rounds
+// against given group key, group file or chain hash
+func newClient(nodes string, groupKey string, groupFile string, chainHash string, timeout time.Duration) (*client.Client, error) {
+	c, err := client.New(strings.Split(nodes, ",")...)
+	if err != nil {
+		return nil, err
+	}
+	c.HTTPClient.Timeout = timeout
+	if c.GroupKey, c.ChainHash, err = loadPins(groupKey, groupFile, chainHash); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadPins group key and chain hash that remote rounds are verified against,
+// a group key is required unless chain hash is given
+func loadPins(groupKey string, groupFile string, chainHash string) (key []byte, hash []byte, err error) {
+	if chainHash != "" {
+		if hash, err = hex.DecodeString(chainHash); err != nil {
+			return nil, nil, fmt.Errorf("invalid chain hash: %v", err)
+		}
+	}
+	if groupKey != "" || groupFile != "" || hash == nil {
+		if key, err = loadGroupKey(groupKey, groupFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	return key, hash, nil
+}
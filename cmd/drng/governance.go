@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/governance"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// governanceCommand dispatch governance subcommands
+func governanceCommand(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "propose":
+			governancePropose(args[1:])
+			return
+		case "approve":
+			governanceApprove(args[1:])
+			return
+		case "publish":
+			governancePublish(args[1:])
+			return
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng governance propose|approve|publish [flags]")
+	os.Exit(1)
+}
+
+// governancePropose write a message file with a new proposal and no approvals
+func governancePropose(args []string) {
+	flags := flag.NewFlagSet("governance propose", flag.ExitOnError)
+	kind := flags.String("kind", "", "Proposal kind: change_period, change_threshold, change_membership, reshare, halt or resume")
+	chainHash := flags.String("chain-hash", "", "Hex chain hash of beacon, as /info reports it")
+	nonce := flags.Uint64("nonce", 0, "Proposal nonce, it must be above nonce of every applied proposal")
+	period := flags.Duration("period", 0, "New round period of change_period")
+	threshold := flags.Uint("threshold", 0, "New threshold of change_threshold and reshare")
+	membersFile := flags.String("members", "", "File listing peer IDs of new members, one per line, of change_membership and reshare")
+	reason := flags.String("reason", "", "Reason shown to members approving proposal")
+	out := flags.String("out", "", "Message file to write, it must not exist")
+	flags.Parse(args)
+
+	if *kind == "" || *chainHash == "" || *nonce == 0 || *out == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*out); err == nil {
+		log.Fatalf("File %s already exists", *out)
+	}
+	hash, err := hex.DecodeString(*chainHash)
+	if err != nil {
+		log.Fatalf("Invalid chain hash: %v", err)
+	}
+	var members []peer.ID
+	if *membersFile != "" {
+		if members, err = loadMembersFile(*membersFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	m := &governance.Message{Proposal: governance.Proposal{
+		Kind:      governance.Kind(*kind),
+		ChainHash: hash,
+		Nonce:     *nonce,
+		Period:    *period,
+		Threshold: *threshold,
+		Members:   members,
+		Reason:    *reason,
+	}}
+	saveGovernanceMessage(*out, m)
+	fmt.Printf("Proposal %d written to %s, members approve it with: drng governance approve -key-file <key> %s\n", *nonce, *out, *out)
+}
+
+// governanceApprove add approval of a member to a message file
+func governanceApprove(args []string) {
+	flags := flag.NewFlagSet("governance approve", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "Key file of member or admin")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	flags.Parse(args)
+
+	if *keyFile == "" || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: drng governance approve -key-file <key> <message file>")
+		os.Exit(1)
+	}
+	m := loadGovernanceMessage(flags.Arg(0))
+	key, err := loadKeyFile(*keyFile, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := m.Approve(key); err != nil {
+		log.Fatal(err)
+	}
+	saveGovernanceMessage(flags.Arg(0), m)
+	fmt.Printf("Proposal %d has %d approvals\n", m.Proposal.Nonce, len(m.Approvals))
+}
+
+// governancePublish gossip a message file to members, each one applies it
+// once approvals reach quorum
+func governancePublish(args []string) {
+	rest := parseNodeFlags("governance publish", args)
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: drng governance publish [node flags] <message file>")
+		os.Exit(1)
+	}
+	m := loadGovernanceMessage(rest[0])
+	net := openNetwork(openNodeKey())
+	peers, err := broadcastOnTopic(net, governance.Topic, func() error {
+		return governance.Publish(net, m)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Proposal %d published to %d peers\n", m.Proposal.Nonce, peers)
+}
+
+// loadGovernanceMessage read a message file
+func loadGovernanceMessage(fileName string) *governance.Message {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m, err := governance.Decode(content)
+	if err != nil {
+		log.Fatalf("Invalid message file %s: %v", fileName, err)
+	}
+	return m
+}
+
+// saveGovernanceMessage write a message file as indented JSON
+func saveGovernanceMessage(fileName string, m *governance.Message) {
+	encoded, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fileName, encoded, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openGovernance apply governance messages that members gossip to beacon,
+// history kept in store is applied first. Beacon skips rounds while admins
+// halt it. It returns nil if governance::quorum is 0
+func openGovernance(net *network.Network, drng *beacon.Beacon, rounds *store.Store) *governance.State {
+	quorum := AppConfig.GetGovernanceQuorum()
+	if quorum == 0 {
+		return nil
+	}
+	if drng.GroupKey() == nil {
+		log.Fatal("Governance needs group public key of beacon")
+	}
+	g, err := group.LoadVerifiedFromFile(AppConfig.GetGroupFile())
+	if err != nil {
+		log.Fatalf("Governance needs a group file: %v", err)
+	}
+	history, ok := rounds.Governance()
+	if !ok {
+		log.Fatalf("Store %s does not keep governance messages", AppConfig.GetStoreDriver())
+	}
+	params := governance.Params{Period: drng.Period, Threshold: uint(g.Threshold), Members: g.CurrentMembers()}
+	state := governance.NewState(drng.ChainInfo().Hash(), params, quorum)
+	admins, err := AppConfig.GetGovernanceAdmins()
+	if err != nil {
+		log.Fatalf("Invalid governance admins: %v", err)
+	}
+	state.SetAdmins(admins, AppConfig.GetGovernanceAdminQuorum())
+	recorded, err := history.GovernanceHistory()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := state.Replay(recorded); err != nil {
+		log.Fatal(err)
+	}
+	if len(recorded) > 0 {
+		log.Infof("Applied %d recorded governance messages", len(recorded))
+	}
+	if err := governance.Watch(net, state, history, nil); err != nil {
+		log.Panic(err)
+	}
+	drng.Halted = state.Halted
+	if state.Halted() {
+		log.Warn("Signing is halted until admins publish a resume")
+	}
+	return state
+}
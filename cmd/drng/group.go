@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// groupCommand dispatch group subcommands
+func groupCommand(args []string) {
+	if len(args) > 0 && args[0] == "sign" {
+		groupSign(args[1:])
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng group sign [flags]")
+	os.Exit(1)
+}
+
+// groupSign add signature of a member to a group file
+func groupSign(args []string) {
+	flags := flag.NewFlagSet("group sign", flag.ExitOnError)
+	groupFile := flags.String("group-file", "", "Group file to sign")
+	keyFile := flags.String("key-file", "", "Key file of member")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	flags.Parse(args)
+
+	if *groupFile == "" || *keyFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	g, err := group.LoadFromFile(*groupFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	nodeKey, err := loadKeyFile(*keyFile, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := g.Sign(nodeKey); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := g.SaveToFile(*groupFile); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Signatures: %d of threshold %d\n", len(g.Signatures), g.Threshold)
+	if err := g.Verify(); err == nil {
+		fmt.Println("Group file is ready")
+	}
+}
+
+// restrictToGroup accept beacon and handover traffic only from current members of group
+func restrictToGroup(net *network.Network, g *group.Group) {
+	members := g.CurrentMembers()
+	if err := net.RestrictTopics(members, beacon.Topic, beacon.PartialTopic, beacon.CommitRevealTopic, beacon.HeartbeatTopic, dkg.RecoverTopic, group.HandoverTopic); err != nil {
+		log.Panic(err)
+	}
+	net.SetMembers(members)
+}
+
+// watchHandovers apply handovers broadcast by members to group and save them
+// to group file, so a member that rotated its key keeps its place in group
+func watchHandovers(net *network.Network, g *group.Group, groupFile string) error {
+	var mutex sync.Mutex
+	return net.Handle(group.HandoverTopic, group.HandoverMessage, func(e *network.Envelope) {
+		h := new(group.Handover)
+		if err := network.DecodePayload(e.Payload, h); err != nil {
+			log.Debugf("Invalid handover from %s: %v", e.Sender, err)
+			return
+		}
+		if e.Sender != h.Old {
+			log.Debugf("Drop handover of %s sent by %s", h.Old, e.Sender)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err := g.AddHandover(h); err != nil {
+			if err != group.ErrHandoverKnown {
+				log.Warnf("Reject handover of %s: %v", h.Old, err)
+			}
+			return
+		}
+		if _, err := g.SaveToFile(groupFile); err != nil {
+			log.Errorf("Unable to save handover to group file: %v", err)
+		}
+		restrictToGroup(net, g)
+		log.Infof("Member %s handed over to %s", h.Old, h.New)
+	})
+}
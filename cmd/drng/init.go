@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/orochi-network/orochimaru/config"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// initCommand write a commented config file and a node key, so a node starts
+// with drng start -config alone
+func initCommand(args []string) {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := flags.String("dir", ".", "Directory that config file, key file and store are created in")
+	configName := flags.String("config", "drng.yaml", "Name of config file to write in -dir")
+	bindHost := flags.String("bind-host", "0.0.0.0", "Bind host of node")
+	bindPort := flags.Uint("bind-port", 4001, "Bind port of node")
+	apiBindHost := flags.String("api-bind-host", "0.0.0.0", "Bind host of HTTP API")
+	apiBindPort := flags.Uint("api-bind-port", 8080, "Bind port of HTTP API, API is disabled if it's 0")
+	groupFile := flags.String("group-file", "", "Group file to write into config")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding passphrase of an existing encrypted key file")
+	force := flags.Bool("force", false, "Overwrite an existing config file, an existing key is always kept")
+	flags.Parse(args)
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		log.Fatal(err)
+	}
+	configFile := filepath.Join(*dir, *configName)
+	if _, err := os.Stat(configFile); err == nil && !*force {
+		log.Fatalf("Config file %s already exists, use -force to overwrite it", configFile)
+	}
+	keyFile := filepath.Join(*dir, "node.json")
+	nodeKey, err := initKey(keyFile, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	values := map[string]interface{}{
+		"node::key_file":  keyFile,
+		"node::bind_host": *bindHost,
+		"node::bind_port": *bindPort,
+		"store::file":     filepath.Join(*dir, "rounds.db"),
+	}
+	if *apiBindPort > 0 {
+		values["api::bind_host"] = *apiBindHost
+		values["api::bind_port"] = *apiBindPort
+	}
+	if *groupFile != "" {
+		values["node::group_file"] = *groupFile
+	}
+	cfg := config.New()
+	cfg.Define(nodeConfigKeys()...)
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "# Config of drng node written by drng init, flags and OROCHI_ environment")
+	fmt.Fprintln(buf, "# variables override it. Uncomment a key to change its default.")
+	fmt.Fprintln(buf)
+	if err := cfg.WriteYAML(buf, values); err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(configFile, buf.Bytes(), 0600); err != nil {
+		log.Fatal(err)
+	}
+
+	nodeID, err := nodeKey.GetID()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Config file: %s\n", configFile)
+	fmt.Printf("Key file: %s\n", keyFile)
+	fmt.Printf("Peer ID: %s\n", nodeID)
+	fmt.Println("Multiaddrs:")
+	for _, host := range listenHosts(*bindHost) {
+		protocol := "ip4"
+		if host.To4() == nil {
+			protocol = "ip6"
+		}
+		fmt.Printf("  /%s/%s/tcp/%d/p2p/%s\n", protocol, host, *bindPort, nodeID)
+	}
+	fmt.Printf("Start the node with: drng start -config %s\n", configFile)
+}
+
+// initKey load key file, or create an Ed25519 key file if it does not exist
+func initKey(keyFile string, passphraseEnv string) (*keypair.KeyPair, error) {
+	if _, err := os.Stat(keyFile); err == nil {
+		fmt.Printf("Using existing key file: %s\n", keyFile)
+		return loadKeyFile(keyFile, passphraseEnv)
+	}
+	nodeKey, err := keypair.New(p2pCrypto.Ed25519, 256)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := nodeKey.SaveToFile(keyFile); err != nil {
+		return nil, err
+	}
+	return nodeKey, nil
+}
+
+// listenHosts addresses peers can dial, unspecified host is expanded to
+// addresses of network interfaces
+func listenHosts(bindHost string) []net.IP {
+	ip := net.ParseIP(bindHost)
+	if ip == nil {
+		return nil
+	}
+	if !ip.IsUnspecified() {
+		return []net.IP{ip}
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return []net.IP{ip}
+	}
+	var hosts []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() || (ip.To4() != nil) != (ipNet.IP.To4() != nil) {
+			continue
+		}
+		hosts = append(hosts, ipNet.IP)
+	}
+	return hosts
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/relayer"
+	"golang.org/x/term"
+)
+
+// keyMigrateOutput JSON output of key migrate
+type keyMigrateOutput struct {
+	KeyFile    string `json:"keyFile"`
+	Format     string `json:"format"`
+	NodeID     string `json:"nodeId"`
+	Written    bool   `json:"written"`
+	BackupFile string `json:"backupFile,omitempty"`
+	Encrypted  bool   `json:"encrypted"`
+	DryRun     bool   `json:"dryRun"`
+}
+
+// printJSON print command output as indented JSON
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readPassphrase read key file passphrase from given environment variable or prompt for it
+func readPassphrase(envName string) (string, error) {
+	return readSecret(envName, "Key file passphrase: ")
+}
+
+// readSecret read a secret from given environment variable or prompt for it,
+// it's not echoed when standard input is a terminal
+func readSecret(envName string, prompt string) (string, error) {
+	if secret := os.Getenv(envName); secret != "" {
+		return secret, nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	var secret string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		raw, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		secret = string(raw)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		secret = line
+	}
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		return "", errors.New("empty " + strings.ToLower(strings.TrimSuffix(prompt, ": ")))
+	}
+	return secret, nil
+}
+
+// keyInfoOutput key file described by key info, its fields are a stable
+// schema that later versions only add to
+type keyInfoOutput struct {
+	KeyFile string `json:"keyFile"`
+	KeyType string `json:"keyType"`
+	NodeID  string `json:"nodeId"`
+	// PublicKey hex encoded raw public key
+	PublicKey string `json:"publicKey"`
+	// Private whether key file holds a private key
+	Private   bool `json:"private"`
+	Encrypted bool `json:"encrypted"`
+	// Address Ethereum account of a secp256k1 private key, as used by relayer
+	Address string `json:"address,omitempty"`
+}
+
+// keyInfo print type, peer ID and public key of a key file
+func keyInfo(args []string) {
+	flags := flag.NewFlagSet("key info", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "Key file to inspect")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	jsonOutput := flags.Bool("json", false, "Print key info as JSON")
+	flags.Parse(args)
+
+	if *keyFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	encrypted, err := keypair.IsEncryptedFile(*keyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	k, err := loadKeyFile(*keyFile, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	output := keyInfoOutput{KeyFile: *keyFile, Private: k.GetPrivateKey() != nil, Encrypted: encrypted}
+	if output.KeyType, err = keypair.SchemeName(k.GetKeyType()); err != nil {
+		log.Fatal(err)
+	}
+	nodeID, err := k.GetID()
+	if err != nil {
+		log.Fatal(err)
+	}
+	output.NodeID = nodeID.Pretty()
+	raw, err := k.GetPublicKey().Raw()
+	if err != nil {
+		log.Fatal(err)
+	}
+	output.PublicKey = hex.EncodeToString(raw)
+	if output.Private && k.GetKeyType() == p2pCrypto.Secp256k1 {
+		address, err := relayer.KeyAddress(k)
+		if err != nil {
+			log.Fatal(err)
+		}
+		output.Address = address.Hex()
+	}
+	if *jsonOutput {
+		printJSON(output)
+		return
+	}
+	fmt.Printf("Key file: %s\nKey type: %s\nNode ID: %s\nPublic key: %s\nPrivate: %t\nEncrypted: %t\n",
+		output.KeyFile, output.KeyType, output.NodeID, output.PublicKey, output.Private, output.Encrypted)
+	if output.Address != "" {
+		fmt.Printf("Address: %s\n", output.Address)
+	}
+}
+
+// keyMigrate convert a version 1 or foreign key file to current key file format
+func keyMigrate(args []string) {
+	flags := flag.NewFlagSet("key migrate", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "Key file to migrate")
+	backupFile := flags.String("backup", "", "Backup file of original key file (default <key-file>.bak)")
+	dryRun := flags.Bool("dry-run", false, "Detect key file format without writing anything")
+	encrypt := flags.Bool("encrypt", false, "Encrypt migrated key file with a passphrase")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding passphrase of migrated key file")
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	flags.Parse(args)
+
+	if *keyFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *backupFile == "" {
+		*backupFile = *keyFile + ".bak"
+	}
+
+	passphrase := ""
+	if *encrypt && !*dryRun {
+		var err error
+		if passphrase, err = readPassphrase(*passphraseEnv); err != nil {
+			log.Fatal(err)
+		}
+	}
+	result, err := keypair.MigrateFile(*keyFile, *backupFile, *dryRun, passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+	nodeID, _ := result.KeyPair.GetID()
+	if *jsonOutput {
+		printJSON(keyMigrateOutput{
+			KeyFile:    result.FileName,
+			Format:     result.Source,
+			NodeID:     nodeID.Pretty(),
+			Written:    result.Written,
+			BackupFile: result.BackupFile,
+			Encrypted:  result.Written && passphrase != "",
+			DryRun:     *dryRun,
+		})
+		return
+	}
+	fmt.Printf("Key file: %s\nFormat: %s\nNode ID: %s\n", result.FileName, result.Source, nodeID)
+	if result.Written && passphrase != "" {
+		fmt.Printf("Migrated and encrypted, original key file was saved to: %s\n", result.BackupFile)
+	} else if result.Written {
+		fmt.Printf("Migrated, original key file was saved to: %s\n", result.BackupFile)
+	} else if *dryRun {
+		fmt.Println("Dry run, nothing was written")
+	} else {
+		fmt.Println("Key file is already in current format")
+	}
+}
+
+// loadKeyFile load a plain or passphrase encrypted key file
+func loadKeyFile(keyFile string, passphraseEnv string) (*keypair.KeyPair, error) {
+	encrypted, err := keypair.IsEncryptedFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return keypair.LoadFromFile(keyFile)
+	}
+	passphrase, err := readPassphrase(passphraseEnv)
+	if err != nil {
+		return nil, err
+	}
+	return keypair.LoadFromFileEncrypted(keyFile, passphrase)
+}
+
+// keyOutput JSON output of keygen and show-id
+type keyOutput struct {
+	KeyFile string `json:"keyFile"`
+	NodeID  string `json:"nodeId"`
+}
+
+// keygenCommand generate a new node key file
+func keygenCommand(args []string) {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "Key file to create")
+	keyType := flags.String("key-type", "ed25519", "Key type: ed25519, or secp256k1 for relayer accounts")
+	encrypted := flags.Bool("key-encrypted", false, "Encrypt key file with a passphrase")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	useMnemonic := flags.Bool("mnemonic", false, "Derive key from a new 24 words mnemonic and print it for backup")
+	recoverKey := flags.Bool("recover", false, "Derive key from an existing mnemonic read from -mnemonic-env or prompted")
+	mnemonicEnv := flags.String("mnemonic-env", "OROCHI_MNEMONIC", "Environment variable holding mnemonic to recover from")
+	mnemonicPasswordEnv := flags.String("mnemonic-password-env", "", "Environment variable holding optional BIP-39 password of mnemonic")
+	path := flags.String("derivation-path", "", "Derivation path of mnemonic key (default "+keypair.PathIdentity+" for ed25519, "+keypair.PathSigning+" for secp256k1)")
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	flags.Parse(args)
+
+	if *keyFile == "" || *useMnemonic && *recoverKey {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*keyFile); err == nil {
+		log.Fatalf("Key file %s already exists", *keyFile)
+	}
+	var typ int
+	switch *keyType {
+	case "ed25519":
+		typ = p2pCrypto.Ed25519
+	case "secp256k1":
+		typ = p2pCrypto.Secp256k1
+	default:
+		log.Fatalf("Unknown key type: %s", *keyType)
+	}
+	var nodeKey *keypair.KeyPair
+	var err error
+	if *useMnemonic || *recoverKey {
+		var mnemonic string
+		if *useMnemonic {
+			mnemonic, err = keypair.NewMnemonic()
+		} else {
+			mnemonic, err = readSecret(*mnemonicEnv, "Mnemonic: ")
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		nodeKey, err = keypair.FromMnemonic(mnemonic, os.Getenv(*mnemonicPasswordEnv), typ, *path)
+		if err == nil && *useMnemonic {
+			fmt.Fprintf(os.Stderr, "Write down these words, they restore this key with drng keygen -recover:\n\n%s\n\n", mnemonic)
+		}
+	} else {
+		nodeKey, err = keypair.New(typ, 256)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *encrypted {
+		passphrase, err := readPassphrase(*passphraseEnv)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, err = nodeKey.SaveToFileEncrypted(*keyFile, passphrase)
+	} else {
+		_, err = nodeKey.SaveToFile(*keyFile)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	printNodeID(*keyFile, nodeKey, *jsonOutput)
+}
+
+// showIDCommand print peer ID of a key file
+func showIDCommand(args []string) {
+	flags := flag.NewFlagSet("show-id", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "Key file to inspect")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	flags.Parse(args)
+
+	if *keyFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	nodeKey, err := loadKeyFile(*keyFile, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	printNodeID(*keyFile, nodeKey, *jsonOutput)
+}
+
+func printNodeID(keyFile string, nodeKey *keypair.KeyPair, jsonOutput bool) {
+	nodeID, err := nodeKey.GetID()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if jsonOutput {
+		printJSON(keyOutput{KeyFile: keyFile, NodeID: nodeID.Pretty()})
+		return
+	}
+	fmt.Println(nodeID.Pretty())
+}
+
+// keyRotate replace node key by a new one. The old key signs a handover to the
+// new key, handover is written to group file and broadcast so running members
+// accept the new key in place of the old one without a new DKG
+func keyRotate(args []string) {
+	rest := parseNodeFlags("key rotate", args)
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: drng key rotate -key-file <old key file> -group-file <group file> [flags] <new key file>")
+		os.Exit(1)
+	}
+	newKeyFile := rest[0]
+	groupFile := AppConfig.GetGroupFile()
+	if groupFile == "" {
+		log.Fatal("Key rotation needs a group file to record handover in")
+	}
+	if _, err := os.Stat(newKeyFile); err == nil {
+		log.Fatalf("Key file %s already exists", newKeyFile)
+	}
+	g, err := group.LoadVerifiedFromFile(groupFile)
+	if err != nil {
+		log.Fatalf("Invalid group file: %v", err)
+	}
+	oldKey, err := loadKeyFile(AppConfig.GetKeyFile(), AppConfig.GetKeyPassphraseEnv())
+	if err != nil {
+		log.Fatal(err)
+	}
+	newKey, err := keypair.New(p2pCrypto.Ed25519, 256)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handover, err := group.NewHandover(oldKey, newKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := g.AddHandover(handover); err != nil {
+		log.Fatal(err)
+	}
+	if AppConfig.GetKeyEncrypted() {
+		passphrase, err := readPassphrase(AppConfig.GetKeyPassphraseEnv())
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, err = newKey.SaveToFileEncrypted(newKeyFile, passphrase)
+	} else {
+		_, err = newKey.SaveToFile(newKeyFile)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := g.SaveToFile(groupFile); err != nil {
+		log.Fatal(err)
+	}
+
+	// Broadcast with old key, members only accept handovers from the key being retired
+	net := openNetwork(oldKey)
+	if err := broadcastHandover(net, handover); err != nil {
+		log.Warnf("Unable to broadcast handover, share group file with members instead: %v", err)
+	}
+	fmt.Printf("Old ID: %s\nNew ID: %s\nRestart node with -key-file %s\n", handover.Old, handover.New, newKeyFile)
+}
+
+// broadcastHandover publish handover once other members subscribe to handover topic
+func broadcastHandover(net *network.Network, handover *group.Handover) error {
+	payload, err := json.Marshal(handover)
+	if err != nil {
+		return err
+	}
+	peers, err := broadcastOnTopic(net, group.HandoverTopic, func() error {
+		return net.Send(group.HandoverTopic, group.HandoverMessage, 0, payload)
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Handover broadcast to %d peers", peers)
+	return nil
+}
+
+// broadcastOnTopic run send once other members subscribe to topic, it returns
+// number of peers the topic has after sending
+func broadcastOnTopic(net *network.Network, topicName string, send func() error) (int, error) {
+	topic, err := net.Topics().JoinTopic(topicName)
+	if err != nil {
+		return 0, err
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for len(topic.ListPeers()) == 0 {
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("no member is listening to %s", topicName)
+		}
+		time.Sleep(time.Second)
+	}
+	// Give gossip mesh a moment to form before and after publishing
+	time.Sleep(2 * time.Second)
+	if err := send(); err != nil {
+		return 0, err
+	}
+	time.Sleep(2 * time.Second)
+	return len(topic.ListPeers()), nil
+}
+
+// keyExport write a key file as PEM or libp2p protobuf key
+func keyExport(args []string) {
+	flags := flag.NewFlagSet("key export", flag.ExitOnError)
+	keyFile := flags.String("key-file", "", "Key file to export")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	format := flags.String("format", "pem", "Output format: pem or protobuf")
+	out := flags.String("out", "", "File to write, it must not exist")
+	flags.Parse(args)
+
+	if *keyFile == "" || *out == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*out); err == nil {
+		log.Fatalf("File %s already exists", *out)
+	}
+	key, err := loadKeyFile(*keyFile, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var encoded []byte
+	switch *format {
+	case "pem":
+		encoded, err = key.ExportPEM()
+	case "protobuf":
+		encoded, err = key.MarshalProtobuf()
+	default:
+		log.Fatalf("Unknown format: %s", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, encoded, 0600); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Exported %s to %s\n", *keyFile, *out)
+}
+
+// keyImport create a key file from a PEM or libp2p protobuf key, protobuf keys
+// may be base64 encoded as in IPFS configs
+func keyImport(args []string) {
+	flags := flag.NewFlagSet("key import", flag.ExitOnError)
+	in := flags.String("in", "", "PEM or protobuf key to import")
+	keyFile := flags.String("key-file", "", "Key file to create")
+	encrypted := flags.Bool("key-encrypted", false, "Encrypt key file with a passphrase")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key file passphrase")
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	flags.Parse(args)
+
+	if *in == "" || *keyFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*keyFile); err == nil {
+		log.Fatalf("Key file %s already exists", *keyFile)
+	}
+	content, err := ioutil.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var key *keypair.KeyPair
+	if bytes.Contains(content, []byte("-----BEGIN")) {
+		key, err = keypair.ImportPEM(content)
+	} else if key, err = keypair.UnmarshalProtobuf(content); err != nil {
+		if decoded, decodeErr := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content))); decodeErr == nil {
+			key, err = keypair.UnmarshalProtobuf(decoded)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *encrypted {
+		passphrase, err := readPassphrase(*passphraseEnv)
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, err = key.SaveToFileEncrypted(*keyFile, passphrase)
+	} else {
+		_, err = key.SaveToFile(*keyFile)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	printNodeID(*keyFile, key, *jsonOutput)
+}
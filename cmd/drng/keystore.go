@@ -0,0 +1,265 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/keystore"
+)
+
+// keystoreCommand dispatch keystore subcommands
+func keystoreCommand(args []string) {
+	subcommands := map[string]func(args []string){
+		"list":   keystoreList,
+		"create": keystoreCreate,
+		"import": keystoreImport,
+		"export": keystoreExport,
+		"delete": keystoreDelete,
+	}
+	if len(args) > 0 {
+		if run, ok := subcommands[args[0]]; ok {
+			run(args[1:])
+			return
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng keystore list|create|import|export|delete [flags]")
+	os.Exit(1)
+}
+
+// keystoreFlags flag set of a keystore subcommand with its common flags
+func keystoreFlags(name string) (*flag.FlagSet, *string, *string) {
+	flags := flag.NewFlagSet("keystore "+name, flag.ExitOnError)
+	dir := flags.String("keystore-dir", "keystore", "Keystore directory")
+	passphraseEnv := flags.String("key-passphrase-env", "OROCHI_KEY_PASSPHRASE", "Environment variable holding key passphrase")
+	return flags, dir, passphraseEnv
+}
+
+func mustOpenKeystore(dir string) *keystore.Keystore {
+	ks, err := keystore.New(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ks
+}
+
+// entryPassphrase passphrase of an entry, empty if entry is not encrypted
+func entryPassphrase(ks *keystore.Keystore, name string, passphraseEnv string) (string, error) {
+	entry, err := ks.Get(name)
+	if err != nil || !entry.Encrypted {
+		return "", err
+	}
+	return readPassphrase(passphraseEnv)
+}
+
+func keystoreList(args []string) {
+	flags, dir, _ := keystoreFlags("list")
+	jsonOutput := flags.Bool("json", false, "Print entries as JSON")
+	flags.Parse(args)
+
+	entries, err := mustOpenKeystore(*dir).List()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *jsonOutput {
+		printJSON(entries)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tENCRYPTED\tCREATED\tID")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\n", e.Name, e.Kind, e.Encrypted, e.Created.Format("2006-01-02 15:04:05"), e.ID)
+	}
+	w.Flush()
+}
+
+func keystoreCreate(args []string) {
+	flags, dir, passphraseEnv := keystoreFlags("create")
+	name := flags.String("name", "", "Name of new key")
+	kind := flags.String("kind", string(keystore.KindNode), "Kind of key: node or relayer")
+	encrypted := flags.Bool("encrypted", false, "Encrypt key with a passphrase")
+	flags.Parse(args)
+
+	if *name == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	passphrase := ""
+	if *encrypted {
+		var err error
+		if passphrase, err = readPassphrase(*passphraseEnv); err != nil {
+			log.Fatal(err)
+		}
+	}
+	entry, err := mustOpenKeystore(*dir).Create(*name, keystore.Kind(*kind), passphrase)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Created %s key %s: %s\n", entry.Kind, entry.Name, entry.ID)
+}
+
+// keystoreImport copy a key file or share file into keystore
+func keystoreImport(args []string) {
+	flags, dir, passphraseEnv := keystoreFlags("import")
+	name := flags.String("name", "", "Name of imported key")
+	kind := flags.String("kind", string(keystore.KindNode), "Kind of key: node, relayer or share")
+	file := flags.String("file", "", "Key file or share file to import")
+	encrypted := flags.Bool("encrypted", false, "Encrypt key in keystore with a passphrase")
+	flags.Parse(args)
+
+	if *name == "" || *file == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	ks := mustOpenKeystore(*dir)
+	var key *keypair.KeyPair
+	var share *dkg.Result
+	var err error
+	if keystore.Kind(*kind) == keystore.KindShare {
+		share, err = dkg.LoadResultFromFile(*file)
+	} else {
+		key, err = loadKeyFile(*file, *passphraseEnv)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	passphrase := ""
+	if *encrypted {
+		if passphrase, err = readPassphrase(*passphraseEnv); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var entry *keystore.Entry
+	if share != nil {
+		entry, err = ks.PutShare(*name, share, passphrase)
+	} else {
+		entry, err = ks.PutKeyPair(*name, keystore.Kind(*kind), key, passphrase)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Imported %s key %s: %s\n", entry.Kind, entry.Name, entry.ID)
+}
+
+// keystoreExport write a keystore entry to a plain key file or share file
+func keystoreExport(args []string) {
+	flags, dir, passphraseEnv := keystoreFlags("export")
+	name := flags.String("name", "", "Name of key to export")
+	file := flags.String("file", "", "File to write, it must not exist")
+	flags.Parse(args)
+
+	if *name == "" || *file == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	ks := mustOpenKeystore(*dir)
+	passphrase, err := entryPassphrase(ks, *name, *passphraseEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ks.Export(*name, passphrase, *file); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Exported %s to %s\n", *name, *file)
+}
+
+func keystoreDelete(args []string) {
+	flags, dir, _ := keystoreFlags("delete")
+	name := flags.String("name", "", "Name of key to delete")
+	flags.Parse(args)
+
+	if *name == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if err := mustOpenKeystore(*dir).Delete(*name); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Deleted %s\n", *name)
+}
+
+// openKeystore keystore of node, nil if node keeps keys in separate files
+func openKeystore() *keystore.Keystore {
+	dir := AppConfig.GetKeystoreDir()
+	if dir == "" {
+		return nil
+	}
+	return mustOpenKeystore(dir)
+}
+
+// keystoreNodeKey load node key from keystore, it's created if it does not exist
+func keystoreNodeKey(ks *keystore.Keystore) *keypair.KeyPair {
+	name := AppConfig.GetKeyName()
+	if _, err := ks.Get(name); err == keystore.ErrNotFound {
+		passphrase := ""
+		if AppConfig.GetKeyEncrypted() {
+			if passphrase, err = readPassphrase(AppConfig.GetKeyPassphraseEnv()); err != nil {
+				log.Fatal(err)
+			}
+		}
+		entry, err := ks.Create(name, keystore.KindNode, passphrase)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Created node key %s in keystore: %s", name, entry.ID)
+	}
+	return keystoreKeyPair(ks, name)
+}
+
+// keystoreKeyPair load a key pair from keystore, passphrase is read only if it's encrypted
+func keystoreKeyPair(ks *keystore.Keystore, name string) *keypair.KeyPair {
+	passphrase, err := entryPassphrase(ks, name, AppConfig.GetKeyPassphraseEnv())
+	if err != nil {
+		log.Fatalf("Unable to load %s from keystore: %v", name, err)
+	}
+	key, err := ks.KeyPair(name, passphrase)
+	if err != nil {
+		log.Fatalf("Unable to load %s from keystore: %v", name, err)
+	}
+	return key
+}
+
+// loadShare load DKG result of node from share file or keystore entry "share",
+// nil if node has no share
+func loadShare() *dkg.Result {
+	if shareFile := AppConfig.GetBeaconShareFile(); shareFile != "" {
+		share, err := dkg.LoadResultFromFile(shareFile)
+		if err != nil {
+			log.Panic(err)
+		}
+		return share
+	}
+	ks := openKeystore()
+	if ks == nil {
+		return nil
+	}
+	passphrase, err := entryPassphrase(ks, string(keystore.KindShare), AppConfig.GetKeyPassphraseEnv())
+	if err == keystore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+	share, err := ks.Share(string(keystore.KindShare), passphrase)
+	if err != nil {
+		log.Panic(err)
+	}
+	return share
+}
+
+// saveShareToKeystore save DKG result as keystore entry "share", it's
+// encrypted if node::key_encrypted is set
+func saveShareToKeystore(result *dkg.Result) error {
+	passphrase := ""
+	if AppConfig.GetKeyEncrypted() {
+		var err error
+		if passphrase, err = readPassphrase(AppConfig.GetKeyPassphraseEnv()); err != nil {
+			return err
+		}
+	}
+	_, err := openKeystore().PutShare(string(keystore.KindShare), result, passphrase)
+	return err
+}
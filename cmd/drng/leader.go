@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/client"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/leader"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// leaderJSON election printed by leader command
+type leaderJSON struct {
+	Round     uint64    `json:"round"`
+	SeedRound uint64    `json:"seedRound"`
+	Leader    peer.ID   `json:"leader"`
+	Committee []peer.ID `json:"committee,omitempty"`
+}
+
+// clientSource rounds fetched from nodes, they are verified by client
+type clientSource struct {
+	client *client.Client
+	ctx    context.Context
+}
+
+func (s *clientSource) Get(round uint64) (*beacon.RoundResult, error) {
+	return s.client.Get(s.ctx, round)
+}
+
+// leaderCommand print leader and committee that round randomness elects among
+// members of group, latest round if no round is given
+func leaderCommand(args []string) {
+	flags := flag.NewFlagSet("leader", flag.ExitOnError)
+	groupFile := flags.String("group-file", "", "Group file listing members that leaders are elected among")
+	storeFile := flags.String("store-file", "rounds.db", "File that rounds are persisted to")
+	node := flags.String("node", "", "HTTP API of nodes separated by commas that rounds are fetched from instead of local store")
+	committee := flags.Int("committee", 1, "Number of members to elect, leader comes first")
+	epoch := flags.Uint64("epoch", 1, "Number of consecutive rounds that share a leader")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of request to nodes")
+	flags.Usage = func() {
+		flags.Output().Write([]byte("Usage: drng leader [flags] [round]\n"))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var round uint64
+	if flags.NArg() > 0 {
+		var err error
+		if round, err = strconv.ParseUint(flags.Arg(0), 10, 64); err != nil || round == 0 {
+			flags.Usage()
+			os.Exit(1)
+		}
+	}
+	if *groupFile == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	g, err := group.LoadVerifiedFromFile(*groupFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var source leader.Source
+	if *node != "" {
+		c, err := newClient(*node, "", *groupFile, "", *timeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		source = &clientSource{client: c, ctx: context.Background()}
+	} else {
+		if _, err := os.Stat(*storeFile); err != nil {
+			log.Fatal(err)
+		}
+		rounds, err := store.Open(*storeFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer rounds.Close()
+		source = rounds
+		if round == 0 {
+			latest, err := rounds.Latest()
+			if err != nil {
+				log.Fatal(err)
+			}
+			round = latest.Round
+		}
+	}
+	if round == 0 {
+		// Round 0 of client is the latest round
+		latest, err := source.Get(0)
+		if err != nil {
+			log.Fatal(err)
+		}
+		round = latest.Round
+	}
+
+	election := leader.New(source, g.CurrentMembers(), g.PublicKey)
+	election.EpochLength = *epoch
+	elected, err := election.CommitteeFor(round, *committee)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result := leaderJSON{Round: round, SeedRound: election.SeedRound(round), Leader: elected[0]}
+	if *committee > 1 {
+		result.Committee = elected
+	}
+	printJSON(result)
+}
@@ -1,15 +1,135 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
 	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/libp2p/go-libp2p"
 	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/chainsync"
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/governance"
+	"github.com/orochi-network/orochimaru/group"
 	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/keystore"
+	"github.com/orochi-network/orochimaru/loadshed"
+	"github.com/orochi-network/orochimaru/logger"
 	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/relayer"
+	"github.com/orochi-network/orochimaru/store"
+	"github.com/orochi-network/orochimaru/tracing"
+	"github.com/orochi-network/orochimaru/vrf"
 )
 
+// command subcommand of drng, args exclude command name
+type command struct {
+	name        string
+	description string
+	run         func(args []string)
+}
+
+// commands all subcommands in the order they are listed in usage
+var commands = []command{
+	{name: "init", description: "Write a commented config file and a node key", run: initCommand},
+	{name: "start", description: "Run a beacon node", run: startCommand},
+	{name: "keygen", description: "Generate a node key file", run: keygenCommand},
+	{name: "show-id", description: "Print peer ID of a key file", run: showIDCommand},
+	{name: "get", description: "Print a round from local store", run: getCommand},
+	{name: "gateway", description: "Serve public API from several upstream nodes, verifying every round", run: gatewayCommand},
+	{name: "sync", description: "Sync local store with peers and exit", run: syncCommand},
+	{name: "verify-chain", description: "Check stored rounds and optionally repair them from peers", run: verifyChainCommand},
+	{name: "chain", description: "Dump stored rounds as CSV or JSON lines (dump)", run: chainCommand},
+	{name: "store", description: "Export stored rounds to an archive (export) or check an archive (verify)", run: storeCommand},
+	{name: "dkg", description: "Create a DKG group file (init), take part in a ceremony (join), coordinate one (propose, accept, status) or recover a lost share (recover)", run: dkgCommand},
+	{name: "group", description: "Sign a group file", run: groupCommand},
+	{name: "governance", description: "Propose, approve and publish governance changes of a group (propose, approve, publish)", run: governanceCommand},
+	{name: "keystore", description: "Manage keys of a keystore directory (list, create, import, export, delete)", run: keystoreCommand},
+	{name: "key", description: "Manage key files (info, migrate, rotate, export, import)", run: keyCommand},
+	{name: "status", description: "Print readiness, chain, rounds and peers of a running node", run: statusCommand},
+	{name: "peers", description: "List peers that a node is connected to", run: peersCommand},
+	{name: "net", description: "Diagnose network connectivity of node (doctor)", run: netCommand},
+	{name: "devnet", description: "Run a group of in-process nodes for local development", run: devnetCommand},
+	{name: "leader", description: "Print leader and committee that a round elects among group members", run: leaderCommand},
+	{name: "plan", description: "Estimate feasibility of group parameters", run: planCommand},
+	{name: "encrypt", description: "Encrypt data toward a future round of an unchained group", run: encryptCommand},
+	{name: "decrypt", description: "Decrypt data once its round is published", run: decryptCommand},
+	{name: "gen-bindings", description: "Generate Go bindings of a contract ABI", run: genBindingsCommand},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: drng <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.description)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun drng <command> -h for flags of a command")
+}
+
 func main() {
+	// Flags without a command start a node as before subcommands existed
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") && os.Args[1] != "-h" && os.Args[1] != "-help" {
+		startCommand(os.Args[1:])
+		return
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			c.run(os.Args[2:])
+			return
+		}
+	}
+	usage()
+	os.Exit(1)
+}
+
+// keyCommand dispatch key subcommands
+func keyCommand(args []string) {
+	if len(args) > 0 && args[0] == "info" {
+		keyInfo(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "migrate" {
+		keyMigrate(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "rotate" {
+		keyRotate(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "export" {
+		keyExport(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "import" {
+		keyImport(args[1:])
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng key info|migrate|rotate|export|import [flags]")
+	os.Exit(1)
+}
+
+// openNodeKey load node key from PKCS#11 token, keystore or key file, a new
+// key is created if key file does not exist
+func openNodeKey() keypair.Signer {
+	if module := AppConfig.GetPKCS11Module(); module != "" {
+		return openPKCS11Key(module)
+	}
+	if ks := openKeystore(); ks != nil {
+		return keystoreNodeKey(ks)
+	}
 	keyfile := AppConfig.GetKeyFile()
+	if keyfile == "" {
+		log.Fatal("Node needs -key-file or -keystore-dir")
+	}
 	var nodeKey *keypair.KeyPair
 	if _, err := os.Stat(keyfile); err != nil {
 		// Create a new key pair
@@ -18,18 +138,608 @@ func main() {
 			log.Panic(err)
 		}
 		log.Debugf("save key to file: %s", keyfile)
-		nodeKey.SaveToFile(keyfile)
+		if AppConfig.GetKeyEncrypted() {
+			passphrase, err := readPassphrase(AppConfig.GetKeyPassphraseEnv())
+			if err != nil {
+				log.Panic(err)
+			}
+			if _, err := nodeKey.SaveToFileEncrypted(keyfile, passphrase); err != nil {
+				log.Panic(err)
+			}
+		} else {
+			nodeKey.SaveToFile(keyfile)
+		}
 	} else {
 		// Load key from json file if existed
-		nodeKey, err = keypair.LoadFromFile(keyfile)
 		log.Debugf("load key from file: %s", keyfile)
+		nodeKey, err = loadKeyFile(keyfile, AppConfig.GetKeyPassphraseEnv())
 		if err != nil {
 			log.Panic(err)
 		}
 	}
+	return nodeKey
+}
 
-	net := network.New(AppConfig.GetBindHost(), AppConfig.GetBindPort(), AppConfig.GetDomain(), nodeKey)
+// openPKCS11Key open node key kept in a PKCS#11 token, it stays open until process exits
+func openPKCS11Key(module string) keypair.Signer {
+	pin, err := readSecret(AppConfig.GetPKCS11PINEnv(), "PKCS#11 PIN: ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	signer, err := keypair.OpenPKCS11(keypair.PKCS11Config{
+		Module:     module,
+		TokenLabel: AppConfig.GetPKCS11Token(),
+		KeyLabel:   AppConfig.GetPKCS11KeyLabel(),
+		PIN:        pin,
+	})
+	if err != nil {
+		log.Fatalf("Unable to open PKCS#11 key: %v", err)
+	}
+	nodeID, _ := signer.GetID()
+	log.Infof("Node key is kept in PKCS#11 token %s: %s", AppConfig.GetPKCS11Token(), nodeID)
+	return signer
+}
+
+// nodeCapabilities features announced to peers in handshake: beacon modes,
+// heartbeats, chain sync and share recovery
+var nodeCapabilities = []string{beacon.ModeChained, beacon.ModeUnchained, beacon.ModeCommitReveal, "heartbeat", "sync", "recover"}
+
+// openNetwork start p2p network of node and announce it
+func openNetwork(nodeKey keypair.Signer) *network.Network {
+	bootstrapPeers, err := AppConfig.GetBootstrapPeers()
+	if err != nil {
+		log.Fatalf("Invalid bootstrap peers: %v", err)
+	}
+	var opts []libp2p.Option
+	if pskFile := AppConfig.GetPSKFile(); pskFile != "" {
+		if opts, err = network.PrivateNetwork(pskFile); err != nil {
+			log.Fatalf("Invalid pre-shared key file: %v", err)
+		}
+		log.Info("Private network, peers without pre-shared key are rejected")
+	}
+	var gater *network.AllowlistGater
+	if allowlist := AppConfig.GetAllowlist(); len(allowlist) > 0 {
+		if gater, err = network.NewAllowlistGater(allowlist); err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Connections are accepted only from allowlist of %d entries and group members", len(allowlist))
+	}
+	bans := network.NewBanGater(gater)
+	opts = append(opts, bans.Option())
+	networkID, err := AppConfig.GetNetwork()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("Network: %s", networkID.Domain())
+	listenAddrs, err := AppConfig.GetListenAddrs()
+	if err != nil {
+		log.Fatalf("Invalid listen addresses: %v", err)
+	}
+	announceAddrs, err := AppConfig.GetAnnounceAddrs()
+	if err != nil {
+		log.Fatalf("Invalid announce addresses: %v", err)
+	}
+	externalAddrs, err := AppConfig.GetExternalAddrs()
+	if err != nil {
+		log.Fatalf("Invalid external addresses: %v", err)
+	}
+	if len(announceAddrs) > 0 || len(externalAddrs) > 0 {
+		opts = append(opts, network.AdvertiseAddrs(announceAddrs, externalAddrs))
+		log.Infof("Advertised addresses: announce %v external %v", announceAddrs, externalAddrs)
+	}
+	transports, err := network.NewTransports(AppConfig.GetSecurity(), AppConfig.GetMuxers())
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts = append(opts, transports.Option())
+	log.Infof("Security transports: %v, stream muxers: %v", transports.Security, transports.Muxers)
+	net := network.New(listenAddrs, networkID, nodeKey, opts...)
+	net.Gater = gater
+	net.Bans = bans
+	net.Transports = transports
+	net.Capabilities = nodeCapabilities
+	if rate := AppConfig.GetRateLimit(); rate > 0 {
+		limiter := network.NewRateLimiter(float64(rate), int(AppConfig.GetRateBurst()))
+		topics := []string{beacon.Topic, beacon.PartialTopic, beacon.CommitRevealTopic, beacon.HeartbeatTopic, beacon.ResultsTopic, dkg.Topic, dkg.ReshareTopic, dkg.RecoverTopic, group.HandoverTopic, group.ProposalTopic, governance.Topic, vrf.Topic}
+		if extra, err := AppConfig.GetExtraBeacons(); err == nil {
+			topics = append(topics, extraBeaconTopics(extra)...)
+		}
+		if err := net.RateLimit(limiter, topics...); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if ttl := AppConfig.GetReplayTTL(); ttl > 0 {
+		// Recovery, proposals and randomness requests answer repeated messages,
+		// they are not deduplicated
+		topics := []string{beacon.Topic, beacon.PartialTopic, beacon.CommitRevealTopic, beacon.HeartbeatTopic, beacon.ResultsTopic, dkg.Topic, dkg.ReshareTopic, group.HandoverTopic}
+		if extra, err := AppConfig.GetExtraBeacons(); err == nil {
+			topics = append(topics, extraBeaconTopics(extra)...)
+		}
+		net.DropReplays(network.NewReplayCache(ttl), topics...)
+	}
+	net.LimitResources(AppConfig.GetResourceLimits())
+	net.BootstrapPeers = bootstrapPeers
+	if net.DirectPeers, err = AppConfig.GetDirectConnect(); err != nil {
+		log.Fatalf("Invalid direct connect peers: %v", err)
+	}
+	net.DHTMode = AppConfig.GetDHTMode()
+	net.DiscoveryInterval = AppConfig.GetDiscoveryInterval()
+	net.MDNS = AppConfig.GetMDNS()
+	events.ExportMetrics(nodeEvents)
+	publishPeerEvents(net)
 	net.Announce()
-	net.Join()
+	return net
+}
+
+// Node modes
+const (
+	// nodeModeMember node takes part in producing rounds
+	nodeModeMember = "member"
+	// nodeModeObserver node follows rounds of group without a share
+	nodeModeObserver = "observer"
+)
+
+// openBeacon create beacon from configuration, group file and share file
+func openBeacon(net *network.Network, nodeKey keypair.Signer) *beacon.Beacon {
+	mode := AppConfig.GetBeaconMode()
+	drng := beacon.New(net, nodeKey, AppConfig.GetBeaconPeriod())
+	drng.Genesis = AppConfig.GetBeaconGenesis()
+	drng.Mode = mode
+	drng.TimeoutRatio = AppConfig.GetBeaconTimeoutRatio()
+	drng.PrecomputeDepth = AppConfig.GetBeaconPrecomputeDepth()
+	var beaconGroup *group.Group
+	if groupFile := AppConfig.GetGroupFile(); groupFile != "" {
+		var err error
+		beaconGroup, err = group.LoadVerifiedFromFile(groupFile)
+		if err != nil {
+			log.Fatalf("Invalid group file: %v", err)
+		}
+		// Parameters agreed by group take precedence over local configuration
+		drng.Period = beaconGroup.Period
+		drng.Genesis = beaconGroup.GenesisTime
+		drng.Members = beaconGroup.CurrentMembers()
+		if beaconGroup.Mode != "" {
+			drng.Mode = beaconGroup.Mode
+		}
+		restrictToGroup(net, beaconGroup)
+		if err := watchHandovers(net, beaconGroup, groupFile); err != nil {
+			log.Panic(err)
+		}
+		log.Infof("Beacon group of %d members, threshold: %d", len(beaconGroup.Members), beaconGroup.Threshold)
+	}
+	if AppConfig.GetNodeMode() == nodeModeObserver {
+		if beaconGroup == nil || beaconGroup.PublicKey == nil {
+			log.Fatal("Observer needs a group file with group public key")
+		}
+		drng.SetGroupKey(beaconGroup.PublicKey)
+		log.Infof("Observer of group key: %x", beaconGroup.PublicKey)
+		checkChainHash(net, drng)
+		return drng
+	}
+	if share := loadShare(); share != nil {
+		groupKey := share.GroupKey()
+		if beaconGroup != nil && beaconGroup.PublicKey != nil && !bytes.Equal(beaconGroup.PublicKey, groupKey) {
+			log.Fatalf("Group key of share file does not match group file")
+		}
+		if err := drng.SetThreshold(share.Share, share.PubPoly); err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Threshold beacon, share: %d group key: %x", share.Share.Index, groupKey)
+		if beaconGroup != nil {
+			if _, err := dkg.ServeRecovery(net, beaconGroup.CurrentMembers(), share); err != nil {
+				log.Warnf("Unable to serve share recovery: %v", err)
+			}
+		}
+	}
+	checkChainHash(net, drng)
+	return drng
+}
+
+// checkChainHash stop node if chain of beacon is not the chain that network
+// is namespaced by, its topics would belong to another chain
+func checkChainHash(net *network.Network, drng *beacon.Beacon) {
+	if len(net.Identifier.ChainHash) == 0 {
+		return
+	}
+	if drng.GroupKey() == nil {
+		log.Warn("Beacon has no group key yet, network::chain_hash is not checked")
+		return
+	}
+	if hash := drng.ChainInfo().Hash(); !bytes.Equal(hash, net.Identifier.ChainHash) {
+		log.Fatalf("Chain hash of beacon %x does not match network::chain_hash %x", hash, net.Identifier.ChainHash)
+	}
+}
+
+// openStore open round store and resume beacon from its latest round
+func openStore(drng *beacon.Beacon) *store.Store {
+	driver := AppConfig.GetStoreDriver()
+	rounds, err := store.OpenDriver(driver, storeSource(driver, AppConfig.GetStoreFile(), AppConfig.GetStoreDSN()))
+	if err != nil {
+		log.Panic(err)
+	}
+	drng.SetStore(rounds)
+	if last, err := rounds.Latest(); err == nil {
+		drng.Resume(last)
+		log.Infof("Resume chain from round: %d", last.Round)
+	}
+	return rounds
+}
+
+// pruneInterval interval that rounds over retention are pruned at
+const pruneInterval = time.Hour
+
+// pruneStore prune rounds over store::retain_rounds now and then every
+// pruneInterval, retention is read each time so config reload applies
+func pruneStore(rounds *store.Store) {
+	for {
+		if retain := AppConfig.GetStoreRetainRounds(); retain > 0 {
+			pruned, err := rounds.Retain(uint64(retain))
+			if err != nil {
+				log.Errorf("Unable to prune store: %v", err)
+			} else if pruned > 0 {
+				log.Infof("Pruned %d rounds, %d latest rounds are kept", pruned, retain)
+			}
+		}
+		time.Sleep(pruneInterval)
+	}
+}
+
+// openRelayer create relayer of finalized rounds, nil if no endpoint is configured
+func openRelayer() *relayer.Relayer {
+	endpoint := AppConfig.GetRelayerEndpoint()
+	if endpoint == "" {
+		return nil
+	}
+	contract, err := relayer.ParseAddress(AppConfig.GetRelayerContract())
+	if err != nil {
+		log.Fatalf("Invalid relayer contract: %v", err)
+	}
+	var key *keypair.KeyPair
+	if ks := openKeystore(); ks != nil && AppConfig.GetRelayerKeyFile() == "" {
+		key = keystoreKeyPair(ks, string(keystore.KindRelayer))
+	} else if key, err = loadKeyFile(AppConfig.GetRelayerKeyFile(), AppConfig.GetKeyPassphraseEnv()); err != nil {
+		log.Fatalf("Unable to load relayer key: %v", err)
+	}
+	conf := relayer.Config{
+		Endpoint:    endpoint,
+		Contract:    contract,
+		GasLimit:    uint64(AppConfig.GetRelayerGasLimit()),
+		GasStrategy: AppConfig.GetRelayerGasStrategy(),
+		GasPrice:    gwei(AppConfig.GetRelayerGasPriceGwei()),
+		MaxGasPrice: gwei(AppConfig.GetRelayerMaxGasPriceGwei()),
+		MaxAttempts: int(AppConfig.GetRelayerMaxAttempts()),
+	}
+	if chainID := AppConfig.GetRelayerChainID(); chainID > 0 {
+		conf.ChainID = new(big.Int).SetUint64(uint64(chainID))
+	}
+	r, err := relayer.New(conf, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return r
+}
+
+// shedder sheds low priority work of node under CPU load, nil if it's never shed
+var shedder *loadshed.Shedder
+
+// openShedder watch CPU load if node::shed_cpu_percent is set
+func openShedder() {
+	if percent := AppConfig.GetShedCPUPercent(); percent > 0 {
+		shedder = loadshed.New(float64(percent) / 100)
+		shedder.Start(context.Background())
+	}
+}
+
+// openTracing export spans of rounds to tracing::endpoint, returned function
+// exports spans left before node exits. Nil if tracing is disabled
+func openTracing(nodeKey keypair.Signer) func(context.Context) error {
+	endpoint := AppConfig.GetTracingEndpoint()
+	if endpoint == "" {
+		return nil
+	}
+	nodeID, _ := nodeKey.GetID()
+	shutdown, err := tracing.Setup(tracing.Config{
+		Endpoint:    endpoint,
+		NodeID:      nodeID.String(),
+		SampleRatio: AppConfig.GetTracingSampleRatio(),
+	})
+	if err != nil {
+		log.Fatalf("Unable to set up tracing: %v", err)
+	}
+	return shutdown
+}
+
+// gwei amount in wei, nil if it's 0
+func gwei(amount uint) *big.Int {
+	if amount == 0 {
+		return nil
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(uint64(amount)), big.NewInt(1e9))
+}
+
+// syncChain catch up with peers up to last finished round
+func syncChain(syncer *chainsync.Syncer, drng *beacon.Beacon) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	latest, err := syncer.Sync(ctx, drng.CurrentRound(time.Now())-1)
+	cancel()
+	if err != nil {
+		log.Warnf("Unable to sync chain: %v", err)
+	} else {
+		log.Infof("Chain synced to round: %d", latest)
+	}
+}
+
+// backgroundSync run syncs of a beacon in background, one at a time
+type backgroundSync struct {
+	syncer  *chainsync.Syncer
+	drng    *beacon.Beacon
+	syncing int32
+}
+
+// start sync chain unless a sync of the beacon is running
+func (b *backgroundSync) start() {
+	if !atomic.CompareAndSwapInt32(&b.syncing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&b.syncing, 0)
+		syncChain(b.syncer, b.drng)
+	}()
+}
+
+// syncOnUnknownChain sync chain whenever beacon can't chain a round to the
+// previous one, such as when it started before its peers
+func syncOnUnknownChain(background *backgroundSync) {
+	background.drng.OnChainUnknown = func(round uint64) {
+		background.start()
+	}
+}
+
+// syncFromGateways fetch missed rounds from gateways of sync::gateways every
+// period while no group member is connected and store lags behind the chain,
+// so a node that can't reach peers still follows the chain
+func syncFromGateways(background *backgroundSync, net *network.Network, rounds *store.Store) {
+	syncer, drng := background.syncer, background.drng
+	gateways := AppConfig.GetSyncGateways()
+	if len(gateways) == 0 {
+		return
+	}
+	if err := syncer.SetGateways(gateways...); err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		ticker := time.NewTicker(drng.Period)
+		defer ticker.Stop()
+		for range ticker.C {
+			if drng.GroupKey() == nil || net.ConnectedMembers() > 0 {
+				continue
+			}
+			latest, err := rounds.Latest()
+			if err != nil && err != store.ErrNotFound {
+				log.Errorf("Unable to read store: %v", err)
+				continue
+			}
+			if latest == nil || latest.Round+1 < drng.CurrentRound(time.Now()) {
+				background.start()
+			}
+		}
+	}()
+}
+
+// startCommand run a beacon node
+func startCommand(args []string) {
+	parseNodeFlags("start", args)
+
+	nodeKey := openNodeKey()
+	if shutdown := openTracing(nodeKey); shutdown != nil {
+		defer shutdown(context.Background())
+	}
+	net := openNetwork(nodeKey)
+	drng := openBeacon(net, nodeKey)
+	if interval := AppConfig.GetPingInterval(); interval > 0 {
+		net.MeasureLatency(interval)
+	}
+	rounds := openStore(drng)
+	defer rounds.Close()
+	go pruneStore(rounds)
+	openShedder()
+	syncer := chainsync.New(net, rounds, drng)
+	syncer.Shedder = shedder
+	publishSyncProgress(syncer, "")
+	syncer.Serve()
+	background := &backgroundSync{syncer: syncer, drng: drng}
+	syncFromGateways(background, net, rounds)
+	if drng.GroupKey() != nil {
+		// Catch up with peers before joining live aggregation
+		syncChain(syncer, drng)
+	}
+	syncOnUnknownChain(background)
+	openGovernance(net, drng, rounds)
+	extraBeacons := openExtraBeacons(net, nodeKey)
+	randomness := openVRF(net, drng)
+	evmRelayer := openRelayer()
+	var server *api.Server
+	if AppConfig.GetAPIBindPort() > 0 || AppConfig.GetAPIUnixSockets().API != "" {
+		server = api.New(AppConfig.GetAPIBindHost(), AppConfig.GetAPIBindPort(), rounds, drng)
+		addReadinessChecks(server, net, drng, rounds)
+		server.SetPeers(net.PeerInfos)
+		server.SetResources(net.ResourceUsage)
+		if randomness != nil {
+			server.SetVRF(randomness)
+		}
+		if keys, ok := rounds.APIKeys(); ok {
+			server.SetAPIKeys(keys, AppConfig.GetAPIKeysRequired())
+		} else if AppConfig.GetAPIKeysRequired() {
+			log.Fatalf("API keys are required but store %s does not keep them", AppConfig.GetStoreDriver())
+		}
+		server.SetAdminToken(os.Getenv(AppConfig.GetAPIAdminTokenEnv()))
+		secureAPI(server)
+		server.SetHTTPOptions(apiHTTPOptions())
+		server.SetAdmin(adminOps(net, syncer, drng, rounds, evmRelayer))
+		if port := AppConfig.GetAPIAdminBindPort(); port > 0 {
+			server.SetAdminAddress(AppConfig.GetAPIAdminBindHost(), port)
+		}
+		if port := AppConfig.GetAPIGRPCBindPort(); port > 0 {
+			server.SetGRPCAddress(AppConfig.GetAPIGRPCBindHost(), port)
+		}
+		server.SetUnixSockets(AppConfig.GetAPIUnixSockets())
+		server.SetShedder(shedder)
+		for _, extra := range extraBeacons {
+			server.AddBeacon(extra.ID, extra.rounds, extra.drng)
+		}
+		server.Subscribe(nodeEvents)
+		if err := server.Start(); err != nil {
+			log.Panic(err)
+		}
+	}
+	if evmRelayer != nil {
+		evmRelayer.Start()
+		evmRelayer.Subscribe(nodeEvents)
+		defer evmRelayer.Stop()
+	}
+	watchVRFRequests(randomness, evmRelayer)
+	attachConsumers()
+	if watcher := watchConfig(evmRelayer); watcher != nil {
+		defer watcher.Close()
+	}
+
+	for _, extra := range extraBeacons {
+		go extra.run()
+	}
+	startHeartbeat(drng)
+	if AppConfig.GetNodeMode() == nodeModeObserver {
+		if err := drng.Follow(); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		drng.Start()
+	}
+	for result := range drng.Results() {
+		log.Infof("Round: %d randomness: %x", result.Round, result.Randomness)
+		ctx := drng.RoundContext(result.Round)
+		if err := tracing.Run(ctx, "store.put", func() error { return rounds.Put(&result) }); err != nil {
+			log.Errorf("Unable to store round %d: %v", result.Round, err)
+		}
+		nodeEvents.Publish(events.RoundFinalized{Result: result})
+	}
+}
+
+// secureAPI serve API over TLS if a certificate is configured and
+// authenticate requests by api::auth_mode
+func secureAPI(server *api.Server) {
+	certFile, keyFile := AppConfig.GetAPITLSCert(), AppConfig.GetAPITLSKey()
+	if (certFile == "") != (keyFile == "") {
+		log.Fatal("API TLS needs both api::tls_cert and api::tls_key")
+	}
+	if certFile != "" {
+		tlsConfig, err := api.NewTLSConfig(certFile, keyFile, AppConfig.GetAPITLSClientCA())
+		if err != nil {
+			log.Fatalf("Invalid API TLS config: %v", err)
+		}
+		server.SetTLS(tlsConfig)
+	}
+	mode := AppConfig.GetAPIAuthMode()
+	tokens := map[string]string{
+		api.RoutesPublic: os.Getenv(AppConfig.GetAPIPublicTokenEnv()),
+		api.RoutesStatus: os.Getenv(AppConfig.GetAPIStatusTokenEnv()),
+	}
+	if (mode == api.AuthToken || mode == api.AuthMTLSToken) && tokens[api.RoutesPublic] == "" && tokens[api.RoutesStatus] == "" {
+		log.Warnf("API auth mode is %s but neither %s nor %s is set", mode, AppConfig.GetAPIPublicTokenEnv(), AppConfig.GetAPIStatusTokenEnv())
+	}
+	if certFile == "" && mode == api.AuthToken {
+		log.Warn("API bearer tokens are sent over plain HTTP, set api::tls_cert to serve HTTPS")
+	}
+	if err := server.SetAuth(mode, tokens); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// apiHTTPOptions CORS, proxies, limits and timeouts of API servers
+func apiHTTPOptions() api.HTTPOptions {
+	proxies, err := api.ParseTrustedProxies(AppConfig.GetAPITrustedProxies())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return api.HTTPOptions{
+		CORSOrigins:    AppConfig.GetAPICORSOrigins(),
+		TrustedProxies: proxies,
+		MaxBodyBytes:   int64(AppConfig.GetAPIMaxBodyBytes()),
+		MaxHeaderBytes: int(AppConfig.GetAPIMaxHeaderBytes()),
+		ReadTimeout:    AppConfig.GetAPIReadTimeout(),
+		WriteTimeout:   AppConfig.GetAPIWriteTimeout(),
+		IdleTimeout:    AppConfig.GetAPIIdleTimeout(),
+		AccessLog:      AppConfig.GetAPIAccessLog(),
+	}
+}
 
+// startHeartbeat send and track heartbeats of members of beacon group
+func startHeartbeat(drng *beacon.Beacon) {
+	interval := AppConfig.GetHeartbeatInterval()
+	if interval <= 0 || len(drng.Members) == 0 {
+		return
+	}
+	if err := drng.StartHeartbeat(interval); err != nil {
+		log.Errorf("Unable to start heartbeat: %v", err)
+	}
+}
+
+// watchConfig reload config file when it changes and apply options that can
+// change while node runs, nil if there is no config file
+func watchConfig(evmRelayer *relayer.Relayer) io.Closer {
+	if AppConfig.configFile == "" {
+		return nil
+	}
+	AppConfig.cfg.Watch("log::level", func(value interface{}) {
+		if err := logger.SetLevel(AppConfig.GetLogLevel()); err != nil {
+			log.Errorf("Invalid log level: %v", err)
+		}
+	})
+	if evmRelayer != nil {
+		AppConfig.cfg.Watch("relayer::max_gas_price_gwei", func(value interface{}) {
+			evmRelayer.SetMaxGasPrice(gwei(AppConfig.GetRelayerMaxGasPriceGwei()))
+		})
+	}
+	watcher, err := AppConfig.cfg.WatchFile(AppConfig.configFile, func(changed []string, err error) {
+		if err != nil {
+			log.Errorf("Config file is not reloaded: %v", err)
+			return
+		}
+		if len(changed) > 0 {
+			log.Infof("Config file reloaded, changed: %s", strings.Join(changed, ", "))
+		}
+	})
+	if err != nil {
+		log.Errorf("Unable to watch config file: %v", err)
+		return nil
+	}
+	return watcher
+}
+
+// addReadinessChecks node is ready when it reaches enough group members to
+// complete rounds and its store accepts writes
+func addReadinessChecks(server *api.Server, net *network.Network, drng *beacon.Beacon, rounds *store.Store) {
+	server.AddReadinessCheck("peers", func() error {
+		// Own partial counts toward threshold
+		needed := drng.Threshold() - 1
+		if connected := net.ConnectedMembers(); connected < needed {
+			return fmt.Errorf("connected to %d group peers, need %d", connected, needed)
+		}
+		return nil
+	})
+	server.AddReadinessCheck("store", rounds.Writable)
+}
+
+// syncCommand fetch missing rounds from peers into local store and exit
+func syncCommand(args []string) {
+	parseNodeFlags("sync", args)
+
+	nodeKey := openNodeKey()
+	net := openNetwork(nodeKey)
+	drng := openBeacon(net, nodeKey)
+	if drng.GroupKey() == nil {
+		log.Fatal("Sync needs a share file to verify rounds against group key")
+	}
+	rounds := openStore(drng)
+	defer rounds.Close()
+	syncChain(chainsync.New(net, rounds, drng), drng)
 }
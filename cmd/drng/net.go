@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	stdnet "net"
+	"os"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// netCommand dispatch net subcommands
+func netCommand(args []string) {
+	if len(args) > 0 && args[0] == "doctor" {
+		netDoctor(args[1:])
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng net doctor [flags]")
+	os.Exit(1)
+}
+
+// netDoctor join network with configuration of node, run diagnostics and
+// print what to fix, exit status is 1 if a check failed
+func netDoctor(args []string) {
+	flags := flag.NewFlagSet("net doctor", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 30*time.Second, "How long peers have to confirm reachability of node")
+	jsonOutput := flags.Bool("json", false, "Print checks as JSON")
+	parseNodeFlags("net doctor", splitFlags(flags, args))
+
+	// A running node holds its port, doctor then joins with a throwaway key
+	// on a random port so it does not take over connections of node
+	bindCheck := network.Check{Name: "bind", Status: network.CheckOK}
+	bindAddr := stdnet.JoinHostPort(AppConfig.GetBindHost(), strconv.FormatUint(uint64(AppConfig.GetBindPort()), 10))
+	bindCheck.Detail = bindAddr + " is free"
+	var nodeKey keypair.Signer
+	listener, err := stdnet.Listen("tcp", bindAddr)
+	switch {
+	case err == nil:
+		listener.Close()
+		nodeKey = openNodeKey()
+	case errors.Is(err, syscall.EADDRINUSE):
+		bindCheck.Status = network.CheckWarn
+		bindCheck.Detail = err.Error()
+		bindCheck.Advice = "port is taken, probably by a running node. Other checks use a throwaway key on a random port, peers with an allowlist may reject it"
+		AppConfig.SetBindPort(0)
+		AppConfig.SetListen("")
+		if nodeKey, err = keypair.NewEd25519(); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		bindCheck.Status = network.CheckFail
+		bindCheck.Detail = err.Error()
+		bindCheck.Advice = "bind-host must be 0.0.0.0 or an address of this machine"
+		printChecks([]network.Check{bindCheck}, *jsonOutput)
+		os.Exit(1)
+	}
+
+	net := openNetwork(nodeKey)
+	topics := []string{beacon.Topic, beacon.ResultsTopic}
+	if groupFile := AppConfig.GetGroupFile(); groupFile != "" {
+		g, err := group.LoadVerifiedFromFile(groupFile)
+		if err != nil {
+			log.Fatalf("Invalid group file: %v", err)
+		}
+		net.SetMembers(g.CurrentMembers())
+		topics = append(topics, beacon.PartialTopic)
+	}
+	if extra, err := AppConfig.GetExtraBeacons(); err == nil {
+		for _, b := range extra {
+			topics = append(topics, beacon.NamespacedTopic(b.ID, beacon.Topic), beacon.NamespacedTopic(b.ID, beacon.ResultsTopic))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	checks := append([]network.Check{bindCheck}, net.Diagnose(ctx, topics)...)
+	printChecks(checks, *jsonOutput)
+	for _, check := range checks {
+		if check.Status == network.CheckFail {
+			os.Exit(1)
+		}
+	}
+}
+
+// printChecks print status, name and detail of each check with advice below
+// checks that did not pass
+func printChecks(checks []network.Check, jsonOutput bool) {
+	if jsonOutput {
+		printJSON(checks)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tCHECK\tDETAIL")
+	for _, check := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.Status, check.Name, check.Detail)
+		if check.Advice != "" {
+			fmt.Fprintf(w, "\t\t-> %s\n", check.Advice)
+		}
+	}
+	w.Flush()
+}
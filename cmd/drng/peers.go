@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// peersCommand print peers that a node is connected to
+func peersCommand(args []string) {
+	flags := flag.NewFlagSet("peers", flag.ExitOnError)
+	node := flags.String("node", "http://127.0.0.1:8080", "HTTP API of node")
+	jsonOutput := flags.Bool("json", false, "Print peers as JSON")
+	members := flags.Bool("members", false, "List only group members")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of request")
+	flags.Parse(args)
+
+	var peers []network.PeerInfo
+	if err := fetchNodeJSON(*node, "/peers", *timeout, &peers); err != nil {
+		log.Fatal(err)
+	}
+	if *members {
+		filtered := peers[:0]
+		for _, p := range peers {
+			if p.Member {
+				filtered = append(filtered, p)
+			}
+		}
+		peers = filtered
+	}
+	if *jsonOutput {
+		printJSON(peers)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMEMBER\tVERSION\tDIRECTION\tSECURITY\tLATENCY\tRTT P50/P95\tAGENT\tTOPICS\tADDRS")
+	for _, p := range peers {
+		latency := "-"
+		if p.Latency > 0 {
+			latency = p.Latency.Round(100 * time.Microsecond).String()
+		}
+		rtt := "-"
+		if p.RTT != nil && p.RTT.Samples > 0 {
+			rtt = p.RTT.P50.Round(100*time.Microsecond).String() + "/" + p.RTT.P95.Round(100*time.Microsecond).String()
+		}
+		version := "-"
+		if p.Version != nil {
+			version = strconv.FormatUint(uint64(p.Version.Version), 10)
+			if !p.Version.Compatible {
+				version += " (incompatible)"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Member, version, p.Direction, connSecurity(p.Conns), latency, rtt, p.AgentVersion, strings.Join(p.Topics, ","), strings.Join(p.Addrs, ","))
+	}
+	w.Flush()
+}
+
+// connSecurity negotiated security transports and muxers of connections,
+// "-" if none is known
+func connSecurity(conns []network.ConnInfo) string {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(conns))
+	for _, conn := range conns {
+		if conn.Security == "" {
+			continue
+		}
+		name := conn.Security + "/" + conn.Muxer
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+// fetchNodeJSON get path from HTTP API of node and decode its JSON body,
+// error of node is returned if it does not answer 200
+func fetchNodeJSON(node string, path string, timeout time.Duration, v interface{}) error {
+	_, err := getNodeJSON(node, path, timeout, v, http.StatusOK)
+	return err
+}
+
+// getNodeJSON get path from HTTP API of node, body of given statuses is
+// decoded into v. Return status that node answered, error of node is returned
+// for other statuses
+func getNodeJSON(node string, path string, timeout time.Duration, v interface{}, statuses ...int) (int, error) {
+	httpClient := &http.Client{Timeout: timeout}
+	resp, err := httpClient.Get(strings.TrimSuffix(node, "/") + path)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return status, json.NewDecoder(resp.Body).Decode(v)
+		}
+	}
+	body := struct {
+		Error string `json:"error"`
+	}{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, fmt.Errorf("node answered %s: %s", resp.Status, body.Error)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/plan"
+)
+
+// planCommand simulate a deployment and tell whether it can meet its period
+func planCommand(args []string) {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	nodes := flags.Int("nodes", 0, "Number of nodes in group")
+	threshold := flags.Int("threshold", 0, "Number of partial signatures needed per round")
+	period := flags.Duration("period", 30*time.Second, "Round period")
+	profileName := flags.String("latency-profile", "region", "Latency profile: "+strings.Join(plan.Profiles(), ", "))
+	trials := flags.Int("trials", 1000, "Number of simulated rounds")
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	measure := flags.Bool("measure", false, "Measure partial verification on this machine instead of assuming its cost")
+	flags.Parse(args)
+
+	profile, err := plan.GetProfile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	params := plan.DefaultParams(*nodes, *threshold, *period, profile)
+	params.Trials = *trials
+	var measurements []*beacon.AggregationMeasurement
+	var precompute *beacon.PrecomputeMeasurement
+	if *measure && *threshold >= 1 && *threshold <= *nodes {
+		workerCounts := []int{1}
+		if procs := runtime.GOMAXPROCS(0); procs > 1 {
+			workerCounts = append(workerCounts, procs)
+		}
+		for _, workers := range workerCounts {
+			measurement, err := beacon.MeasureAggregation(*nodes, *threshold, workers)
+			if err != nil {
+				log.Fatal(err)
+			}
+			measurements = append(measurements, measurement)
+		}
+		params.VerifyCost = measurements[0].Elapsed / time.Duration(*threshold)
+		if precompute, err = beacon.MeasurePrecompute(20); err != nil {
+			log.Fatal(err)
+		}
+	}
+	report, err := plan.Simulate(params)
+	if err != nil {
+		flags.Usage()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		printJSON(report)
+		return
+	}
+	fmt.Printf("Nodes: %d threshold: %d period: %s latency profile: %s\n", *nodes, *threshold, *period, profile.Name)
+	for _, measurement := range measurements {
+		fmt.Printf("Aggregation on %d workers: threshold reached in %s (%.0f partials/s)\n", measurement.Workers, measurement.Elapsed.Round(time.Millisecond), measurement.PartialsPerSecond)
+	}
+	if precompute != nil {
+		fmt.Printf("Round start: partial ready in %s, %s when precomputed\n", precompute.Signed.Round(time.Microsecond), precompute.Precomputed.Round(time.Microsecond))
+	}
+	fmt.Printf("Gossip hops: %d\n", report.Hops)
+	fmt.Printf("Messages per round: %d (%d transmissions, %d bytes)\n", report.MessagesPerRound, report.TransmitsPerRound, report.BytesPerRound)
+	fmt.Printf("Time to threshold: p50 %s p95 %s p99 %s\n", report.P50, report.P95, report.P99)
+	if report.Feasible {
+		fmt.Printf("Feasible: p99 is within %s budget\n", report.Budget)
+	} else {
+		fmt.Printf("Not feasible: p99 exceeds %s budget, increase period or lower threshold\n", report.Budget)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// statusOutput status of a node printed with -json, its fields are a stable
+// schema that later versions only add to
+type statusOutput struct {
+	Node  string `json:"node"`
+	Ready bool   `json:"ready"`
+	// Checks failed readiness checks by name, empty if node is ready
+	Checks       map[string]string `json:"checks"`
+	ChainHash    string            `json:"chainHash"`
+	Mode         string            `json:"mode"`
+	Scheme       string            `json:"scheme"`
+	Period       uint64            `json:"period"`
+	GenesisTime  int64             `json:"genesisTime"`
+	CurrentRound uint64            `json:"currentRound"`
+	// LatestRound latest stored round, 0 if node has none
+	LatestRound uint64 `json:"latestRound"`
+	// Behind rounds before the current round that node has not stored
+	Behind   uint64 `json:"behind"`
+	Produced uint64 `json:"produced"`
+	Skipped  uint64 `json:"skipped"`
+	Peers    int    `json:"peers"`
+	Members  int    `json:"members"`
+}
+
+// statusCommand print readiness, chain, rounds and peers of a running node,
+// exit status is 1 if node is not ready
+func statusCommand(args []string) {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	node := flags.String("node", "http://127.0.0.1:8080", "HTTP API of node")
+	jsonOutput := flags.Bool("json", false, "Print status as JSON")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of each request")
+	flags.Parse(args)
+
+	status := statusOutput{Node: *node, Checks: map[string]string{}}
+	info := new(api.Info)
+	if err := fetchNodeJSON(*node, "/info", *timeout, info); err != nil {
+		log.Fatal(err)
+	}
+	status.ChainHash, status.Mode, status.Scheme = info.Hash, info.Mode, info.Scheme
+	status.Period, status.GenesisTime = info.Period, info.GenesisTime
+	if genesis := time.Unix(info.GenesisTime, 0); info.Period > 0 && !time.Now().Before(genesis) {
+		status.CurrentRound = uint64(time.Since(genesis)/(time.Duration(info.Period)*time.Second)) + 1
+	}
+
+	health := new(api.Health)
+	code, err := getNodeJSON(*node, "/readyz", *timeout, health, http.StatusOK, http.StatusServiceUnavailable)
+	if err != nil {
+		log.Fatal(err)
+	}
+	status.Ready = code == http.StatusOK
+	for name, failure := range health.Checks {
+		status.Checks[name] = failure
+	}
+
+	latest := new(api.Round)
+	code, err = getNodeJSON(*node, "/public/latest", *timeout, latest, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if code == http.StatusOK {
+		status.LatestRound = latest.Round
+	}
+	if status.CurrentRound > status.LatestRound+1 {
+		status.Behind = status.CurrentRound - status.LatestRound - 1
+	}
+
+	var stats beacon.Stats
+	if err := fetchNodeJSON(*node, "/stats", *timeout, &stats); err != nil {
+		log.Fatal(err)
+	}
+	status.Produced, status.Skipped = stats.Produced, stats.Skipped
+
+	var peers []network.PeerInfo
+	if err := fetchNodeJSON(*node, "/peers", *timeout, &peers); err != nil {
+		log.Fatal(err)
+	}
+	status.Peers = len(peers)
+	for _, p := range peers {
+		if p.Member {
+			status.Members++
+		}
+	}
+
+	if *jsonOutput {
+		printJSON(status)
+	} else {
+		printStatus(&status)
+	}
+	if !status.Ready {
+		os.Exit(1)
+	}
+}
+
+func printStatus(status *statusOutput) {
+	fmt.Printf("Node: %s\n", status.Node)
+	if status.Ready {
+		fmt.Println("Ready: yes")
+	} else {
+		names := make([]string, 0, len(status.Checks))
+		for name := range status.Checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		failures := make([]string, len(names))
+		for i, name := range names {
+			failures[i] = name + ": " + status.Checks[name]
+		}
+		fmt.Printf("Ready: no (%s)\n", strings.Join(failures, "; "))
+	}
+	hash := status.ChainHash
+	if hash == "" {
+		hash = "-"
+	}
+	fmt.Printf("Chain: %s, %s, %s, period %ds\n", hash, status.Mode, status.Scheme, status.Period)
+	fmt.Printf("Rounds: current %d, latest stored %d, %d behind\n", status.CurrentRound, status.LatestRound, status.Behind)
+	fmt.Printf("Produced: %d, skipped: %d\n", status.Produced, status.Skipped)
+	fmt.Printf("Peers: %d connected, %d members\n", status.Peers, status.Members)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// storeCommand dispatch store subcommands
+func storeCommand(args []string) {
+	if len(args) > 0 && args[0] == "export" {
+		storeExport(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "verify" {
+		storeVerify(args[1:])
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Usage: drng store export|verify [flags]")
+	os.Exit(1)
+}
+
+// storeSource data source of store driver, bolt driver opens store file and
+// database drivers connect with dsn
+func storeSource(driver string, fileName string, dsn string) string {
+	if driver == store.DriverBolt {
+		return fileName
+	}
+	return dsn
+}
+
+// storeExport write a range of stored rounds to an archive that is verified
+// against chain info of group
+func storeExport(args []string) {
+	flags := flag.NewFlagSet("store export", flag.ExitOnError)
+	storeFile := flags.String("store-file", "rounds.db", "File that rounds are persisted to")
+	driver := flags.String("store-driver", store.DriverBolt, "Store driver: bolt or postgres")
+	dsn := flags.String("store-dsn", "", "Connection string of database drivers")
+	groupFile := flags.String("group-file", "", "Group file holding chain info of rounds")
+	from := flags.Uint64("from", 1, "First round to export")
+	to := flags.Uint64("to", 0, "Last round to export, latest round if it's 0")
+	out := flags.String("out", "", "Archive file to write")
+	node := flags.String("node", "", "Admin API of a running node that builds archive, instead of reading store")
+	adminTokenEnv := flags.String("admin-token-env", "OROCHI_API_ADMIN_TOKEN", "Environment variable holding admin token of node")
+	flags.Parse(args)
+
+	if *node != "" && *out != "" {
+		exportFromNode(*node, os.Getenv(*adminTokenEnv), *from, *to, *out)
+		return
+	}
+	if *groupFile == "" || *out == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	g, err := group.LoadVerifiedFromFile(*groupFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if g.PublicKey == nil {
+		log.Fatal("Group file has no group public key")
+	}
+	info := beacon.ChainInfo{PublicKey: g.PublicKey, Period: g.Period, GenesisTime: g.GenesisTime, Mode: g.Mode, Scheme: keypair.SchemeBLS}
+	if info.Mode == "" {
+		info.Mode = beacon.ModeChained
+	}
+
+	rounds, err := store.OpenDriver(*driver, storeSource(*driver, *storeFile, *dsn))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rounds.Close()
+	if *to == 0 {
+		latest, err := rounds.Latest()
+		if err != nil {
+			log.Fatal(err)
+		}
+		*to = latest.Round
+	}
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	count, err := rounds.Export(file, info, *from, *to)
+	if err == nil {
+		err = file.Sync()
+	}
+	file.Close()
+	if err != nil {
+		os.Remove(*out)
+		log.Fatal(err)
+	}
+	fmt.Printf("Archive: %s\nRounds: %d from %d to %d\n", *out, count, *from, *to)
+}
+
+// exportFromNode download an archive that node builds from its store, node
+// defers it while its CPU load is shed. Archive is verified once it's written
+func exportFromNode(node string, token string, from uint64, to uint64, out string) {
+	query := url.Values{"from": {strconv.FormatUint(from, 10)}}
+	if to > 0 {
+		query.Set("to", strconv.FormatUint(to, 10))
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(node, "/")+"/admin/archive?"+query.Encode(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body := struct {
+			Error string `json:"error"`
+		}{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		log.Fatalf("Node answered %s: %s", resp.Status, body.Error)
+	}
+	file, err := os.OpenFile(out, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = io.Copy(file, resp.Body)
+	if err == nil {
+		err = file.Sync()
+	}
+	var header *store.ArchiveHeader
+	var count int
+	if err == nil {
+		if _, err = file.Seek(0, io.SeekStart); err == nil {
+			header, count, err = store.VerifyArchive(file, nil)
+		}
+	}
+	file.Close()
+	if err != nil {
+		os.Remove(out)
+		log.Fatalf("Unable to export archive from %s: %v", node, err)
+	}
+	fmt.Printf("Archive: %s\nRounds: %d from %d to %d\n", out, count, header.From, header.To)
+}
+
+// storeVerifyOutput result of store verify printed with -json, its fields are
+// a stable schema that later versions only add to
+type storeVerifyOutput struct {
+	Valid     bool   `json:"valid"`
+	ChainHash string `json:"chainHash,omitempty"`
+	From      uint64 `json:"from"`
+	To        uint64 `json:"to"`
+	// Rounds number of rounds checked, valid ones if archive is invalid
+	Rounds int `json:"rounds"`
+	// GroupChecked whether archive was checked to belong to the given group
+	GroupChecked bool   `json:"groupChecked"`
+	Error        string `json:"error,omitempty"`
+}
+
+// storeVerify check every round of an archive
+func storeVerify(args []string) {
+	flags := flag.NewFlagSet("store verify", flag.ExitOnError)
+	groupKey := flags.String("group-key", "", "Hex encoded group public key that archive must belong to")
+	groupFile := flags.String("group-file", "", "Group file holding group public key, used if -group-key is not given")
+	in := flags.String("in", "", "Archive file to verify")
+	jsonOutput := flags.Bool("json", false, "Print result as JSON")
+	flags.Parse(args)
+
+	if *in == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	var key []byte
+	if *groupKey != "" || *groupFile != "" {
+		var err error
+		if key, err = loadGroupKey(*groupKey, *groupFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	file, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	header, count, err := store.VerifyArchive(file, key)
+	if *jsonOutput {
+		output := storeVerifyOutput{Valid: err == nil, Rounds: count, GroupChecked: key != nil}
+		if header != nil {
+			output.ChainHash, output.From, output.To = header.Hash, header.From, header.To
+		}
+		if err != nil {
+			output.Error = err.Error()
+		}
+		printJSON(output)
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+	if err != nil {
+		log.Fatalf("Archive is invalid after %d rounds: %v", count, err)
+	}
+	fmt.Printf("Archive is valid\nChain hash: %s\nRounds: %d from %d to %d\n", header.Hash, count, header.From, header.To)
+	if key == nil {
+		fmt.Println("Group key was not checked, pass -group-key or -group-file to pin it")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/orochi-network/orochimaru/client"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/tlock"
+)
+
+// encryptCommand encrypt a file toward a future round of an unchained group
+func encryptCommand(args []string) {
+	flags := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	round := flags.Uint64("round", 0, "Round that unlocks the data")
+	groupKey := flags.String("group-key", "", "Hex encoded group public key")
+	groupFile := flags.String("group-file", "", "Group file holding group public key, used if -group-key is not given")
+	in := flags.String("in", "", "File to encrypt, standard input if not given")
+	out := flags.String("out", "", "File to write ciphertext to, standard output if not given")
+	flags.Parse(args)
+
+	if *round == 0 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *groupKey == "" && *groupFile != "" {
+		g, err := group.LoadVerifiedFromFile(*groupFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if g.Mode != group.ModeUnchained {
+			log.Fatalf("Group of %s is not unchained, its rounds can not be known in advance", *groupFile)
+		}
+		if g.GenesisTime.Unix() > 0 && g.Period > 0 {
+			unlock := g.GenesisTime.Add(time.Duration(*round-1) * g.Period)
+			fmt.Fprintf(os.Stderr, "Round %d unlocks at %s\n", *round, unlock.Format(time.RFC3339))
+		}
+	}
+	key, err := loadGroupKey(*groupKey, *groupFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	plaintext, err := readInput(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ciphertext, err := tlock.Encrypt(key, *round, plaintext)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoded, err := json.MarshalIndent(ciphertext, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeOutput(*out, append(encoded, '\n')); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// decryptCommand decrypt a ciphertext with its round fetched from a node
+func decryptCommand(args []string) {
+	flags := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	node := flags.String("node", "", "HTTP API of nodes separated by commas, e.g. http://127.0.0.1:8080")
+	groupKey := flags.String("group-key", "", "Hex encoded group public key that the round is verified against")
+	groupFile := flags.String("group-file", "", "Group file holding group public key, used if -group-key is not given")
+	chainHash := flags.String("chain-hash", "", "Hex encoded chain hash that chain info of nodes must match")
+	in := flags.String("in", "", "Ciphertext file, standard input if not given")
+	out := flags.String("out", "", "File to write decrypted data to, standard output if not given")
+	timeout := flags.Duration("timeout", 10*time.Second, "Timeout of request to node")
+	flags.Parse(args)
+
+	if *node == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	c, err := newClient(*node, *groupKey, *groupFile, *chainHash, *timeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoded, err := readInput(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ciphertext := new(tlock.Ciphertext)
+	if err := json.Unmarshal(encoded, ciphertext); err != nil {
+		log.Fatalf("Invalid ciphertext: %v", err)
+	}
+	ctx := context.Background()
+	result, err := c.Get(ctx, ciphertext.Round)
+	if err == client.ErrNotFound {
+		log.Fatalf("Round %d is not published yet", ciphertext.Round)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chainInfo, err := info.ChainInfo()
+	if err != nil {
+		log.Fatal(err)
+	}
+	plaintext, err := tlock.Decrypt(chainInfo.PublicKey, result, ciphertext)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := writeOutput(*out, plaintext); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readInput read given file or standard input if file name is empty
+func readInput(fileName string) ([]byte, error) {
+	if fileName == "" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(fileName)
+}
+
+// writeOutput write data to given file or standard output if file name is empty
+func writeOutput(fileName string, data []byte) error {
+	if fileName == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(fileName, data, 0600)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/chainsync"
+	"github.com/orochi-network/orochimaru/group"
+	"github.com/orochi-network/orochimaru/store"
+)
+
+// verifyChainOutput result of verify-chain printed with -json, problems are
+// what the first check found and remaining what is left after -repair
+type verifyChainOutput struct {
+	Checked   int                  `json:"checked"`
+	Problems  []verifyChainProblem `json:"problems"`
+	Repairs   []verifyChainRepair  `json:"repairs,omitempty"`
+	Remaining []verifyChainProblem `json:"remaining,omitempty"`
+}
+
+// verifyChainProblem rounds from to to inclusive that are invalid, missing or
+// a gap of an unchained beacon
+type verifyChainProblem struct {
+	Kind  chainsync.ProblemKind `json:"kind"`
+	From  uint64                `json:"from"`
+	To    uint64                `json:"to"`
+	Error string                `json:"error,omitempty"`
+}
+
+// verifyChainRepair rounds fetched again for a problem
+type verifyChainRepair struct {
+	Problem verifyChainProblem `json:"problem"`
+	Fetched int                `json:"fetched"`
+	Error   string             `json:"error,omitempty"`
+}
+
+func newVerifyChainProblem(p chainsync.Problem) verifyChainProblem {
+	problem := verifyChainProblem{Kind: p.Kind, From: p.From, To: p.To}
+	if p.Err != nil {
+		problem.Error = p.Err.Error()
+	}
+	return problem
+}
+
+// verifyChainCommand check every stored round against group key and the
+// round before it, invalid and missing rounds are fetched again from nodes
+// with -repair
+func verifyChainCommand(args []string) {
+	flags := flag.NewFlagSet("verify-chain", flag.ExitOnError)
+	storeFile := flags.String("store-file", "rounds.db", "File that rounds are persisted to")
+	driver := flags.String("store-driver", store.DriverBolt, "Store driver: bolt or postgres")
+	dsn := flags.String("store-dsn", "", "Connection string of database drivers")
+	groupFile := flags.String("group-file", "", "Group file holding chain info of rounds")
+	repair := flags.Bool("repair", false, "Fetch invalid and missing rounds again from -node")
+	node := flags.String("node", "", "HTTP API of nodes separated by commas that rounds are repaired from")
+	timeout := flags.Duration("timeout", 30*time.Second, "Timeout of each request to nodes")
+	jsonOutput := flags.Bool("json", false, "Print checked rounds and problems as JSON")
+	flags.Parse(args)
+
+	if *groupFile == "" || *repair && *node == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+	g, err := group.LoadVerifiedFromFile(*groupFile)
+	if err != nil {
+		log.Fatalf("Invalid group file: %v", err)
+	}
+	if g.PublicKey == nil {
+		log.Fatal("Group file has no group public key")
+	}
+	drng := beacon.New(nil, nil, g.Period)
+	drng.Genesis = g.GenesisTime
+	if g.Mode != "" {
+		drng.Mode = g.Mode
+	}
+	drng.SetGroupKey(g.PublicKey)
+	rounds, err := store.OpenDriver(*driver, storeSource(*driver, *storeFile, *dsn))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rounds.Close()
+
+	output := verifyChainOutput{Problems: make([]verifyChainProblem, 0)}
+	var problems []chainsync.Problem
+	count, err := chainsync.Verify(rounds, drng, func(p chainsync.Problem) {
+		if !*jsonOutput {
+			fmt.Println(p)
+		}
+		problems = append(problems, p)
+		output.Problems = append(output.Problems, newVerifyChainProblem(p))
+	})
+	if err != nil {
+		log.Fatalf("Unable to read store after %d rounds: %v", count, err)
+	}
+	output.Checked = count
+	if len(problems) == 0 || !*repair {
+		if *jsonOutput {
+			printJSON(output)
+		} else {
+			fmt.Printf("Checked %d rounds, found %d problems\n", count, len(problems))
+			if len(problems) > 0 {
+				fmt.Println("Run again with -repair -node <url> to fetch them from nodes")
+			}
+		}
+		exitOnProblems(problems, drng.Mode)
+		return
+	}
+	if !*jsonOutput {
+		fmt.Printf("Checked %d rounds, found %d problems\n", count, len(problems))
+	}
+
+	c, err := newClient(*node, "", *groupFile, "", *timeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	output.Repairs = make([]verifyChainRepair, 0, len(problems))
+	for _, p := range problems {
+		repair := verifyChainRepair{Problem: newVerifyChainProblem(p)}
+		fetched, err := c.Range(context.Background(), p.From, p.To-p.From+1)
+		if err != nil {
+			repair.Error = err.Error()
+			output.Repairs = append(output.Repairs, repair)
+			if !*jsonOutput {
+				fmt.Printf("Unable to repair %s: %v\n", p, err)
+			}
+			continue
+		}
+		for i := range fetched {
+			if err := rounds.Put(&fetched[i]); err != nil {
+				log.Fatal(err)
+			}
+		}
+		repair.Fetched = len(fetched)
+		output.Repairs = append(output.Repairs, repair)
+		if !*jsonOutput {
+			fmt.Printf("Repaired %s, fetched %d rounds\n", p, len(fetched))
+		}
+	}
+	problems = nil
+	output.Remaining = make([]verifyChainProblem, 0)
+	count, err = chainsync.Verify(rounds, drng, func(p chainsync.Problem) {
+		if !*jsonOutput {
+			fmt.Println(p)
+		}
+		problems = append(problems, p)
+		output.Remaining = append(output.Remaining, newVerifyChainProblem(p))
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *jsonOutput {
+		output.Checked = count
+		printJSON(output)
+	} else {
+		fmt.Printf("Checked %d rounds again, %d problems are left\n", count, len(problems))
+	}
+	exitOnProblems(problems, drng.Mode)
+}
+
+// exitOnProblems exit with status 1 unless all problems are gaps of an
+// unchained beacon, which may be rounds skipped by group
+func exitOnProblems(problems []chainsync.Problem, mode string) {
+	for _, p := range problems {
+		if p.Kind != chainsync.ProblemGap || mode != beacon.ModeUnchained {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/contracts"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+	"github.com/orochi-network/orochimaru/relayer"
+	"github.com/orochi-network/orochimaru/vrf"
+)
+
+// openVRF answer on-demand randomness requests with share of beacon, nil if
+// vrf::enabled is not set
+func openVRF(net *network.Network, drng *beacon.Beacon) *vrf.Service {
+	if !AppConfig.GetVRFEnabled() {
+		return nil
+	}
+	share, pubPoly := drng.ThresholdKeys()
+	service, err := vrf.New(net, drng.TopicName(vrf.Topic), share, pubPoly)
+	if err != nil {
+		log.Fatal(err)
+	}
+	service.Timeout = AppConfig.GetVRFTimeout()
+	service.OnOutput = func(output *vrf.Output) {
+		nodeEvents.Publish(events.VRFFulfilled{Output: *output})
+	}
+	if err := service.Start(); err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("On-demand randomness requests are answered, timeout: %v", service.Timeout)
+	return service
+}
+
+// watchVRFRequests sign requests of vrf::contract with group and relay their
+// randomness back to contract
+func watchVRFRequests(service *vrf.Service, evmRelayer *relayer.Relayer) {
+	if service == nil || AppConfig.GetVRFContract() == "" {
+		return
+	}
+	if evmRelayer == nil {
+		log.Fatal("vrf::contract needs relayer::endpoint to fulfill requests")
+	}
+	address, err := relayer.ParseAddress(AppConfig.GetVRFContract())
+	if err != nil {
+		log.Fatalf("Invalid vrf contract: %v", err)
+	}
+	client, err := ethclient.Dial(AppConfig.GetRelayerEndpoint())
+	if err != nil {
+		log.Fatal(err)
+	}
+	coordinator, err := contracts.NewOrochiVRF(address, client)
+	if err != nil {
+		log.Fatal(err)
+	}
+	coordinatorABI, err := contracts.OrochiVRFMetaData.GetAbi()
+	if err != nil {
+		log.Fatal(err)
+	}
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{address},
+		Topics:    [][]common.Hash{{coordinatorABI.Events["RandomnessRequested"].ID}},
+	}
+	go relayer.WatchLogs(context.Background(), client, query, uint64(AppConfig.GetVRFConfirmations()), AppConfig.GetVRFPollInterval(), func(l types.Log) {
+		requested, err := coordinator.ParseRandomnessRequested(l)
+		if err != nil {
+			log.Debugf("Invalid RandomnessRequested log: %v", err)
+			return
+		}
+		// Group signs request ID, it binds seed to requester and contract
+		requestID := requested.RequestId
+		id, err := service.Request(requestID[:])
+		if err != nil {
+			log.Warnf("Unable to request randomness of request %x: %v", requestID, err)
+			return
+		}
+		go fulfillVRFRequest(service, evmRelayer, coordinator, address, requestID, id)
+	})
+	log.Infof("Randomness requests of contract %s are fulfilled", address)
+}
+
+// fulfillVRFRequest relay randomness of an on-chain request once group signed
+// it, unless another member fulfilled it first
+func fulfillVRFRequest(service *vrf.Service, evmRelayer *relayer.Relayer, coordinator *contracts.OrochiVRF, address common.Address, requestID [32]byte, id []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), service.Timeout+5*time.Second)
+	defer cancel()
+	output, err := service.Wait(ctx, id)
+	if err != nil {
+		log.Warnf("Request %x is not fulfilled: %v", requestID, err)
+		return
+	}
+	if fulfilled, err := coordinator.Randomness(&bind.CallOpts{Context: ctx}, requestID); err == nil && fulfilled != [32]byte{} {
+		return
+	}
+	// Contract takes signature uncompressed
+	signature, err := keypair.EncodeG1EVM(output.Signature)
+	if err != nil {
+		log.Warnf("Request %x is not fulfilled: %v", requestID, err)
+		return
+	}
+	var randomness [32]byte
+	copy(randomness[:], output.Randomness)
+	coordinatorABI, err := contracts.OrochiVRFMetaData.GetAbi()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	data, err := coordinatorABI.Pack("fulfillRandomness", requestID, randomness, signature)
+	if err != nil {
+		log.Warnf("Request %x is not fulfilled: %v", requestID, err)
+		return
+	}
+	evmRelayer.SubmitCall(fmt.Sprintf("request %x", requestID), address, data)
+}
@@ -1,14 +1,23 @@
 package config
 
 import (
+	"encoding/hex"
 	"errors"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
-//Config main storage
+// Config main storage
 type Config struct {
 	cfgStorage map[string]interface{}
-	mutex      sync.Mutex
+	watchers   map[string][]WatchFunc
+	// pinned keys set by flags or environment variables, reloads leave them alone
+	pinned map[string]bool
+	schema map[string]Key
+	order  []string
+	mutex  sync.Mutex
 }
 
 var onceCfg sync.Once
@@ -66,8 +75,12 @@ func (c *Config) GetBool(key string) bool {
 func (c *Config) GetInt(key string) int {
 	v, err := c.get(key)
 	if err == nil {
-		if rv, ok := v.(int); ok {
+		switch rv := v.(type) {
+		case int:
 			return rv
+		case int64:
+			// Integers loaded from config file
+			return int(rv)
 		}
 	}
 	return 0
@@ -77,8 +90,14 @@ func (c *Config) GetInt(key string) int {
 func (c *Config) GetUint(key string) uint {
 	v, err := c.get(key)
 	if err == nil {
-		if rv, ok := v.(uint); ok {
+		switch rv := v.(type) {
+		case uint:
 			return rv
+		case int64:
+			// Integers loaded from config file
+			if rv >= 0 {
+				return uint(rv)
+			}
 		}
 	}
 	return 0
@@ -95,13 +114,105 @@ func (c *Config) GetString(key string) string {
 	return ""
 }
 
+// GetFloat64 get float value from given key, integers and numeric strings are converted
+func (c *Config) GetFloat64(key string) float64 {
+	v, err := c.get(key)
+	if err == nil {
+		switch rv := v.(type) {
+		case float64:
+			return rv
+		case int64:
+			return float64(rv)
+		case int:
+			return float64(rv)
+		case uint:
+			return float64(rv)
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(rv), 64); err == nil {
+				return f
+			}
+		}
+	}
+	return 0
+}
+
+// GetDuration get duration from given key, strings are parsed like "30s" or
+// "1m30s" and plain numbers are seconds
+func (c *Config) GetDuration(key string) time.Duration {
+	v, err := c.get(key)
+	if err == nil {
+		switch rv := v.(type) {
+		case time.Duration:
+			return rv
+		case string:
+			rv = strings.TrimSpace(rv)
+			if d, err := time.ParseDuration(rv); err == nil {
+				return d
+			}
+			if f, err := strconv.ParseFloat(rv, 64); err == nil {
+				return time.Duration(f * float64(time.Second))
+			}
+		case int64, int, uint, float64:
+			return time.Duration(c.GetFloat64(key) * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+// GetStringSlice get list of strings from given key, strings are split by
+// comma like "a, b,c" and empty items are dropped
+func (c *Config) GetStringSlice(key string) []string {
+	v, err := c.get(key)
+	if err == nil {
+		switch rv := v.(type) {
+		case []string:
+			return rv
+		case string:
+			items := make([]string, 0)
+			for _, item := range strings.Split(rv, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items
+		}
+	}
+	return nil
+}
+
+// GetBytes get bytes from given key, strings are hex with optional 0x prefix
+func (c *Config) GetBytes(key string) []byte {
+	v, err := c.get(key)
+	if err == nil {
+		switch rv := v.(type) {
+		case []byte:
+			return rv
+		case string:
+			rv = strings.TrimPrefix(strings.TrimSpace(rv), "0x")
+			if b, err := hex.DecodeString(rv); err == nil {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// get value of key, default of key is used if it's not set
 func (c *Config) get(key string) (interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	if v, ok := c.cfgStorage[key]; ok {
 		return v, nil
 	}
+	if k, ok := c.schema[key]; ok && k.Default != nil {
+		return k.Default, nil
+	}
 	return nil, errors.New("this key does not exit")
 }
 
 func (c *Config) init() {
 	c.cfgStorage = make(map[string]interface{})
+	c.watchers = make(map[string][]WatchFunc)
+	c.pinned = make(map[string]bool)
+	c.schema = make(map[string]Key)
 }
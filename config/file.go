@@ -0,0 +1,277 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config files map nested tables to keys joined by "::", e.g. the YAML
+//
+//	node:
+//	  bind_port: 4001
+//	network:
+//	  bootstrap_peers:
+//	    - /ip4/10.0.0.1/tcp/4001/p2p/12D3KooW...
+//
+// sets node::bind_port and network::bootstrap_peers. Lists of scalars are
+// joined by comma, the form GetStringSlice and list flags read, and lists of
+// tables are flattened by index, e.g. beacon::extra::0::name.
+
+// LoadFromFile load configuration from a JSON, YAML or TOML file, format is
+// chosen by file extension
+func (c *Config) LoadFromFile(fileName string) error {
+	values, err := readFile(fileName)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		c.Set(key, value)
+	}
+	return nil
+}
+
+// readFile parse a config file into flattened keys
+func readFile(fileName string) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		err = parseJSON(content, values)
+	case ".yaml", ".yml":
+		err = parseYAML(content, values)
+	case ".toml":
+		err = parseTOML(content, values)
+	default:
+		err = errors.New("unsupported config file format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return values, nil
+}
+
+// LoadFromEnv load configuration from environment variables starting with
+// prefix, PREFIX_NODE_BIND_PORT sets node::bind_port. Values are kept as strings
+// and keys are pinned, so reloading config file does not override them
+func (c *Config) LoadFromEnv(prefix string) {
+	prefix = strings.ToUpper(prefix) + "_"
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], prefix) {
+			continue
+		}
+		// Namespace is the first word, the rest is the key
+		name := strings.SplitN(strings.ToLower(strings.TrimPrefix(parts[0], prefix)), "_", 2)
+		if len(name) != 2 || name[0] == "" || name[1] == "" {
+			continue
+		}
+		key := joinKey(name[0], name[1])
+		c.Set(key, parts[1])
+		c.Pin(key)
+	}
+}
+
+// Has check whether key was set
+func (c *Config) Has(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok := c.cfgStorage[key]
+	return ok
+}
+
+// Get raw value of key
+func (c *Config) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	v, ok := c.cfgStorage[key]
+	return v, ok
+}
+
+func joinKey(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "::" + key
+}
+
+func parseJSON(content []byte, values map[string]interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	root := make(map[string]interface{})
+	if err := decoder.Decode(&root); err != nil {
+		return err
+	}
+	return flatten("", root, values)
+}
+
+func parseYAML(content []byte, values map[string]interface{}) error {
+	root := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return err
+	}
+	return flatten("", root, values)
+}
+
+func parseTOML(content []byte, values map[string]interface{}) error {
+	root := make(map[string]interface{})
+	if err := toml.Unmarshal(content, &root); err != nil {
+		return err
+	}
+	return flatten("", root, values)
+}
+
+// flatten decoded tables into "::" keys
+func flatten(prefix string, node map[string]interface{}, values map[string]interface{}) error {
+	for key, value := range node {
+		key = joinKey(prefix, key)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, v, values); err != nil {
+				return err
+			}
+		case []map[string]interface{}:
+			// TOML arrays of tables
+			for i, table := range v {
+				if err := flatten(joinKey(key, strconv.Itoa(i)), table, values); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			if err := flattenList(key, v, values); err != nil {
+				return err
+			}
+		default:
+			scalar, err := normalizeScalar(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			values[key] = scalar
+		}
+	}
+	return nil
+}
+
+// flattenList join a list of scalars by comma and flatten a list of tables by
+// index
+func flattenList(key string, list []interface{}, values map[string]interface{}) error {
+	items := make([]string, 0, len(list))
+	tables := 0
+	for i, item := range list {
+		if table, ok := item.(map[string]interface{}); ok {
+			tables++
+			if err := flatten(joinKey(key, strconv.Itoa(i)), table, values); err != nil {
+				return err
+			}
+			continue
+		}
+		scalar, err := normalizeScalar(item)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		items = append(items, fmt.Sprint(scalar))
+	}
+	if tables > 0 && len(items) > 0 {
+		return fmt.Errorf("%s: list mixes tables and values", key)
+	}
+	if tables == 0 {
+		values[key] = strings.Join(items, ",")
+	}
+	return nil
+}
+
+// normalizeScalar convert decoded scalar to types config getters read,
+// integers are int64 and other numbers float64
+func normalizeScalar(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, fmt.Errorf("value %d overflows int64", v)
+		}
+		return int64(v), nil
+	case json.Number:
+		return parseNumber(v.String()), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		// TOML local dates and times
+		return v.String(), nil
+	case nil:
+		return nil, errors.New("null values are not supported")
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
+
+// parseNumber parse integer as int64 and other numbers as float64
+func parseNumber(s string) interface{} {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v
+	}
+	return s
+}
+
+// WriteYAML write a YAML config file of schema. Each key is preceded by its
+// description, keys that are not in values are commented out with their
+// default so the file documents every option
+func (c *Config) WriteYAML(w io.Writer, values map[string]interface{}) error {
+	var namespaces []string
+	keys := make(map[string][]Key)
+	for _, key := range c.Schema() {
+		parts := strings.SplitN(key.Name, "::", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("key %s has no namespace", key.Name)
+		}
+		if _, ok := keys[parts[0]]; !ok {
+			namespaces = append(namespaces, parts[0])
+		}
+		keys[parts[0]] = append(keys[parts[0]], key)
+	}
+	buf := new(bytes.Buffer)
+	for i, namespace := range namespaces {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "%s:\n", namespace)
+		for _, key := range keys[namespace] {
+			name := strings.SplitN(key.Name, "::", 2)[1]
+			fmt.Fprintf(buf, "  # %s\n", key.Description)
+			if value, ok := values[key.Name]; ok {
+				fmt.Fprintf(buf, "  %s: %s\n", name, formatScalar(value))
+			} else {
+				fmt.Fprintf(buf, "  # %s: %s\n", name, formatScalar(key.Default))
+			}
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// formatScalar format a value as YAML scalar, strings are always quoted
+func formatScalar(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
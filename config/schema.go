@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type of values a key holds
+type Type string
+
+// Types of keys
+const (
+	TypeString Type = "string"
+	TypeBool   Type = "bool"
+	TypeInt    Type = "int"
+	TypeUint   Type = "uint"
+)
+
+// Validator check a value that was converted to type of its key
+type Validator func(value interface{}) error
+
+// Key schema of a config key
+type Key struct {
+	Name string
+	Type Type
+	// Default value used when key is not set, it must have type of key
+	Default  interface{}
+	Required bool
+	// Immutable value can not change by reloading config file
+	Immutable   bool
+	Validate    Validator
+	Description string
+}
+
+// Violations every problem Validate found, one per key
+type Violations []string
+
+func (v Violations) Error() string {
+	return strings.Join(v, "\n")
+}
+
+// Define add keys to schema, a key defined again replaces its definition
+func (c *Config) Define(keys ...Key) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, key := range keys {
+		if _, ok := c.schema[key.Name]; !ok {
+			c.order = append(c.order, key.Name)
+		}
+		c.schema[key.Name] = key
+	}
+}
+
+// Schema defined keys in order of definition
+func (c *Config) Schema() []Key {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	keys := make([]Key, 0, len(c.order))
+	for _, name := range c.order {
+		keys = append(keys, c.schema[name])
+	}
+	return keys
+}
+
+// Values value of every defined key, defaults of keys that are not set
+func (c *Config) Values() map[string]interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	values := make(map[string]interface{}, len(c.order))
+	for _, name := range c.order {
+		if v, ok := c.cfgStorage[name]; ok {
+			values[name] = v
+		} else {
+			values[name] = c.schema[name].Default
+		}
+	}
+	return values
+}
+
+// Validate convert values of defined keys to their types and check them,
+// all violations are returned at once. Keys that are not defined are left as is
+func (c *Config) Validate() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	violations := make(Violations, 0)
+	for _, name := range c.order {
+		key := c.schema[name]
+		value, ok := c.cfgStorage[name]
+		if !ok {
+			if key.Required {
+				violations = append(violations, name+" is required")
+			}
+			continue
+		}
+		value, err := key.check(value)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		c.cfgStorage[name] = value
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// check convert value to type of key and validate it
+func (k Key) check(value interface{}) (interface{}, error) {
+	value, err := k.Normalize(value)
+	if err != nil {
+		return nil, err
+	}
+	if k.Validate != nil {
+		if err := k.Validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// Normalize convert value read from a file, an environment variable or a flag
+// to type of key
+func (k Key) Normalize(value interface{}) (interface{}, error) {
+	mismatch := fmt.Errorf("value %v is not a %s", value, k.Type)
+	switch v := value.(type) {
+	case string:
+		switch k.Type {
+		case TypeString:
+			return v, nil
+		case TypeBool:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		case TypeInt:
+			if i, err := strconv.ParseInt(v, 10, 0); err == nil {
+				return int(i), nil
+			}
+		case TypeUint:
+			if u, err := strconv.ParseUint(v, 10, 0); err == nil {
+				return uint(u), nil
+			}
+		}
+	case bool:
+		if k.Type == TypeBool {
+			return v, nil
+		}
+	case int:
+		return k.Normalize(int64(v))
+	case uint:
+		if k.Type == TypeUint {
+			return v, nil
+		}
+	case int64:
+		if k.Type == TypeInt {
+			return int(v), nil
+		}
+		if k.Type == TypeUint && v >= 0 {
+			return uint(v), nil
+		}
+	}
+	return nil, mismatch
+}
+
+// OneOf value must be one of given strings
+func OneOf(values ...string) Validator {
+	return func(value interface{}) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+// Range unsigned value must be within min and max inclusive
+func Range(min uint, max uint) Validator {
+	return func(value interface{}) error {
+		if v, ok := value.(uint); !ok || v < min || v > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// Port value must be a TCP port, 0 lets system pick one or disables a listener
+var Port = Range(0, 65535)
+
+// NotEmpty string value must not be empty
+func NotEmpty(value interface{}) error {
+	if value == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
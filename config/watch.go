@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchFunc called with new value after a reload changed a key
+type WatchFunc func(value interface{})
+
+// reloadDelay editors write a file in several steps, reload once they are done
+const reloadDelay = 200 * time.Millisecond
+
+// Watch call callback whenever a reload changes key
+func (c *Config) Watch(key string, callback WatchFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.watchers[key] = append(c.watchers[key], callback)
+}
+
+// Pin keep key at its current value across reloads, used for keys set by flags
+func (c *Config) Pin(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.pinned[key] = true
+}
+
+// Reload read config file again and apply changed keys, pinned keys are
+// skipped and keys removed from file keep their value. Nothing is applied if
+// an immutable key changed or a value violates schema. It returns changed keys
+// in order
+func (c *Config) Reload(fileName string) ([]string, error) {
+	values, err := readFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	changed := make([]string, 0)
+	for key, value := range values {
+		if c.pinned[key] {
+			continue
+		}
+		schema, defined := c.schema[key]
+		if defined {
+			if value, err = schema.check(value); err != nil {
+				c.mutex.Unlock()
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			values[key] = value
+		}
+		current, ok := c.cfgStorage[key]
+		if !ok && defined {
+			current, ok = schema.Default, schema.Default != nil
+		}
+		if ok && fmt.Sprint(current) == fmt.Sprint(value) {
+			continue
+		}
+		if schema.Immutable {
+			c.mutex.Unlock()
+			return nil, fmt.Errorf("%s can not be changed without a restart", key)
+		}
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	notify := make([]func(), 0)
+	for _, key := range changed {
+		value := values[key]
+		c.cfgStorage[key] = value
+		for _, callback := range c.watchers[key] {
+			callback := callback
+			notify = append(notify, func() { callback(value) })
+		}
+	}
+	c.mutex.Unlock()
+	// Callbacks may read config
+	for _, call := range notify {
+		call()
+	}
+	return changed, nil
+}
+
+// WatchFile reload config file whenever it's written, onReload is called with
+// result of each reload. Directory of file is watched so editors that replace
+// the file are noticed. Close returned watcher to stop
+func (c *Config) WatchFile(fileName string, onReload func(changed []string, err error)) (io.Closer, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(fileName)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	target := filepath.Clean(fileName)
+	go func() {
+		var timer <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == target && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					timer = time.After(reloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				onReload(nil, err)
+			case <-timer:
+				timer = nil
+				onReload(c.Reload(fileName))
+			}
+		}
+	}()
+	return watcher, nil
+}
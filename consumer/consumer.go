@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Round consumers act on each finalized round of the default beacon of node,
+// e.g. push it to a queue, call a webhook or write it to a file. Plugins are
+// compiled into drng by a package that registers its consumers in init and
+// is imported for side effects by cmd/drng:
+//
+//	func init() {
+//		consumer.Register("sqs", func(arg string) (consumer.RoundConsumer, error) {
+//			return newSQSConsumer(arg)
+//		})
+//	}
+
+var consumerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "consumer",
+	Name:      "errors_total",
+	Help:      "Rounds that a round consumer failed to handle, by consumer",
+}, []string{"consumer"})
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("consumer")
+	metrics.Registry.MustRegister(consumerErrors)
+}
+
+// RoundConsumer act on finalized rounds, rounds are handed over one at a
+// time in order. A returned error is logged and counted, it does not stop
+// later rounds
+type RoundConsumer interface {
+	OnRound(result beacon.RoundResult) error
+}
+
+// Factory create a consumer from argument of its configuration entry
+type Factory func(arg string) (RoundConsumer, error)
+
+var (
+	factories     = make(map[string]Factory)
+	factoriesLock sync.Mutex
+)
+
+// Register make a consumer available by name, it's called from init of
+// consumer packages. Registering a name twice panics
+func Register(name string, factory Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	if _, ok := factories[name]; ok {
+		panic("round consumer " + name + " is registered twice")
+	}
+	factories[name] = factory
+}
+
+// Registered names of registered consumers, sorted
+func Registered() []string {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseEntry split a configuration entry name:argument into consumer name
+// and argument, the argument is optional
+func ParseEntry(entry string) (string, string, error) {
+	name, arg := entry, ""
+	if i := strings.Index(entry, ":"); i >= 0 {
+		name, arg = entry[:i], entry[i+1:]
+	}
+	factoriesLock.Lock()
+	_, ok := factories[name]
+	factoriesLock.Unlock()
+	if !ok {
+		return "", "", fmt.Errorf("unknown round consumer %q, registered consumers: %s", name, strings.Join(Registered(), ", "))
+	}
+	return name, arg, nil
+}
+
+// Open create consumer of a configuration entry name:argument
+func Open(entry string) (string, RoundConsumer, error) {
+	name, arg, err := ParseEntry(entry)
+	if err != nil {
+		return "", nil, err
+	}
+	factoriesLock.Lock()
+	factory := factories[name]
+	factoriesLock.Unlock()
+	c, err := factory(arg)
+	if err != nil {
+		return "", nil, fmt.Errorf("round consumer %s: %v", name, err)
+	}
+	return name, c, nil
+}
+
+// Attach hand rounds of the default beacon finalized on bus to consumer
+func Attach(bus *events.Bus, name string, c RoundConsumer) *events.Subscription {
+	return bus.Subscribe("consumer "+name, func(event events.Event) {
+		finalized := event.(events.RoundFinalized)
+		if finalized.Beacon != "" {
+			return
+		}
+		if err := c.OnRound(finalized.Result); err != nil {
+			consumerErrors.WithLabelValues(name).Inc()
+			log.Warnf("Round consumer %s failed on round %d: %v", name, finalized.Result.Round, err)
+		}
+	}, events.TypeRoundFinalized)
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+)
+
+func init() {
+	Register("file", func(arg string) (RoundConsumer, error) {
+		return NewFile(arg)
+	})
+}
+
+// File append each round to a file as a line of JSON, in the format of the
+// HTTP API
+type File struct {
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// NewFile open file that rounds are appended to, it's created if it does not exist
+func NewFile(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("file path is missing, use file:<path>")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &File{file: file}, nil
+}
+
+// OnRound append round to file
+func (f *File) OnRound(result beacon.RoundResult) error {
+	line, err := json.Marshal(api.NewRound(&result))
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close file
+func (f *File) Close() error {
+	return f.file.Close()
+}
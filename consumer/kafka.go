@@ -0,0 +1,110 @@
+package consumer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// kafkaAttempts produce requests sent per round, writer refreshes metadata
+// between attempts in case partition leadership moved
+const kafkaAttempts = 3
+
+// kafkaTimeout longest time producing a round may take, attempts included
+const kafkaTimeout = 30 * time.Second
+
+// KafkaConfig Kafka publisher configuration
+type KafkaConfig struct {
+	// Brokers host:port of bootstrap brokers
+	Brokers []string
+	Topic   string
+	// User SASL/PLAIN user name, no authentication if it's empty
+	User     string
+	Password string
+	// TLS connect to brokers over TLS
+	TLS bool
+	// CAFile PEM file of certificate authorities brokers are verified with,
+	// system roots if it's empty
+	CAFile string
+}
+
+// Kafka publish each round as JSON, in the format of the HTTP API, to a Kafka
+// topic. Records are keyed by round number, partition is picked from the key
+// like Kafka's default partitioner does
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+// NewKafka create publisher to topic of conf, brokers are connected to on the
+// first round
+func NewKafka(conf KafkaConfig) (*Kafka, error) {
+	if len(conf.Brokers) == 0 {
+		return nil, errors.New("no kafka brokers")
+	}
+	for _, broker := range conf.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			return nil, fmt.Errorf("kafka broker %q is not host:port", broker)
+		}
+	}
+	if conf.Topic == "" {
+		return nil, errors.New("no kafka topic")
+	}
+	transport := &kafka.Transport{ClientID: "drng"}
+	if conf.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+		if conf.CAFile != "" {
+			pem, err := os.ReadFile(conf.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			transport.TLS.RootCAs = x509.NewCertPool()
+			if !transport.TLS.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates in %s", conf.CAFile)
+			}
+		}
+	}
+	if conf.User != "" {
+		transport.SASL = plain.Mechanism{Username: conf.User, Password: conf.Password}
+	}
+	return &Kafka{writer: &kafka.Writer{
+		Addr:         kafka.TCP(conf.Brokers...),
+		Topic:        conf.Topic,
+		Balancer:     &kafka.Murmur2Balancer{},
+		MaxAttempts:  kafkaAttempts,
+		RequiredAcks: kafka.RequireAll,
+		// Rounds are produced one at a time, there is nothing to batch
+		BatchSize: 1,
+		Transport: transport,
+	}}, nil
+}
+
+// OnRound produce round to topic and wait for every in-sync replica to have it
+func (k *Kafka) OnRound(result beacon.RoundResult) error {
+	value, err := json.Marshal(api.NewRound(&result))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaTimeout)
+	defer cancel()
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.FormatUint(result.Round, 10)),
+		Value: value,
+	})
+}
+
+// Close flush pending records and close connections to brokers
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
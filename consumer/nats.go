@@ -0,0 +1,81 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+)
+
+// natsFlushTimeout longest time to wait for server to acknowledge a round
+const natsFlushTimeout = 5 * time.Second
+
+// NATSConfig NATS publisher configuration
+type NATSConfig struct {
+	// URL comma-separated server URLs, tls:// for TLS
+	URL     string
+	Subject string
+	// CredsFile user credentials file with JWT and NKey seed, for NATS 2.0
+	// accounts
+	CredsFile string
+	// Token authentication token, it's ignored if CredsFile is set
+	Token string
+	// CAFile PEM file of certificate authorities servers are verified with
+	CAFile string
+}
+
+// NATS publish each round as JSON, in the format of the HTTP API, to a NATS
+// subject
+type NATS struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATS connect to servers of conf, connection is retried in background if
+// they are not reachable yet
+func NewNATS(conf NATSConfig) (*NATS, error) {
+	if conf.Subject == "" {
+		return nil, errors.New("no nats subject")
+	}
+	opts := []nats.Option{
+		nats.Name("drng"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warnf("NATS connection lost: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Infof("NATS reconnected to %s", conn.ConnectedUrl())
+		}),
+	}
+	if conf.CredsFile != "" {
+		opts = append(opts, nats.UserCredentials(conf.CredsFile))
+	} else if conf.Token != "" {
+		opts = append(opts, nats.Token(conf.Token))
+	}
+	if conf.CAFile != "" {
+		opts = append(opts, nats.RootCAs(conf.CAFile))
+	}
+	conn, err := nats.Connect(conf.URL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &NATS{conn: conn, subject: conf.Subject}, nil
+}
+
+// OnRound publish round and wait for server to receive it
+func (n *NATS) OnRound(result beacon.RoundResult) error {
+	body, err := json.Marshal(api.NewRound(&result))
+	if err != nil {
+		return err
+	}
+	if err := n.conn.Publish(n.subject, body); err != nil {
+		return err
+	}
+	return n.conn.FlushTimeout(natsFlushTimeout)
+}
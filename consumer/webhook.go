@@ -0,0 +1,228 @@
+package consumer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Webhook defaults
+const (
+	DefaultWebhookAttempts = 5
+	DefaultWebhookBackoff  = time.Second
+)
+
+// webhookTimeout longest time a webhook call may take
+const webhookTimeout = 10 * time.Second
+
+// maxWebhookBackoff cap of wait between attempts
+const maxWebhookBackoff = time.Minute
+
+// Headers of webhook requests
+const (
+	HeaderRound     = "X-Drng-Round"
+	HeaderTimestamp = "X-Drng-Timestamp"
+	// HeaderSignature sha256=<hex HMAC-SHA256 of timestamp, a dot and body>
+	HeaderSignature = "X-Drng-Signature"
+)
+
+var webhookDeadLetters = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "consumer",
+	Name:      "webhook_dead_letters_total",
+	Help:      "Rounds that a webhook gave up on after its attempts ran out",
+})
+
+var (
+	// webhookDefaults settings of webhooks opened by name, see SetWebhookDefaults
+	webhookDefaults     WebhookConfig
+	webhookDefaultsLock sync.Mutex
+)
+
+func init() {
+	metrics.Registry.MustRegister(webhookDeadLetters)
+	Register("webhook", func(arg string) (RoundConsumer, error) {
+		webhookDefaultsLock.Lock()
+		conf := webhookDefaults
+		webhookDefaultsLock.Unlock()
+		conf.URL = arg
+		return NewWebhook(conf)
+	})
+}
+
+// SetWebhookDefaults set secret, attempts, backoff and dead-letter file of
+// webhook consumers opened as webhook:<url>, URL of conf is ignored
+func SetWebhookDefaults(conf WebhookConfig) {
+	webhookDefaultsLock.Lock()
+	defer webhookDefaultsLock.Unlock()
+	webhookDefaults = conf
+}
+
+// WebhookConfig webhook configuration
+type WebhookConfig struct {
+	URL string
+	// Secret key that requests are signed with, they are not signed if it's empty
+	Secret []byte
+	// MaxAttempts requests sent per round, DefaultWebhookAttempts if 0
+	MaxAttempts int
+	// Backoff wait after first failed attempt, it doubles after each failure.
+	// DefaultWebhookBackoff if 0
+	Backoff time.Duration
+	// DeadLetter file that rounds given up on are appended to, they are only
+	// logged if it's empty
+	DeadLetter string
+}
+
+// Webhook post each round as JSON, in the format of the HTTP API, to a URL.
+// Failed requests are retried with exponential backoff, a round that still
+// fails is written to the dead-letter file
+type Webhook struct {
+	conf   WebhookConfig
+	client *http.Client
+}
+
+// deadLetter round a webhook gave up on
+type deadLetter struct {
+	Time     time.Time  `json:"time"`
+	URL      string     `json:"url"`
+	Attempts int        `json:"attempts"`
+	Error    string     `json:"error"`
+	Round    *api.Round `json:"round"`
+}
+
+// deadLetterLock serializes writes to dead-letter files, webhooks may share one
+var deadLetterLock sync.Mutex
+
+// NewWebhook create consumer posting rounds to URL of conf
+func NewWebhook(conf WebhookConfig) (*Webhook, error) {
+	if err := ValidateWebhookURL(conf.URL); err != nil {
+		return nil, err
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = DefaultWebhookAttempts
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = DefaultWebhookBackoff
+	}
+	return &Webhook{conf: conf, client: &http.Client{Timeout: webhookTimeout}}, nil
+}
+
+// ValidateWebhookURL check that a webhook URL is an http or https URL
+func ValidateWebhookURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook URL %q is not an http or https URL", endpoint)
+	}
+	return nil
+}
+
+// Sign signature of a webhook request, receivers compute it with their copy
+// of secret and compare it to HeaderSignature
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// OnRound post round until it's accepted or attempts run out
+func (w *Webhook) OnRound(result beacon.RoundResult) error {
+	round := api.NewRound(&result)
+	body, err := json.Marshal(round)
+	if err != nil {
+		return err
+	}
+	backoff := w.conf.Backoff
+	attempt := 1
+	for ; ; attempt++ {
+		var retry bool
+		if retry, err = w.post(result.Round, body); err == nil {
+			return nil
+		}
+		if !retry || attempt == w.conf.MaxAttempts {
+			break
+		}
+		log.Debugf("Webhook attempt %d of round %d failed, retry in %s: %v", attempt, result.Round, backoff, err)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxWebhookBackoff {
+			backoff = maxWebhookBackoff
+		}
+	}
+	w.deadLetter(round, attempt, err)
+	return err
+}
+
+// post send round once, retry tells whether a failure may succeed later
+func (w *Webhook) post(round uint64, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderRound, strconv.FormatUint(round, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if len(w.conf.Secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(w.conf.Secret, timestamp, body))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook responded %s", resp.Status)
+	// Other client errors mean the request itself is rejected, it fails again
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return retry, err
+}
+
+// deadLetter record a round that could not be delivered
+func (w *Webhook) deadLetter(round *api.Round, attempts int, err error) {
+	webhookDeadLetters.Inc()
+	if w.conf.DeadLetter == "" {
+		log.Errorf("Webhook gave up on round %d after %d attempts: %v", round.Round, attempts, err)
+		return
+	}
+	line, _ := json.Marshal(&deadLetter{
+		Time:     time.Now().UTC(),
+		URL:      w.conf.URL,
+		Attempts: attempts,
+		Error:    err.Error(),
+		Round:    round,
+	})
+	deadLetterLock.Lock()
+	defer deadLetterLock.Unlock()
+	file, openErr := os.OpenFile(w.conf.DeadLetter, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if openErr == nil {
+		_, openErr = file.Write(append(line, '\n'))
+		if closeErr := file.Close(); openErr == nil {
+			openErr = closeErr
+		}
+	}
+	if openErr != nil {
+		log.Errorf("Unable to write round %d to dead-letter file: %v", round.Round, openErr)
+		return
+	}
+	log.Warnf("Webhook gave up on round %d after %d attempts, it was written to %s: %v", round.Round, attempts, w.conf.DeadLetter, err)
+}
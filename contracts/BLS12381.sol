@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+pragma solidity ^0.8.4;
+
+/// @title BLS signature verification over BLS12-381 (EIP-2537)
+/// @notice Verifies group signatures of threshold drng beacons with the
+/// BLS12-381 precompiles of EIP-2537. Public keys are points of G2 and
+/// signatures points of G1, both in the encoding of the precompiles where each
+/// coordinate takes 64 bytes. Messages are hashed to G1 with the
+/// BLS12381G1_XMD:SHA-256_SSWU_RO_ suite of RFC 9380, as drng nodes hash them.
+library BLS12381 {
+    // EIP-2537 precompiles, field reduction uses modexp
+    address private constant MODEXP = address(0x05);
+    address private constant G1_ADD = address(0x0b);
+    address private constant PAIRING_CHECK = address(0x0f);
+    address private constant MAP_FP_TO_G1 = address(0x10);
+
+    // Field modulus, the high word holds its top 16 bytes
+    uint256 private constant P_HI = 0x1a0111ea397fe69a4b1ba7b6434bacd7;
+    uint256 private constant P_LO = 0x64774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab;
+    // (P - 1) / 2, compressed points flag y coordinates above it
+    uint256 private constant HALF_P_HI = 0x0d0088f51cbff34d258dd3db21a5d66b;
+    uint256 private constant HALF_P_LO = 0xb23ba5c279c2895fb39869507b587b120f55ffff58a9ffffdcff7fffffffd555;
+
+    // Negated generator of G2
+    uint256 private constant G2_X0_HI = 0x024aa2b2f08f0a91260805272dc51051;
+    uint256 private constant G2_X0_LO = 0xc6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8;
+    uint256 private constant G2_X1_HI = 0x13e02b6052719f607dacd3a088274f65;
+    uint256 private constant G2_X1_LO = 0x596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e;
+    uint256 private constant NEG_G2_Y0_HI = 0x0d1b3cc2c7027888be51d9ef691d77bc;
+    uint256 private constant NEG_G2_Y0_LO = 0xb679afda66c73f17f9ee3837a55024f78c71363275a75d75d86bab79f74782aa;
+    uint256 private constant NEG_G2_Y1_HI = 0x13fa4d4a0ad8b1ce186ed5061789213d;
+    uint256 private constant NEG_G2_Y1_LO = 0x993923066dddaf1040bc3ff59f825c78df74f2d75467e25e0f55f8a00fa030ed;
+
+    // Domain separation tag of signatures
+    string private constant DST = "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_";
+
+    /// @notice Check signature of message by public key, e(H(m), publicKey)
+    /// must equal e(signature, g2). The pairing precompile checks that both
+    /// points are on their curves and in the prime order subgroup
+    /// @param publicKey point of G2, 256 bytes
+    /// @param message signed message
+    /// @param signature point of G1, 128 bytes
+    function verify(bytes memory publicKey, bytes memory message, bytes memory signature) internal view returns (bool) {
+        if (publicKey.length != 256 || signature.length != 128 || isZero(publicKey) || isZero(signature)) {
+            return false;
+        }
+        (bool ok, bytes memory hashed) = hashToG1(message);
+        if (!ok) {
+            return false;
+        }
+        bytes memory input = abi.encodePacked(
+            hashed,
+            publicKey,
+            signature,
+            abi.encodePacked(G2_X0_HI, G2_X0_LO, G2_X1_HI, G2_X1_LO),
+            abi.encodePacked(NEG_G2_Y0_HI, NEG_G2_Y0_LO, NEG_G2_Y1_HI, NEG_G2_Y1_LO)
+        );
+        bytes memory out;
+        (ok, out) = PAIRING_CHECK.staticcall(input);
+        return ok && out.length == 32 && abi.decode(out, (uint256)) == 1;
+    }
+
+    /// @notice Compressed encoding of a point of G1, the 48 bytes drng nodes
+    /// publish as signatures: x with the compression flag, and the sign flag
+    /// when y is above (P - 1) / 2. Point must not be the identity
+    /// @param point point of G1, 128 bytes
+    function compress(bytes memory point) internal pure returns (bytes memory) {
+        require(point.length == 128, "G1 point must be 128 bytes");
+        uint256 xHi;
+        uint256 xLo;
+        uint256 yHi;
+        uint256 yLo;
+        assembly {
+            xHi := mload(add(point, 32))
+            xLo := mload(add(point, 64))
+            yHi := mload(add(point, 96))
+            yLo := mload(add(point, 128))
+        }
+        uint256 flags = 0x80;
+        if (yHi > HALF_P_HI || (yHi == HALF_P_HI && yLo > HALF_P_LO)) {
+            flags |= 0x20;
+        }
+        return abi.encodePacked(uint128(xHi | (flags << 120)), xLo);
+    }
+
+    /// @notice Map message to G1 as RFC 9380 hash_to_curve does, the map
+    /// precompile clears the cofactor of each point before they are added
+    function hashToG1(bytes memory message) internal view returns (bool, bytes memory) {
+        bytes memory uniform = expandMessage(message);
+        (bool ok0, bytes memory q0) = mapToG1(uniform, 0);
+        (bool ok1, bytes memory q1) = mapToG1(uniform, 64);
+        if (!ok0 || !ok1) {
+            return (false, "");
+        }
+        (bool ok, bytes memory sum) = G1_ADD.staticcall(abi.encodePacked(q0, q1));
+        return (ok && sum.length == 128, sum);
+    }
+
+    /// expand_message_xmd of RFC 9380 with SHA-256, 128 bytes that make two
+    /// field elements
+    function expandMessage(bytes memory message) private pure returns (bytes memory) {
+        bytes memory dst = abi.encodePacked(DST, uint8(bytes(DST).length));
+        bytes32 b0 = sha256(abi.encodePacked(new bytes(64), message, uint16(128), uint8(0), dst));
+        bytes32 b1 = sha256(abi.encodePacked(b0, uint8(1), dst));
+        bytes32 b2 = sha256(abi.encodePacked(b0 ^ b1, uint8(2), dst));
+        bytes32 b3 = sha256(abi.encodePacked(b0 ^ b2, uint8(3), dst));
+        bytes32 b4 = sha256(abi.encodePacked(b0 ^ b3, uint8(4), dst));
+        return abi.encodePacked(b1, b2, b3, b4);
+    }
+
+    /// mapToG1 reduce 64 bytes of uniform at offset modulo P and map the
+    /// field element to G1
+    function mapToG1(bytes memory uniform, uint256 offset) private view returns (bool, bytes memory) {
+        bytes32 hi;
+        bytes32 lo;
+        assembly {
+            hi := mload(add(add(uniform, 32), offset))
+            lo := mload(add(add(uniform, 64), offset))
+        }
+        // modexp of base ^ 1 with 64 bytes base, 1 byte exponent and 48 bytes modulus
+        (bool ok, bytes memory element) = MODEXP.staticcall(
+            abi.encodePacked(uint256(64), uint256(1), uint256(48), hi, lo, uint8(1), uint128(P_HI), P_LO)
+        );
+        if (!ok || element.length != 48) {
+            return (false, "");
+        }
+        (ok, element) = MAP_FP_TO_G1.staticcall(abi.encodePacked(bytes16(0), element));
+        return (ok && element.length == 128, element);
+    }
+
+    /// isZero whether every byte is zero, the identity is encoded as zeros
+    function isZero(bytes memory b) internal pure returns (bool) {
+        for (uint256 i = 0; i < b.length; i++) {
+            if (b[i] != 0) {
+                return false;
+            }
+        }
+        return true;
+    }
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+pragma solidity ^0.8.4;
+
+/// @title Ed25519 signature verification (RFC 8032)
+/// @notice Verifies signatures of single-node drng beacons, whose rounds are
+/// signed with the Ed25519 identity key of the node. Field inversion and
+/// square roots use the modexp precompile, everything else is plain mulmod.
+library Ed25519 {
+    // Field modulus 2^255 - 19
+    uint256 internal constant P = 0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed;
+    // Order of base point 2^252 + 27742317777372353535851937790883648493
+    uint256 internal constant L = 0x1000000000000000000000000000000014def9dea2f79cd65812631a5cf5d3ed;
+    // Curve constant -121665 / 121666
+    uint256 internal constant D = 0x52036cee2b6ffe738cc740797779e89800700a4d4141d8ab75eb4dca135978a3;
+    // 2 * D
+    uint256 internal constant D2 = 0x2406d9dc56dffce7198e80f2eef3d13000e0149a8283b156ebd69b9426b2f159;
+    // Square root of -1
+    uint256 internal constant SQRT_M1 = 0x2b8324804fc1df0b2b4d00993dfbd7a72f431806ad2fe478c4ee1b274a0ea0b0;
+    // 2^256 mod L, reduces the high half of a 512 bits hash
+    uint256 internal constant R256 = 0x0ffffffffffffffffffffffffffffffec6ef5bf4737dcf70d6ec31748d98951d;
+    // Base point
+    uint256 internal constant BX = 0x216936d3cd6e53fec0a4e231fdd6dc5c692cc7609525a7b2c9562d608f25d51a;
+    uint256 internal constant BY = 0x6666666666666666666666666666666666666666666666666666666666666658;
+
+    /// Point in extended coordinates, x = X / Z, y = Y / Z and x * y = T / Z
+    struct Point {
+        uint256 x;
+        uint256 y;
+        uint256 z;
+        uint256 t;
+    }
+
+    /// @notice Check signature of message by public key
+    /// @param publicKey encoded public key
+    /// @param message signed message
+    /// @param signature encoded R followed by little endian S
+    function verify(bytes32 publicKey, bytes memory message, bytes memory signature) internal view returns (bool) {
+        if (signature.length != 64) {
+            return false;
+        }
+        bytes32 r;
+        bytes32 sBytes;
+        assembly {
+            r := mload(add(signature, 32))
+            sBytes := mload(add(signature, 64))
+        }
+        uint256 s = reverse(uint256(sBytes));
+        if (s >= L) {
+            return false;
+        }
+        (bool ok, uint256 ax, uint256 ay) = decompress(publicKey);
+        if (!ok) {
+            return false;
+        }
+        // h = SHA-512(R || A || M) as a little endian integer mod L
+        bytes memory digest = Sha512.hash(abi.encodePacked(r, publicKey, message));
+        uint256 lo;
+        uint256 hi;
+        assembly {
+            lo := mload(add(digest, 32))
+            hi := mload(add(digest, 64))
+        }
+        uint256 h = addmod(mulmod(reverse(hi), R256, L), reverse(lo) % L, L);
+
+        // [S]B - [h]A must equal R
+        Point memory result = combine(s, h, ax, ay);
+        uint256 zInv = invert(result.z);
+        uint256 x = mulmod(result.x, zInv, P);
+        uint256 y = mulmod(result.y, zInv, P);
+        return reverse(y | ((x & 1) << 255)) == uint256(r);
+    }
+
+    /// @dev [s]B + [h](-A) by simultaneous double-and-add over 253 bits
+    function combine(uint256 s, uint256 h, uint256 ax, uint256 ay) private pure returns (Point memory acc) {
+        Point memory b = Point(BX, BY, 1, mulmod(BX, BY, P));
+        uint256 negAx = P - ax;
+        Point memory negA = Point(negAx, ay, 1, mulmod(negAx, ay, P));
+        Point memory both = addPoints(b, negA);
+        acc = Point(0, 1, 1, 0);
+        for (uint256 i = 253; i > 0; i--) {
+            acc = doublePoint(acc);
+            uint256 bits = (((s >> (i - 1)) & 1) << 1) | ((h >> (i - 1)) & 1);
+            if (bits == 3) {
+                acc = addPoints(acc, both);
+            } else if (bits == 2) {
+                acc = addPoints(acc, b);
+            } else if (bits == 1) {
+                acc = addPoints(acc, negA);
+            }
+        }
+    }
+
+    /// @dev Unified addition of twisted Edwards curve with a = -1 (add-2008-hwcd-3)
+    function addPoints(Point memory p1, Point memory p2) private pure returns (Point memory) {
+        uint256 a = mulmod(addmod(p1.y, P - p1.x, P), addmod(p2.y, P - p2.x, P), P);
+        uint256 b = mulmod(addmod(p1.y, p1.x, P), addmod(p2.y, p2.x, P), P);
+        uint256 c = mulmod(mulmod(p1.t, D2, P), p2.t, P);
+        uint256 d = mulmod(mulmod(p1.z, 2, P), p2.z, P);
+        uint256 e = addmod(b, P - a, P);
+        uint256 f = addmod(d, P - c, P);
+        uint256 g = addmod(d, c, P);
+        uint256 hh = addmod(b, a, P);
+        return Point(mulmod(e, f, P), mulmod(g, hh, P), mulmod(f, g, P), mulmod(e, hh, P));
+    }
+
+    /// @dev Doubling of twisted Edwards curve with a = -1 (dbl-2008-hwcd)
+    function doublePoint(Point memory p1) private pure returns (Point memory) {
+        uint256 a = mulmod(p1.x, p1.x, P);
+        uint256 b = mulmod(p1.y, p1.y, P);
+        uint256 c = mulmod(2, mulmod(p1.z, p1.z, P), P);
+        uint256 xy = addmod(p1.x, p1.y, P);
+        uint256 hh = addmod(a, b, P);
+        uint256 e = addmod(hh, P - mulmod(xy, xy, P), P);
+        uint256 g = addmod(a, P - b, P);
+        uint256 f = addmod(c, g, P);
+        return Point(mulmod(e, f, P), mulmod(g, hh, P), mulmod(f, g, P), mulmod(e, hh, P));
+    }
+
+    /// @dev Decode a point, ok is false if it's not on the curve
+    function decompress(bytes32 encoded) private view returns (bool ok, uint256 x, uint256 y) {
+        uint256 v = reverse(uint256(encoded));
+        uint256 sign = v >> 255;
+        y = v & ((1 << 255) - 1);
+        if (y >= P) {
+            return (false, 0, 0);
+        }
+        // x^2 = (y^2 - 1) / (d y^2 + 1)
+        uint256 yy = mulmod(y, y, P);
+        uint256 u = addmod(yy, P - 1, P);
+        uint256 w = addmod(mulmod(D, yy, P), 1, P);
+        // x = u w^3 (u w^7)^((p - 5) / 8)
+        uint256 w3 = mulmod(mulmod(w, w, P), w, P);
+        uint256 uw7 = mulmod(mulmod(u, mulmod(w3, w3, P), P), w, P);
+        x = mulmod(mulmod(u, w3, P), modexp(uw7, (P - 5) / 8), P);
+        uint256 check = mulmod(w, mulmod(x, x, P), P);
+        if (check != u) {
+            if (check != P - u) {
+                return (false, 0, 0);
+            }
+            x = mulmod(x, SQRT_M1, P);
+        }
+        if (x == 0 && sign == 1) {
+            return (false, 0, 0);
+        }
+        if (x & 1 != sign) {
+            x = P - x;
+        }
+        return (true, x, y);
+    }
+
+    function invert(uint256 v) private view returns (uint256) {
+        return modexp(v, P - 2);
+    }
+
+    /// @dev base^exponent mod P with the modexp precompile
+    function modexp(uint256 base, uint256 exponent) private view returns (uint256 result) {
+        uint256 modulus = P;
+        assembly {
+            let input := mload(0x40)
+            mstore(input, 32)
+            mstore(add(input, 32), 32)
+            mstore(add(input, 64), 32)
+            mstore(add(input, 96), base)
+            mstore(add(input, 128), exponent)
+            mstore(add(input, 160), modulus)
+            if iszero(staticcall(gas(), 0x05, input, 192, input, 32)) {
+                revert(0, 0)
+            }
+            result := mload(input)
+        }
+    }
+
+    /// @dev Reverse byte order, Ed25519 encodes integers little endian
+    function reverse(uint256 v) private pure returns (uint256) {
+        v =
+            ((v & 0xff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00) >> 8) |
+            ((v & 0x00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff00ff) << 8);
+        v =
+            ((v & 0xffff0000ffff0000ffff0000ffff0000ffff0000ffff0000ffff0000ffff0000) >> 16) |
+            ((v & 0x0000ffff0000ffff0000ffff0000ffff0000ffff0000ffff0000ffff0000ffff) << 16);
+        v =
+            ((v & 0xffffffff00000000ffffffff00000000ffffffff00000000ffffffff00000000) >> 32) |
+            ((v & 0x00000000ffffffff00000000ffffffff00000000ffffffff00000000ffffffff) << 32);
+        v =
+            ((v & 0xffffffffffffffff0000000000000000ffffffffffffffff0000000000000000) >> 64) |
+            ((v & 0x0000000000000000ffffffffffffffff0000000000000000ffffffffffffffff) << 64);
+        return (v >> 128) | (v << 128);
+    }
+}
+
+/// @title SHA-512 (FIPS 180-4), which Ed25519 hashes with and the EVM has no
+/// precompile for
+library Sha512 {
+    function hash(bytes memory data) internal pure returns (bytes memory digest) {
+        uint64[8] memory state = [
+            uint64(0x6a09e667f3bcc908),
+            0xbb67ae8584caa73b,
+            0x3c6ef372fe94f82b,
+            0xa54ff53a5f1d36f1,
+            0x510e527fade682d1,
+            0x9b05688c2b3e6c1f,
+            0x1f83d9abfb41bd6b,
+            0x5be0cd19137e2179
+        ];
+        // Padding: 0x80, zeros and the 128 bits length in bits
+        uint256 padded = ((data.length + 17 + 127) / 128) * 128;
+        bytes memory message = new bytes(padded);
+        for (uint256 i = 0; i < data.length; i++) {
+            message[i] = data[i];
+        }
+        message[data.length] = 0x80;
+        uint256 bitLength = data.length * 8;
+        for (uint256 i = 0; i < 16; i++) {
+            message[padded - 1 - i] = bytes1(uint8(bitLength >> (8 * i)));
+        }
+        uint64[80] memory k = constants();
+        uint64[80] memory w;
+        for (uint256 offset = 0; offset < padded; offset += 128) {
+            compress(state, k, w, message, offset);
+        }
+        return abi.encodePacked(state[0], state[1], state[2], state[3], state[4], state[5], state[6], state[7]);
+    }
+
+    function compress(
+        uint64[8] memory state,
+        uint64[80] memory k,
+        uint64[80] memory w,
+        bytes memory message,
+        uint256 offset
+    ) private pure {
+        unchecked {
+            for (uint256 i = 0; i < 16; i++) {
+                uint64 word;
+                assembly {
+                    word := shr(192, mload(add(add(message, 32), add(offset, mul(i, 8)))))
+                }
+                w[i] = word;
+            }
+            for (uint256 i = 16; i < 80; i++) {
+                uint64 w15 = w[i - 15];
+                uint64 w2 = w[i - 2];
+                uint64 s0 = rotr(w15, 1) ^ rotr(w15, 8) ^ (w15 >> 7);
+                uint64 s1 = rotr(w2, 19) ^ rotr(w2, 61) ^ (w2 >> 6);
+                w[i] = w[i - 16] + s0 + w[i - 7] + s1;
+            }
+            uint64[8] memory v = state;
+            uint64 a = v[0];
+            uint64 b = v[1];
+            uint64 c = v[2];
+            uint64 d = v[3];
+            uint64 e = v[4];
+            uint64 f = v[5];
+            uint64 g = v[6];
+            uint64 h = v[7];
+            for (uint256 i = 0; i < 80; i++) {
+                uint64 t1 = h + (rotr(e, 14) ^ rotr(e, 18) ^ rotr(e, 41)) + ((e & f) ^ (~e & g)) + k[i] + w[i];
+                uint64 t2 = (rotr(a, 28) ^ rotr(a, 34) ^ rotr(a, 39)) + ((a & b) ^ (a & c) ^ (b & c));
+                h = g;
+                g = f;
+                f = e;
+                e = d + t1;
+                d = c;
+                c = b;
+                b = a;
+                a = t1 + t2;
+            }
+            state[0] += a;
+            state[1] += b;
+            state[2] += c;
+            state[3] += d;
+            state[4] += e;
+            state[5] += f;
+            state[6] += g;
+            state[7] += h;
+        }
+    }
+
+    function rotr(uint64 x, uint256 n) private pure returns (uint64) {
+        return (x >> n) | (x << (64 - n));
+    }
+
+    function constants() private pure returns (uint64[80] memory) {
+        return [
+            uint64(0x428a2f98d728ae22), 0x7137449123ef65cd, 0xb5c0fbcfec4d3b2f, 0xe9b5dba58189dbbc,
+            0x3956c25bf348b538, 0x59f111f1b605d019, 0x923f82a4af194f9b, 0xab1c5ed5da6d8118,
+            0xd807aa98a3030242, 0x12835b0145706fbe, 0x243185be4ee4b28c, 0x550c7dc3d5ffb4e2,
+            0x72be5d74f27b896f, 0x80deb1fe3b1696b1, 0x9bdc06a725c71235, 0xc19bf174cf692694,
+            0xe49b69c19ef14ad2, 0xefbe4786384f25e3, 0x0fc19dc68b8cd5b5, 0x240ca1cc77ac9c65,
+            0x2de92c6f592b0275, 0x4a7484aa6ea6e483, 0x5cb0a9dcbd41fbd4, 0x76f988da831153b5,
+            0x983e5152ee66dfab, 0xa831c66d2db43210, 0xb00327c898fb213f, 0xbf597fc7beef0ee4,
+            0xc6e00bf33da88fc2, 0xd5a79147930aa725, 0x06ca6351e003826f, 0x142929670a0e6e70,
+            0x27b70a8546d22ffc, 0x2e1b21385c26c926, 0x4d2c6dfc5ac42aed, 0x53380d139d95b3df,
+            0x650a73548baf63de, 0x766a0abb3c77b2a8, 0x81c2c92e47edaee6, 0x92722c851482353b,
+            0xa2bfe8a14cf10364, 0xa81a664bbc423001, 0xc24b8b70d0f89791, 0xc76c51a30654be30,
+            0xd192e819d6ef5218, 0xd69906245565a910, 0xf40e35855771202a, 0x106aa07032bbd1b8,
+            0x19a4c116b8d2d0c8, 0x1e376c085141ab53, 0x2748774cdf8eeb99, 0x34b0bcb5e19b48a8,
+            0x391c0cb3c5c95a63, 0x4ed8aa4ae3418acb, 0x5b9cca4f7763e373, 0x682e6ff3d6b2b8a3,
+            0x748f82ee5defb2fc, 0x78a5636f43172f60, 0x84c87814a1f0ab72, 0x8cc702081a6439ec,
+            0x90befffa23631e28, 0xa4506cebde82bde9, 0xbef9a3f7b2c67915, 0xc67178f2e372532b,
+            0xca273eceea26619c, 0xd186b8c721c0c207, 0xeada7dd6cde0eb1e, 0xf57d4f7fee6ed178,
+            0x06f067aa72176fba, 0x0a637dc5a2c898a6, 0x113f9804bef90dae, 0x1b710b35131c471b,
+            0x28db77f523047d84, 0x32caab7b40c72493, 0x3c9ebe0a15c9bebc, 0x431d67c49c100d4c,
+            0x4cc5d4becb3e42b6, 0x597f299cfc657e2a, 0x5fcb6fab3ad6faec, 0x6c44198c4a475817
+        ];
+    }
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+pragma solidity ^0.8.4;
+
+import "./BLS12381.sol";
+import "./Ed25519.sol";
+
+/// @title Verified drng beacon rounds
+/// @notice Keeps rounds of one beacon chain after checking them against the
+/// chain's public key, so consumers rely on the beacon's signature rather than
+/// on whoever submits rounds. Anyone may submit, the drng relayer calls
+/// submitRound with every finalized round. BLS signatures are submitted as
+/// uncompressed points of G1 in the EIP-2537 encoding, the contract compresses
+/// them to the 48 bytes that randomness and chaining cover.
+contract OrochiBeacon {
+    uint8 public constant SCHEME_BLS = 1;
+    uint8 public constant SCHEME_ED25519 = 2;
+
+    /// Signature scheme of rounds, BLS for threshold groups and Ed25519 for
+    /// single-node beacons
+    uint8 public immutable scheme;
+    /// Group public key as a point of G2 in the EIP-2537 encoding, or Ed25519
+    /// key of the node
+    bytes public publicKey;
+    /// Whether each round signs the signature of the round before it
+    bool public immutable chained;
+    /// Previous signature of round 1 of a chained beacon
+    bytes32 public immutable genesisSeed;
+    /// Public key of Ed25519 scheme as a word
+    bytes32 private immutable ed25519Key;
+
+    /// Latest round submitted
+    uint64 public latestRound;
+    /// Randomness of submitted rounds, zero for rounds not submitted
+    mapping(uint64 => bytes32) public randomness;
+    /// Hash of signature of submitted rounds, later rounds must chain to it
+    mapping(uint64 => bytes32) public signatureHash;
+
+    event RoundSubmitted(uint64 indexed round, bytes32 randomness);
+
+    constructor(
+        uint8 scheme_,
+        bytes memory publicKey_,
+        bool chained_,
+        uint64 genesisTime
+    ) {
+        if (scheme_ == SCHEME_ED25519) {
+            require(publicKey_.length == 32, "Ed25519 public key must be 32 bytes");
+        } else {
+            require(scheme_ == SCHEME_BLS, "unknown signature scheme");
+            require(publicKey_.length == 256, "BLS public key must be 256 bytes");
+            require(!BLS12381.isZero(publicKey_), "BLS public key must not be the identity");
+        }
+        bytes32 key;
+        if (scheme_ == SCHEME_ED25519) {
+            assembly {
+                key := mload(add(publicKey_, 32))
+            }
+        }
+        scheme = scheme_;
+        publicKey = publicKey_;
+        chained = chained_;
+        genesisSeed = sha256(abi.encodePacked(genesisTime));
+        ed25519Key = key;
+    }
+
+    /// @notice Store a round once it's verified, a round is only stored once
+    function submitRound(
+        uint64 round,
+        bytes32 randomness_,
+        bytes calldata signature,
+        bytes calldata previousSignature
+    ) external {
+        require(randomness[round] == 0, "round is already submitted");
+        require(verifyRound(round, randomness_, signature, previousSignature), "invalid round");
+        randomness[round] = randomness_;
+        signatureHash[round] = keccak256(encodedSignature(signature));
+        if (round > latestRound) {
+            latestRound = round;
+        }
+        emit RoundSubmitted(round, randomness_);
+    }
+
+    /// @notice Check a round the way drng nodes do: randomness is the SHA-256
+    /// of the encoded signature, and the signature covers SHA-256 of previous
+    /// signature and round number. A chained round must chain to the stored
+    /// round before it, when that round was submitted
+    function verifyRound(
+        uint64 round,
+        bytes32 randomness_,
+        bytes calldata signature,
+        bytes calldata previousSignature
+    ) public view returns (bool) {
+        if (round == 0 || (scheme == SCHEME_BLS && signature.length != 128)) {
+            return false;
+        }
+        if (sha256(encodedSignature(signature)) != randomness_) {
+            return false;
+        }
+        if (!chained) {
+            if (previousSignature.length != 0) {
+                return false;
+            }
+        } else if (round == 1) {
+            if (keccak256(previousSignature) != keccak256(abi.encodePacked(genesisSeed))) {
+                return false;
+            }
+        } else if (previousSignature.length != 0) {
+            bytes32 previous = signatureHash[round - 1];
+            if (previous != 0 && previous != keccak256(previousSignature)) {
+                return false;
+            }
+        }
+        bytes32 message = sha256(abi.encodePacked(previousSignature, round));
+        if (scheme == SCHEME_ED25519) {
+            return Ed25519.verify(ed25519Key, abi.encodePacked(message), signature);
+        }
+        return BLS12381.verify(publicKey, abi.encodePacked(message), signature);
+    }
+
+    /// @notice Signature as drng nodes publish it, BLS signatures are
+    /// compressed
+    function encodedSignature(bytes calldata signature) internal view returns (bytes memory) {
+        if (scheme == SCHEME_BLS) {
+            return BLS12381.compress(signature);
+        }
+        return signature;
+    }
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+pragma solidity ^0.8.4;
+
+import "./BLS12381.sol";
+
+/// @notice Contract that is called back with randomness it requested
+interface IRandomnessConsumer {
+    function onRandomness(bytes32 requestId, bytes32 randomness) external;
+}
+
+/// @title On-demand randomness of a drng group
+/// @notice Contracts request randomness of a seed, drng members watch
+/// RandomnessRequested and sign the request with their shares, then a relayer
+/// fulfills it with the group signature. Randomness is checked against the
+/// group key, so requesters rely on the group rather than on the relayer.
+/// Signatures are submitted as uncompressed points of G1 in the EIP-2537
+/// encoding.
+contract OrochiVRF {
+    /// Gas given to the callback of a requester
+    uint256 public constant CALLBACK_GAS_LIMIT = 200000;
+
+    /// Group public key as a point of G2 in the EIP-2537 encoding
+    bytes public groupKey;
+
+    /// Requests made, it makes IDs of requests with the same seed unique
+    uint256 public nonce;
+    /// Account that made each request
+    mapping(bytes32 => address) public requester;
+    /// Randomness of fulfilled requests, zero while a request is pending
+    mapping(bytes32 => bytes32) public randomness;
+
+    event RandomnessRequested(bytes32 indexed requestId, address indexed requester, bytes seed);
+    event RandomnessFulfilled(bytes32 indexed requestId, bytes32 randomness);
+
+    constructor(bytes memory groupKey_) {
+        require(groupKey_.length == 256, "BLS public key must be 256 bytes");
+        require(!BLS12381.isZero(groupKey_), "BLS public key must not be the identity");
+        groupKey = groupKey_;
+    }
+
+    /// @notice Request randomness, group signs the returned request ID which
+    /// binds seed to requester and this contract
+    function requestRandomness(bytes calldata seed) external returns (bytes32 requestId) {
+        requestId = keccak256(abi.encodePacked(address(this), msg.sender, nonce, seed));
+        nonce++;
+        requester[requestId] = msg.sender;
+        emit RandomnessRequested(requestId, msg.sender, seed);
+    }
+
+    /// @notice Store randomness of a request once it's verified and call back
+    /// requester if it's a contract. A failing callback does not revert
+    /// fulfillment
+    function fulfillRandomness(bytes32 requestId, bytes32 randomness_, bytes calldata signature) external {
+        address consumer = requester[requestId];
+        require(consumer != address(0), "unknown request");
+        require(randomness[requestId] == 0, "request is already fulfilled");
+        require(verifyRandomness(requestId, randomness_, signature), "invalid randomness");
+        randomness[requestId] = randomness_;
+        emit RandomnessFulfilled(requestId, randomness_);
+        if (consumer.code.length > 0) {
+            try IRandomnessConsumer(consumer).onRandomness{gas: CALLBACK_GAS_LIMIT}(requestId, randomness_) {} catch {}
+        }
+    }
+
+    /// @notice Check randomness of a request the way drng nodes do: randomness
+    /// is the SHA-256 of the compressed signature, and the signature covers
+    /// SHA-256 of "OROCHI-VRF" and request ID
+    function verifyRandomness(
+        bytes32 requestId,
+        bytes32 randomness_,
+        bytes calldata signature
+    ) public view returns (bool) {
+        if (signature.length != 128 || sha256(BLS12381.compress(signature)) != randomness_) {
+            return false;
+        }
+        bytes32 message = sha256(abi.encodePacked("OROCHI-VRF", requestId));
+        return BLS12381.verify(groupKey, abi.encodePacked(message), signature);
+    }
+}
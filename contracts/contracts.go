@@ -0,0 +1,7 @@
+// Package contracts holds the Solidity contracts that verify beacon rounds and
+// on-demand randomness on EVM chains, and Go bindings of them generated by
+// drng gen-bindings, which writes the bindings that abigen of go-ethereum does
+package contracts
+
+//go:generate go run ../cmd/drng gen-bindings -abi OrochiBeacon.abi.json -out orochibeacon.go
+//go:generate go run ../cmd/drng gen-bindings -abi OrochiVRF.abi.json -out orochivrf.go
@@ -0,0 +1,641 @@
+package dkg
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Joint-Feldman distributed key generation, every participant deals a
+// random secret to all others and the group secret is the sum of the
+// secrets dealt by qualified dealers. Shares are encrypted to recipients
+// with ephemeral X25519 keys, signed envelopes authenticate senders.
+
+// Topic pubsub topic used by DKG
+const Topic = "dkg"
+
+// validatorDKG key of DKG validator
+const validatorDKG = "dkg"
+
+// Phase of DKG protocol
+type Phase string
+
+const (
+	// PhaseAnnounce participants announce their encryption keys
+	PhaseAnnounce Phase = "announce"
+	// PhaseDeal dealers distribute commitments and encrypted shares
+	PhaseDeal Phase = "deal"
+	// PhaseComplaint recipients complain about invalid shares
+	PhaseComplaint Phase = "complaint"
+	// PhaseJustification dealers reveal shares that were complained about
+	PhaseJustification Phase = "justification"
+	// PhaseFinished protocol finished
+	PhaseFinished Phase = "finished"
+)
+
+// phaseOrder position of each phase in protocol
+var phaseOrder = map[Phase]int{
+	PhaseAnnounce:      1,
+	PhaseDeal:          2,
+	PhaseComplaint:     3,
+	PhaseJustification: 4,
+	PhaseFinished:      5,
+}
+
+// Config DKG ceremony configuration
+type Config struct {
+	// Participants of ceremony, index of a participant is its position plus one
+	Participants []peer.ID
+	Threshold    int
+	PhaseTimeout time.Duration
+}
+
+// Result output of DKG for this node, Share is nil if this node left group
+// during resharing
+type Result struct {
+	Share     *keypair.PriShare
+	PubPoly   *keypair.PubPoly
+	Qualified []uint32
+}
+
+// message payload of DKG envelopes, envelope type is the phase of message
+type message struct {
+	Session string          `json:"session"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type announcePayload struct {
+	EncryptionKey []byte `json:"encryptionKey"`
+}
+
+type dealPayload struct {
+	Commits []byte            `json:"commits"`
+	Shares  map[uint32][]byte `json:"shares"`
+}
+
+type complaintPayload struct {
+	Dealer uint32 `json:"dealer"`
+}
+
+type justificationPayload struct {
+	Shares map[uint32][]byte `json:"shares"`
+}
+
+// Protocol state of a DKG ceremony
+type Protocol struct {
+	conf    Config
+	session string
+	topic   string
+	// index of this node in participants, 0 if it only deals
+	index uint32
+	// dealers deal shares to participants, they are the participants in DKG
+	// and members of current group in resharing
+	dealers     []peer.ID
+	dealerIndex uint32
+	// previous public polynomial of resharing, nil in DKG
+	previous       *keypair.PubPoly
+	net            *network.Network
+	encryptionPub  *[32]byte
+	encryptionPriv *[32]byte
+	poly           *keypair.PriPoly
+	phase          Phase
+	keys           map[peer.ID]*[32]byte
+	commits        map[uint32]*keypair.PubPoly
+	shares         map[uint32]*big.Int
+	complaints     map[uint32]map[uint32]bool
+	justifications map[uint32]map[uint32]*big.Int
+	disqualified   map[uint32]bool
+	pending        map[Phase][]*network.Envelope
+	seen           seenPhases
+	// mutex guards state of ceremony against Status
+	mutex sync.Mutex
+	// OnPhase called whenever protocol enters a new phase
+	OnPhase func(phase Phase)
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("dkg")
+	// Deals and justifications carry a share for every participant
+	network.SetPayloadLimit(string(PhaseDeal), 512<<10)
+	network.SetPayloadLimit(string(PhaseJustification), 512<<10)
+}
+
+// New prepare a DKG ceremony, this node must be one of participants
+func New(net *network.Network, conf Config) (*Protocol, error) {
+	if indexOf(conf.Participants, net.NodeID) == 0 {
+		return nil, errors.New("this node is not a participant")
+	}
+	return newProtocol(net, conf, Session(conf.Participants, conf.Threshold), Topic, conf.Participants, nil)
+}
+
+// newProtocol prepare a ceremony where dealers share secret to participants, a
+// random secret is dealt if secret is nil
+func newProtocol(net *network.Network, conf Config, session string, topic string, dealers []peer.ID, secret *big.Int) (*Protocol, error) {
+	if conf.Threshold < 1 || conf.Threshold > len(conf.Participants) {
+		return nil, errors.New("threshold must be between 1 and number of participants")
+	}
+	if conf.PhaseTimeout <= 0 {
+		return nil, errors.New("phase timeout must be positive")
+	}
+	index := indexOf(conf.Participants, net.NodeID)
+	dealerIndex := indexOf(dealers, net.NodeID)
+	if index == 0 && dealerIndex == 0 {
+		return nil, errors.New("this node is neither a dealer nor a participant")
+	}
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var poly *keypair.PriPoly
+	if dealerIndex != 0 {
+		if poly, err = keypair.NewPriPoly(conf.Threshold, secret); err != nil {
+			return nil, err
+		}
+	}
+	return &Protocol{
+		conf:           conf,
+		session:        session,
+		topic:          topic,
+		index:          index,
+		dealers:        dealers,
+		dealerIndex:    dealerIndex,
+		net:            net,
+		encryptionPub:  pub,
+		encryptionPriv: priv,
+		poly:           poly,
+		keys:           make(map[peer.ID]*[32]byte),
+		commits:        make(map[uint32]*keypair.PubPoly),
+		shares:         make(map[uint32]*big.Int),
+		complaints:     make(map[uint32]map[uint32]bool),
+		justifications: make(map[uint32]map[uint32]*big.Int),
+		disqualified:   make(map[uint32]bool),
+		pending:        make(map[Phase][]*network.Envelope),
+		seen:           make(seenPhases),
+	}, nil
+}
+
+// Session identifier of a ceremony derived from its participants and threshold
+func Session(participants []peer.ID, threshold int) string {
+	h := sha256.New()
+	for _, participant := range participants {
+		h.Write([]byte(participant))
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(threshold))
+	h.Write(buf)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Index index of this node in participants, 0 if this node only deals
+func (p *Protocol) Index() uint32 {
+	return p.index
+}
+
+// Phase current phase of ceremony
+func (p *Protocol) Phase() Phase {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.phase
+}
+
+// Run DKG ceremony until it finishes or context is cancelled
+func (p *Protocol) Run(ctx context.Context) (*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	incoming := make(chan *network.Envelope, 64)
+	phases := []Phase{PhaseAnnounce, PhaseDeal, PhaseComplaint, PhaseJustification}
+	defer func() {
+		// Handlers blocked on incoming return once context is cancelled
+		cancel()
+		for _, phase := range phases {
+			p.net.RemoveHandler(p.topic, string(phase))
+		}
+		p.net.Topics().RemoveValidator(p.topic, validatorDKG)
+	}()
+	for _, phase := range phases {
+		err := p.net.Handle(p.topic, string(phase), func(e *network.Envelope) {
+			select {
+			case incoming <- e:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.net.Topics().AddValidator(p.topic, validatorDKG, p.validate); err != nil {
+		return nil, err
+	}
+
+	for _, phase := range phases {
+		p.enter(phase)
+		p.mutex.Lock()
+		err := p.broadcast(phase)
+		for _, msg := range p.pending[phase] {
+			p.handle(msg)
+		}
+		delete(p.pending, phase)
+		p.mutex.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		// Our message is repeated during phase for peers that joined topic late
+		timer := time.NewTimer(p.conf.PhaseTimeout)
+		ticker := time.NewTicker(p.conf.PhaseTimeout / 4)
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				ticker.Stop()
+				return nil, ctx.Err()
+			case e := <-incoming:
+				p.mutex.Lock()
+				p.handle(e)
+				p.mutex.Unlock()
+			case <-ticker.C:
+				p.mutex.Lock()
+				err := p.broadcast(phase)
+				p.mutex.Unlock()
+				if err != nil {
+					log.Warnf("DKG unable to repeat %s message: %v", phase, err)
+				}
+			case <-timer.C:
+				break wait
+			}
+		}
+		ticker.Stop()
+		p.mutex.Lock()
+		p.conclude(phase)
+		p.mutex.Unlock()
+	}
+	p.enter(PhaseFinished)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.result()
+}
+
+func (p *Protocol) enter(phase Phase) {
+	p.mutex.Lock()
+	p.phase = phase
+	p.mutex.Unlock()
+	log.Debugf("DKG session %s enter phase: %s", p.session[:8], phase)
+	if p.OnPhase != nil {
+		p.OnPhase(phase)
+	}
+}
+
+// broadcast our own message of given phase
+func (p *Protocol) broadcast(phase Phase) error {
+	var payload interface{}
+	switch phase {
+	case PhaseAnnounce:
+		payload = announcePayload{EncryptionKey: p.encryptionPub[:]}
+	case PhaseDeal:
+		if p.poly == nil {
+			return nil
+		}
+		deal := dealPayload{Commits: p.poly.Commit().Marshal(), Shares: make(map[uint32][]byte)}
+		for i, participant := range p.conf.Participants {
+			key, ok := p.keys[participant]
+			if !ok {
+				continue
+			}
+			index := uint32(i + 1)
+			var nonce [24]byte
+			if _, err := rand.Read(nonce[:]); err != nil {
+				return err
+			}
+			share := scalarBytes(p.poly.Eval(index).Secret)
+			deal.Shares[index] = box.Seal(nonce[:], share, &nonce, key, p.encryptionPriv)
+		}
+		payload = deal
+	case PhaseComplaint:
+		for dealer := range p.complaints {
+			if p.complaints[dealer][p.index] {
+				if err := p.send(phase, complaintPayload{Dealer: dealer}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case PhaseJustification:
+		if p.poly == nil {
+			return nil
+		}
+		justification := justificationPayload{Shares: make(map[uint32][]byte)}
+		for complainer := range p.complaints[p.dealerIndex] {
+			justification.Shares[complainer] = scalarBytes(p.poly.Eval(complainer).Secret)
+		}
+		if len(justification.Shares) == 0 {
+			return nil
+		}
+		payload = justification
+	default:
+		return nil
+	}
+	return p.send(phase, payload)
+}
+
+func (p *Protocol) send(phase Phase, payload interface{}) error {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(message{Session: p.session, Payload: encodedPayload})
+	if err != nil {
+		return err
+	}
+	return p.net.Send(p.topic, string(phase), 0, data)
+}
+
+// validate drop messages of other ceremonies and reject malformed messages of
+// participants before they are propagated
+func (p *Protocol) validate(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	e := network.EnvelopeOf(msg)
+	if e == nil {
+		return pubsub.ValidationReject
+	}
+	if indexOf(p.conf.Participants, e.Sender) == 0 && indexOf(p.dealers, e.Sender) == 0 {
+		return pubsub.ValidationIgnore
+	}
+	m := new(message)
+	if err := network.DecodePayload(e.Payload, m); err != nil {
+		return pubsub.ValidationReject
+	}
+	if m.Session != p.session {
+		return pubsub.ValidationIgnore
+	}
+	if _, ok := phaseOrder[Phase(e.Type)]; !ok {
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationAccept
+}
+
+// indexOf index of peer in list, 0 if peer is not in list
+func indexOf(list []peer.ID, id peer.ID) uint32 {
+	for i, member := range list {
+		if member == id {
+			return uint32(i + 1)
+		}
+	}
+	return 0
+}
+
+// handle an incoming message, messages of a past or future phase are ignored
+func (p *Protocol) handle(e *network.Envelope) {
+	// Participants announce and complain, dealers deal and justify
+	participant := indexOf(p.conf.Participants, e.Sender)
+	dealer := indexOf(p.dealers, e.Sender)
+	if participant == 0 && dealer == 0 {
+		return
+	}
+	m := new(message)
+	if err := network.DecodePayload(e.Payload, m); err != nil || m.Session != p.session {
+		return
+	}
+	phase := Phase(e.Type)
+	if _, ok := phaseOrder[phase]; ok {
+		p.seen.add(e.Sender, phase)
+	}
+	if phaseOrder[phase] > phaseOrder[p.phase] {
+		p.pending[phase] = append(p.pending[phase], e)
+		return
+	}
+	if phase != p.phase {
+		return
+	}
+	switch phase {
+	case PhaseAnnounce:
+		payload := new(announcePayload)
+		if json.Unmarshal(m.Payload, payload) != nil || len(payload.EncryptionKey) != 32 {
+			return
+		}
+		key := new([32]byte)
+		copy(key[:], payload.EncryptionKey)
+		p.keys[e.Sender] = key
+	case PhaseDeal:
+		payload := new(dealPayload)
+		if dealer == 0 || json.Unmarshal(m.Payload, payload) != nil {
+			return
+		}
+		p.handleDeal(dealer, payload)
+	case PhaseComplaint:
+		payload := new(complaintPayload)
+		if participant == 0 || json.Unmarshal(m.Payload, payload) != nil {
+			return
+		}
+		if _, ok := p.commits[payload.Dealer]; ok {
+			p.complain(payload.Dealer, participant)
+		}
+	case PhaseJustification:
+		payload := new(justificationPayload)
+		if dealer == 0 || json.Unmarshal(m.Payload, payload) != nil {
+			return
+		}
+		for complainer, share := range payload.Shares {
+			if p.justifications[dealer] == nil {
+				p.justifications[dealer] = make(map[uint32]*big.Int)
+			}
+			p.justifications[dealer][complainer] = new(big.Int).SetBytes(share)
+		}
+	}
+}
+
+func (p *Protocol) handleDeal(dealer uint32, deal *dealPayload) {
+	if _, ok := p.shares[dealer]; ok || p.disqualified[dealer] {
+		return
+	}
+	pubPoly, ok := p.commits[dealer]
+	if ok {
+		// Repeated deal must carry the same commitments
+		if string(pubPoly.Marshal()) != string(deal.Commits) {
+			log.Warnf("DKG dealer %d sent conflicting commitments", dealer)
+			p.disqualified[dealer] = true
+			return
+		}
+	} else {
+		var err error
+		pubPoly, err = keypair.UnmarshalPubPoly(deal.Commits)
+		if err != nil || pubPoly.Threshold() != p.conf.Threshold {
+			p.disqualified[dealer] = true
+			return
+		}
+		// Resharing dealer must deal its share of current group
+		if p.previous != nil && !bls12381.NewG2().Equal(pubPoly.Commits[0], p.previous.Eval(dealer)) {
+			log.Warnf("DKG dealer %d did not reshare its share", dealer)
+			p.disqualified[dealer] = true
+			return
+		}
+		p.commits[dealer] = pubPoly
+	}
+	if p.index == 0 {
+		return
+	}
+	encrypted, ok := deal.Shares[p.index]
+	dealerKey := p.keys[p.dealers[dealer-1]]
+	if !ok || dealerKey == nil || len(encrypted) < 24 {
+		p.complain(dealer, p.index)
+		return
+	}
+	var nonce [24]byte
+	copy(nonce[:], encrypted[:24])
+	decrypted, ok := box.Open(nil, encrypted[24:], &nonce, dealerKey, p.encryptionPriv)
+	if !ok {
+		p.complain(dealer, p.index)
+		return
+	}
+	share := new(big.Int).SetBytes(decrypted)
+	if !verifyShare(pubPoly, p.index, share) {
+		p.complain(dealer, p.index)
+		return
+	}
+	p.shares[dealer] = share
+	delete(p.complaints[dealer], p.index)
+}
+
+func (p *Protocol) complain(dealer uint32, complainer uint32) {
+	if p.complaints[dealer] == nil {
+		p.complaints[dealer] = make(map[uint32]bool)
+	}
+	p.complaints[dealer][complainer] = true
+}
+
+// conclude phase, disqualify participants that failed to act
+func (p *Protocol) conclude(phase Phase) {
+	switch phase {
+	case PhaseAnnounce:
+		announced := 0
+		for _, participant := range p.conf.Participants {
+			if _, ok := p.keys[participant]; ok {
+				announced++
+			}
+		}
+		if announced < p.conf.Threshold {
+			log.Warnf("DKG only %d of %d participants announced", announced, len(p.conf.Participants))
+		}
+	case PhaseDeal:
+		for i := range p.dealers {
+			dealer := uint32(i + 1)
+			if _, ok := p.commits[dealer]; !ok {
+				p.disqualified[dealer] = true
+			}
+		}
+	case PhaseJustification:
+		for dealer, complainers := range p.complaints {
+			if p.disqualified[dealer] {
+				continue
+			}
+			for complainer := range complainers {
+				share, ok := p.justifications[dealer][complainer]
+				if !ok || !verifyShare(p.commits[dealer], complainer, share) {
+					log.Warnf("DKG dealer %d was disqualified", dealer)
+					p.disqualified[dealer] = true
+					break
+				}
+				if complainer == p.index {
+					p.shares[dealer] = share
+				}
+			}
+		}
+	}
+}
+
+// result combine shares and commitments of qualified dealers, DKG sums all of
+// them while resharing interpolates threshold of them at zero
+func (p *Protocol) result() (*Result, error) {
+	qualified := make([]uint32, 0, len(p.commits))
+	for dealer := range p.commits {
+		if !p.disqualified[dealer] {
+			qualified = append(qualified, dealer)
+		}
+	}
+	sort.Slice(qualified, func(i, j int) bool { return qualified[i] < qualified[j] })
+	needed := p.conf.Threshold
+	if p.previous != nil {
+		needed = p.previous.Threshold()
+	}
+	if len(qualified) < needed {
+		return nil, errors.New("not enough qualified dealers")
+	}
+	var weights []*big.Int
+	if p.previous != nil {
+		qualified = qualified[:needed]
+		var err error
+		if weights, err = keypair.LagrangeCoefficients(qualified); err != nil {
+			return nil, err
+		}
+	}
+	secret := new(big.Int)
+	g2 := bls12381.NewG2()
+	commits := make([]*bls12381.PointG2, p.conf.Threshold)
+	for n, dealer := range qualified {
+		share, ok := p.shares[dealer]
+		if p.index != 0 && !ok {
+			return nil, errors.New("missing share of a qualified dealer")
+		}
+		if p.index != 0 {
+			if weights != nil {
+				share = new(big.Int).Mul(share, weights[n])
+			}
+			secret.Add(secret, share)
+			secret.Mod(secret, keypair.CurveOrder)
+		}
+		for i, commit := range p.commits[dealer].Commits {
+			if weights != nil {
+				commit = g2.MulScalarBig(g2.New(), commit, weights[n])
+			}
+			if commits[i] == nil {
+				commits[i] = g2.New().Set(commit)
+			} else {
+				g2.Add(commits[i], commits[i], commit)
+			}
+		}
+	}
+	result := &Result{
+		PubPoly:   &keypair.PubPoly{Commits: commits},
+		Qualified: qualified,
+	}
+	if p.previous != nil && string(result.GroupKey()) != string(p.previous.PublicKey()) {
+		return nil, errors.New("resharing changed group public key")
+	}
+	if p.index != 0 {
+		result.Share = &keypair.PriShare{Index: p.index, Secret: secret}
+	}
+	return result, nil
+}
+
+// GroupKey group public key
+func (r *Result) GroupKey() []byte {
+	return r.PubPoly.PublicKey()
+}
+
+// verifyShare check share against dealer's commitment
+func verifyShare(pubPoly *keypair.PubPoly, index uint32, share *big.Int) bool {
+	return bls12381.NewG2().Equal(pubPoly.Eval(index), keypair.G2Base(share))
+}
+
+func scalarBytes(v *big.Int) []byte {
+	buf := make([]byte, 32)
+	b := v.Bytes()
+	copy(buf[32-len(b):], b)
+	return buf
+}
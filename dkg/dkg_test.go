@@ -0,0 +1,149 @@
+package dkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/libp2p/go-libp2p-core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// newMockNetworks nodes fully connected over an in-memory transport
+func newMockNetworks(t *testing.T, n int) ([]*network.Network, []peer.ID) {
+	mn := mocknet.New(context.Background())
+	nets := make([]*network.Network, n)
+	members := make([]peer.ID, n)
+	for i := range nets {
+		key, err := keypair.NewEd25519()
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip6/100::%x/tcp/4001", i+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, err := mn.AddPeer(key.GetPrivateKey(), addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nets[i] = network.NewWithHost(host, network.Identifier{Name: network.Devnet}, key)
+		members[i] = nets[i].NodeID
+	}
+	if err := mn.LinkAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mn.ConnectAllButSelf(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, net := range nets {
+			net.Close()
+		}
+	})
+	return nets, members
+}
+
+// runDKG run a ceremony among all nodes and return the result of each
+func runDKG(t *testing.T, nets []*network.Network, conf Config) []*Result {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	results := make([]*Result, len(nets))
+	errs := make([]error, len(nets))
+	var wg sync.WaitGroup
+	for i, net := range nets {
+		protocol, err := New(net, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = protocol.Run(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("DKG of node %d: %v", i+1, err)
+		}
+	}
+	return results
+}
+
+func TestDKG(t *testing.T) {
+	const members, threshold = 4, 3
+	nets, ids := newMockNetworks(t, members)
+	results := runDKG(t, nets, Config{Participants: ids, Threshold: threshold, PhaseTimeout: time.Second})
+
+	groupKey := results[0].GroupKey()
+	shares := make([]*keypair.PriShare, members)
+	for i, result := range results {
+		if !bytes.Equal(result.GroupKey(), groupKey) {
+			t.Fatalf("node %d derived another group key", i+1)
+		}
+		if len(result.Qualified) != members {
+			t.Fatalf("node %d qualified %d dealers, want %d", i+1, len(result.Qualified), members)
+		}
+		if result.Share.Index != uint32(i+1) || !verifyShare(result.PubPoly, result.Share.Index, result.Share.Secret) {
+			t.Fatalf("share of node %d does not match public polynomial", i+1)
+		}
+		shares[i] = result.Share
+	}
+
+	// Threshold shares sign for group key
+	data := []byte("round 1")
+	partials := make([][]byte, threshold)
+	for i := range partials {
+		partials[i] = keypair.SignPartial(shares[members-1-i], data)
+	}
+	signature, err := keypair.RecoverSignature(partials, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := keypair.BLSVerify(groupKey, data, signature); err != nil || !ok {
+		t.Fatalf("group signature does not verify: %v", err)
+	}
+	secret, err := keypair.RecoverSecret(shares, threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bls12381.NewG2().ToCompressed(keypair.G2Base(secret)), groupKey) {
+		t.Fatal("group key is not public key of shared secret")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	const members, threshold = 4, 3
+	nets, ids := newMockNetworks(t, members)
+	results := runDKG(t, nets, Config{Participants: ids, Threshold: threshold, PhaseTimeout: time.Second})
+
+	// Node 1 lost its share, other members help it back
+	for i := 1; i < members; i++ {
+		if _, err := ServeRecovery(nets[i], ids, results[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recovered, err := Recover(context.Background(), nets[0], RecoverConfig{
+		Members:   ids,
+		GroupKey:  results[0].GroupKey(),
+		Threshold: threshold,
+		Timeout:   time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered.Share.Index != 1 || recovered.Share.Secret.Cmp(results[0].Share.Secret) != 0 {
+		t.Fatal("recovered share is not the lost share")
+	}
+	if !bytes.Equal(recovered.GroupKey(), results[0].GroupKey()) {
+		t.Fatal("recovered share belongs to another group key")
+	}
+}
@@ -0,0 +1,597 @@
+package dkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Share recovery lets a member that lost its share get it back from threshold
+// other members without resharing the group. The recovering member r asks
+// for help, threshold helpers are selected among members that offer it. Each
+// helper deals a random mask polynomial that is zero at r, sending encrypted
+// mask shares to other helpers, then sends r its own share plus all masks it
+// received. Masked values lie on a polynomial that equals the group
+// polynomial at r, so r interpolates its share while shares of helpers stay
+// hidden. Every value is checked against the group public polynomial and the
+// commitments of masks.
+
+// RecoverTopic pubsub topic used by share recovery
+const RecoverTopic = "recover"
+
+// validatorRecover key of share recovery validator
+const validatorRecover = "recover"
+
+// recoverRepeat interval that the recovering member repeats its messages at,
+// helpers answer each of them with their own messages
+const recoverRepeat = 2 * time.Second
+
+// recoverSessionTTL time that helpers keep state of a recovery session
+const recoverSessionTTL = 10 * time.Minute
+
+// Message types of share recovery
+const (
+	recoverRequest = "request"
+	recoverOffer   = "offer"
+	recoverSelect  = "select"
+	recoverMask    = "mask"
+	recoverReply   = "reply"
+)
+
+type recoverRequestPayload struct {
+	Index         uint32 `json:"index"`
+	GroupKey      []byte `json:"groupKey"`
+	EncryptionKey []byte `json:"encryptionKey"`
+}
+
+type recoverSelectPayload struct {
+	Helpers []uint32 `json:"helpers"`
+}
+
+type recoverReplyPayload struct {
+	PubPoly []byte `json:"pubPoly"`
+	Share   []byte `json:"share"`
+}
+
+// RecoverConfig share recovery configuration
+type RecoverConfig struct {
+	// Members of group, index of a member is its position plus one
+	Members []peer.ID
+	// GroupKey group public key that recovered share must belong to
+	GroupKey  []byte
+	Threshold int
+	Timeout   time.Duration
+}
+
+// recovery state of the recovering member
+type recovery struct {
+	conf           RecoverConfig
+	net            *network.Network
+	session        string
+	index          uint32
+	encryptionPub  *[32]byte
+	encryptionPriv *[32]byte
+	keys           map[uint32]*[32]byte
+	helpers        []uint32
+	commits        map[uint32]*keypair.PubPoly
+	replies        map[uint32]*recoverReplyPayload
+}
+
+// Recover ask threshold other members for the share of this node, which must
+// be a member of group
+func Recover(ctx context.Context, net *network.Network, conf RecoverConfig) (*Result, error) {
+	index := indexOf(conf.Members, net.NodeID)
+	if index == 0 {
+		return nil, errors.New("this node is not a member")
+	}
+	if conf.Threshold < 1 || conf.Threshold >= len(conf.Members) {
+		return nil, errors.New("threshold must be between 1 and number of other members")
+	}
+	if conf.Timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	r := &recovery{
+		conf:           conf,
+		net:            net,
+		session:        hex.EncodeToString(nonce),
+		index:          index,
+		encryptionPub:  pub,
+		encryptionPriv: priv,
+		keys:           make(map[uint32]*[32]byte),
+		commits:        make(map[uint32]*keypair.PubPoly),
+		replies:        make(map[uint32]*recoverReplyPayload),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
+	incoming := make(chan *network.Envelope, 64)
+	types := []string{recoverOffer, recoverMask, recoverReply}
+	defer func() {
+		cancel()
+		for _, msgType := range types {
+			net.RemoveHandler(RecoverTopic, msgType)
+		}
+		net.Topics().RemoveValidator(RecoverTopic, validatorRecover)
+	}()
+	for _, msgType := range types {
+		err := net.Handle(RecoverTopic, msgType, func(e *network.Envelope) {
+			select {
+			case incoming <- e:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := net.Topics().AddValidator(RecoverTopic, validatorRecover, recoverValidator(conf.Members)); err != nil {
+		return nil, err
+	}
+
+	log.Infof("Recover share %d in session %s", index, r.session[:8])
+	ticker := time.NewTicker(recoverRepeat)
+	defer ticker.Stop()
+	r.broadcast()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("share recovery did not finish: %v, %s", ctx.Err(), r.progress())
+		case e := <-incoming:
+			m := new(message)
+			if network.DecodePayload(e.Payload, m) != nil || m.Session != r.session {
+				continue
+			}
+			helper := indexOf(conf.Members, e.Sender)
+			switch e.Type {
+			case recoverOffer:
+				r.handleOffer(helper, m.Payload)
+			case recoverMask:
+				r.handleMask(helper, m.Payload)
+			case recoverReply:
+				r.handleReply(helper, m.Payload)
+			}
+			if result, err := r.result(); result != nil || err != nil {
+				return result, err
+			}
+		case <-ticker.C:
+			r.broadcast()
+		}
+	}
+}
+
+// broadcast request and selection of helpers once they are chosen
+func (r *recovery) broadcast() {
+	err := sendRecover(r.net, r.session, recoverRequest, recoverRequestPayload{
+		Index:         r.index,
+		GroupKey:      r.conf.GroupKey,
+		EncryptionKey: r.encryptionPub[:],
+	})
+	if err == nil && r.helpers != nil {
+		err = sendRecover(r.net, r.session, recoverSelect, recoverSelectPayload{Helpers: r.helpers})
+	}
+	if err != nil {
+		log.Warnf("Unable to send share recovery request: %v", err)
+	}
+}
+
+// progress describe which helpers did not answer yet
+func (r *recovery) progress() string {
+	if r.helpers == nil {
+		return fmt.Sprintf("%d of %d members offered help", len(r.keys), r.conf.Threshold)
+	}
+	return fmt.Sprintf("helpers %v, %d masks and %d replies received", r.helpers, len(r.commits), len(r.replies))
+}
+
+// handleOffer select first threshold members that offer help
+func (r *recovery) handleOffer(helper uint32, payload json.RawMessage) {
+	key := decodeKey(payload)
+	if helper == r.index || key == nil || r.helpers != nil {
+		return
+	}
+	r.keys[helper] = key
+	if len(r.keys) < r.conf.Threshold {
+		return
+	}
+	for index := range r.keys {
+		r.helpers = append(r.helpers, index)
+	}
+	sort.Slice(r.helpers, func(i, j int) bool { return r.helpers[i] < r.helpers[j] })
+	r.helpers = r.helpers[:r.conf.Threshold]
+	log.Infof("Share recovery helpers: %v", r.helpers)
+	r.broadcast()
+}
+
+// handleMask keep commitments of masks, they must be zero at our index
+func (r *recovery) handleMask(helper uint32, payload json.RawMessage) {
+	if !containsIndex(r.helpers, helper) {
+		return
+	}
+	if _, ok := r.commits[helper]; ok {
+		return
+	}
+	mask := new(dealPayload)
+	if json.Unmarshal(payload, mask) != nil {
+		return
+	}
+	commits, err := keypair.UnmarshalPubPoly(mask.Commits)
+	if err != nil || !validMask(commits, r.conf.Threshold, r.index) {
+		log.Warnf("Helper %d sent an invalid mask", helper)
+		return
+	}
+	r.commits[helper] = commits
+}
+
+func (r *recovery) handleReply(helper uint32, payload json.RawMessage) {
+	if !containsIndex(r.helpers, helper) {
+		return
+	}
+	reply := new(recoverReplyPayload)
+	if json.Unmarshal(payload, reply) != nil {
+		return
+	}
+	r.replies[helper] = reply
+}
+
+// result interpolate share once all helpers replied, nil result and error
+// while replies are missing
+func (r *recovery) result() (*Result, error) {
+	if r.helpers == nil || len(r.commits) < len(r.helpers) || len(r.replies) < len(r.helpers) {
+		return nil, nil
+	}
+	var pubPoly *keypair.PubPoly
+	values := make([]*big.Int, len(r.helpers))
+	for i, helper := range r.helpers {
+		reply := r.replies[helper]
+		helperPoly, err := keypair.UnmarshalPubPoly(reply.PubPoly)
+		if err != nil || string(helperPoly.PublicKey()) != string(r.conf.GroupKey) {
+			return nil, fmt.Errorf("helper %d sent public polynomial of another group", helper)
+		}
+		if pubPoly == nil {
+			pubPoly = helperPoly
+		} else if string(pubPoly.Marshal()) != string(helperPoly.Marshal()) {
+			return nil, fmt.Errorf("helper %d sent a conflicting public polynomial", helper)
+		}
+		value, err := openScalar(reply.Share, r.keys[helper], r.encryptionPriv)
+		if err != nil {
+			return nil, fmt.Errorf("helper %d sent an unreadable share: %v", helper, err)
+		}
+		// Masked share of helper is its share plus masks of all helpers
+		g2 := bls12381.NewG2()
+		expected := pubPoly.Eval(helper)
+		for _, commits := range r.commits {
+			g2.Add(expected, expected, commits.Eval(helper))
+		}
+		if !g2.Equal(expected, keypair.G2Base(value)) {
+			return nil, fmt.Errorf("helper %d sent an invalid share", helper)
+		}
+		values[i] = value
+	}
+	weights, err := keypair.LagrangeCoefficientsAt(r.helpers, r.index)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(big.Int)
+	for i, weight := range weights {
+		secret.Add(secret, new(big.Int).Mul(values[i], weight))
+		secret.Mod(secret, keypair.CurveOrder)
+	}
+	if !verifyShare(pubPoly, r.index, secret) {
+		return nil, errors.New("recovered share does not match public polynomial")
+	}
+	return &Result{
+		Share:   &keypair.PriShare{Index: r.index, Secret: secret},
+		PubPoly: pubPoly,
+	}, nil
+}
+
+// Helper answers share recovery requests of other members with the share of
+// this node
+type Helper struct {
+	net      *network.Network
+	members  []peer.ID
+	share    *Result
+	sessions map[string]*helperSession
+	mutex    sync.Mutex
+}
+
+// helperSession state of a helper in one recovery session
+type helperSession struct {
+	requester      uint32
+	requesterKey   *[32]byte
+	encryptionPub  *[32]byte
+	encryptionPriv *[32]byte
+	helpers        []uint32
+	keys           map[uint32]*[32]byte
+	mask           *keypair.PriPoly
+	masks          map[uint32]*big.Int
+	// sent messages of this helper, repeated whenever requester repeats its own
+	sent    map[string]interface{}
+	created time.Time
+}
+
+// ServeRecovery help members of group to recover their share, this node must
+// hold a share of group
+func ServeRecovery(net *network.Network, members []peer.ID, share *Result) (*Helper, error) {
+	if share == nil || share.Share == nil || indexOf(members, net.NodeID) != share.Share.Index {
+		return nil, errors.New("share does not belong to this member")
+	}
+	h := &Helper{
+		net:      net,
+		members:  members,
+		share:    share,
+		sessions: make(map[string]*helperSession),
+	}
+	for _, msgType := range []string{recoverRequest, recoverOffer, recoverSelect, recoverMask} {
+		if err := net.Handle(RecoverTopic, msgType, h.handle); err != nil {
+			return nil, err
+		}
+	}
+	if err := net.Topics().AddValidator(RecoverTopic, validatorRecover, recoverValidator(members)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *Helper) handle(e *network.Envelope) {
+	sender := indexOf(h.members, e.Sender)
+	m := new(message)
+	if sender == 0 || network.DecodePayload(e.Payload, m) != nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	s, ok := h.sessions[m.Session]
+	if e.Type == recoverRequest && !ok {
+		if s = h.open(sender, m.Payload); s == nil {
+			return
+		}
+		h.sessions[m.Session] = s
+	}
+	if s == nil {
+		return
+	}
+	switch e.Type {
+	case recoverRequest:
+		if sender != s.requester {
+			return
+		}
+	case recoverOffer:
+		if key := decodeKey(m.Payload); key != nil {
+			s.keys[sender] = key
+		}
+	case recoverSelect:
+		payload := new(recoverSelectPayload)
+		if sender != s.requester || s.helpers != nil || json.Unmarshal(m.Payload, payload) != nil {
+			return
+		}
+		if len(payload.Helpers) != h.share.PubPoly.Threshold() || containsIndex(payload.Helpers, s.requester) {
+			return
+		}
+		s.helpers = payload.Helpers
+	case recoverMask:
+		h.handleMask(s, sender, m.Payload)
+	}
+	h.advance(m.Session, s)
+	if sender == s.requester {
+		// Requester repeats its messages until it's done, so do we
+		for msgType, payload := range s.sent {
+			if err := sendRecover(h.net, m.Session, msgType, payload); err != nil {
+				log.Warnf("Unable to send share recovery %s: %v", msgType, err)
+			}
+		}
+	}
+}
+
+// open a session for a request, nil if request is not valid
+func (h *Helper) open(sender uint32, payload json.RawMessage) *helperSession {
+	request := new(recoverRequestPayload)
+	if json.Unmarshal(payload, request) != nil || request.Index != sender || sender == h.share.Share.Index {
+		return nil
+	}
+	if string(request.GroupKey) != string(h.share.GroupKey()) || len(request.EncryptionKey) != 32 {
+		return nil
+	}
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Errorf("Unable to generate share recovery key: %v", err)
+		return nil
+	}
+	for session, s := range h.sessions {
+		if time.Since(s.created) > recoverSessionTTL {
+			delete(h.sessions, session)
+		}
+	}
+	log.Infof("Member %d requests share recovery", sender)
+	requesterKey := new([32]byte)
+	copy(requesterKey[:], request.EncryptionKey)
+	return &helperSession{
+		requester:      sender,
+		requesterKey:   requesterKey,
+		encryptionPub:  pub,
+		encryptionPriv: priv,
+		keys:           make(map[uint32]*[32]byte),
+		masks:          make(map[uint32]*big.Int),
+		sent:           map[string]interface{}{recoverOffer: announcePayload{EncryptionKey: pub[:]}},
+		created:        time.Now(),
+	}
+}
+
+// handleMask keep mask share dealt to this node by another helper
+func (h *Helper) handleMask(s *helperSession, sender uint32, payload json.RawMessage) {
+	index := h.share.Share.Index
+	if _, ok := s.masks[sender]; ok || !containsIndex(s.helpers, sender) || !containsIndex(s.helpers, index) {
+		return
+	}
+	mask := new(dealPayload)
+	if json.Unmarshal(payload, mask) != nil || s.keys[sender] == nil {
+		return
+	}
+	commits, err := keypair.UnmarshalPubPoly(mask.Commits)
+	if err != nil || !validMask(commits, h.share.PubPoly.Threshold(), s.requester) {
+		log.Warnf("Helper %d sent an invalid share recovery mask", sender)
+		return
+	}
+	value, err := openScalar(mask.Shares[index], s.keys[sender], s.encryptionPriv)
+	if err != nil || !verifyShare(commits, index, value) {
+		log.Warnf("Helper %d sent an invalid share recovery mask", sender)
+		return
+	}
+	s.masks[sender] = value
+}
+
+// advance deal mask once keys of all helpers are known, and reply to
+// requester once masks of all helpers are received
+func (h *Helper) advance(session string, s *helperSession) {
+	index := h.share.Share.Index
+	if !containsIndex(s.helpers, index) {
+		return
+	}
+	if s.mask == nil {
+		for _, helper := range s.helpers {
+			if helper != index && s.keys[helper] == nil {
+				return
+			}
+		}
+		mask, err := keypair.NewZeroPoly(h.share.PubPoly.Threshold(), s.requester)
+		if err != nil {
+			log.Errorf("Unable to create share recovery mask: %v", err)
+			return
+		}
+		deal := dealPayload{Commits: mask.Commit().Marshal(), Shares: make(map[uint32][]byte)}
+		for _, helper := range s.helpers {
+			if helper == index {
+				continue
+			}
+			if deal.Shares[helper], err = sealScalar(mask.Eval(helper).Secret, s.keys[helper], s.encryptionPriv); err != nil {
+				log.Errorf("Unable to encrypt share recovery mask: %v", err)
+				return
+			}
+		}
+		s.mask = mask
+		s.masks[index] = mask.Eval(index).Secret
+		s.sent[recoverMask] = deal
+		if err := sendRecover(h.net, session, recoverMask, deal); err != nil {
+			log.Warnf("Unable to send share recovery mask: %v", err)
+		}
+	}
+	if _, ok := s.sent[recoverReply]; ok || len(s.masks) < len(s.helpers) {
+		return
+	}
+	value := new(big.Int).Set(h.share.Share.Secret)
+	for _, mask := range s.masks {
+		value.Add(value, mask)
+	}
+	value.Mod(value, keypair.CurveOrder)
+	share, err := sealScalar(value, s.requesterKey, s.encryptionPriv)
+	if err != nil {
+		log.Errorf("Unable to encrypt share recovery reply: %v", err)
+		return
+	}
+	reply := recoverReplyPayload{PubPoly: h.share.PubPoly.Marshal(), Share: share}
+	s.sent[recoverReply] = reply
+	if err := sendRecover(h.net, session, recoverReply, reply); err != nil {
+		log.Warnf("Unable to send share recovery reply: %v", err)
+	}
+	log.Infof("Sent masked share to member %d", s.requester)
+}
+
+// recoverValidator accept well formed share recovery messages of members
+func recoverValidator(members []peer.ID) pubsub.ValidatorEx {
+	return func(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		e := network.EnvelopeOf(msg)
+		if e == nil {
+			return pubsub.ValidationReject
+		}
+		if indexOf(members, e.Sender) == 0 {
+			return pubsub.ValidationIgnore
+		}
+		m := new(message)
+		if err := network.DecodePayload(e.Payload, m); err != nil {
+			return pubsub.ValidationReject
+		}
+		switch e.Type {
+		case recoverRequest, recoverOffer, recoverSelect, recoverMask, recoverReply:
+			return pubsub.ValidationAccept
+		}
+		return pubsub.ValidationReject
+	}
+}
+
+func sendRecover(net *network.Network, session string, msgType string, payload interface{}) error {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(message{Session: session, Payload: encodedPayload})
+	if err != nil {
+		return err
+	}
+	return net.Send(RecoverTopic, msgType, 0, data)
+}
+
+// validMask check that mask has given threshold and is zero at index
+func validMask(commits *keypair.PubPoly, threshold int, index uint32) bool {
+	return commits.Threshold() == threshold && bls12381.NewG2().IsZero(commits.Eval(index))
+}
+
+// decodeKey encryption key of an offer, nil if it's invalid
+func decodeKey(payload json.RawMessage) *[32]byte {
+	offer := new(announcePayload)
+	if json.Unmarshal(payload, offer) != nil || len(offer.EncryptionKey) != 32 {
+		return nil
+	}
+	key := new([32]byte)
+	copy(key[:], offer.EncryptionKey)
+	return key
+}
+
+func sealScalar(v *big.Int, peerKey *[32]byte, privateKey *[32]byte) ([]byte, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return box.Seal(nonce[:], scalarBytes(v), &nonce, peerKey, privateKey), nil
+}
+
+func openScalar(encrypted []byte, peerKey *[32]byte, privateKey *[32]byte) (*big.Int, error) {
+	if peerKey == nil || len(encrypted) < 24 {
+		return nil, errors.New("missing encrypted value")
+	}
+	var nonce [24]byte
+	copy(nonce[:], encrypted[:24])
+	decrypted, ok := box.Open(nil, encrypted[24:], &nonce, peerKey, privateKey)
+	if !ok {
+		return nil, errors.New("unable to decrypt value")
+	}
+	return new(big.Int).SetBytes(decrypted), nil
+}
+
+func containsIndex(list []uint32, index uint32) bool {
+	for _, item := range list {
+		if item == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package dkg
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/governance"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// Resharing moves the group secret to a new member set and threshold. Every
+// member of current group deals its own share with a fresh polynomial, new
+// members interpolate threshold of the dealt values at zero so the group
+// public key does not change.
+
+// ReshareTopic pubsub topic used by resharing
+const ReshareTopic = "reshare"
+
+// ReshareConfig resharing ceremony configuration
+type ReshareConfig struct {
+	// Members of current group, index of a member is its position plus one
+	Members []peer.ID
+	// PubPoly public polynomial of current group
+	PubPoly *keypair.PubPoly
+	// Share of this node in current group, nil if this node only joins new group
+	Share *keypair.PriShare
+	// Proposal reshare proposal, new members and threshold are taken from it
+	Proposal *governance.Message
+	// ChainHash hash of chain info of group, proposal must be for this chain
+	ChainHash []byte
+	// Quorum number of current members that must approve proposal
+	Quorum       uint
+	PhaseTimeout time.Duration
+}
+
+// NewReshare prepare a resharing ceremony, this node must be a member of
+// current group or of the group proposed by a quorum approved proposal
+func NewReshare(net *network.Network, conf ReshareConfig) (*Protocol, error) {
+	if conf.Proposal == nil || conf.Proposal.Proposal.Kind != governance.KindReshare {
+		return nil, errors.New("resharing requires a reshare proposal")
+	}
+	if conf.PubPoly == nil || len(conf.Members) < conf.PubPoly.Threshold() {
+		return nil, errors.New("current group has fewer members than its threshold")
+	}
+	if conf.Quorum == 0 {
+		return nil, errors.New("quorum must be positive")
+	}
+	if !bytes.Equal(conf.Proposal.Proposal.ChainHash, conf.ChainHash) {
+		return nil, errors.New("reshare proposal is for another chain")
+	}
+	if err := conf.Proposal.Verify(conf.Members, conf.Quorum); err != nil {
+		return nil, err
+	}
+	var secret *big.Int
+	if dealer := indexOf(conf.Members, net.NodeID); dealer != 0 {
+		if conf.Share == nil || conf.Share.Index != dealer || !verifyShare(conf.PubPoly, dealer, conf.Share.Secret) {
+			return nil, errors.New("share does not belong to this member")
+		}
+		secret = conf.Share.Secret
+	}
+	digest, err := conf.Proposal.Proposal.Digest()
+	if err != nil {
+		return nil, err
+	}
+	proposal := conf.Proposal.Proposal
+	p, err := newProtocol(net, Config{
+		Participants: proposal.Members,
+		Threshold:    int(proposal.Threshold),
+		PhaseTimeout: conf.PhaseTimeout,
+	}, hex.EncodeToString(digest), ReshareTopic, conf.Members, secret)
+	if err != nil {
+		return nil, err
+	}
+	p.previous = conf.PubPoly
+	return p, nil
+}
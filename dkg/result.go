@@ -0,0 +1,82 @@
+package dkg
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// ResultJSON JSON structure of DKG result
+type ResultJSON struct {
+	Index     uint32   `json:"index"`
+	Share     string   `json:"share"`
+	PubPoly   string   `json:"pubPoly"`
+	Qualified []uint32 `json:"qualified"`
+}
+
+// Marshal encode DKG result including private share
+func (r *Result) Marshal() ([]byte, error) {
+	if r.Share == nil {
+		return nil, errors.New("this node holds no share of group")
+	}
+	return json.Marshal(ResultJSON{
+		Index:     r.Share.Index,
+		Share:     hex.EncodeToString(scalarBytes(r.Share.Secret)),
+		PubPoly:   hex.EncodeToString(r.PubPoly.Marshal()),
+		Qualified: r.Qualified,
+	})
+}
+
+// UnmarshalResult decode DKG result and check share against public polynomial
+func UnmarshalResult(content []byte) (*Result, error) {
+	resultJSON := new(ResultJSON)
+	if err := json.Unmarshal(content, resultJSON); err != nil {
+		return nil, err
+	}
+	share, err := hex.DecodeString(resultJSON.Share)
+	if err != nil {
+		return nil, err
+	}
+	rawPubPoly, err := hex.DecodeString(resultJSON.PubPoly)
+	if err != nil {
+		return nil, err
+	}
+	pubPoly, err := keypair.UnmarshalPubPoly(rawPubPoly)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Share:     &keypair.PriShare{Index: resultJSON.Index, Secret: new(big.Int).SetBytes(share)},
+		PubPoly:   pubPoly,
+		Qualified: resultJSON.Qualified,
+	}
+	if !verifyShare(pubPoly, result.Share.Index, result.Share.Secret) {
+		return nil, errors.New("share does not match public polynomial")
+	}
+	return result, nil
+}
+
+// SaveToFile save DKG result including private share to file
+func (r *Result) SaveToFile(fileName string) (bool, error) {
+	encoded, err := r.Marshal()
+	if err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(fileName, encoded, 0600); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LoadResultFromFile load DKG result from file
+func LoadResultFromFile(fileName string) (*Result, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalResult(content)
+}
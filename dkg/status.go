@@ -0,0 +1,197 @@
+package dkg
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/network"
+)
+
+// Status progress of a ceremony as seen by one node
+type Status struct {
+	Session      string              `json:"session"`
+	Phase        Phase               `json:"phase"`
+	Threshold    int                 `json:"threshold"`
+	Participants []ParticipantStatus `json:"participants"`
+	Complaints   []Complaint         `json:"complaints"`
+}
+
+// ParticipantStatus messages received from a participant or dealer
+type ParticipantStatus struct {
+	ID peer.ID `json:"id"`
+	// Index of participant, 0 if it only deals
+	Index uint32 `json:"index"`
+	// Seen phases that messages of participant were received in
+	Seen         []Phase `json:"seen"`
+	Disqualified bool    `json:"disqualified,omitempty"`
+}
+
+// Complaint participant complained about the share dealt to it by dealer
+type Complaint struct {
+	Dealer     uint32 `json:"dealer"`
+	Complainer uint32 `json:"complainer"`
+}
+
+// seenPhases record phases that messages of each sender were received in
+type seenPhases map[peer.ID]map[Phase]bool
+
+func (s seenPhases) add(sender peer.ID, phase Phase) {
+	if s[sender] == nil {
+		s[sender] = make(map[Phase]bool)
+	}
+	s[sender][phase] = true
+}
+
+// list phases of sender in protocol order
+func (s seenPhases) list(sender peer.ID) []Phase {
+	phases := make([]Phase, 0, len(s[sender]))
+	for phase := range s[sender] {
+		phases = append(phases, phase)
+	}
+	sort.Slice(phases, func(i, j int) bool { return phaseOrder[phases[i]] < phaseOrder[phases[j]] })
+	return phases
+}
+
+// complaintList flatten complaints ordered by dealer then complainer
+func complaintList(complaints map[uint32]map[uint32]bool) []Complaint {
+	list := make([]Complaint, 0)
+	for dealer, complainers := range complaints {
+		for complainer := range complainers {
+			list = append(list, Complaint{Dealer: dealer, Complainer: complainer})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Dealer != list[j].Dealer {
+			return list[i].Dealer < list[j].Dealer
+		}
+		return list[i].Complainer < list[j].Complainer
+	})
+	return list
+}
+
+// Status progress of ceremony, participants are listed in order followed by
+// dealers that are not participants
+func (p *Protocol) Status() *Status {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	status := &Status{
+		Session:    p.session,
+		Phase:      p.phase,
+		Threshold:  p.conf.Threshold,
+		Complaints: complaintList(p.complaints),
+	}
+	peers := append([]peer.ID(nil), p.conf.Participants...)
+	for _, dealer := range p.dealers {
+		if indexOf(p.conf.Participants, dealer) == 0 {
+			peers = append(peers, dealer)
+		}
+	}
+	for _, id := range peers {
+		dealer := indexOf(p.dealers, id)
+		status.Participants = append(status.Participants, ParticipantStatus{
+			ID:           id,
+			Index:        indexOf(p.conf.Participants, id),
+			Seen:         p.seen.list(id),
+			Disqualified: dealer != 0 && p.disqualified[dealer],
+		})
+	}
+	return status
+}
+
+// Monitor follow messages of a DKG ceremony without taking part in it, so a
+// coordinator can report which participants are active and who complained
+type Monitor struct {
+	conf    Config
+	session string
+	net     *network.Network
+	phase   Phase
+	// dealt time that the first deal was received, ceremony ends three
+	// phases after it
+	dealt      time.Time
+	seen       seenPhases
+	complaints map[uint32]map[uint32]bool
+	mutex      sync.Mutex
+}
+
+// NewMonitor prepare monitor of DKG ceremony of given configuration
+func NewMonitor(net *network.Network, conf Config) *Monitor {
+	return &Monitor{
+		conf:       conf,
+		session:    Session(conf.Participants, conf.Threshold),
+		net:        net,
+		seen:       make(seenPhases),
+		complaints: make(map[uint32]map[uint32]bool),
+	}
+}
+
+// Start receive messages of ceremony
+func (m *Monitor) Start() error {
+	for _, phase := range []Phase{PhaseAnnounce, PhaseDeal, PhaseComplaint, PhaseJustification} {
+		if err := m.net.Handle(Topic, string(phase), m.handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop receiving messages
+func (m *Monitor) Stop() {
+	for _, phase := range []Phase{PhaseAnnounce, PhaseDeal, PhaseComplaint, PhaseJustification} {
+		m.net.RemoveHandler(Topic, string(phase))
+	}
+}
+
+func (m *Monitor) handle(e *network.Envelope) {
+	participant := indexOf(m.conf.Participants, e.Sender)
+	msg := new(message)
+	if participant == 0 || network.DecodePayload(e.Payload, msg) != nil || msg.Session != m.session {
+		return
+	}
+	phase := Phase(e.Type)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.seen.add(e.Sender, phase)
+	if phaseOrder[phase] > phaseOrder[m.phase] {
+		m.phase = phase
+	}
+	if phase == PhaseDeal && m.dealt.IsZero() {
+		m.dealt = time.Now()
+	}
+	if phase == PhaseComplaint {
+		payload := new(complaintPayload)
+		if json.Unmarshal(msg.Payload, payload) == nil {
+			if m.complaints[payload.Dealer] == nil {
+				m.complaints[payload.Dealer] = make(map[uint32]bool)
+			}
+			m.complaints[payload.Dealer][participant] = true
+		}
+	}
+}
+
+// Status progress of ceremony, phase is the latest phase that a message was
+// received in until three phase timeouts after the first deal, when
+// participants have finished
+func (m *Monitor) Status() *Status {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	status := &Status{
+		Session:    m.session,
+		Phase:      m.phase,
+		Threshold:  m.conf.Threshold,
+		Complaints: complaintList(m.complaints),
+	}
+	if !m.dealt.IsZero() && time.Since(m.dealt) > 3*m.conf.PhaseTimeout {
+		status.Phase = PhaseFinished
+	}
+	for i, id := range m.conf.Participants {
+		status.Participants = append(status.Participants, ParticipantStatus{
+			ID:    id,
+			Index: uint32(i + 1),
+			Seen:  m.seen.list(id),
+		})
+	}
+	return status
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// queueSize events queued per subscriber, newer events are dropped for a
+// subscriber whose queue is full
+const queueSize = 256
+
+var (
+	eventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "events",
+		Name:      "published_total",
+		Help:      "Events published on event bus, by type",
+	}, []string{"type"})
+	eventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "events",
+		Name:      "dropped_total",
+		Help:      "Events dropped because a subscriber fell behind, by subscriber",
+	}, []string{"subscriber"})
+)
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("events")
+	metrics.Registry.MustRegister(eventsPublished, eventsDropped)
+}
+
+// Handler handle an event delivered to a subscriber
+type Handler func(Event)
+
+// Bus deliver events published by subsystems of node to subscribers. Each
+// subscriber handles events in order of publishing on a goroutine of its
+// own, so a slow subscriber never holds up the publisher or other subscribers
+type Bus struct {
+	subscribers map[*Subscription]bool
+	mutex       sync.RWMutex
+}
+
+// Subscription events delivered to one subscriber until it's closed
+type Subscription struct {
+	Name string
+	// types events are delivered of, every type if it's empty
+	types   map[string]bool
+	handler Handler
+	queue   chan Event
+	bus     *Bus
+	once    sync.Once
+}
+
+// New create an event bus without subscribers
+func New() *Bus {
+	return &Bus{subscribers: make(map[*Subscription]bool)}
+}
+
+// Subscribe handle events of given types with handler, events of every type if
+// none is given. Name identifies subscriber in logs and metrics
+func (b *Bus) Subscribe(name string, handler Handler, types ...string) *Subscription {
+	sub := &Subscription{
+		Name:    name,
+		types:   make(map[string]bool, len(types)),
+		handler: handler,
+		queue:   make(chan Event, queueSize),
+		bus:     b,
+	}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+	b.mutex.Lock()
+	b.subscribers[sub] = true
+	b.mutex.Unlock()
+	go sub.run()
+	return sub
+}
+
+// Publish deliver event to subscribers of its type, it does not wait for them
+// to handle it
+func (b *Bus) Publish(event Event) {
+	eventsPublished.WithLabelValues(event.Type()).Inc()
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for sub := range b.subscribers {
+		if len(sub.types) > 0 && !sub.types[event.Type()] {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			eventsDropped.WithLabelValues(sub.Name).Inc()
+			log.Warnf("Subscriber %s fell behind, %s event is dropped", sub.Name, event.Type())
+		}
+	}
+}
+
+// Close stop delivering events, events already queued are still handled
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mutex.Lock()
+		delete(s.bus.subscribers, s)
+		s.bus.mutex.Unlock()
+		close(s.queue)
+	})
+}
+
+func (s *Subscription) run() {
+	for event := range s.queue {
+		s.handler(event)
+	}
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/vrf"
+)
+
+// Event types
+const (
+	TypePeerConnected    = "peer.connected"
+	TypePeerDisconnected = "peer.disconnected"
+	TypeRoundFinalized   = "round.finalized"
+	TypeDKGPhaseChanged  = "dkg.phase"
+	TypeSyncProgress     = "sync.progress"
+	TypeVRFFulfilled     = "vrf.fulfilled"
+)
+
+// Event something that happened in node, subscribers tell events apart by
+// their type
+type Event interface {
+	Type() string
+}
+
+// PeerConnected node opened its first connection to a peer
+type PeerConnected struct {
+	Peer peer.ID
+	// Direction inbound or outbound
+	Direction string
+}
+
+func (PeerConnected) Type() string { return TypePeerConnected }
+
+// PeerDisconnected last connection of node to a peer was closed
+type PeerDisconnected struct {
+	Peer peer.ID
+}
+
+func (PeerDisconnected) Type() string { return TypePeerDisconnected }
+
+// RoundFinalized a round was produced or followed and stored
+type RoundFinalized struct {
+	// Beacon id of beacon that round belongs to, empty for the default beacon
+	// of node
+	Beacon string
+	Result beacon.RoundResult
+}
+
+func (RoundFinalized) Type() string { return TypeRoundFinalized }
+
+// DKGPhaseChanged a DKG ceremony entered a phase
+type DKGPhaseChanged struct {
+	Phase string
+}
+
+func (DKGPhaseChanged) Type() string { return TypeDKGPhaseChanged }
+
+// SyncProgress rounds were fetched from a peer while catching up with the chain
+type SyncProgress struct {
+	// Beacon id of synced beacon, empty for the default beacon of node
+	Beacon string
+	Peer   peer.ID
+	// Latest round in store
+	Latest uint64
+	// Target round that sync catches up to
+	Target uint64
+}
+
+func (SyncProgress) Type() string { return TypeSyncProgress }
+
+// VRFFulfilled group signed seed of an on-demand randomness request
+type VRFFulfilled struct {
+	Output vrf.Output
+}
+
+func (VRFFulfilled) Type() string { return TypeVRFFulfilled }
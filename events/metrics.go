@@ -0,0 +1,71 @@
+package events
+
+import (
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	roundsFinalized = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "beacon",
+		Name:      "rounds_finalized_total",
+		Help:      "Rounds finalized and stored, by beacon id, empty for the default beacon",
+	}, []string{"beacon"})
+	latestRound = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "beacon",
+		Name:      "latest_round",
+		Help:      "Latest finalized round, by beacon id, empty for the default beacon",
+	}, []string{"beacon"})
+	connectedPeers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "connected_peers",
+		Help:      "Peers node is connected to",
+	})
+	dkgPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "dkg",
+		Name:      "phase",
+		Help:      "Phase of a running DKG ceremony, 1 for the current phase",
+	}, []string{"phase"})
+	syncLatest = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "sync",
+		Name:      "latest_round",
+		Help:      "Latest round stored while catching up with the chain, by beacon id",
+	}, []string{"beacon"})
+	vrfFulfilled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "vrf",
+		Name:      "fulfilled_total",
+		Help:      "On-demand randomness requests fulfilled by group",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(roundsFinalized, latestRound, connectedPeers, dkgPhase, syncLatest, vrfFulfilled)
+}
+
+// ExportMetrics subscribe to events of bus and export them as metrics
+func ExportMetrics(bus *Bus) *Subscription {
+	return bus.Subscribe("metrics", func(event Event) {
+		switch e := event.(type) {
+		case PeerConnected:
+			connectedPeers.Inc()
+		case PeerDisconnected:
+			connectedPeers.Dec()
+		case RoundFinalized:
+			roundsFinalized.WithLabelValues(e.Beacon).Inc()
+			latestRound.WithLabelValues(e.Beacon).Set(float64(e.Result.Round))
+		case DKGPhaseChanged:
+			dkgPhase.Reset()
+			dkgPhase.WithLabelValues(e.Phase).Set(1)
+		case SyncProgress:
+			syncLatest.WithLabelValues(e.Beacon).Set(float64(e.Latest))
+		case VRFFulfilled:
+			vrfFulfilled.Inc()
+		}
+	})
+}
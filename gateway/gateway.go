@@ -0,0 +1,426 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/client"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// DefaultCacheSize number of verified rounds kept in memory
+const DefaultCacheSize = 1024
+
+// maxResponseBytes largest answer read from an upstream, a full page of
+// rounds is well below it
+const maxResponseBytes = 4 << 20
+
+// ErrNotFound round is not published by any upstream
+var ErrNotFound = errors.New("round not found")
+
+var upstreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "gateway",
+	Name:      "upstream_requests_total",
+	Help:      "Requests sent to upstream nodes, by upstream and outcome",
+}, []string{"upstream", "outcome"})
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("gateway")
+	metrics.Registry.MustRegister(upstreamRequests)
+}
+
+// Gateway serve public API of a beacon chain from several upstream nodes.
+// Every read is sent to all upstreams and the first answer whose rounds
+// verify against chain info is served, so the gateway keeps answering while
+// some upstreams are down, lagging behind or compromised
+type Gateway struct {
+	// GroupKey trusted group public key, when nil the key reported by the
+	// first upstream that answers is trusted
+	GroupKey []byte
+	// ChainHash trusted chain hash, chain info of upstreams must hash to it
+	ChainHash []byte
+	// HTTPClient used for requests to upstreams
+	HTTPClient *http.Client
+	// CacheSize number of verified rounds kept in memory besides latest round
+	CacheSize int
+	// APIKey and Token sent with every request to upstreams that meter or
+	// authenticate requests, nothing is sent if they're empty
+	APIKey    string
+	Token     string
+	upstreams []string
+	info      *api.Info
+	latest    *entry
+	// checked time upstreams were last asked for latest round
+	checked time.Time
+	rounds  map[uint64]*entry
+	order   []uint64
+	mutex   sync.Mutex
+	// refresh held while upstreams are asked for latest round, so concurrent
+	// requests wait for one answer instead of each asking every upstream
+	refresh sync.Mutex
+	mux     *http.ServeMux
+}
+
+// entry verified round with its encoded response
+type entry struct {
+	result *beacon.RoundResult
+	body   []byte
+	etag   string
+}
+
+// New create a gateway of given upstream endpoints, e.g. http://127.0.0.1:8080
+func New(upstreams ...string) (*Gateway, error) {
+	if len(upstreams) == 0 {
+		return nil, errors.New("at least one upstream is required")
+	}
+	g := &Gateway{
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		CacheSize:  DefaultCacheSize,
+		rounds:     make(map[uint64]*entry),
+		mux:        http.NewServeMux(),
+	}
+	for _, upstream := range upstreams {
+		g.upstreams = append(g.upstreams, strings.TrimRight(upstream, "/"))
+	}
+	g.mux.HandleFunc("/public", g.handleRounds)
+	g.mux.HandleFunc("/public/latest", g.handleLatest)
+	g.mux.HandleFunc("/public/", g.handleRound)
+	g.mux.HandleFunc("/info", g.handleInfo)
+	g.mux.HandleFunc("/healthz", api.ServeHealth)
+	g.mux.HandleFunc("/readyz", g.handleReady)
+	g.mux.Handle("/metrics", metrics.Handler())
+	return g, nil
+}
+
+// Handler HTTP handler serving the public API of upstreams
+func (g *Gateway) Handler() http.Handler {
+	return g.mux
+}
+
+// Info chain info of beacon, checked against GroupKey and ChainHash. The
+// first chain info that passes is pinned for the life of gateway
+func (g *Gateway) Info(ctx context.Context) (*api.Info, error) {
+	g.mutex.Lock()
+	info := g.info
+	g.mutex.Unlock()
+	if info != nil {
+		return info, nil
+	}
+	value, err := g.race(ctx, "/info", g.decodeInfo)
+	if err != nil {
+		return nil, err
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.info == nil {
+		g.info = value.(*api.Info)
+	}
+	return g.info, nil
+}
+
+func (g *Gateway) decodeInfo(body []byte) (interface{}, error) {
+	info := new(api.Info)
+	if err := json.Unmarshal(body, info); err != nil {
+		return nil, err
+	}
+	chainInfo, err := info.ChainInfo()
+	if err != nil || len(chainInfo.PublicKey) == 0 {
+		return nil, errors.New("upstream does not report a group public key")
+	}
+	if g.GroupKey != nil && !bytes.Equal(chainInfo.PublicKey, g.GroupKey) {
+		return nil, errors.New("upstream reports a different group public key")
+	}
+	if g.ChainHash != nil && !bytes.Equal(chainInfo.Hash(), g.ChainHash) {
+		return nil, errors.New("chain info of upstream does not match chain hash")
+	}
+	return info, nil
+}
+
+// latestRound verified latest round, upstreams are asked again only once a
+// newer round is due. The last verified round is served while no upstream
+// answers
+func (g *Gateway) latestRound(ctx context.Context) (*entry, error) {
+	info, err := g.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest := g.freshLatest(info); latest != nil {
+		return latest, nil
+	}
+	g.refresh.Lock()
+	defer g.refresh.Unlock()
+	// Another request may have asked upstreams while this one waited
+	if latest := g.freshLatest(info); latest != nil {
+		return latest, nil
+	}
+	value, err := g.race(ctx, "/public/latest", decodeRound(info, 0))
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.checked = time.Now()
+	if err != nil {
+		if g.latest != nil && err != ErrNotFound {
+			log.Warnf("Serve cached round %d, no upstream answered: %v", g.latest.result.Round, err)
+			return g.latest, nil
+		}
+		return nil, err
+	}
+	g.remember(value.(*entry))
+	return g.latest, nil
+}
+
+// freshLatest cached latest round unless a newer round is due and upstreams
+// were not asked for it recently
+func (g *Gateway) freshLatest(info *api.Info) *entry {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.latest == nil {
+		return nil
+	}
+	now := time.Now()
+	if g.latest.result.Round >= currentRound(info, now) || now.Sub(g.checked) < refreshInterval(info) {
+		return g.latest
+	}
+	return nil
+}
+
+// round verified round by its number, rounds that are not due yet are not
+// asked of upstreams
+func (g *Gateway) round(ctx context.Context, round uint64) (*entry, error) {
+	info, err := g.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if round > currentRound(info, time.Now()) {
+		return nil, ErrNotFound
+	}
+	g.mutex.Lock()
+	cached := g.rounds[round]
+	g.mutex.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	value, err := g.race(ctx, fmt.Sprintf("/public/%d", round), decodeRound(info, round))
+	if err != nil {
+		return nil, err
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.remember(value.(*entry))
+	return value.(*entry), nil
+}
+
+// list verified rounds from start, at most count of them
+func (g *Gateway) list(ctx context.Context, start uint64, count uint64) ([]*api.Round, error) {
+	info, err := g.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := g.race(ctx, fmt.Sprintf("/public?start=%d&count=%d", start, count), decodeRounds(info, start, count))
+	if err != nil {
+		return nil, err
+	}
+	results := value.([]*beacon.RoundResult)
+	rounds := make([]*api.Round, 0, len(results))
+	for _, result := range results {
+		rounds = append(rounds, api.NewRound(result))
+	}
+	return rounds, nil
+}
+
+// remember cache a verified round, oldest cached rounds are evicted first.
+// Mutex must be held
+func (g *Gateway) remember(cached *entry) {
+	round := cached.result.Round
+	if g.latest == nil || round > g.latest.result.Round {
+		g.latest = cached
+	}
+	if g.CacheSize <= 0 || g.rounds[round] != nil {
+		return
+	}
+	g.rounds[round] = cached
+	g.order = append(g.order, round)
+	for len(g.order) > g.CacheSize {
+		delete(g.rounds, g.order[0])
+		g.order = g.order[1:]
+	}
+}
+
+// race ask every upstream for path and return the first answer that decode
+// accepts, ErrNotFound is returned only if no upstream has it
+func (g *Gateway) race(ctx context.Context, path string, decode func([]byte) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type answer struct {
+		value interface{}
+		err   error
+	}
+	answers := make(chan answer, len(g.upstreams))
+	for _, upstream := range g.upstreams {
+		go func(upstream string) {
+			value, err := g.ask(ctx, upstream, path, decode)
+			answers <- answer{value: value, err: err}
+		}(upstream)
+	}
+	var errs []string
+	notFound := false
+	for range g.upstreams {
+		a := <-answers
+		if a.err == nil {
+			return a.value, nil
+		}
+		// A lagging upstream may miss a round others have
+		if a.err == ErrNotFound {
+			notFound = true
+			continue
+		}
+		errs = append(errs, a.err.Error())
+	}
+	if notFound {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("no upstream answered: %s", strings.Join(errs, "; "))
+}
+
+// ask send a request to upstream and decode its answer
+func (g *Gateway) ask(ctx context.Context, upstream string, path string, decode func([]byte) (interface{}, error)) (interface{}, error) {
+	outcome := "ok"
+	defer func() {
+		// Answers that lost the race are canceled rather than failed
+		if outcome != "ok" && ctx.Err() != nil {
+			outcome = "canceled"
+		}
+		upstreamRequests.WithLabelValues(upstream, outcome).Inc()
+	}()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream+path, nil)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	if g.APIKey != "" {
+		request.Header.Set(api.APIKeyHeader, g.APIKey)
+	}
+	if g.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	resp, err := g.HTTPClient.Do(request)
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		outcome = "not_found"
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		outcome = "error"
+		return nil, fmt.Errorf("%s: %s", upstream, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		outcome = "error"
+		return nil, err
+	}
+	value, err := decode(body)
+	if err != nil {
+		outcome = "invalid"
+		log.Warnf("Upstream %s sent an invalid answer to %s: %v", upstream, path, err)
+		return nil, fmt.Errorf("%s: %v", upstream, err)
+	}
+	return value, nil
+}
+
+// decodeRound decode and verify a round, expected is 0 for latest round
+func decodeRound(info *api.Info, expected uint64) func([]byte) (interface{}, error) {
+	return func(body []byte) (interface{}, error) {
+		apiRound := new(api.Round)
+		if err := json.Unmarshal(body, apiRound); err != nil {
+			return nil, err
+		}
+		result, err := apiRound.Result()
+		if err != nil {
+			return nil, err
+		}
+		if expected > 0 && result.Round != expected {
+			return nil, fmt.Errorf("round %d instead of %d", result.Round, expected)
+		}
+		if err := client.Verify(info, result); err != nil {
+			return nil, err
+		}
+		return newEntry(result)
+	}
+}
+
+// decodeRounds decode and verify ascending rounds from start, at most count
+// of them
+func decodeRounds(info *api.Info, start uint64, count uint64) func([]byte) (interface{}, error) {
+	return func(body []byte) (interface{}, error) {
+		var apiRounds []api.Round
+		if err := json.Unmarshal(body, &apiRounds); err != nil {
+			return nil, err
+		}
+		results := make([]*beacon.RoundResult, 0, len(apiRounds))
+		var last uint64
+		for _, apiRound := range apiRounds {
+			result, err := apiRound.Result()
+			if err != nil {
+				return nil, err
+			}
+			if result.Round < start || result.Round-start >= count || result.Round <= last {
+				return nil, fmt.Errorf("round %d out of requested range", result.Round)
+			}
+			if err := client.Verify(info, result); err != nil {
+				return nil, err
+			}
+			last = result.Round
+			results = append(results, result)
+		}
+		return results, nil
+	}
+}
+
+// newEntry encode round the way the API of a node does, ETag is a hash of
+// the encoded response so it's strong
+func newEntry(result *beacon.RoundResult) (*entry, error) {
+	body, err := json.Marshal(api.NewRound(result))
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, '\n')
+	return &entry{result: result, body: body, etag: api.ETag(body)}, nil
+}
+
+// currentRound round number at given time, round 1 starts at genesis
+func currentRound(info *api.Info, t time.Time) uint64 {
+	genesis := time.Unix(info.GenesisTime, 0)
+	if info.Period == 0 || t.Before(genesis) {
+		return 0
+	}
+	return uint64(t.Sub(genesis)/(time.Duration(info.Period)*time.Second)) + 1
+}
+
+// refreshInterval time between requests for a round that is due but not
+// published yet
+func refreshInterval(info *api.Info) time.Duration {
+	interval := time.Duration(info.Period) * time.Second / 10
+	if interval < 500*time.Millisecond {
+		interval = 500 * time.Millisecond
+	}
+	return interval
+}
@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/orochi-network/orochimaru/api"
+)
+
+func (g *Gateway) handleLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	g.writeLatest(w, r)
+}
+
+func (g *Gateway) handleRound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	round, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/public/"), 10, 64)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, errors.New("invalid round number"))
+		return
+	}
+	// Round 0 is an alias of latest round
+	if round == 0 {
+		g.writeLatest(w, r)
+		return
+	}
+	cached, err := g.round(r.Context(), round)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	api.WriteCached(w, r, cached.body, cached.etag, api.CacheControl("public", api.ImmutableMaxAge))
+}
+
+// writeLatest write latest round, clients may cache it until the next round
+// is due
+func (g *Gateway) writeLatest(w http.ResponseWriter, r *http.Request) {
+	latest, err := g.latestRound(r.Context())
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	info, _ := g.Info(r.Context())
+	api.WriteCached(w, r, latest.body, latest.etag, api.CacheControl("public", latestMaxAge(info, latest.result.Round)))
+}
+
+// handleRounds serve up to count sequential rounds from start like the API of
+// a node does
+func (g *Gateway) handleRounds(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	query := r.URL.Query()
+	start, err := strconv.ParseUint(query.Get("start"), 10, 64)
+	if err != nil || start == 0 {
+		api.WriteError(w, http.StatusBadRequest, errors.New("invalid start round"))
+		return
+	}
+	count := uint64(api.DefaultRoundsCount)
+	if value := query.Get("count"); value != "" {
+		if count, err = strconv.ParseUint(value, 10, 64); err != nil || count == 0 || count > api.MaxRoundsCount {
+			api.WriteError(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", api.MaxRoundsCount))
+			return
+		}
+	}
+	rounds, err := g.list(r.Context(), start, count)
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	body, err := json.Marshal(rounds)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	body = append(body, '\n')
+	info, _ := g.Info(r.Context())
+	// Upstreams may still sync missing rounds, so lists are not immutable
+	api.WriteCached(w, r, body, api.ETag(body), api.CacheControl("public", latestMaxAge(info, currentRound(info, time.Now()))))
+}
+
+func (g *Gateway) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	info, err := g.Info(r.Context())
+	if err != nil {
+		writeFailure(w, err)
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, info)
+}
+
+// handleReady report whether gateway serves fresh rounds, it responds 503 if
+// no upstream gives a recent round
+func (g *Gateway) handleReady(w http.ResponseWriter, r *http.Request) {
+	api.ServeReady(w, r, []api.ReadinessCheck{{Name: "round", Run: func() error {
+		return g.checkLatestRound(r)
+	}}})
+}
+
+// checkLatestRound latest round must be within api.StaleRounds periods of
+// current round
+func (g *Gateway) checkLatestRound(r *http.Request) error {
+	latest, err := g.latestRound(r.Context())
+	if err == ErrNotFound {
+		return errors.New("no round yet")
+	}
+	if err != nil {
+		log.Warn(err)
+		return errors.New("no upstream answered")
+	}
+	info, _ := g.Info(r.Context())
+	if current := currentRound(info, time.Now()); latest.result.Round+api.StaleRounds < current {
+		return fmt.Errorf("latest round %d is behind current round %d", latest.result.Round, current)
+	}
+	return nil
+}
+
+// latestMaxAge seconds until a round newer than latest is due, 0 if it's
+// already being produced
+func latestMaxAge(info *api.Info, latest uint64) int {
+	now := time.Now()
+	if latest < currentRound(info, now) {
+		return 0
+	}
+	next := time.Unix(info.GenesisTime, 0).Add(time.Duration(latest) * time.Duration(info.Period) * time.Second)
+	return int(next.Sub(now).Seconds())
+}
+
+// writeFailure 404 if no upstream has a round, 502 if no upstream answered.
+// Errors of upstreams are logged rather than sent, they name upstreams
+func writeFailure(w http.ResponseWriter, err error) {
+	if err == ErrNotFound {
+		api.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	log.Warn(err)
+	api.WriteError(w, http.StatusBadGateway, errors.New("no upstream answered"))
+}
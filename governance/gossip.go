@@ -0,0 +1,73 @@
+package governance
+
+import (
+	"fmt"
+
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"go.uber.org/zap"
+)
+
+// Topic pubsub topic that governance messages are gossiped on
+const Topic = "governance"
+
+// MessageGovernance envelope type of governance messages
+const MessageGovernance = "governance"
+
+// Recorder keeps applied messages as part of chain history
+type Recorder interface {
+	PutGovernance(m *Message) error
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("governance")
+}
+
+// Publish gossip message to peers, each one applies it once it's approved
+// by quorum
+func Publish(net *network.Network, m *Message) error {
+	payload, err := m.Encode()
+	if err != nil {
+		return err
+	}
+	return net.Send(Topic, MessageGovernance, 0, payload)
+}
+
+// Watch apply messages gossiped on Topic to state. Applied messages are
+// recorded, then passed to onApply if it's not nil
+func Watch(net *network.Network, state *State, recorder Recorder, onApply func(*Message)) error {
+	return net.Handle(Topic, MessageGovernance, func(e *network.Envelope) {
+		m := new(Message)
+		if err := network.DecodePayload(e.Payload, m); err != nil {
+			log.Debugf("Invalid governance message from %s: %v", e.Sender, err)
+			return
+		}
+		if err := state.Apply(m); err != nil {
+			if err != ErrNonceUsed {
+				log.Warnf("Reject %s proposal %d from %s: %v", m.Proposal.Kind, m.Proposal.Nonce, e.Sender, err)
+			}
+			return
+		}
+		if recorder != nil {
+			if err := recorder.PutGovernance(m); err != nil {
+				log.Errorf("Unable to record %s proposal %d: %v", m.Proposal.Kind, m.Proposal.Nonce, err)
+			}
+		}
+		log.Infof("Applied %s proposal %d", m.Proposal.Kind, m.Proposal.Nonce)
+		if onApply != nil {
+			onApply(m)
+		}
+	})
+}
+
+// Replay apply recorded history to state in order, as after a restart
+func (s *State) Replay(history []*Message) error {
+	for _, m := range history {
+		if err := s.Apply(m); err != nil {
+			return fmt.Errorf("recorded %s proposal %d does not apply: %v", m.Proposal.Kind, m.Proposal.Nonce, err)
+		}
+	}
+	return nil
+}
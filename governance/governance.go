@@ -0,0 +1,239 @@
+package governance
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// Kind of governance action
+type Kind string
+
+const (
+	// KindChangePeriod change round period
+	KindChangePeriod Kind = "change_period"
+	// KindChangeThreshold change signing threshold
+	KindChangeThreshold Kind = "change_threshold"
+	// KindChangeMembership replace participant set
+	KindChangeMembership Kind = "change_membership"
+	// KindReshare reshare group secret to a new member set and threshold
+	KindReshare Kind = "reshare"
+	// KindHalt stop signing immediately, requires admin quorum
+	KindHalt Kind = "halt"
+	// KindResume resume signing after a halt, requires admin quorum
+	KindResume Kind = "resume"
+)
+
+// Proposal parameter change proposed to participants
+type Proposal struct {
+	Kind Kind `json:"kind"`
+	// ChainHash hash of chain info of beacon the proposal applies to, digest
+	// covers it so approvals can't be replayed on another chain
+	ChainHash []byte        `json:"chainHash"`
+	Nonce     uint64        `json:"nonce"`
+	Period    time.Duration `json:"period,omitempty"`
+	Threshold uint          `json:"threshold,omitempty"`
+	Members   []peer.ID     `json:"members,omitempty"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// Approval signature of a participant over a proposal
+type Approval struct {
+	Signer    peer.ID `json:"signer"`
+	Signature []byte  `json:"signature"`
+}
+
+// Message proposal together with its approvals
+type Message struct {
+	Proposal  Proposal   `json:"proposal"`
+	Approvals []Approval `json:"approvals"`
+}
+
+// ErrNonceUsed proposal was applied already, or a later one was
+var ErrNonceUsed = errors.New("proposal nonce was already used")
+
+// Params governed parameters
+type Params struct {
+	Period    time.Duration
+	Threshold uint
+	Members   []peer.ID
+}
+
+// State current governed parameters and applied history
+type State struct {
+	chainHash   []byte
+	params      Params
+	quorum      uint
+	admins      []peer.ID
+	adminQuorum uint
+	halted      bool
+	nonce       uint64
+	history     []*Message
+	mutex       sync.Mutex
+}
+
+// Digest of proposal, this is the data participants sign
+func (p *Proposal) Digest() ([]byte, error) {
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(encoded)
+	return digest[:], nil
+}
+
+// Decode message from its JSON form
+func Decode(data []byte) (*Message, error) {
+	msg := new(Message)
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Encode message to its JSON form
+func (m *Message) Encode() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// Approve sign proposal with given signer and attach approval
+func (m *Message) Approve(k keypair.Signer) error {
+	signer, err := k.GetID()
+	if err != nil {
+		return err
+	}
+	digest, err := m.Proposal.Digest()
+	if err != nil {
+		return err
+	}
+	signature, err := k.Sign(digest)
+	if err != nil {
+		return err
+	}
+	m.Approvals = append(m.Approvals, Approval{Signer: signer, Signature: signature})
+	return nil
+}
+
+// Verify message carry valid approvals from at least quorum distinct members
+func (m *Message) Verify(members []peer.ID, quorum uint) error {
+	digest, err := m.Proposal.Digest()
+	if err != nil {
+		return err
+	}
+	isMember := make(map[peer.ID]bool)
+	for _, member := range members {
+		isMember[member] = true
+	}
+	approved := make(map[peer.ID]bool)
+	for _, approval := range m.Approvals {
+		if !isMember[approval.Signer] || approved[approval.Signer] {
+			continue
+		}
+		pubKey, err := approval.Signer.ExtractPublicKey()
+		if err != nil {
+			continue
+		}
+		if ok, err := pubKey.Verify(digest, approval.Signature); err == nil && ok {
+			approved[approval.Signer] = true
+		}
+	}
+	if uint(len(approved)) < quorum {
+		return errors.New("proposal does not reach quorum")
+	}
+	return nil
+}
+
+// NewState create governance state of chain with given hash, with initial
+// parameters and quorum
+func NewState(chainHash []byte, params Params, quorum uint) *State {
+	return &State{chainHash: chainHash, params: params, quorum: quorum}
+}
+
+// SetAdmins set designated admin keys allowed to halt and resume, k-of-m
+func (s *State) SetAdmins(admins []peer.ID, quorum uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.admins = append([]peer.ID(nil), admins...)
+	s.adminQuorum = quorum
+}
+
+// Halted participants must not sign while state is halted
+func (s *State) Halted() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.halted
+}
+
+// Params get current parameters
+func (s *State) Params() Params {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.params
+}
+
+// History get applied messages in order
+func (s *State) History() []*Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*Message(nil), s.history...)
+}
+
+// Apply message to state, it takes effect only if signed by quorum of current members
+func (s *State) Apply(m *Message) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !bytes.Equal(m.Proposal.ChainHash, s.chainHash) {
+		return errors.New("proposal is for another chain")
+	}
+	if m.Proposal.Nonce <= s.nonce {
+		return ErrNonceUsed
+	}
+	if m.Proposal.Kind == KindHalt || m.Proposal.Kind == KindResume {
+		if len(s.admins) == 0 || s.adminQuorum == 0 {
+			return errors.New("no admin keys were configured")
+		}
+		if err := m.Verify(s.admins, s.adminQuorum); err != nil {
+			return err
+		}
+	} else if err := m.Verify(s.params.Members, s.quorum); err != nil {
+		return err
+	}
+	switch m.Proposal.Kind {
+	case KindHalt:
+		s.halted = true
+	case KindResume:
+		s.halted = false
+	case KindChangePeriod:
+		if m.Proposal.Period <= 0 {
+			return errors.New("period must be positive")
+		}
+		s.params.Period = m.Proposal.Period
+	case KindChangeThreshold:
+		if m.Proposal.Threshold == 0 || m.Proposal.Threshold > uint(len(s.params.Members)) {
+			return errors.New("threshold is out of range")
+		}
+		s.params.Threshold = m.Proposal.Threshold
+	case KindChangeMembership:
+		if uint(len(m.Proposal.Members)) < s.params.Threshold {
+			return errors.New("members are fewer than threshold")
+		}
+		s.params.Members = append([]peer.ID(nil), m.Proposal.Members...)
+	case KindReshare:
+		if m.Proposal.Threshold == 0 || m.Proposal.Threshold > uint(len(m.Proposal.Members)) {
+			return errors.New("threshold is out of range")
+		}
+		s.params.Members = append([]peer.ID(nil), m.Proposal.Members...)
+		s.params.Threshold = m.Proposal.Threshold
+	default:
+		return errors.New("unknown proposal kind")
+	}
+	s.nonce = m.Proposal.Nonce
+	s.history = append(s.history, m)
+	return nil
+}
@@ -0,0 +1,253 @@
+package group
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// Group beacon group, members sign the group file to agree on its parameters
+type Group struct {
+	// Members of group, index of a member is its position plus one
+	Members     []peer.ID
+	Threshold   int
+	Period      time.Duration
+	GenesisTime time.Time
+	// Mode beacon mode of group, chained or unchained, empty means chained
+	Mode string
+	// PublicKey group public key, it's known once DKG has finished
+	PublicKey  []byte
+	Signatures []Signature
+	// Handovers key changes of members, they are not covered by signatures
+	// of group since each one is signed by the keys it concerns
+	Handovers []Handover
+}
+
+// Signature signature of a member over group digest
+type Signature struct {
+	Signer    peer.ID `json:"signer"`
+	Signature []byte  `json:"signature"`
+}
+
+// groupJSON file format of group, period is in seconds and genesis time is a Unix timestamp
+type groupJSON struct {
+	Members     []peer.ID   `json:"members"`
+	Threshold   int         `json:"threshold"`
+	Period      uint64      `json:"period"`
+	GenesisTime int64       `json:"genesisTime"`
+	Mode        string      `json:"mode,omitempty"`
+	PublicKey   string      `json:"publicKey,omitempty"`
+	Signatures  []Signature `json:"signatures,omitempty"`
+	Handovers   []Handover  `json:"handovers,omitempty"`
+}
+
+// Beacon modes a group can run, they match modes of beacon package
+const (
+	ModeChained   = "chained"
+	ModeUnchained = "unchained"
+)
+
+// New create an unsigned group
+func New(members []peer.ID, threshold int, period time.Duration, genesisTime time.Time, mode string) (*Group, error) {
+	g := &Group{Members: members, Threshold: threshold, Period: period, GenesisTime: genesisTime, Mode: mode}
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// check parameters of group
+func (g *Group) check() error {
+	if len(g.Members) == 0 {
+		return errors.New("group has no member")
+	}
+	if g.Threshold < 1 || g.Threshold > len(g.Members) {
+		return errors.New("threshold must be between 1 and number of members")
+	}
+	if g.Period < time.Second || g.Period%time.Second != 0 {
+		return errors.New("period must be a positive number of seconds")
+	}
+	if g.Mode != "" && g.Mode != ModeChained && g.Mode != ModeUnchained {
+		return errors.New("mode of group must be chained or unchained")
+	}
+	seen := make(map[peer.ID]bool)
+	for _, member := range g.Members {
+		if seen[member] {
+			return errors.New("group has duplicated member")
+		}
+		if _, err := member.ExtractPublicKey(); err != nil {
+			return errors.New("public key of member can not be extracted from its peer ID")
+		}
+		seen[member] = true
+	}
+	return nil
+}
+
+func (g *Group) toJSON() *groupJSON {
+	return &groupJSON{
+		Members:     g.Members,
+		Threshold:   g.Threshold,
+		Period:      uint64(g.Period / time.Second),
+		GenesisTime: g.GenesisTime.Unix(),
+		Mode:        g.Mode,
+		PublicKey:   hex.EncodeToString(g.PublicKey),
+		Signatures:  g.Signatures,
+		Handovers:   g.Handovers,
+	}
+}
+
+// Digest of group parameters, this is the data members sign
+func (g *Group) Digest() ([]byte, error) {
+	unsigned := g.toJSON()
+	unsigned.Signatures = nil
+	unsigned.Handovers = nil
+	encoded, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(encoded)
+	return digest[:], nil
+}
+
+// Index index of a member, 0 if peer is not a member
+func (g *Group) Index(id peer.ID) uint32 {
+	for i, member := range g.Members {
+		if member == id {
+			return uint32(i + 1)
+		}
+	}
+	return 0
+}
+
+// IsMember check whether peer is a member of group
+func (g *Group) IsMember(id peer.ID) bool {
+	return g.Index(id) != 0
+}
+
+// Sign group with signer of a member and attach signature
+func (g *Group) Sign(k keypair.Signer) error {
+	signer, err := k.GetID()
+	if err != nil {
+		return err
+	}
+	if !g.IsMember(signer) {
+		return errors.New("signer is not a member of group")
+	}
+	digest, err := g.Digest()
+	if err != nil {
+		return err
+	}
+	signature, err := k.Sign(digest)
+	if err != nil {
+		return err
+	}
+	signatures := make([]Signature, 0, len(g.Signatures)+1)
+	for _, s := range g.Signatures {
+		if s.Signer != signer {
+			signatures = append(signatures, s)
+		}
+	}
+	g.Signatures = append(signatures, Signature{Signer: signer, Signature: signature})
+	return nil
+}
+
+// Verify group carries valid signatures from at least threshold distinct members
+func (g *Group) Verify() error {
+	if err := g.check(); err != nil {
+		return err
+	}
+	digest, err := g.Digest()
+	if err != nil {
+		return err
+	}
+	signed := make(map[peer.ID]bool)
+	for _, s := range g.Signatures {
+		if !g.IsMember(s.Signer) || signed[s.Signer] {
+			continue
+		}
+		pubKey, err := s.Signer.ExtractPublicKey()
+		if err != nil {
+			continue
+		}
+		if ok, err := pubKey.Verify(digest, s.Signature); err == nil && ok {
+			signed[s.Signer] = true
+		}
+	}
+	if len(signed) < g.Threshold {
+		return errors.New("group file is not signed by threshold of members")
+	}
+	// Replay handovers so each one is checked against members at its time
+	handovers := g.Handovers
+	g.Handovers = nil
+	for i := range handovers {
+		if err := g.AddHandover(&handovers[i]); err != nil {
+			g.Handovers = handovers
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveToFile save group to file
+func (g *Group) SaveToFile(fileName string) (bool, error) {
+	encoded, err := json.MarshalIndent(g.toJSON(), "", "  ")
+	if err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(fileName, encoded, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LoadFromFile load group from file, group is not verified
+func LoadFromFile(fileName string) (*Group, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	file := new(groupJSON)
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, err
+	}
+	return file.group()
+}
+
+// group decode group of file format
+func (file *groupJSON) group() (*Group, error) {
+	publicKey, err := hex.DecodeString(file.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKey) == 0 {
+		publicKey = nil
+	}
+	return &Group{
+		Members:     file.Members,
+		Threshold:   file.Threshold,
+		Period:      time.Duration(file.Period) * time.Second,
+		GenesisTime: time.Unix(file.GenesisTime, 0),
+		Mode:        file.Mode,
+		PublicKey:   publicKey,
+		Signatures:  file.Signatures,
+		Handovers:   file.Handovers,
+	}, nil
+}
+
+// LoadVerifiedFromFile load group from file and verify its signatures
+func LoadVerifiedFromFile(fileName string) (*Group, error) {
+	g, err := LoadFromFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.Verify(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
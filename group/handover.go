@@ -0,0 +1,134 @@
+package group
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// HandoverTopic topic that handover statements are broadcast on
+const HandoverTopic = "handover"
+
+// HandoverMessage envelope type of handover statements
+const HandoverMessage = "handover"
+
+// ErrHandoverKnown handover was already applied to group
+var ErrHandoverKnown = errors.New("handover was already applied")
+
+// Handover statement of a member retiring its node key in favor of a new one.
+// It's signed by both keys, old key authorizes handover and new key proves it
+// is held by the same operator
+type Handover struct {
+	Old          peer.ID `json:"old"`
+	New          peer.ID `json:"new"`
+	Timestamp    int64   `json:"timestamp"`
+	OldSignature []byte  `json:"oldSignature"`
+	NewSignature []byte  `json:"newSignature"`
+}
+
+// NewHandover create a handover from old key to new key signed by both
+func NewHandover(oldKey keypair.Signer, newKey keypair.Signer) (*Handover, error) {
+	oldID, err := oldKey.GetID()
+	if err != nil {
+		return nil, err
+	}
+	newID, err := newKey.GetID()
+	if err != nil {
+		return nil, err
+	}
+	if oldID == newID {
+		return nil, errors.New("new key is the same as old key")
+	}
+	h := &Handover{Old: oldID, New: newID, Timestamp: time.Now().Unix()}
+	digest, err := h.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if h.OldSignature, err = oldKey.Sign(digest); err != nil {
+		return nil, err
+	}
+	if h.NewSignature, err = newKey.Sign(digest); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Digest of handover, this is the data both keys sign
+func (h *Handover) Digest() ([]byte, error) {
+	encoded, err := json.Marshal(&Handover{Old: h.Old, New: h.New, Timestamp: h.Timestamp})
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(encoded)
+	return digest[:], nil
+}
+
+// Verify signatures of old and new key
+func (h *Handover) Verify() error {
+	digest, err := h.Digest()
+	if err != nil {
+		return err
+	}
+	for _, s := range []struct {
+		signer    peer.ID
+		signature []byte
+	}{{h.Old, h.OldSignature}, {h.New, h.NewSignature}} {
+		pubKey, err := s.signer.ExtractPublicKey()
+		if err != nil {
+			return errors.New("public key of handover key can not be extracted from its peer ID")
+		}
+		if ok, err := pubKey.Verify(digest, s.signature); err != nil || !ok {
+			return errors.New("invalid handover signature of " + s.signer.Pretty())
+		}
+	}
+	return nil
+}
+
+// CurrentMembers members of group after applying handovers, a member keeps
+// its index when its key is handed over
+func (g *Group) CurrentMembers() []peer.ID {
+	members := append([]peer.ID(nil), g.Members...)
+	for _, h := range g.Handovers {
+		for i, member := range members {
+			if member == h.Old {
+				members[i] = h.New
+			}
+		}
+	}
+	return members
+}
+
+// AddHandover verify a handover against current members and record it
+func (g *Group) AddHandover(h *Handover) error {
+	for _, known := range g.Handovers {
+		if known.Old == h.Old && known.New == h.New {
+			return ErrHandoverKnown
+		}
+	}
+	members := g.CurrentMembers()
+	if indexOf(members, h.Old) == 0 {
+		return errors.New("old key of handover is not a member")
+	}
+	if indexOf(members, h.New) != 0 {
+		return errors.New("new key of handover is already a member")
+	}
+	if err := h.Verify(); err != nil {
+		return err
+	}
+	g.Handovers = append(g.Handovers, *h)
+	return nil
+}
+
+// indexOf position of id in list plus one, 0 if it's not in list
+func indexOf(list []peer.ID, id peer.ID) int {
+	for i, member := range list {
+		if member == id {
+			return i + 1
+		}
+	}
+	return 0
+}
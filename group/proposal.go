@@ -0,0 +1,106 @@
+package group
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ProposalTopic topic that coordinators propose new groups on and members
+// accept them
+const ProposalTopic = "group-proposal"
+
+// Message types of ProposalTopic
+const (
+	// ProposalMessage coordinator proposes a group and its DKG ceremony
+	ProposalMessage = "propose"
+	// AcceptanceMessage member accepts a proposal
+	AcceptanceMessage = "accept"
+	// StartMessage coordinator tells members to start the ceremony once all
+	// of them accepted
+	StartMessage = "start"
+)
+
+// Proposal group proposed by a coordinator before its DKG ceremony, members
+// that accept it run the ceremony with its phase timeout
+type Proposal struct {
+	Group        *Group
+	PhaseTimeout time.Duration
+}
+
+// Acceptance payload of acceptance and start messages, the envelope
+// signature tells which member accepted
+type Acceptance struct {
+	ID string `json:"id"`
+}
+
+// proposalJSON wire format of proposal, phase timeout is in seconds
+type proposalJSON struct {
+	Group        *groupJSON `json:"group"`
+	PhaseTimeout uint64     `json:"phaseTimeout"`
+}
+
+// NewProposal propose an unsigned group without public key
+func NewProposal(g *Group, phaseTimeout time.Duration) (*Proposal, error) {
+	p := &Proposal{Group: g, PhaseTimeout: phaseTimeout}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Proposal) check() error {
+	if err := p.Group.check(); err != nil {
+		return err
+	}
+	if p.Group.PublicKey != nil || len(p.Group.Signatures) > 0 || len(p.Group.Handovers) > 0 {
+		return errors.New("proposed group must not have a public key, signatures or handovers")
+	}
+	if p.PhaseTimeout < time.Second || p.PhaseTimeout%time.Second != 0 {
+		return errors.New("phase timeout must be a positive number of seconds")
+	}
+	return nil
+}
+
+// ID identify proposal by digest of its group and phase timeout, members are
+// given ID out of band so they accept exactly the proposed ceremony
+func (p *Proposal) ID() (string, error) {
+	digest, err := p.Group.Digest()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(p.PhaseTimeout/time.Second))
+	h := sha256.New()
+	h.Write(digest)
+	h.Write(buf)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Marshal encode proposal
+func (p *Proposal) Marshal() ([]byte, error) {
+	return json.Marshal(proposalJSON{Group: p.Group.toJSON(), PhaseTimeout: uint64(p.PhaseTimeout / time.Second)})
+}
+
+// UnmarshalProposal decode proposal and check its group
+func UnmarshalProposal(data []byte) (*Proposal, error) {
+	file := new(proposalJSON)
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	if file.Group == nil {
+		return nil, errors.New("proposal has no group")
+	}
+	g, err := file.Group.group()
+	if err != nil {
+		return nil, err
+	}
+	p := &Proposal{Group: g, PhaseTimeout: time.Duration(file.PhaseTimeout) * time.Second}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
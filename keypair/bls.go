@@ -0,0 +1,414 @@
+package keypair
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// BLS signatures over the BLS12-381 pairing curve, public keys live in G2 and
+// signatures in G1 so signatures stay short. Points are encoded compressed as
+// ZCash does, and messages are hashed to G1 with the
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ suite of RFC 9380, so signatures follow the
+// proof of possession scheme of the IETF BLS signature draft
+
+// SchemeBLS name of BLS scheme
+const SchemeBLS = "bls12-381"
+
+// Sizes of encoded BLS values
+const (
+	BLSSecretKeySize = 32
+	BLSPublicKeySize = 96
+	BLSSignatureSize = 48
+)
+
+// Domain separation tags of signatures and of proofs of possession
+var (
+	blsDomain = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_")
+	popDomain = []byte("BLS_POP_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_")
+)
+
+// CurveOrder order of BLS12-381 groups, secret keys and shares are scalars
+// modulo it
+var CurveOrder = bls12381.NewG1().Q()
+
+// BLSKeyPair BLS key pair
+type BLSKeyPair struct {
+	secret *big.Int
+	public *bls12381.PointG2
+}
+
+func init() {
+	RegisterScheme(&blsScheme{})
+}
+
+// NewBLS generate a new BLS key pair
+func NewBLS() (*BLSKeyPair, error) {
+	return newBLSWithReader(rand.Reader)
+}
+
+func newBLSWithReader(random io.Reader) (*BLSKeyPair, error) {
+	secret, err := RandomScalar(random)
+	if err != nil {
+		return nil, err
+	}
+	return &BLSKeyPair{secret: secret, public: G2Base(secret)}, nil
+}
+
+// RandomScalar random non-zero scalar modulo CurveOrder
+func RandomScalar(random io.Reader) (*big.Int, error) {
+	scalar, err := rand.Int(random, new(big.Int).Sub(CurveOrder, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return scalar.Add(scalar, big.NewInt(1)), nil
+}
+
+// G2Base scalar multiple of generator of G2
+func G2Base(scalar *big.Int) *bls12381.PointG2 {
+	g2 := bls12381.NewG2()
+	return g2.MulScalarBig(g2.New(), g2.One(), scalar)
+}
+
+// BLSFromSecret restore a BLS key pair from raw secret key
+func BLSFromSecret(b []byte) (*BLSKeyPair, error) {
+	if len(b) != BLSSecretKeySize {
+		return nil, errors.New("invalid BLS secret key size")
+	}
+	secret := new(big.Int).SetBytes(b)
+	if secret.Sign() == 0 || secret.Cmp(CurveOrder) >= 0 {
+		return nil, errors.New("BLS secret key is out of range")
+	}
+	return &BLSKeyPair{secret: secret, public: G2Base(secret)}, nil
+}
+
+// SecretKey raw secret key
+func (k *BLSKeyPair) SecretKey() []byte {
+	return scalarBytes(k.secret)
+}
+
+// PublicKey raw public key
+func (k *BLSKeyPair) PublicKey() []byte {
+	return bls12381.NewG2().ToCompressed(k.public)
+}
+
+// Sign data
+func (k *BLSKeyPair) Sign(data []byte) ([]byte, error) {
+	return blsSign(k.secret, data), nil
+}
+
+// Verify signature of data
+func (k *BLSKeyPair) Verify(data []byte, signature []byte) (bool, error) {
+	return BLSVerify(k.PublicKey(), data, signature)
+}
+
+// ProvePossession proof that holder of public key knows its secret key, it's
+// a signature over the public key under its own domain separation tag
+func (k *BLSKeyPair) ProvePossession() []byte {
+	hashed, err := bls12381.NewG1().HashToCurve(k.PublicKey(), popDomain)
+	if err != nil {
+		// Hash to curve only fails on domain tags over 255 bytes
+		panic(err)
+	}
+	return blsSignPoint(k.secret, hashed)
+}
+
+// BLSVerifyPossession verify proof of possession of raw public key
+func BLSVerifyPossession(pubKey []byte, proof []byte) (bool, error) {
+	public, err := decodePublicKey(pubKey)
+	if err != nil {
+		return false, err
+	}
+	sig, err := decodeSignature(proof)
+	if err != nil {
+		return false, err
+	}
+	hashed, err := bls12381.NewG1().HashToCurve(pubKey, popDomain)
+	if err != nil {
+		return false, err
+	}
+	return blsVerifyPoint(public, hashed, sig), nil
+}
+
+// BLSVerify verify signature of data with raw public key
+func BLSVerify(pubKey []byte, data []byte, signature []byte) (bool, error) {
+	public, err := decodePublicKey(pubKey)
+	if err != nil {
+		return false, err
+	}
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+	return blsVerify(public, data, sig), nil
+}
+
+// DecodeG1 decode a compressed point of G1, point must be in the prime order
+// subgroup
+func DecodeG1(b []byte) (*bls12381.PointG1, error) {
+	return bls12381.NewG1().FromCompressed(b)
+}
+
+// DecodeG2 decode a compressed point of G2, point must be in the prime order
+// subgroup
+func DecodeG2(b []byte) (*bls12381.PointG2, error) {
+	return bls12381.NewG2().FromCompressed(b)
+}
+
+// EncodeG1EVM encode a compressed point of G1 the way EIP-2537 precompiles
+// take it, each coordinate is padded to 64 bytes. EVM contracts can't afford
+// to decompress points, so signatures are handed to them in this encoding
+func EncodeG1EVM(b []byte) ([]byte, error) {
+	g1 := bls12381.NewG1()
+	point, err := g1.FromCompressed(b)
+	if err != nil {
+		return nil, err
+	}
+	return evmCoordinates(g1.ToBytes(point), 0, 1), nil
+}
+
+// EncodeG2EVM encode a compressed point of G2 the way EIP-2537 precompiles
+// take it, c0 of each coordinate comes before c1 and both are padded to 64 bytes
+func EncodeG2EVM(b []byte) ([]byte, error) {
+	g2 := bls12381.NewG2()
+	point, err := g2.FromCompressed(b)
+	if err != nil {
+		return nil, err
+	}
+	// Raw encoding puts c1 of each coordinate before c0
+	return evmCoordinates(g2.ToBytes(point), 1, 0, 3, 2), nil
+}
+
+// evmCoordinates pad 48 bytes field elements of raw to 64 bytes in given order
+func evmCoordinates(raw []byte, order ...int) []byte {
+	out := make([]byte, 64*len(order))
+	for i, j := range order {
+		copy(out[64*i+16:64*(i+1)], raw[48*j:48*(j+1)])
+	}
+	return out
+}
+
+// decodePublicKey decode public key, the identity is rejected as every
+// signature of it would be the identity as well
+func decodePublicKey(pubKey []byte) (*bls12381.PointG2, error) {
+	public, err := DecodeG2(pubKey)
+	if err != nil || bls12381.NewG2().IsZero(public) {
+		return nil, errors.New("invalid BLS public key")
+	}
+	return public, nil
+}
+
+// decodeSignature decode signature, the identity is rejected
+func decodeSignature(signature []byte) (*bls12381.PointG1, error) {
+	sig, err := DecodeG1(signature)
+	if err != nil || bls12381.NewG1().IsZero(sig) {
+		return nil, errors.New("invalid BLS signature")
+	}
+	return sig, nil
+}
+
+// AggregateSignatures aggregate BLS signatures into one signature
+func AggregateSignatures(signatures [][]byte) ([]byte, error) {
+	if len(signatures) == 0 {
+		return nil, errors.New("no signature to aggregate")
+	}
+	g1 := bls12381.NewG1()
+	aggregated := g1.Zero()
+	for _, signature := range signatures {
+		sig, err := decodeSignature(signature)
+		if err != nil {
+			return nil, err
+		}
+		g1.Add(aggregated, aggregated, sig)
+	}
+	return g1.ToCompressed(aggregated), nil
+}
+
+// AggregatePublicKeys aggregate BLS public keys into one public key, so that
+// signatures of the same message by all keys verify as one. Each key needs
+// its proof of possession in proofs, otherwise a rogue key chosen as a
+// function of the others could forge the aggregate
+func AggregatePublicKeys(pubKeys [][]byte, proofs [][]byte) ([]byte, error) {
+	if len(pubKeys) == 0 {
+		return nil, errors.New("no public key to aggregate")
+	}
+	if len(proofs) != len(pubKeys) {
+		return nil, errors.New("number of public keys and proofs of possession mismatch")
+	}
+	g2 := bls12381.NewG2()
+	aggregated := g2.Zero()
+	for i, pubKey := range pubKeys {
+		ok, err := BLSVerifyPossession(pubKey, proofs[i])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("invalid proof of possession")
+		}
+		public, err := decodePublicKey(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		g2.Add(aggregated, aggregated, public)
+	}
+	return g2.ToCompressed(aggregated), nil
+}
+
+// VerifyAggregate verify an aggregated signature where pubKeys[i] signed
+// messages[i]. Messages must be distinct, same messages are verified against
+// an aggregated public key instead
+func VerifyAggregate(pubKeys [][]byte, messages [][]byte, signature []byte) (bool, error) {
+	if len(pubKeys) == 0 || len(pubKeys) != len(messages) {
+		return false, errors.New("number of public keys and messages mismatch")
+	}
+	seen := make(map[string]bool, len(messages))
+	for _, message := range messages {
+		if seen[string(message)] {
+			return false, errors.New("aggregated messages must be distinct")
+		}
+		seen[string(message)] = true
+	}
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+	g2 := bls12381.NewG2()
+	engine := bls12381.NewEngine()
+	for i, pubKey := range pubKeys {
+		public, err := decodePublicKey(pubKey)
+		if err != nil {
+			return false, err
+		}
+		hashed, err := hashToG1(messages[i])
+		if err != nil {
+			return false, err
+		}
+		engine.AddPair(hashed, public)
+	}
+	engine.AddPairInv(sig, g2.One())
+	return engine.Check(), nil
+}
+
+// BLSHashToPoint encoded point of G1 that data is mapped to before it's signed
+func BLSHashToPoint(data []byte) ([]byte, error) {
+	hashed, err := hashToG1(data)
+	if err != nil {
+		return nil, err
+	}
+	return bls12381.NewG1().ToCompressed(hashed), nil
+}
+
+// HashedMessage message together with the point of G1 it maps to, so it's
+// hashed to curve once however often it's signed or verified
+type HashedMessage struct {
+	Data  []byte
+	point *bls12381.PointG1
+}
+
+// HashMessage map message to curve ahead of signing or verifying it
+func HashMessage(data []byte) (*HashedMessage, error) {
+	point, err := hashToG1(data)
+	if err != nil {
+		return nil, err
+	}
+	return &HashedMessage{Data: data, point: point}, nil
+}
+
+func blsSign(secret *big.Int, data []byte) []byte {
+	hashed, err := hashToG1(data)
+	if err != nil {
+		// Hash to curve only fails on domain tags over 255 bytes
+		panic(err)
+	}
+	return blsSignPoint(secret, hashed)
+}
+
+func blsSignPoint(secret *big.Int, hashed *bls12381.PointG1) []byte {
+	g1 := bls12381.NewG1()
+	return g1.ToCompressed(g1.MulScalarBig(g1.New(), hashed, secret))
+}
+
+func blsVerify(public *bls12381.PointG2, data []byte, sig *bls12381.PointG1) bool {
+	hashed, err := hashToG1(data)
+	if err != nil {
+		return false
+	}
+	return blsVerifyPoint(public, hashed, sig)
+}
+
+// blsVerifyPoint check e(hashed, public) == e(sig, generator of G2)
+func blsVerifyPoint(public *bls12381.PointG2, hashed *bls12381.PointG1, sig *bls12381.PointG1) bool {
+	engine := bls12381.NewEngine()
+	engine.AddPair(hashed, public)
+	engine.AddPairInv(sig, bls12381.NewG2().One())
+	return engine.Check()
+}
+
+// hashToG1 map data to a point of G1 as RFC 9380 hash_to_curve does
+func hashToG1(data []byte) (*bls12381.PointG1, error) {
+	return bls12381.NewG1().HashToCurve(data, blsDomain)
+}
+
+// scalarBytes encode a scalar in 32 bytes big endian
+func scalarBytes(v *big.Int) []byte {
+	buf := make([]byte, 32)
+	b := v.Bytes()
+	copy(buf[32-len(b):], b)
+	return buf
+}
+
+// blsScheme BLS scheme for scheme registry
+type blsScheme struct{}
+
+var _ ThresholdScheme = (*blsScheme)(nil)
+
+func (s *blsScheme) Name() string {
+	return SchemeBLS
+}
+
+func (s *blsScheme) GenerateKey(random io.Reader) ([]byte, []byte, error) {
+	k, err := newBLSWithReader(random)
+	if err != nil {
+		return nil, nil, err
+	}
+	return k.SecretKey(), k.PublicKey(), nil
+}
+
+func (s *blsScheme) Sign(privKey []byte, data []byte) ([]byte, error) {
+	k, err := BLSFromSecret(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return k.Sign(data)
+}
+
+func (s *blsScheme) Verify(pubKey []byte, data []byte, signature []byte) (bool, error) {
+	return BLSVerify(pubKey, data, signature)
+}
+
+func (s *blsScheme) Aggregate(signatures [][]byte) ([]byte, error) {
+	return AggregateSignatures(signatures)
+}
+
+func (s *blsScheme) HashMessage(data []byte) (*HashedMessage, error) {
+	return HashMessage(data)
+}
+
+func (s *blsScheme) SignPartial(share *PriShare, message *HashedMessage) []byte {
+	return SignPartialHashed(share, message)
+}
+
+func (s *blsScheme) VerifyPartial(pubPoly *PubPoly, message *HashedMessage, partial []byte) (bool, error) {
+	return VerifyPartialHashed(pubPoly, message, partial)
+}
+
+func (s *blsScheme) RecoverSignature(partials [][]byte, threshold int) ([]byte, error) {
+	return RecoverSignature(partials, threshold)
+}
+
+func (s *blsScheme) PartialSize() int {
+	return PartialSignatureSize
+}
@@ -0,0 +1,74 @@
+package keypair
+
+import (
+	"encoding/hex"
+	"testing"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// hashToG1Vectors BLS12381G1_XMD:SHA-256_SSWU_RO_ vectors of RFC 9380
+// appendix J.9.1, the suite that BLS signatures hash messages with
+var hashToG1Vectors = []struct {
+	msg, x, y string
+}{
+	{
+		msg: "",
+		x:   "052926add2207b76ca4fa57a8734416c8dc95e24501772c814278700eed6d1e4e8cf62d9c09db0fac349612b759e79a1",
+		y:   "08ba738453bfed09cb546dbb0783dbb3a5f1f566ed67bb6be0e8c67e2e81a4cc68ee29813bb7994998f3eae0c9c6a265",
+	},
+	{
+		msg: "abc",
+		x:   "03567bc5ef9c690c2ab2ecdf6a96ef1c139cc0b2f284dca0a9a7943388a49a3aee664ba5379a7655d3c68900be2f6903",
+		y:   "0b9c15f3fe6e5cf4211f346271d7b01c8f3b28be689c8429c85b67af215533311f0b8dfaaa154fa6b88176c229f2885d",
+	},
+	{
+		msg: "abcdef0123456789",
+		x:   "11e0b079dea29a68f0383ee94fed1b940995272407e3bb916bbf268c263ddd57a6a27200a784cbc248e84f357ce82d98",
+		y:   "03a87ae2caf14e8ee52e51fa2ed8eefe80f02457004ba4d486d6aa1f517c0889501dc7413753f9599b099ebcbbd2d709",
+	},
+}
+
+func TestHashToG1Vectors(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G1_XMD:SHA-256_SSWU_RO_")
+	g1 := bls12381.NewG1()
+	for _, v := range hashToG1Vectors {
+		point, err := g1.HashToCurve([]byte(v.msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := v.x + v.y
+		if got := hex.EncodeToString(g1.ToBytes(point)); got != want {
+			t.Errorf("hash of %q to G1 is %s, want %s", v.msg, got, want)
+		}
+	}
+}
+
+func TestBLSSignVerify(t *testing.T) {
+	k, err := NewBLS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("round 1")
+	signature, err := k.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signature is the secret key times the RFC 9380 hash of data
+	hashed, err := bls12381.NewG1().HashToCurve(data, blsDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := blsSignPoint(k.secret, hashed); hex.EncodeToString(signature) != hex.EncodeToString(want) {
+		t.Fatal("signature is not the secret key times hash of data")
+	}
+	if ok, err := BLSVerify(k.PublicKey(), data, signature); err != nil || !ok {
+		t.Fatalf("signature does not verify: %v", err)
+	}
+	if ok, _ := BLSVerify(k.PublicKey(), []byte("round 2"), signature); ok {
+		t.Fatal("signature verifies another message")
+	}
+	if ok, err := BLSVerifyPossession(k.PublicKey(), k.ProvePossession()); err != nil || !ok {
+		t.Fatalf("proof of possession does not verify: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package keypair
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Names of key derivation function and cipher of encrypted key files
+const (
+	KDFArgon2id             = "argon2id"
+	CipherXChaCha20Poly1305 = "xchacha20-poly1305"
+)
+
+// Default Argon2id parameters, memory is in KiB
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2SaltLen = 16
+)
+
+// Limits of Argon2id parameters read from key files, so a corrupt or hostile
+// key file can't exhaust memory or CPU when it's loaded
+const (
+	argon2MaxTime   = 64
+	argon2MaxMemory = 4 * 1024 * 1024
+)
+
+// ErrEncryptedKey key file is encrypted and must be loaded with a passphrase
+var ErrEncryptedKey = errors.New("key file is encrypted, passphrase is required")
+
+// ErrWrongPassphrase passphrase does not decrypt key file
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupt key file")
+
+// EncryptedKey passphrase encrypted private key
+type EncryptedKey struct {
+	KDF        string `json:"kdf"`
+	Salt       string `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// additionalData bind ciphertext to version and type of key file
+func additionalData(jsonKey *JSON) []byte {
+	return []byte(fmt.Sprintf("orochi-key:%d:%d", jsonKey.Version, jsonKey.KeyType))
+}
+
+// SaveToFileEncrypted save key pair to file with private key encrypted by passphrase
+func (k *KeyPair) SaveToFileEncrypted(fileName string, passphrase string) (bool, error) {
+	if !k.isAbleToSign() {
+		return false, errors.New("only a private key can be encrypted")
+	}
+	key, err := k.privKey.Raw()
+	if err != nil {
+		return false, err
+	}
+	jsonKey := &JSON{Version: JSONVersion, KeyType: k.keyType, SignKey: true}
+	if jsonKey.Crypto, err = Encrypt(key, passphrase, additionalData(jsonKey)); err != nil {
+		return false, err
+	}
+	fid, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return false, err
+	}
+	defer fid.Close()
+	return writeToJSON(fid, jsonKey)
+}
+
+// Encrypt secret with a key derived from passphrase, additional data is
+// authenticated but not encrypted
+func Encrypt(secret []byte, passphrase string, additional []byte) (*EncryptedKey, error) {
+	salt := make([]byte, argon2SaltLen)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(argon2.IDKey([]byte(passphrase), salt, argon2Time, argon2Memory, argon2Threads, chacha20poly1305.KeySize))
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedKey{
+		KDF:        KDFArgon2id,
+		Salt:       hex.EncodeToString(salt),
+		Time:       argon2Time,
+		Memory:     argon2Memory,
+		Threads:    argon2Threads,
+		Cipher:     CipherXChaCha20Poly1305,
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(aead.Seal(nil, nonce, secret, additional)),
+	}, nil
+}
+
+// LoadFromFileEncrypted load key pair from file, private key is decrypted with
+// passphrase if it's encrypted
+func LoadFromFileEncrypted(fileName string, passphrase string) (*KeyPair, error) {
+	fileContent, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	jsonKey := new(JSON)
+	if err := json.Unmarshal(fileContent, jsonKey); err != nil {
+		return nil, err
+	}
+	if jsonKey.Crypto == nil {
+		return LoadFromFile(fileName)
+	}
+	key, err := jsonKey.Crypto.Decrypt(passphrase, additionalData(jsonKey))
+	if err != nil {
+		return nil, err
+	}
+	return FromPrivateKey(jsonKey.KeyType, key)
+}
+
+// IsEncryptedFile check whether key file is encrypted
+func IsEncryptedFile(fileName string) (bool, error) {
+	fileContent, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return false, err
+	}
+	jsonKey := new(JSON)
+	if err := json.Unmarshal(fileContent, jsonKey); err != nil {
+		return false, err
+	}
+	return jsonKey.Crypto != nil, nil
+}
+
+// Decrypt secret encrypted by Encrypt
+func (e *EncryptedKey) Decrypt(passphrase string, additional []byte) ([]byte, error) {
+	if e.KDF != KDFArgon2id || e.Cipher != CipherXChaCha20Poly1305 {
+		return nil, errors.New("unsupported key file encryption")
+	}
+	salt, err := hex.DecodeString(e.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(e.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := hex.DecodeString(e.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != chacha20poly1305.NonceSizeX {
+		return nil, errors.New("invalid nonce size")
+	}
+	if e.Time < 1 || e.Time > argon2MaxTime {
+		return nil, fmt.Errorf("invalid argon2id time %d, it must be from 1 to %d", e.Time, argon2MaxTime)
+	}
+	if e.Threads < 1 {
+		return nil, errors.New("invalid argon2id threads 0, it must be at least 1")
+	}
+	if e.Memory < 8*uint32(e.Threads) || e.Memory > argon2MaxMemory {
+		return nil, fmt.Errorf("invalid argon2id memory %d KiB, it must be from 8 KiB per thread to %d KiB", e.Memory, argon2MaxMemory)
+	}
+	aead, err := chacha20poly1305.NewX(argon2.IDKey([]byte(passphrase), salt, e.Time, e.Memory, e.Threads, chacha20poly1305.KeySize))
+	if err != nil {
+		return nil, err
+	}
+	key, err := aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
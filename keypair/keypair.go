@@ -19,11 +19,17 @@ type KeyPair struct {
 
 // JSON structure
 type JSON struct {
+	Version int    `json:"version,omitempty"`
 	KeyType int    `json:"type"`
 	SignKey bool   `json:"signKey"`
 	Key     string `json:"key"`
+	// Crypto encrypted private key, Key is empty when it's set
+	Crypto *EncryptedKey `json:"crypto,omitempty"`
 }
 
+// JSONVersion current version of JSON key file, version 1 files did not record key type
+const JSONVersion = 2
+
 // NewEd25519 generates a new Ed25519 KeyPair
 func NewEd25519() (*KeyPair, error) {
 	return New(p2pCrypto.Ed25519, 256)
@@ -36,6 +42,9 @@ func NewSecp256k1() (*KeyPair, error) {
 
 // New generate a new key pair
 func New(typ int, bits int) (*KeyPair, error) {
+	if _, err := SchemeName(typ); err != nil {
+		return nil, err
+	}
 	p, v, err := p2pCrypto.GenerateKeyPairWithReader(typ, bits, rand.Reader)
 	if err == nil {
 		return &KeyPair{keyType: typ, privKey: p, pubKey: v}, nil
@@ -51,9 +60,11 @@ func FromPrivateKey(typ int, b []byte) (*KeyPair, error) {
 		p, err = p2pCrypto.UnmarshalEd25519PrivateKey(b)
 	} else if typ == p2pCrypto.Secp256k1 {
 		p, err = p2pCrypto.UnmarshalSecp256k1PrivateKey(b)
+	} else {
+		err = p2pCrypto.ErrBadKeyType
 	}
 	if err == nil {
-		return &KeyPair{privKey: p, pubKey: p.GetPublic()}, nil
+		return &KeyPair{keyType: typ, privKey: p, pubKey: p.GetPublic()}, nil
 	}
 	return nil, err
 }
@@ -71,7 +82,7 @@ func FromBase64PrivateKey(typ int, b string) (*KeyPair, error) {
 func FromPublicKey(b []byte) (*KeyPair, error) {
 	v, err := p2pCrypto.UnmarshalEd25519PublicKey(b)
 	if err == nil {
-		return &KeyPair{pubKey: v}, nil
+		return &KeyPair{keyType: p2pCrypto.Ed25519, pubKey: v}, nil
 	}
 	return nil, err
 }
@@ -102,7 +113,7 @@ func writeToJSON(fid *os.File, jsonKey *JSON) (bool, error) {
 func (k *KeyPair) SaveToFile(fileName string) (bool, error) {
 	fid, err := os.Create(fileName)
 	if err == nil {
-		jsonKey := new(JSON)
+		jsonKey := &JSON{Version: JSONVersion, KeyType: k.keyType}
 		defer fid.Close()
 		// Sign able key
 		if k.isAbleToSign() {
@@ -133,16 +144,24 @@ func LoadFromFile(fileName string) (*KeyPair, error) {
 		jsonKey := new(JSON)
 		err := json.Unmarshal(fileContent, jsonKey)
 		if err == nil {
-			if jsonKey.SignKey {
-				return FromBase64PrivateKey(jsonKey.KeyType, jsonKey.Key)
+			if jsonKey.Crypto != nil {
+				return nil, ErrEncryptedKey
 			}
-			return FromBase64PublicKey(jsonKey.Key)
+			if jsonKey.Version < JSONVersion {
+				return fromVersion1(jsonKey)
+			}
+			return LoadFromJSON(jsonKey)
 		}
 		return nil, err
 	}
 	return nil, err
 }
 
+// GetKeyType of this key pair
+func (k *KeyPair) GetKeyType() int {
+	return k.keyType
+}
+
 // isAbleToSign with this key pair
 func (k *KeyPair) isAbleToSign() bool {
 	return k.privKey != nil
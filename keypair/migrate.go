@@ -0,0 +1,173 @@
+package keypair
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// Migration result of migrating a key file
+type Migration struct {
+	FileName   string
+	BackupFile string
+	Source     string
+	KeyPair    *KeyPair
+	Written    bool
+}
+
+// ipfsConfig identity section of an IPFS/libp2p config file
+type ipfsConfig struct {
+	Identity struct {
+		PeerID  string
+		PrivKey string
+	}
+}
+
+// Raw key sizes used to infer key type of version 1 key files
+const (
+	ed25519PrivateKeySize       = 64
+	ed25519LegacyPrivateKeySize = 96
+	ed25519PublicKeySize        = 32
+	secp256k1PrivateKeySize     = 32
+	secp256k1PublicKeySize      = 33
+)
+
+// fromVersion1 restore a KeyPair from version 1 JSON, key type is inferred from key size
+func fromVersion1(jsonKey *JSON) (*KeyPair, error) {
+	raw, err := p2pCrypto.ConfigDecodeKey(jsonKey.Key)
+	if err != nil {
+		return nil, err
+	}
+	if jsonKey.SignKey {
+		switch len(raw) {
+		case ed25519PrivateKeySize, ed25519LegacyPrivateKeySize:
+			return FromPrivateKey(p2pCrypto.Ed25519, raw)
+		case secp256k1PrivateKeySize:
+			return FromPrivateKey(p2pCrypto.Secp256k1, raw)
+		}
+		return nil, errors.New("unable to infer type of private key")
+	}
+	switch len(raw) {
+	case ed25519PublicKeySize:
+		return FromPublicKey(raw)
+	case secp256k1PublicKeySize:
+		v, err := p2pCrypto.UnmarshalSecp256k1PublicKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &KeyPair{keyType: p2pCrypto.Secp256k1, pubKey: v}, nil
+	}
+	return nil, errors.New("unable to infer type of public key")
+}
+
+// fromProtobufPrivateKey restore a KeyPair from libp2p protobuf encoded private key
+func fromProtobufPrivateKey(b []byte) (*KeyPair, error) {
+	p, err := p2pCrypto.UnmarshalPrivateKey(b)
+	if err != nil {
+		return nil, err
+	}
+	typ := int(p.Type())
+	if typ != p2pCrypto.Ed25519 && typ != p2pCrypto.Secp256k1 {
+		return nil, p2pCrypto.ErrBadKeyType
+	}
+	return &KeyPair{keyType: typ, privKey: p, pubKey: p.GetPublic()}, nil
+}
+
+// parseKeyFile detect format of key file content and restore its KeyPair
+func parseKeyFile(content []byte) (*KeyPair, string, error) {
+	jsonKey := new(JSON)
+	if err := json.Unmarshal(content, jsonKey); err == nil && jsonKey.Crypto != nil {
+		return nil, "", ErrEncryptedKey
+	}
+	if err := json.Unmarshal(content, jsonKey); err == nil && jsonKey.Key != "" {
+		if jsonKey.Version < JSONVersion {
+			k, err := fromVersion1(jsonKey)
+			return k, "json-v1", err
+		}
+		k, err := LoadFromJSON(jsonKey)
+		return k, "json-v2", err
+	}
+	ipfsKey := new(ipfsConfig)
+	if err := json.Unmarshal(content, ipfsKey); err == nil && ipfsKey.Identity.PrivKey != "" {
+		raw, err := base64.StdEncoding.DecodeString(ipfsKey.Identity.PrivKey)
+		if err != nil {
+			return nil, "", err
+		}
+		k, err := fromProtobufPrivateKey(raw)
+		return k, "ipfs-config", err
+	}
+	if raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content))); err == nil {
+		if k, err := fromProtobufPrivateKey(raw); err == nil {
+			return k, "libp2p-base64", nil
+		}
+	}
+	if k, err := fromProtobufPrivateKey(content); err == nil {
+		return k, "libp2p-protobuf", nil
+	}
+	return nil, "", errors.New("unknown key file format")
+}
+
+// LoadFromJSON restore a KeyPair from current version of JSON structure
+func LoadFromJSON(jsonKey *JSON) (*KeyPair, error) {
+	if jsonKey.SignKey {
+		return FromBase64PrivateKey(jsonKey.KeyType, jsonKey.Key)
+	}
+	return FromBase64PublicKey(jsonKey.Key)
+}
+
+// MigrateFile convert a version 1 or foreign key file to current JSON format,
+// private key is encrypted with passphrase unless it's empty. Original file is
+// copied to backup file before it get replaced
+func MigrateFile(fileName string, backupFile string, dryRun bool, passphrase string) (*Migration, error) {
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	k, source, err := parseKeyFile(content)
+	if err != nil {
+		return nil, err
+	}
+	result := &Migration{FileName: fileName, BackupFile: backupFile, Source: source, KeyPair: k}
+	if dryRun || source == "json-v2" && passphrase == "" {
+		return result, nil
+	}
+	if backupFile != "" {
+		if err := ioutil.WriteFile(backupFile, content, 0600); err != nil {
+			return nil, err
+		}
+	}
+	if err := replaceKeyFile(k, fileName, passphrase); err != nil {
+		return nil, err
+	}
+	result.Written = true
+	return result, nil
+}
+
+// replaceKeyFile write key pair to a new file only owner can read and rename
+// it over key file, so the key is never readable by others nor half written
+func replaceKeyFile(k *KeyPair, fileName string, passphrase string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	tmp.Close()
+	if passphrase != "" {
+		_, err = k.SaveToFileEncrypted(tmpName, passphrase)
+	} else {
+		_, err = k.SaveToFile(tmpName)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, fileName)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+	}
+	return err
+}
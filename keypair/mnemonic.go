@@ -0,0 +1,155 @@
+package keypair
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Derivation paths of keys restored from a mnemonic. Ed25519 node identity is
+// derived by SLIP-0010, which only allows hardened steps. Secp256k1 signing
+// keys are derived by BIP-32 on the path Ethereum wallets use, so a relayer
+// account restored here matches the one a wallet derives from the same words
+const (
+	PathIdentity = "m/44'/9669'/0'/0'"
+	PathSigning  = "m/44'/60'/0'/0/0"
+)
+
+// mnemonicEntropyBits entropy of a 24 words mnemonic
+const mnemonicEntropyBits = 256
+
+const hardenedOffset uint32 = 0x80000000
+
+// NewMnemonic generate a 24 words BIP-39 mnemonic
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// FromMnemonic derive a key pair of given type from a BIP-39 mnemonic and
+// optional password, path is PathIdentity or PathSigning by default
+func FromMnemonic(mnemonic string, password string, keyType int, path string) (*KeyPair, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
+	if err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case p2pCrypto.Ed25519:
+		if path == "" {
+			path = PathIdentity
+		}
+		key, err := deriveEd25519(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		return FromPrivateKey(p2pCrypto.Ed25519, ed25519.NewKeyFromSeed(key))
+	case p2pCrypto.Secp256k1:
+		if path == "" {
+			path = PathSigning
+		}
+		key, err := deriveSecp256k1(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		return FromPrivateKey(p2pCrypto.Secp256k1, key)
+	}
+	return nil, p2pCrypto.ErrBadKeyType
+}
+
+// parsePath parse a derivation path like m/44'/60'/0'/0/0
+func parsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, errors.New("derivation path must start with m")
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		index, err := strconv.ParseUint(strings.TrimRight(part, "'h"), 10, 31)
+		if err != nil {
+			return nil, errors.New("invalid derivation path step: " + part)
+		}
+		if hardened {
+			index += uint64(hardenedOffset)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// deriveEd25519 SLIP-0010 derivation of an Ed25519 seed
+func deriveEd25519(seed []byte, path string) ([]byte, error) {
+	indexes, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key, chainCode := hmacSplit([]byte("ed25519 seed"), seed)
+	for _, index := range indexes {
+		if index < hardenedOffset {
+			return nil, errors.New("Ed25519 derivation only allows hardened steps")
+		}
+		key, chainCode = hmacSplit(chainCode, append(append([]byte{0}, key...), ser32(index)...))
+	}
+	return key, nil
+}
+
+// deriveSecp256k1 BIP-32 derivation of a secp256k1 private key
+func deriveSecp256k1(seed []byte, path string) ([]byte, error) {
+	indexes, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	n := btcec.S256().N
+	key, chainCode := hmacSplit([]byte("Bitcoin seed"), seed)
+	k := new(big.Int).SetBytes(key)
+	if k.Sign() == 0 || k.Cmp(n) >= 0 {
+		return nil, errors.New("invalid master key, use another mnemonic")
+	}
+	for _, index := range indexes {
+		var data []byte
+		if index >= hardenedOffset {
+			data = append([]byte{0}, k.FillBytes(make([]byte, 32))...)
+		} else {
+			_, pub := btcec.PrivKeyFromBytes(btcec.S256(), k.FillBytes(make([]byte, 32)))
+			data = pub.SerializeCompressed()
+		}
+		var tweak []byte
+		tweak, chainCode = hmacSplit(chainCode, append(data, ser32(index)...))
+		t := new(big.Int).SetBytes(tweak)
+		if t.Cmp(n) >= 0 {
+			return nil, errors.New("invalid child key, use another derivation path")
+		}
+		k.Add(k, t).Mod(k, n)
+		if k.Sign() == 0 {
+			return nil, errors.New("invalid child key, use another derivation path")
+		}
+	}
+	return k.FillBytes(make([]byte, 32)), nil
+}
+
+// hmacSplit HMAC-SHA512 of data split into key and chain code
+func hmacSplit(key []byte, data []byte) ([]byte, []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+func ser32(i uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, i)
+	return b
+}
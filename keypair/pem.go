@@ -0,0 +1,230 @@
+package keypair
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// PEM block types, Ed25519 private keys are PKCS#8 and secp256k1 private
+// keys are SEC 1 as written by openssl, public keys are PKIX
+const (
+	PEMPrivateKey   = "PRIVATE KEY"
+	PEMECPrivateKey = "EC PRIVATE KEY"
+	PEMPublicKey    = "PUBLIC KEY"
+)
+
+var (
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1   = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// ecPrivateKey SEC 1 EC private key structure
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 PKCS#8 private key structure
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo PKIX subject public key info structure
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// ExportPEM encode key pair as PEM, private key is exported if key pair holds one
+func (k *KeyPair) ExportPEM() ([]byte, error) {
+	var block *pem.Block
+	var err error
+	if k.isAbleToSign() {
+		block, err = k.privateKeyBlock()
+	} else {
+		block, err = k.publicKeyBlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+func (k *KeyPair) privateKeyBlock() (*pem.Block, error) {
+	raw, err := k.privKey.Raw()
+	if err != nil {
+		return nil, err
+	}
+	switch k.keyType {
+	case p2pCrypto.Ed25519:
+		der, err := x509.MarshalPKCS8PrivateKey(ed25519.PrivateKey(raw))
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: PEMPrivateKey, Bytes: der}, nil
+	case p2pCrypto.Secp256k1:
+		_, pub := btcec.PrivKeyFromBytes(btcec.S256(), raw)
+		uncompressed := pub.SerializeUncompressed()
+		der, err := asn1.Marshal(ecPrivateKey{
+			Version:       1,
+			PrivateKey:    raw,
+			NamedCurveOID: oidSecp256k1,
+			PublicKey:     asn1.BitString{Bytes: uncompressed, BitLength: 8 * len(uncompressed)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: PEMECPrivateKey, Bytes: der}, nil
+	}
+	return nil, errors.New("key type can not be exported as PEM")
+}
+
+func (k *KeyPair) publicKeyBlock() (*pem.Block, error) {
+	raw, err := k.pubKey.Raw()
+	if err != nil {
+		return nil, err
+	}
+	var der []byte
+	switch k.keyType {
+	case p2pCrypto.Ed25519:
+		der, err = x509.MarshalPKIXPublicKey(ed25519.PublicKey(raw))
+	case p2pCrypto.Secp256k1:
+		pub, parseErr := btcec.ParsePubKey(raw, btcec.S256())
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		uncompressed := pub.SerializeUncompressed()
+		params, _ := asn1.Marshal(oidSecp256k1)
+		der, err = asn1.Marshal(publicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidECPublicKey, Parameters: asn1.RawValue{FullBytes: params}},
+			PublicKey: asn1.BitString{Bytes: uncompressed, BitLength: 8 * len(uncompressed)},
+		})
+	default:
+		return nil, errors.New("key type can not be exported as PEM")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: PEMPublicKey, Bytes: der}, nil
+}
+
+// ImportPEM restore a key pair from the first PEM block of an Ed25519 or
+// secp256k1 private or public key
+func ImportPEM(data []byte) (*KeyPair, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case PEMPrivateKey:
+		return fromPKCS8(block.Bytes)
+	case PEMECPrivateKey:
+		return fromSEC1(block.Bytes)
+	case PEMPublicKey:
+		return fromPKIX(block.Bytes)
+	}
+	return nil, errors.New("unsupported PEM block: " + block.Type)
+}
+
+func fromPKCS8(der []byte) (*KeyPair, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err == nil {
+		if edKey, ok := key.(ed25519.PrivateKey); ok {
+			return FromPrivateKey(p2pCrypto.Ed25519, edKey)
+		}
+		return nil, errors.New("only Ed25519 and secp256k1 keys are supported")
+	}
+	// Go does not know secp256k1, its PKCS#8 wraps a SEC 1 key
+	var info pkcs8
+	if _, asnErr := asn1.Unmarshal(der, &info); asnErr != nil {
+		return nil, err
+	}
+	var curve asn1.ObjectIdentifier
+	if !info.Algo.Algorithm.Equal(oidECPublicKey) {
+		return nil, err
+	}
+	if _, asnErr := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &curve); asnErr != nil || !curve.Equal(oidSecp256k1) {
+		return nil, err
+	}
+	return fromSEC1(info.PrivateKey)
+}
+
+func fromSEC1(der []byte) (*KeyPair, error) {
+	var key ecPrivateKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	if len(key.NamedCurveOID) > 0 && !key.NamedCurveOID.Equal(oidSecp256k1) {
+		return nil, errors.New("only secp256k1 EC keys are supported")
+	}
+	// Private key is a fixed size big endian integer, pad it if it's short
+	raw := make([]byte, secp256k1PrivateKeySize)
+	new(big.Int).SetBytes(key.PrivateKey).FillBytes(raw)
+	return FromPrivateKey(p2pCrypto.Secp256k1, raw)
+}
+
+func fromPKIX(der []byte) (*KeyPair, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err == nil {
+		if edKey, ok := key.(ed25519.PublicKey); ok {
+			pub, err := p2pCrypto.UnmarshalEd25519PublicKey(edKey)
+			if err != nil {
+				return nil, err
+			}
+			return &KeyPair{keyType: p2pCrypto.Ed25519, pubKey: pub}, nil
+		}
+		return nil, errors.New("only Ed25519 and secp256k1 keys are supported")
+	}
+	var info publicKeyInfo
+	if _, asnErr := asn1.Unmarshal(der, &info); asnErr != nil || !info.Algorithm.Algorithm.Equal(oidECPublicKey) {
+		return nil, err
+	}
+	var curve asn1.ObjectIdentifier
+	if _, asnErr := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curve); asnErr != nil || !curve.Equal(oidSecp256k1) {
+		return nil, err
+	}
+	pub, err := p2pCrypto.UnmarshalSecp256k1PublicKey(info.PublicKey.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{keyType: p2pCrypto.Secp256k1, pubKey: pub}, nil
+}
+
+// MarshalProtobuf encode key pair in libp2p protobuf key format as used by
+// IPFS configs, private key is encoded if key pair holds one
+func (k *KeyPair) MarshalProtobuf() ([]byte, error) {
+	if k.isAbleToSign() {
+		return p2pCrypto.MarshalPrivateKey(k.privKey)
+	}
+	return p2pCrypto.MarshalPublicKey(k.pubKey)
+}
+
+// UnmarshalProtobuf restore a key pair from libp2p protobuf private or public key
+func UnmarshalProtobuf(b []byte) (*KeyPair, error) {
+	if privKey, err := p2pCrypto.UnmarshalPrivateKey(b); err == nil {
+		if _, err := SchemeName(int(privKey.Type())); err != nil {
+			return nil, err
+		}
+		return &KeyPair{keyType: int(privKey.Type()), privKey: privKey, pubKey: privKey.GetPublic()}, nil
+	}
+	pubKey, err := p2pCrypto.UnmarshalPublicKey(b)
+	if err != nil {
+		return nil, errors.New("data is not a libp2p protobuf key")
+	}
+	if _, err := SchemeName(int(pubKey.Type())); err != nil {
+		return nil, err
+	}
+	return &KeyPair{keyType: int(pubKey.Type()), pubKey: pubKey}, nil
+}
@@ -0,0 +1,220 @@
+//go:build pkcs11
+// +build pkcs11
+
+package keypair
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	pb "github.com/libp2p/go-libp2p-core/crypto/pb"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/miekg/pkcs11"
+)
+
+// PKCS#11 3.0 constants that pkcs11 package does not define
+const (
+	ckkECEdwards = 0x00000040
+	ckmEdDSA     = 0x00001057
+)
+
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// PKCS11Signer signer whose private key stays in a PKCS#11 token. Ed25519
+// keys sign with CKM_EDDSA and secp256k1 keys with CKM_ECDSA
+type PKCS11Signer struct {
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	keyType int
+	pubKey  p2pCrypto.PubKey
+	// mutex sessions must not be used concurrently
+	mutex sync.Mutex
+}
+
+// OpenPKCS11 log in to token and find private key with given label
+func OpenPKCS11(conf PKCS11Config) (*PKCS11Signer, error) {
+	ctx := pkcs11.New(conf.Module)
+	if ctx == nil {
+		return nil, errors.New("unable to load PKCS#11 module " + conf.Module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, err
+	}
+	s := &PKCS11Signer{ctx: ctx}
+	if err := s.open(conf); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *PKCS11Signer) open(conf PKCS11Config) error {
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return err
+	}
+	slot, found := uint(0), false
+	for _, id := range slots {
+		info, err := s.ctx.GetTokenInfo(id)
+		if err == nil && info.Label == conf.TokenLabel {
+			slot, found = id, true
+			break
+		}
+	}
+	if !found {
+		return errors.New("PKCS#11 token not found: " + conf.TokenLabel)
+	}
+	if s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION); err != nil {
+		return err
+	}
+	if err := s.ctx.Login(s.session, pkcs11.CKU_USER, conf.PIN); err != nil {
+		return err
+	}
+	if s.key, err = s.findObject(pkcs11.CKO_PRIVATE_KEY, conf.KeyLabel); err != nil {
+		return err
+	}
+	pub, err := s.findObject(pkcs11.CKO_PUBLIC_KEY, conf.KeyLabel)
+	if err != nil {
+		return err
+	}
+	attributes, err := s.ctx.GetAttributeValue(s.session, pub, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return err
+	}
+	keyType := new(big.Int).SetBytes(reverse(attributes[0].Value)).Uint64()
+	var point []byte
+	if _, err := asn1.Unmarshal(attributes[2].Value, &point); err != nil {
+		// Some tokens return the point without OCTET STRING wrapping
+		point = attributes[2].Value
+	}
+	var curve asn1.ObjectIdentifier
+	asn1.Unmarshal(attributes[1].Value, &curve)
+	switch {
+	case keyType == ckkECEdwards:
+		s.keyType = p2pCrypto.Ed25519
+		s.pubKey, err = p2pCrypto.UnmarshalEd25519PublicKey(point)
+	case keyType == pkcs11.CKK_EC && curve.Equal(oidSecp256k1):
+		s.keyType = p2pCrypto.Secp256k1
+		s.pubKey, err = p2pCrypto.UnmarshalSecp256k1PublicKey(point)
+	default:
+		return errors.New("PKCS#11 key must be an Ed25519 or secp256k1 key")
+	}
+	return err
+}
+
+func (s *PKCS11Signer) findObject(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 1)
+	s.ctx.FindObjectsFinal(s.session)
+	if err != nil {
+		return 0, err
+	}
+	if len(objects) == 0 {
+		return 0, errors.New("PKCS#11 key not found: " + label)
+	}
+	return objects[0], nil
+}
+
+// Close log out and unload module
+func (s *PKCS11Signer) Close() {
+	if s.session != 0 {
+		s.ctx.Logout(s.session)
+		s.ctx.CloseSession(s.session)
+	}
+	s.ctx.Finalize()
+	s.ctx.Destroy()
+}
+
+// GetID peer ID of key
+func (s *PKCS11Signer) GetID() (peer.ID, error) {
+	return peer.IDFromPublicKey(s.pubKey)
+}
+
+// GetPublicKey public key read from token
+func (s *PKCS11Signer) GetPublicKey() p2pCrypto.PubKey {
+	return s.pubKey
+}
+
+// GetPrivateKey private key handle for libp2p host, its raw bytes can not be read
+func (s *PKCS11Signer) GetPrivateKey() p2pCrypto.PrivKey {
+	return &pkcs11PrivKey{signer: s}
+}
+
+// Sign data in token, signatures have the same format as libp2p keys produce
+func (s *PKCS11Signer) Sign(data []byte) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.keyType == p2pCrypto.Ed25519 {
+		if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(ckmEdDSA, nil)}, s.key); err != nil {
+			return nil, err
+		}
+		return s.ctx.Sign(s.session, data)
+	}
+	// libp2p secp256k1 signatures are DER encoded ECDSA over SHA-256 of data
+	hash := sha256.Sum256(data)
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.key); err != nil {
+		return nil, err
+	}
+	raw, err := s.ctx.Sign(s.session, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != 64 {
+		return nil, errors.New("unexpected ECDSA signature size from PKCS#11 token")
+	}
+	signature := &btcec.Signature{R: new(big.Int).SetBytes(raw[:32]), S: new(big.Int).SetBytes(raw[32:])}
+	// Serialize normalizes S to lower half of curve order
+	return signature.Serialize(), nil
+}
+
+// pkcs11PrivKey libp2p private key backed by a PKCS#11 signer
+type pkcs11PrivKey struct {
+	signer *PKCS11Signer
+}
+
+func (k *pkcs11PrivKey) Equals(other p2pCrypto.Key) bool {
+	priv, ok := other.(p2pCrypto.PrivKey)
+	return ok && k.signer.pubKey.Equals(priv.GetPublic())
+}
+
+func (k *pkcs11PrivKey) Raw() ([]byte, error) {
+	return nil, errors.New("private key is kept in PKCS#11 token")
+}
+
+func (k *pkcs11PrivKey) Type() pb.KeyType {
+	return k.signer.pubKey.Type()
+}
+
+func (k *pkcs11PrivKey) Sign(data []byte) ([]byte, error) {
+	return k.signer.Sign(data)
+}
+
+func (k *pkcs11PrivKey) GetPublic() p2pCrypto.PubKey {
+	return k.signer.pubKey
+}
+
+// reverse byte order, CK_ULONG attributes are in host byte order
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
@@ -0,0 +1,46 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package keypair
+
+import (
+	"errors"
+
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// PKCS11Signer placeholder of builds without PKCS#11 support, build with
+// -tags pkcs11 to keep keys in a hardware module
+type PKCS11Signer struct{}
+
+// ErrNoPKCS11 binary was built without PKCS#11 support
+var ErrNoPKCS11 = errors.New("PKCS#11 support is not built in, rebuild with -tags pkcs11")
+
+// OpenPKCS11 always fails in builds without PKCS#11 support
+func OpenPKCS11(conf PKCS11Config) (*PKCS11Signer, error) {
+	return nil, ErrNoPKCS11
+}
+
+// Close does nothing
+func (s *PKCS11Signer) Close() {}
+
+// GetID always fails
+func (s *PKCS11Signer) GetID() (peer.ID, error) {
+	return "", ErrNoPKCS11
+}
+
+// GetPublicKey returns nil
+func (s *PKCS11Signer) GetPublicKey() p2pCrypto.PubKey {
+	return nil
+}
+
+// GetPrivateKey returns nil
+func (s *PKCS11Signer) GetPrivateKey() p2pCrypto.PrivKey {
+	return nil
+}
+
+// Sign always fails
+func (s *PKCS11Signer) Sign(data []byte) ([]byte, error) {
+	return nil, ErrNoPKCS11
+}
@@ -0,0 +1,174 @@
+package keypair
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// Scheme signature scheme, keys and signatures are exchanged in raw bytes
+type Scheme interface {
+	// Name unique name of scheme
+	Name() string
+	// GenerateKey generate a new raw private and public key
+	GenerateKey(random io.Reader) (privKey []byte, pubKey []byte, err error)
+	// Sign data with raw private key
+	Sign(privKey []byte, data []byte) ([]byte, error)
+	// Verify signature of data with raw public key
+	Verify(pubKey []byte, data []byte, signature []byte) (bool, error)
+	// Aggregate signatures into one, return ErrAggregateNotSupported if scheme can not aggregate
+	Aggregate(signatures [][]byte) ([]byte, error)
+}
+
+// ThresholdScheme scheme whose signatures can be split into partial signatures
+// of shares of a group key, any threshold of them recover the group signature
+type ThresholdScheme interface {
+	Scheme
+	// HashMessage prepare message ahead of signing or verifying partials of it
+	HashMessage(data []byte) (*HashedMessage, error)
+	// SignPartial sign prepared message with share, partial carries share index
+	SignPartial(share *PriShare, message *HashedMessage) []byte
+	// VerifyPartial verify partial signature of prepared message against public polynomial
+	VerifyPartial(pubPoly *PubPoly, message *HashedMessage, partial []byte) (bool, error)
+	// RecoverSignature recover group signature from threshold partial signatures
+	RecoverSignature(partials [][]byte, threshold int) ([]byte, error)
+	// PartialSize size of encoded partial signature
+	PartialSize() int
+}
+
+// ErrAggregateNotSupported scheme does not support signature aggregation
+var ErrAggregateNotSupported = errors.New("scheme does not support signature aggregation")
+
+// Names of built-in schemes
+const (
+	SchemeEd25519   = "ed25519"
+	SchemeSecp256k1 = "secp256k1"
+)
+
+var schemeMutex sync.RWMutex
+var schemeRegistry = make(map[string]Scheme)
+
+func init() {
+	RegisterScheme(&libp2pScheme{name: SchemeEd25519, keyType: p2pCrypto.Ed25519})
+	RegisterScheme(&libp2pScheme{name: SchemeSecp256k1, keyType: p2pCrypto.Secp256k1})
+}
+
+// RegisterScheme add a scheme to registry, scheme name must be unique
+func RegisterScheme(s Scheme) error {
+	schemeMutex.Lock()
+	defer schemeMutex.Unlock()
+	if _, ok := schemeRegistry[s.Name()]; ok {
+		return errors.New("scheme was already registered")
+	}
+	schemeRegistry[s.Name()] = s
+	return nil
+}
+
+// GetScheme get scheme by its name
+func GetScheme(name string) (Scheme, error) {
+	schemeMutex.RLock()
+	defer schemeMutex.RUnlock()
+	if s, ok := schemeRegistry[name]; ok {
+		return s, nil
+	}
+	return nil, errors.New("unknown signature scheme")
+}
+
+// GetThresholdScheme get scheme by its name, it must support threshold signatures
+func GetThresholdScheme(name string) (ThresholdScheme, error) {
+	s, err := GetScheme(name)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := s.(ThresholdScheme); ok {
+		return t, nil
+	}
+	return nil, errors.New("scheme does not support threshold signatures")
+}
+
+// Schemes list names of registered schemes
+func Schemes() []string {
+	schemeMutex.RLock()
+	defer schemeMutex.RUnlock()
+	names := make([]string, 0, len(schemeRegistry))
+	for name := range schemeRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SchemeName name of scheme of given libp2p key type
+func SchemeName(keyType int) (string, error) {
+	switch keyType {
+	case p2pCrypto.Ed25519:
+		return SchemeEd25519, nil
+	case p2pCrypto.Secp256k1:
+		return SchemeSecp256k1, nil
+	}
+	return "", p2pCrypto.ErrBadKeyType
+}
+
+// GetScheme get signature scheme of this key pair
+func (k *KeyPair) GetScheme() (Scheme, error) {
+	name, err := SchemeName(k.keyType)
+	if err != nil {
+		return nil, err
+	}
+	return GetScheme(name)
+}
+
+// libp2pScheme scheme backed by libp2p crypto keys
+type libp2pScheme struct {
+	name    string
+	keyType int
+}
+
+func (s *libp2pScheme) Name() string {
+	return s.name
+}
+
+func (s *libp2pScheme) GenerateKey(random io.Reader) ([]byte, []byte, error) {
+	p, v, err := p2pCrypto.GenerateKeyPairWithReader(s.keyType, 256, random)
+	if err != nil {
+		return nil, nil, err
+	}
+	privKey, err := p.Raw()
+	if err != nil {
+		return nil, nil, err
+	}
+	pubKey, err := v.Raw()
+	if err != nil {
+		return nil, nil, err
+	}
+	return privKey, pubKey, nil
+}
+
+func (s *libp2pScheme) Sign(privKey []byte, data []byte) ([]byte, error) {
+	k, err := FromPrivateKey(s.keyType, privKey)
+	if err != nil {
+		return nil, err
+	}
+	return k.Sign(data)
+}
+
+func (s *libp2pScheme) Verify(pubKey []byte, data []byte, signature []byte) (bool, error) {
+	var v p2pCrypto.PubKey
+	var err error
+	if s.keyType == p2pCrypto.Ed25519 {
+		v, err = p2pCrypto.UnmarshalEd25519PublicKey(pubKey)
+	} else {
+		v, err = p2pCrypto.UnmarshalSecp256k1PublicKey(pubKey)
+	}
+	if err != nil {
+		return false, err
+	}
+	return v.Verify(data, signature)
+}
+
+func (s *libp2pScheme) Aggregate(signatures [][]byte) ([]byte, error) {
+	return nil, ErrAggregateNotSupported
+}
@@ -0,0 +1,29 @@
+package keypair
+
+import (
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Signer node identity that signs messages. KeyPair keeps its private key in
+// memory, a PKCS#11 signer keeps it in a hardware module and only hands out
+// signatures
+type Signer interface {
+	GetID() (peer.ID, error)
+	GetPublicKey() p2pCrypto.PubKey
+	// GetPrivateKey private key used by libp2p host, it may not expose raw bytes
+	GetPrivateKey() p2pCrypto.PrivKey
+	Sign(data []byte) ([]byte, error)
+}
+
+// PKCS11Config location of a key in a PKCS#11 token
+type PKCS11Config struct {
+	// Module path of PKCS#11 library, e.g. /usr/lib/softhsm/libsofthsm2.so
+	Module     string
+	TokenLabel string
+	// KeyLabel CKA_LABEL of private key and its public key
+	KeyLabel string
+	PIN      string
+}
+
+var _ Signer = (*KeyPair)(nil)
@@ -0,0 +1,335 @@
+package keypair
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// PriShare share of a threshold BLS secret key, index starts from 1
+type PriShare struct {
+	Index  uint32
+	Secret *big.Int
+}
+
+// PriPoly secret sharing polynomial, its constant term is the shared secret
+type PriPoly struct {
+	coefficients []*big.Int
+}
+
+// PubPoly public commitment of a sharing polynomial in G2
+type PubPoly struct {
+	Commits []*bls12381.PointG2
+}
+
+// partialIndexSize size of share index prefixing a partial signature
+const partialIndexSize = 4
+
+// PartialSignatureSize size of encoded partial signature, 4 bytes index and signature
+const PartialSignatureSize = partialIndexSize + BLSSignatureSize
+
+// NewPriPoly create a random polynomial of degree threshold - 1 sharing given secret,
+// a random secret is used if secret is nil
+func NewPriPoly(threshold int, secret *big.Int) (*PriPoly, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold must be positive")
+	}
+	coefficients := make([]*big.Int, threshold)
+	for i := range coefficients {
+		c, err := rand.Int(rand.Reader, CurveOrder)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+	if secret != nil {
+		coefficients[0] = new(big.Int).Mod(secret, CurveOrder)
+	}
+	return &PriPoly{coefficients: coefficients}, nil
+}
+
+// NewZeroPoly create a random polynomial of degree threshold - 1 that
+// evaluates to zero at given index, adding its shares to a sharing masks
+// them without changing the share of index
+func NewZeroPoly(threshold int, index uint32) (*PriPoly, error) {
+	p, err := NewPriPoly(threshold, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.coefficients[0].Sub(p.coefficients[0], p.Eval(index).Secret)
+	p.coefficients[0].Mod(p.coefficients[0], CurveOrder)
+	return p, nil
+}
+
+// Threshold number of shares needed to recover secret
+func (p *PriPoly) Threshold() int {
+	return len(p.coefficients)
+}
+
+// Secret shared secret
+func (p *PriPoly) Secret() *big.Int {
+	return new(big.Int).Set(p.coefficients[0])
+}
+
+// Eval evaluate share of given index
+func (p *PriPoly) Eval(index uint32) *PriShare {
+	x := big.NewInt(int64(index))
+	result := new(big.Int)
+	for i := len(p.coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, p.coefficients[i])
+		result.Mod(result, CurveOrder)
+	}
+	return &PriShare{Index: index, Secret: result}
+}
+
+// Shares evaluate shares of index 1 to n
+func (p *PriPoly) Shares(n int) []*PriShare {
+	shares := make([]*PriShare, n)
+	for i := range shares {
+		shares[i] = p.Eval(uint32(i + 1))
+	}
+	return shares
+}
+
+// Commit public commitment of polynomial
+func (p *PriPoly) Commit() *PubPoly {
+	commits := make([]*bls12381.PointG2, len(p.coefficients))
+	for i, c := range p.coefficients {
+		commits[i] = G2Base(c)
+	}
+	return &PubPoly{Commits: commits}
+}
+
+// Threshold number of partial signatures needed to recover a signature
+func (p *PubPoly) Threshold() int {
+	return len(p.Commits)
+}
+
+// PublicKey group public key
+func (p *PubPoly) PublicKey() []byte {
+	return bls12381.NewG2().ToCompressed(p.Commits[0])
+}
+
+// Eval evaluate public key of share with given index
+func (p *PubPoly) Eval(index uint32) *bls12381.PointG2 {
+	g2 := bls12381.NewG2()
+	x := big.NewInt(int64(index))
+	last := len(p.Commits) - 1
+	result := g2.New().Set(p.Commits[last])
+	for i := last - 1; i >= 0; i-- {
+		g2.MulScalarBig(result, result, x)
+		g2.Add(result, result, p.Commits[i])
+	}
+	return result
+}
+
+// Marshal encode commitments
+func (p *PubPoly) Marshal() []byte {
+	g2 := bls12381.NewG2()
+	encoded := make([]byte, 0, len(p.Commits)*BLSPublicKeySize)
+	for _, commit := range p.Commits {
+		encoded = append(encoded, g2.ToCompressed(commit)...)
+	}
+	return encoded
+}
+
+// UnmarshalPubPoly decode commitments, they may be the identity as masks of
+// share recovery commit to zero
+func UnmarshalPubPoly(b []byte) (*PubPoly, error) {
+	if len(b) == 0 || len(b)%BLSPublicKeySize != 0 {
+		return nil, errors.New("invalid public polynomial size")
+	}
+	commits := make([]*bls12381.PointG2, len(b)/BLSPublicKeySize)
+	for i := range commits {
+		commit, err := DecodeG2(b[i*BLSPublicKeySize : (i+1)*BLSPublicKeySize])
+		if err != nil {
+			return nil, errors.New("invalid public polynomial commitment")
+		}
+		commits[i] = commit
+	}
+	return &PubPoly{Commits: commits}, nil
+}
+
+// SignPartial sign data with a secret share, partial signature is prefixed by share index
+func SignPartial(share *PriShare, data []byte) []byte {
+	partial := make([]byte, partialIndexSize, PartialSignatureSize)
+	binary.BigEndian.PutUint32(partial, share.Index)
+	return append(partial, blsSign(share.Secret, data)...)
+}
+
+// SignPartialHashed sign a message hashed ahead with a secret share, like
+// SignPartial
+func SignPartialHashed(share *PriShare, message *HashedMessage) []byte {
+	partial := make([]byte, partialIndexSize, PartialSignatureSize)
+	binary.BigEndian.PutUint32(partial, share.Index)
+	return append(partial, blsSignPoint(share.Secret, message.point)...)
+}
+
+// PartialIndex index of share that produced a partial signature
+func PartialIndex(partial []byte) (uint32, error) {
+	if len(partial) != PartialSignatureSize {
+		return 0, errors.New("invalid partial signature size")
+	}
+	return binary.BigEndian.Uint32(partial), nil
+}
+
+// VerifyPartial verify a partial signature against public polynomial
+func VerifyPartial(pubPoly *PubPoly, data []byte, partial []byte) (bool, error) {
+	index, err := PartialIndex(partial)
+	if err != nil {
+		return false, err
+	}
+	if index == 0 {
+		return false, errors.New("invalid partial signature index")
+	}
+	sig, err := decodeSignature(partial[partialIndexSize:])
+	if err != nil {
+		return false, err
+	}
+	return blsVerify(pubPoly.Eval(index), data, sig), nil
+}
+
+// VerifyPartialHashed verify a partial signature of a message hashed ahead,
+// like VerifyPartial
+func VerifyPartialHashed(pubPoly *PubPoly, message *HashedMessage, partial []byte) (bool, error) {
+	index, err := PartialIndex(partial)
+	if err != nil {
+		return false, err
+	}
+	if index == 0 {
+		return false, errors.New("invalid partial signature index")
+	}
+	sig, err := decodeSignature(partial[partialIndexSize:])
+	if err != nil {
+		return false, err
+	}
+	return blsVerifyPoint(pubPoly.Eval(index), message.point, sig), nil
+}
+
+// RecoverSignature recover group signature from at least threshold partial
+// signatures, partials with a zero or repeated index are skipped
+func RecoverSignature(partials [][]byte, threshold int) ([]byte, error) {
+	indexes := make([]*big.Int, 0, threshold)
+	points := make([]*bls12381.PointG1, 0, threshold)
+	seen := make(map[uint32]bool)
+	for _, partial := range partials {
+		if len(points) == threshold {
+			break
+		}
+		index, err := PartialIndex(partial)
+		if err != nil || index == 0 || seen[index] {
+			continue
+		}
+		sig, err := decodeSignature(partial[partialIndexSize:])
+		if err != nil {
+			continue
+		}
+		seen[index] = true
+		indexes = append(indexes, big.NewInt(int64(index)))
+		points = append(points, sig)
+	}
+	if len(points) < threshold {
+		return nil, errors.New("not enough partial signatures")
+	}
+	g1 := bls12381.NewG1()
+	recovered := g1.Zero()
+	for i, point := range points {
+		g1.Add(recovered, recovered, g1.MulScalarBig(g1.New(), point, lagrangeBasis(indexes, i)))
+	}
+	return g1.ToCompressed(recovered), nil
+}
+
+// RecoverSecret recover shared secret from at least threshold shares, shares
+// must have distinct positive indexes
+func RecoverSecret(shares []*PriShare, threshold int) (*big.Int, error) {
+	if threshold < 1 {
+		return nil, errors.New("threshold must be positive")
+	}
+	if len(shares) < threshold {
+		return nil, errors.New("not enough shares")
+	}
+	indexes := make([]uint32, threshold)
+	for i := range indexes {
+		if shares[i] == nil || shares[i].Secret == nil {
+			return nil, errors.New("invalid share")
+		}
+		indexes[i] = shares[i].Index
+	}
+	coefficients, err := LagrangeCoefficients(indexes)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(big.Int)
+	for i, coefficient := range coefficients {
+		secret.Add(secret, new(big.Int).Mul(shares[i].Secret, coefficient))
+		secret.Mod(secret, CurveOrder)
+	}
+	return secret, nil
+}
+
+// LagrangeCoefficients Lagrange coefficients at zero of given share indexes,
+// the shared secret is the sum of each share times its coefficient
+func LagrangeCoefficients(indexes []uint32) ([]*big.Int, error) {
+	return LagrangeCoefficientsAt(indexes, 0)
+}
+
+// LagrangeCoefficientsAt Lagrange coefficients at index x of given share
+// indexes, the share of x is the sum of each share times its coefficient
+func LagrangeCoefficientsAt(indexes []uint32, x uint32) ([]*big.Int, error) {
+	if err := checkIndexes(indexes); err != nil {
+		return nil, err
+	}
+	points := make([]*big.Int, len(indexes))
+	for i, index := range indexes {
+		points[i] = big.NewInt(int64(index))
+	}
+	coefficients := make([]*big.Int, len(indexes))
+	for i := range coefficients {
+		coefficients[i] = lagrangeBasisAt(points, i, big.NewInt(int64(x)))
+	}
+	return coefficients, nil
+}
+
+// checkIndexes share indexes must be positive and distinct, interpolation
+// divides by their differences
+func checkIndexes(indexes []uint32) error {
+	seen := make(map[uint32]bool, len(indexes))
+	for _, index := range indexes {
+		if index == 0 {
+			return errors.New("share index must be positive")
+		}
+		if seen[index] {
+			return fmt.Errorf("duplicate share index %d", index)
+		}
+		seen[index] = true
+	}
+	return nil
+}
+
+// lagrangeBasis Lagrange basis polynomial of indexes[i] evaluated at zero
+func lagrangeBasis(indexes []*big.Int, i int) *big.Int {
+	return lagrangeBasisAt(indexes, i, new(big.Int))
+}
+
+// lagrangeBasisAt Lagrange basis polynomial of indexes[i] evaluated at x
+func lagrangeBasisAt(indexes []*big.Int, i int, x *big.Int) *big.Int {
+	numerator := big.NewInt(1)
+	denominator := big.NewInt(1)
+	for j, xj := range indexes {
+		if j == i {
+			continue
+		}
+		numerator.Mul(numerator, new(big.Int).Sub(xj, x))
+		numerator.Mod(numerator, CurveOrder)
+		diff := new(big.Int).Sub(xj, indexes[i])
+		denominator.Mul(denominator, diff)
+		denominator.Mod(denominator, CurveOrder)
+	}
+	inverse := new(big.Int).ModInverse(denominator, CurveOrder)
+	return numerator.Mul(numerator, inverse).Mod(numerator, CurveOrder)
+}
@@ -0,0 +1,126 @@
+package keypair
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+)
+
+func TestThresholdSignRecover(t *testing.T) {
+	const members, threshold = 5, 3
+	poly, err := NewPriPoly(threshold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPoly := poly.Commit()
+	data := []byte("round 1")
+	shares := poly.Shares(members)
+	partials := make([][]byte, len(shares))
+	for i, share := range shares {
+		partials[i] = SignPartial(share, data)
+		if ok, err := VerifyPartial(pubPoly, data, partials[i]); err != nil || !ok {
+			t.Fatalf("partial of share %d does not verify: %v", share.Index, err)
+		}
+	}
+
+	// Any threshold partials recover the same group signature
+	signature, err := RecoverSignature(partials[:threshold], threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := BLSVerify(pubPoly.PublicKey(), data, signature); err != nil || !ok {
+		t.Fatalf("group signature does not verify: %v", err)
+	}
+	other, err := RecoverSignature(partials[members-threshold:], threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signature, other) {
+		t.Fatal("other partials recover another group signature")
+	}
+	if want := blsSign(poly.Secret(), data); !bytes.Equal(signature, want) {
+		t.Fatal("group signature is not signature of shared secret")
+	}
+
+	// A repeated partial does not count twice
+	repeated := [][]byte{partials[0], partials[0], partials[1]}
+	if _, err := RecoverSignature(repeated, threshold); err == nil {
+		t.Fatal("signature recovered from a repeated partial")
+	}
+	recovered, err := RecoverSignature(append(repeated, partials[2]), threshold)
+	if err != nil || !bytes.Equal(recovered, signature) {
+		t.Fatalf("repeated partial was not skipped: %v", err)
+	}
+
+	// A partial of index zero would be the group signature itself
+	zero := append([]byte(nil), partials[2]...)
+	binary.BigEndian.PutUint32(zero, 0)
+	if _, err := RecoverSignature([][]byte{partials[0], partials[1], zero}, threshold); err == nil {
+		t.Fatal("signature recovered with a partial of index zero")
+	}
+	if _, err := VerifyPartial(pubPoly, data, zero); err == nil {
+		t.Fatal("partial of index zero verifies")
+	}
+}
+
+func TestRecoverSecret(t *testing.T) {
+	const threshold = 3
+	poly, err := NewPriPoly(threshold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares := poly.Shares(4)
+	secret, err := RecoverSecret(shares[1:], threshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Cmp(poly.Secret()) != 0 {
+		t.Fatal("recovered secret is not the shared secret")
+	}
+	if _, err := RecoverSecret([]*PriShare{shares[0], shares[0], shares[1]}, threshold); err == nil {
+		t.Fatal("secret recovered from a repeated share")
+	}
+	if _, err := RecoverSecret([]*PriShare{{Index: 0, Secret: poly.Secret()}, shares[0], shares[1]}, threshold); err == nil {
+		t.Fatal("secret recovered from a share of index zero")
+	}
+	if _, err := RecoverSecret(shares[:threshold-1], threshold); err == nil {
+		t.Fatal("secret recovered from fewer shares than threshold")
+	}
+}
+
+func TestZeroPolyMask(t *testing.T) {
+	const threshold, lost = 3, 2
+	poly, err := NewPriPoly(threshold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask, err := NewZeroPoly(threshold, lost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mask.Eval(lost).Secret.Sign() != 0 {
+		t.Fatal("mask is not zero at index of lost share")
+	}
+	// Masked shares of helpers interpolate to the lost share only
+	helpers := []uint32{1, 3, 4}
+	coefficients, err := LagrangeCoefficientsAt(helpers, lost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := new(big.Int)
+	for i, index := range helpers {
+		masked := new(big.Int).Add(poly.Eval(index).Secret, mask.Eval(index).Secret)
+		share.Add(share, masked.Mul(masked, coefficients[i]))
+		share.Mod(share, CurveOrder)
+	}
+	if share.Cmp(poly.Eval(lost).Secret) != 0 {
+		t.Fatal("masked shares do not interpolate to the lost share")
+	}
+	if _, err := LagrangeCoefficientsAt([]uint32{1, 1, 3}, lost); err == nil {
+		t.Fatal("coefficients computed for repeated indexes")
+	}
+	if _, err := LagrangeCoefficientsAt([]uint32{0, 1, 3}, lost); err == nil {
+		t.Fatal("coefficients computed for index zero")
+	}
+}
@@ -0,0 +1,163 @@
+package keypair
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
+
+	"filippo.io/edwards25519"
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// ECVRF-EDWARDS25519-SHA512-TAI from RFC 9381, secret scalars are only
+// multiplied in constant time since the VRF key is the node identity key
+
+// Sizes of VRF values
+const (
+	VRFProofSize  = 80
+	VRFOutputSize = 64
+)
+
+const vrfSuite = 0x03
+
+// edDecode decode a point, return false if bytes is not the canonical
+// encoding of a point as RFC 8032 requires
+func edDecode(b []byte) (*edwards25519.Point, bool) {
+	if len(b) != 32 {
+		return nil, false
+	}
+	point, err := new(edwards25519.Point).SetBytes(b)
+	// SetBytes accepts y >= p and negative zero x, they don't encode back to b
+	if err != nil || !bytes.Equal(point.Bytes(), b) {
+		return nil, false
+	}
+	return point, true
+}
+
+// scalar16 decode the 16 bytes challenge of a proof as a scalar
+func scalar16(b []byte) *edwards25519.Scalar {
+	buf := make([]byte, 32)
+	copy(buf, b)
+	// A 128-bit value is always below the group order
+	c, _ := edwards25519.NewScalar().SetCanonicalBytes(buf)
+	return c
+}
+
+// vrfEncodeToCurve hash public key and seed to a point with try-and-increment
+func vrfEncodeToCurve(pubKey []byte, seed []byte) (*edwards25519.Point, error) {
+	for counter := 0; counter < 256; counter++ {
+		h := sha512.New()
+		h.Write([]byte{vrfSuite, 0x01})
+		h.Write(pubKey)
+		h.Write(seed)
+		h.Write([]byte{byte(counter), 0x00})
+		if point, ok := edDecode(h.Sum(nil)[:32]); ok {
+			return point.MultByCofactor(point), nil
+		}
+	}
+	return nil, errors.New("unable to encode seed to curve")
+}
+
+// vrfChallenge challenge of proof, truncated to 16 bytes
+func vrfChallenge(points ...*edwards25519.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte{vrfSuite, 0x02})
+	for _, point := range points {
+		h.Write(point.Bytes())
+	}
+	h.Write([]byte{0x00})
+	return h.Sum(nil)[:16]
+}
+
+// vrfProofToHash VRF output of a decoded gamma
+func vrfProofToHash(gamma *edwards25519.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte{vrfSuite, 0x03})
+	h.Write(new(edwards25519.Point).MultByCofactor(gamma).Bytes())
+	h.Write([]byte{0x00})
+	return h.Sum(nil)
+}
+
+// VRFProve produce VRF proof and output of seed, key pair must be an Ed25519 key pair
+func (k *KeyPair) VRFProve(seed []byte) (proof []byte, output []byte, err error) {
+	if k.privKey == nil || k.keyType != p2pCrypto.Ed25519 {
+		return nil, nil, errors.New("VRF requires an Ed25519 private key")
+	}
+	raw, err := k.privKey.Raw()
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := sha512.Sum512(raw[:32])
+	x, err := edwards25519.NewScalar().SetBytesWithClamping(digest[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	y := new(edwards25519.Point).ScalarBaseMult(x)
+	pubKey := y.Bytes()
+
+	hPoint, err := vrfEncodeToCurve(pubKey, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	gamma := new(edwards25519.Point).ScalarMult(x, hPoint)
+	nonceHash := sha512.New()
+	nonceHash.Write(digest[32:])
+	nonceHash.Write(hPoint.Bytes())
+	nonce, err := edwards25519.NewScalar().SetUniformBytes(nonceHash.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	challenge := vrfChallenge(y, hPoint, gamma, new(edwards25519.Point).ScalarBaseMult(nonce), new(edwards25519.Point).ScalarMult(nonce, hPoint))
+	s := edwards25519.NewScalar().MultiplyAdd(scalar16(challenge), x, nonce)
+
+	proof = append(gamma.Bytes(), challenge...)
+	proof = append(proof, s.Bytes()...)
+	return proof, vrfProofToHash(gamma), nil
+}
+
+// VRFVerify verify VRF proof of seed against raw Ed25519 public key and return VRF output
+func VRFVerify(pubKey []byte, seed []byte, proof []byte) ([]byte, error) {
+	if len(proof) != VRFProofSize {
+		return nil, errors.New("invalid VRF proof size")
+	}
+	y, ok := edDecode(pubKey)
+	if !ok {
+		return nil, errors.New("invalid VRF public key")
+	}
+	if new(edwards25519.Point).MultByCofactor(y).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, errors.New("VRF public key has low order")
+	}
+	gamma, ok := edDecode(proof[:32])
+	if !ok {
+		return nil, errors.New("invalid VRF proof")
+	}
+	c := scalar16(proof[32:48])
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(proof[48:80])
+	if err != nil {
+		return nil, errors.New("invalid VRF proof")
+	}
+	hPoint, err := vrfEncodeToCurve(pubKey, seed)
+	if err != nil {
+		return nil, err
+	}
+	// Inputs of verification are public, so variable time is fine
+	negC := edwards25519.NewScalar().Negate(c)
+	u := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(negC, y, s)
+	v := new(edwards25519.Point).VarTimeMultiScalarMult([]*edwards25519.Scalar{s, negC}, []*edwards25519.Point{hPoint, gamma})
+	if !bytes.Equal(vrfChallenge(y, hPoint, gamma, u, v), proof[32:48]) {
+		return nil, errors.New("VRF proof does not verify")
+	}
+	return vrfProofToHash(gamma), nil
+}
+
+// VRFProofToHash VRF output of a proof without verifying it
+func VRFProofToHash(proof []byte) ([]byte, error) {
+	if len(proof) != VRFProofSize {
+		return nil, errors.New("invalid VRF proof size")
+	}
+	gamma, ok := edDecode(proof[:32])
+	if !ok {
+		return nil, errors.New("invalid VRF proof")
+	}
+	return vrfProofToHash(gamma), nil
+}
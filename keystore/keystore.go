@@ -0,0 +1,327 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/relayer"
+)
+
+// Keystore directory of named secrets, each secret is kept in its own file
+// <name>.json and is encrypted with its own passphrase or stored in plain
+type Keystore struct {
+	dir string
+}
+
+// Kind of secret held by an entry
+type Kind string
+
+const (
+	// KindNode Ed25519 node identity key
+	KindNode Kind = "node"
+	// KindRelayer secp256k1 key of relayer account
+	KindRelayer Kind = "relayer"
+	// KindShare BLS share of a DKG result
+	KindShare Kind = "share"
+)
+
+// entryVersion current version of entry files
+const entryVersion = 1
+
+// ErrNotFound no entry with given name
+var ErrNotFound = errors.New("key is not in keystore")
+
+// ErrExists an entry with given name already exists
+var ErrExists = errors.New("key already exists in keystore")
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Entry public information of a keystore entry
+type Entry struct {
+	Name      string    `json:"name"`
+	Kind      Kind      `json:"kind"`
+	Created   time.Time `json:"created"`
+	Encrypted bool      `json:"encrypted"`
+	// ID public identity of secret: peer ID of node keys, account address of
+	// relayer keys and group public key of shares
+	ID string `json:"id"`
+}
+
+// entryJSON file format of an entry, secret is hex encoded unless it's encrypted
+type entryJSON struct {
+	Version int                   `json:"version"`
+	Kind    Kind                  `json:"kind"`
+	Created int64                 `json:"created"`
+	ID      string                `json:"id"`
+	Secret  string                `json:"secret,omitempty"`
+	Crypto  *keypair.EncryptedKey `json:"crypto,omitempty"`
+}
+
+// New open keystore in given directory, directory is created if it does not exist
+func New(dir string) (*Keystore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Keystore{dir: dir}, nil
+}
+
+// Dir directory of keystore
+func (s *Keystore) Dir() string {
+	return s.dir
+}
+
+func (s *Keystore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// additionalData bind ciphertext to name and kind of entry
+func additionalData(name string, kind Kind) []byte {
+	return []byte(fmt.Sprintf("orochi-keystore:%d:%s:%s", entryVersion, name, kind))
+}
+
+// List entries sorted by name
+func (s *Keystore) List() ([]*Entry, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0)
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".json")
+		if file.IsDir() || name == file.Name() || !namePattern.MatchString(name) {
+			continue
+		}
+		entry, _, err := s.read(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Get public information of an entry
+func (s *Keystore) Get(name string) (*Entry, error) {
+	entry, _, err := s.read(name)
+	return entry, err
+}
+
+// Create generate a new node or relayer key, it's encrypted unless passphrase is empty
+func (s *Keystore) Create(name string, kind Kind, passphrase string) (*Entry, error) {
+	var key *keypair.KeyPair
+	var err error
+	switch kind {
+	case KindNode:
+		key, err = keypair.New(p2pCrypto.Ed25519, 256)
+	case KindRelayer:
+		key, err = keypair.New(p2pCrypto.Secp256k1, 256)
+	default:
+		return nil, errors.New("only node and relayer keys can be created, shares come from DKG")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.PutKeyPair(name, kind, key, passphrase)
+}
+
+// PutKeyPair add a node or relayer key pair
+func (s *Keystore) PutKeyPair(name string, kind Kind, key *keypair.KeyPair, passphrase string) (*Entry, error) {
+	var id string
+	switch kind {
+	case KindNode:
+		peerID, err := key.GetID()
+		if err != nil {
+			return nil, err
+		}
+		id = peerID.Pretty()
+	case KindRelayer:
+		address, err := relayer.KeyAddress(key)
+		if err != nil {
+			return nil, err
+		}
+		id = address.String()
+	default:
+		return nil, errors.New("kind of a key pair must be node or relayer")
+	}
+	if key.GetPrivateKey() == nil {
+		return nil, errors.New("keystore only holds private keys")
+	}
+	raw, err := key.GetPrivateKey().Raw()
+	if err != nil {
+		return nil, err
+	}
+	return s.write(name, kind, id, raw, passphrase)
+}
+
+// PutShare add a BLS share of a DKG result
+func (s *Keystore) PutShare(name string, result *dkg.Result, passphrase string) (*Entry, error) {
+	encoded, err := result.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return s.write(name, KindShare, hex.EncodeToString(result.GroupKey()), encoded, passphrase)
+}
+
+// KeyPair load a node or relayer key pair
+func (s *Keystore) KeyPair(name string, passphrase string) (*keypair.KeyPair, error) {
+	entry, secret, err := s.open(name, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	switch entry.Kind {
+	case KindNode:
+		return keypair.FromPrivateKey(p2pCrypto.Ed25519, secret)
+	case KindRelayer:
+		return keypair.FromPrivateKey(p2pCrypto.Secp256k1, secret)
+	}
+	return nil, fmt.Errorf("%s is a %s, not a key pair", name, entry.Kind)
+}
+
+// Share load a BLS share
+func (s *Keystore) Share(name string, passphrase string) (*dkg.Result, error) {
+	entry, secret, err := s.open(name, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Kind != KindShare {
+		return nil, fmt.Errorf("%s is a %s, not a share", name, entry.Kind)
+	}
+	return dkg.UnmarshalResult(secret)
+}
+
+// Export write secret of an entry to a plain key file or share file that
+// node::key_file, relayer::key_file or beacon::share_file accept
+func (s *Keystore) Export(name string, passphrase string, fileName string) error {
+	entry, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(fileName); err == nil {
+		return fmt.Errorf("file %s already exists", fileName)
+	}
+	if entry.Kind == KindShare {
+		result, err := s.Share(name, passphrase)
+		if err != nil {
+			return err
+		}
+		_, err = result.SaveToFile(fileName)
+		return err
+	}
+	key, err := s.KeyPair(name, passphrase)
+	if err != nil {
+		return err
+	}
+	if _, err := key.SaveToFile(fileName); err != nil {
+		return err
+	}
+	return os.Chmod(fileName, 0600)
+}
+
+// Delete remove an entry
+func (s *Keystore) Delete(name string) error {
+	if !namePattern.MatchString(name) {
+		return errors.New("invalid key name: " + name)
+	}
+	if err := os.Remove(s.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// write a new entry, existing entries are never overwritten
+func (s *Keystore) write(name string, kind Kind, id string, secret []byte, passphrase string) (*Entry, error) {
+	if !namePattern.MatchString(name) {
+		return nil, errors.New("key name must be 1 to 64 letters, digits, - or _")
+	}
+	file := &entryJSON{Version: entryVersion, Kind: kind, Created: time.Now().Unix(), ID: id}
+	if passphrase != "" {
+		crypto, err := keypair.Encrypt(secret, passphrase, additionalData(name, kind))
+		if err != nil {
+			return nil, err
+		}
+		file.Crypto = crypto
+	} else {
+		file.Secret = hex.EncodeToString(secret)
+	}
+	encoded, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	fid, err := os.OpenFile(s.path(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrExists
+		}
+		return nil, err
+	}
+	defer fid.Close()
+	if _, err := fid.Write(encoded); err != nil {
+		return nil, err
+	}
+	return file.entry(name), nil
+}
+
+// read entry file
+func (s *Keystore) read(name string) (*Entry, *entryJSON, error) {
+	if !namePattern.MatchString(name) {
+		return nil, nil, errors.New("invalid key name: " + name)
+	}
+	content, err := ioutil.ReadFile(s.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	file := new(entryJSON)
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, nil, err
+	}
+	if file.Version != entryVersion {
+		return nil, nil, fmt.Errorf("unsupported keystore entry version %d", file.Version)
+	}
+	return file.entry(name), file, nil
+}
+
+// open read entry and decrypt its secret, passphrase is ignored if entry is not encrypted
+func (s *Keystore) open(name string, passphrase string) (*Entry, []byte, error) {
+	entry, file, err := s.read(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if file.Crypto == nil {
+		secret, err := hex.DecodeString(file.Secret)
+		return entry, secret, err
+	}
+	if passphrase == "" {
+		return nil, nil, keypair.ErrEncryptedKey
+	}
+	secret, err := file.Crypto.Decrypt(passphrase, additionalData(name, file.Kind))
+	return entry, secret, err
+}
+
+func (e *entryJSON) entry(name string) *Entry {
+	return &Entry{
+		Name:      name,
+		Kind:      e.Kind,
+		Created:   time.Unix(e.Created, 0),
+		Encrypted: e.Crypto != nil,
+		ID:        e.ID,
+	}
+}
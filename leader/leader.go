@@ -0,0 +1,121 @@
+package leader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// Leaders are elected by sortition over beacon output. Every member draws a
+// ticket hash(domain | round | randomness | member), the lowest tickets win.
+// Randomness is unknown until the group signs the round, so no member can
+// bias its ticket, and anyone holding the verified round recomputes the
+// election.
+
+// ticketDomain separates election tickets from other hashes of randomness
+const ticketDomain = "orochi-leader-v1"
+
+// Source provide rounds that leaders are elected from
+type Source interface {
+	// Get round by its number
+	Get(round uint64) (*beacon.RoundResult, error)
+}
+
+// Election elect leaders of rounds among members of a group
+type Election struct {
+	// Members of group, members of the same group must be given in the same
+	// order by every node
+	Members []peer.ID
+	// GroupKey rounds are verified against it before they are used, rounds
+	// are trusted if it's nil
+	GroupKey []byte
+	// EpochLength number of consecutive rounds that share a leader, every
+	// round has its own leader if it's 0 or 1
+	EpochLength uint64
+	source      Source
+}
+
+// New create election of members over rounds of source
+func New(source Source, members []peer.ID, groupKey []byte) *Election {
+	return &Election{Members: members, GroupKey: groupKey, source: source}
+}
+
+// SeedRound round whose randomness elects leader of given round, the first
+// round of its epoch
+func (e *Election) SeedRound(round uint64) uint64 {
+	if e.EpochLength <= 1 {
+		return round
+	}
+	return (round-1)/e.EpochLength*e.EpochLength + 1
+}
+
+// LeaderFor leader of round
+func (e *Election) LeaderFor(round uint64) (peer.ID, error) {
+	committee, err := e.CommitteeFor(round, 1)
+	if err != nil {
+		return "", err
+	}
+	return committee[0], nil
+}
+
+// CommitteeFor elect size members for round, leader comes first
+func (e *Election) CommitteeFor(round uint64, size int) ([]peer.ID, error) {
+	if round == 0 {
+		return nil, errors.New("rounds start at 1")
+	}
+	if size < 1 || size > len(e.Members) {
+		return nil, fmt.Errorf("committee size must be between 1 and %d", len(e.Members))
+	}
+	seed := e.SeedRound(round)
+	result, err := e.source.Get(seed)
+	if err != nil {
+		return nil, fmt.Errorf("round %d is not available: %v", seed, err)
+	}
+	if e.GroupKey != nil {
+		if err := beacon.Verify(keypair.SchemeBLS, e.GroupKey, result); err != nil {
+			return nil, fmt.Errorf("round %d is invalid: %v", seed, err)
+		}
+	}
+	return Committee(result, e.Members, size), nil
+}
+
+// Ticket sortition ticket of member in a round
+func Ticket(result *beacon.RoundResult, member peer.ID) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, result.Round)
+	h := sha256.New()
+	h.Write([]byte(ticketDomain))
+	h.Write(buf)
+	h.Write(result.Randomness)
+	h.Write([]byte(member))
+	return h.Sum(nil)
+}
+
+// Committee elect size members with the lowest tickets of round, ordered by
+// ticket
+func Committee(result *beacon.RoundResult, members []peer.ID, size int) []peer.ID {
+	type draw struct {
+		member peer.ID
+		ticket []byte
+	}
+	draws := make([]draw, len(members))
+	for i, member := range members {
+		draws[i] = draw{member: member, ticket: Ticket(result, member)}
+	}
+	sort.Slice(draws, func(i, j int) bool { return bytes.Compare(draws[i].ticket, draws[j].ticket) < 0 })
+	if size > len(draws) {
+		size = len(draws)
+	}
+	committee := make([]peer.ID, size)
+	for i := range committee {
+		committee[i] = draws[i].member
+	}
+	return committee
+}
@@ -0,0 +1,14 @@
+//go:build windows || plan9 || js
+// +build windows plan9 js
+
+package loadshed
+
+import (
+	"errors"
+	"time"
+)
+
+// cpuTime is not available on this platform
+func cpuTime() (time.Duration, error) {
+	return 0, errors.New("CPU time of process is not available on this platform")
+}
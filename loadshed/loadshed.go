@@ -0,0 +1,185 @@
+// Package loadshed sheds low priority work of a node while its CPU is
+// saturated. Signing, verifying and publishing the current round never ask a
+// shedder, so they keep the CPU that shed work leaves free
+package loadshed
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Kinds of low priority work
+const (
+	// WorkSync serving sync requests of peers
+	WorkSync = "sync"
+	// WorkHistory API queries of rounds that are not cached
+	WorkHistory = "history"
+	// WorkArchive building archives of stored rounds
+	WorkArchive = "archive"
+)
+
+// sampleInterval time between CPU samples
+const sampleInterval = time.Second
+
+// smoothing weight of the latest sample in smoothed load
+const smoothing = 0.5
+
+// recovery fraction of threshold that load must fall below before shedding
+// stops, so shedding doesn't flap around the threshold
+const recovery = 0.9
+
+var (
+	shedWork = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "loadshed",
+		Name:      "shed_total",
+		Help:      "Low priority work dropped under CPU pressure, by kind of work",
+	}, []string{"work"})
+	deferredWork = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "loadshed",
+		Name:      "deferred_total",
+		Help:      "Low priority work delayed under CPU pressure, by kind of work",
+	}, []string{"work"})
+	cpuLoad = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "loadshed",
+		Name:      "cpu_load",
+		Help:      "Smoothed CPU time of node as a fraction of the CPUs it may use",
+	})
+	shedding = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "loadshed",
+		Name:      "shedding",
+		Help:      "1 while low priority work is shed, 0 otherwise",
+	})
+)
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("loadshed")
+	metrics.Registry.MustRegister(shedWork, deferredWork, cpuLoad, shedding)
+}
+
+// Shedder watch CPU load of process and shed low priority work while it's at
+// or above threshold. Methods of a nil shedder let all work run
+type Shedder struct {
+	threshold float64
+	// load smoothed CPU load as bits of a float64
+	load       uint64
+	overloaded int32
+}
+
+// New create shedder of threshold, a fraction of the CPUs that process may
+// use, e.g. 0.9
+func New(threshold float64) *Shedder {
+	return &Shedder{threshold: threshold}
+}
+
+// Start sample CPU load until ctx is done, work is never shed if CPU time
+// of process can't be read on this platform
+func (s *Shedder) Start(ctx context.Context) {
+	last, err := cpuTime()
+	if err != nil {
+		log.Warnf("Load shedding is off, unable to read CPU time: %v", err)
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+		lastSample := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				used, err := cpuTime()
+				if err != nil {
+					log.Errorf("Unable to read CPU time: %v", err)
+					continue
+				}
+				capacity := now.Sub(lastSample) * time.Duration(runtime.GOMAXPROCS(0))
+				if capacity > 0 {
+					s.sample(float64(used-last) / float64(capacity))
+				}
+				last, lastSample = used, now
+			}
+		}
+	}()
+}
+
+// sample add a load sample and update whether work is shed
+func (s *Shedder) sample(load float64) {
+	smoothed := smoothing*load + (1-smoothing)*s.Load()
+	atomic.StoreUint64(&s.load, math.Float64bits(smoothed))
+	cpuLoad.Set(smoothed)
+	overloaded := s.Overloaded()
+	switch {
+	case !overloaded && smoothed >= s.threshold:
+		atomic.StoreInt32(&s.overloaded, 1)
+		shedding.Set(1)
+		log.Warnf("CPU load %.0f%% reached %.0f%%, shedding low priority work", smoothed*100, s.threshold*100)
+	case overloaded && smoothed < s.threshold*recovery:
+		atomic.StoreInt32(&s.overloaded, 0)
+		shedding.Set(0)
+		log.Infof("CPU load fell to %.0f%%, low priority work runs again", smoothed*100)
+	}
+}
+
+// Load smoothed CPU load as a fraction of the CPUs that process may use
+func (s *Shedder) Load() float64 {
+	if s == nil {
+		return 0
+	}
+	return math.Float64frombits(atomic.LoadUint64(&s.load))
+}
+
+// Overloaded whether low priority work is shed
+func (s *Shedder) Overloaded() bool {
+	return s != nil && atomic.LoadInt32(&s.overloaded) == 1
+}
+
+// Allow whether low priority work of given kind may run now, work that may
+// not is counted as shed
+func (s *Shedder) Allow(work string) bool {
+	if !s.Overloaded() {
+		return true
+	}
+	shedWork.WithLabelValues(work).Inc()
+	return false
+}
+
+// Wait defer low priority work of given kind while work is shed, for at most
+// max. It's false, and work is counted as shed, if load stays high that long
+// or ctx is done first
+func (s *Shedder) Wait(ctx context.Context, work string, max time.Duration) bool {
+	if !s.Overloaded() {
+		return true
+	}
+	deferredWork.WithLabelValues(work).Inc()
+	deadline := time.NewTimer(max)
+	defer deadline.Stop()
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	for s.Overloaded() {
+		select {
+		case <-ctx.Done():
+			shedWork.WithLabelValues(work).Inc()
+			return false
+		case <-deadline.C:
+			shedWork.WithLabelValues(work).Inc()
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
@@ -2,35 +2,278 @@ package logger
 
 import (
 	"encoding/hex"
+	"errors"
+	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var once sync.Once
+var mutex sync.Mutex
+
+// root logger every named logger derives from, it logs every level and named
+// loggers filter by their own level
+var root *zap.Logger
 var sugar *zap.SugaredLogger
 
+// file log file of root logger, nil if it logs only to stderr
+var file *lumberjack.Logger
+var named = make(map[string]*zap.SugaredLogger)
+
+// defaultLevel level of loggers without a level of their own
+var defaultLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+var levels = make(map[string]zap.AtomicLevel)
+
 func init() {
-	once.Do(func() {
-		config := zap.NewDevelopmentConfig()
-		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		logger, err := config.Build()
-		if err == nil {
-			sugar = logger.Sugar()
-			sugar.Debug("Logger online")
-			defer func() {
-				logger.Sync()
-				sugar.Sync()
-			}()
-			return
-		}
+	logger, _, err := build(Options{Format: FormatConsole})
+	if err != nil {
 		panic(err)
+	}
+	root = logger
+	sugar = withLevel(root, defaultLevel).Sugar()
+	sugar.Debug("Logger online")
+}
+
+// Log formats
+const (
+	// FormatConsole human readable development output with colored levels
+	FormatConsole = "console"
+	// FormatJSON one JSON object per line as zap production config writes
+	FormatJSON = "json"
+)
+
+//Options of singleton logger
+type Options struct {
+	// Level default level optionally followed by levels of named loggers,
+	// e.g. info,network=debug,beacon=warn
+	Level  string
+	Format string
+	// File log file written besides stderr, empty to log only to stderr
+	File string
+	// MaxSize megabytes of log file before it's rotated
+	MaxSize int
+	// MaxBackups number of rotated files to keep, 0 keeps all
+	MaxBackups int
+	// MaxAge days to keep rotated files, 0 keeps them regardless of age
+	MaxAge int
+}
+
+//Configure rebuild singleton logger with given options, loggers packages
+//already hold are updated in place
+func Configure(options Options) error {
+	level, moduleLevels, err := ParseLevels(options.Level)
+	if err != nil {
+		return err
+	}
+	logger, logFile, err := build(options)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	root.Sync()
+	if file != nil {
+		file.Close()
+	}
+	root = logger
+	file = logFile
+	setLevels(level, moduleLevels)
+	return nil
+}
+
+//SetLevel change levels of running loggers without rebuilding their output,
+//spec is the same as Options.Level
+func SetLevel(spec string) error {
+	level, moduleLevels, err := ParseLevels(spec)
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	setLevels(level, moduleLevels)
+	return nil
+}
+
+//setLevels update levels and rebuild loggers from root, caller must hold mutex
+func setLevels(level zapcore.Level, moduleLevels map[string]zapcore.Level) {
+	defaultLevel.SetLevel(level)
+	for name := range levels {
+		if _, ok := moduleLevels[name]; !ok {
+			delete(levels, name)
+		}
+	}
+	for name, moduleLevel := range moduleLevels {
+		if atomicLevel, ok := levels[name]; ok {
+			atomicLevel.SetLevel(moduleLevel)
+		} else {
+			levels[name] = zap.NewAtomicLevelAt(moduleLevel)
+		}
+	}
+	*sugar = *withLevel(root, defaultLevel).Sugar()
+	for name, namedLogger := range named {
+		*namedLogger = *newNamed(name)
+	}
+}
+
+//ParseLevels parse a default level and levels of named loggers, e.g.
+//info,network=debug, default level is debug if it's not given
+func ParseLevels(spec string) (zapcore.Level, map[string]zapcore.Level, error) {
+	level := zapcore.DebugLevel
+	moduleLevels := make(map[string]zapcore.Level)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, levelName := "", part
+		if i := strings.Index(part, "="); i >= 0 {
+			name, levelName = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+			if name == "" {
+				return level, nil, errors.New("missing logger name in log level: " + part)
+			}
+		}
+		parsed, err := zapcore.ParseLevel(levelName)
+		if err != nil {
+			return level, nil, err
+		}
+		if name == "" {
+			level = parsed
+		} else {
+			moduleLevels[name] = parsed
+		}
+	}
+	return level, moduleLevels, nil
+}
+
+//Rotate start a new log file, the current one is kept as a rotated file
+func Rotate() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if file == nil {
+		return errors.New("node does not write a log file")
+	}
+	return file.Rotate()
+}
+
+//build root logger writing every level to stderr and log file, log file is
+//nil if options have no file
+func build(options Options) (*zap.Logger, *lumberjack.Logger, error) {
+	var config zap.Config
+	switch options.Format {
+	case FormatConsole:
+		config = zap.NewDevelopmentConfig()
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	case FormatJSON:
+		config = zap.NewProductionConfig()
+		config.EncoderConfig.TimeKey = "time"
+		config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	default:
+		return nil, nil, errors.New("log format must be console or json")
+	}
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	opts := []zap.Option{zap.Hooks(recordError)}
+	var logFile *lumberjack.Logger
+	if options.File != "" {
+		var fileCore zapcore.Core
+		fileCore, logFile = newFileCore(config, options)
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, fileCore)
+		}))
+	}
+	logger, err := config.Build(opts...)
+	return logger, logFile, err
+}
+
+//Entry error logged by a module
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Logger  string    `json:"logger"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+//maxRecentErrors number of latest errors kept for RecentErrors
+const maxRecentErrors = 50
+
+var recentMutex sync.Mutex
+var recentErrors []Entry
+
+//recordError keep entries of error level and above for RecentErrors
+func recordError(entry zapcore.Entry) error {
+	if entry.Level < zapcore.ErrorLevel {
+		return nil
+	}
+	recentMutex.Lock()
+	defer recentMutex.Unlock()
+	recentErrors = append(recentErrors, Entry{
+		Time:    entry.Time,
+		Logger:  entry.LoggerName,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
 	})
+	if len(recentErrors) > maxRecentErrors {
+		recentErrors = recentErrors[len(recentErrors)-maxRecentErrors:]
+	}
+	return nil
+}
+
+//RecentErrors latest entries logged at error level or above, newest last
+func RecentErrors() []Entry {
+	recentMutex.Lock()
+	defer recentMutex.Unlock()
+	return append([]Entry{}, recentErrors...)
+}
+
+//withLevel logger of root filtered by given level
+func withLevel(logger *zap.Logger, level zap.AtomicLevel) *zap.Logger {
+	return logger.WithOptions(zap.IncreaseLevel(level))
+}
+
+//newNamed build named logger from root, caller must hold mutex
+func newNamed(name string) *zap.SugaredLogger {
+	level, ok := levels[name]
+	if !ok {
+		level = defaultLevel
+	}
+	return withLevel(root.Named(name), level).Sugar()
+}
+
+//Named logger of a module, its entries carry the module name and its level can
+//be set apart from other modules, e.g. network=debug
+func Named(name string) *zap.SugaredLogger {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if namedLogger, ok := named[name]; ok {
+		return namedLogger
+	}
+	namedLogger := newNamed(name)
+	named[name] = namedLogger
+	return namedLogger
+}
 
+//newFileCore core writing to a rotated log file, colors are left out of files
+func newFileCore(config zap.Config, options Options) (zapcore.Core, *lumberjack.Logger) {
+	encoderConfig := config.EncoderConfig
+	var encoder zapcore.Encoder
+	if options.Format == FormatJSON {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+	writer := &lumberjack.Logger{
+		Filename:   options.File,
+		MaxSize:    options.MaxSize,
+		MaxBackups: options.MaxBackups,
+		MaxAge:     options.MaxAge,
+	}
+	return zapcore.NewCore(encoder, zapcore.AddSync(writer), config.Level), writer
 }
 
-//GetSugarLogger get singleton sugar logger
+//GetSugarLogger get singleton sugar logger of no module, packages use Named
 func GetSugarLogger() *zap.SugaredLogger {
 	return sugar
 }
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefix of metric names of node
+const Namespace = "drng"
+
+// Registry metrics of node, packages register their collectors in init and
+// API serves them at /metrics
+var Registry = prometheus.NewRegistry()
+
+// Handler serve metrics of Registry in Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,53 @@
+package network
+
+import (
+	"fmt"
+	stdnet "net"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// TCPAddr multiaddr of a TCP listen address, host is an IPv4 or IPv6 address
+func TCPAddr(host string, port uint) (multiaddr.Multiaddr, error) {
+	ip := stdnet.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid bind host %q, it must be an IP address", host)
+	}
+	protocol := "ip4"
+	if ip.To4() == nil {
+		protocol = "ip6"
+	}
+	return multiaddr.NewMultiaddr(fmt.Sprintf("/%s/%s/tcp/%d", protocol, ip, port))
+}
+
+// AdvertiseAddrs option of a host that tells peers announce addresses
+// instead of the addresses it listens on, for nodes behind a load balancer.
+// External addresses are added to what host tells, for nodes behind NAT or in
+// a cloud network whose public address is not one of their interfaces.
+// Either list may be empty
+func AdvertiseAddrs(announce []multiaddr.Multiaddr, external []multiaddr.Multiaddr) libp2p.Option {
+	return libp2p.AddrsFactory(func(addrs []multiaddr.Multiaddr) []multiaddr.Multiaddr {
+		if len(announce) > 0 {
+			addrs = announce
+		}
+		advertised := make([]multiaddr.Multiaddr, 0, len(addrs)+len(external))
+		advertised = append(advertised, addrs...)
+		for _, addr := range external {
+			if !containsAddr(advertised, addr) {
+				advertised = append(advertised, addr)
+			}
+		}
+		return advertised
+	})
+}
+
+// containsAddr addrs has addr
+func containsAddr(addrs []multiaddr.Multiaddr, addr multiaddr.Multiaddr) bool {
+	for _, a := range addrs {
+		if a.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package network
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/control"
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// ErrNotBanned peer is not banned
+var ErrNotBanned = errors.New("peer is not banned")
+
+// Ban peer that connections are refused to and from
+type Ban struct {
+	Peer peer.ID `json:"peer"`
+	// Until time ban expires, nil if it lasts until node restarts
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// BanGater connection gater refusing banned peers, connections of other peers
+// are left to an optional allowlist
+type BanGater struct {
+	allowlist *AllowlistGater
+	// bans expiry of each ban, zero if it does not expire
+	bans  map[peer.ID]time.Time
+	mutex sync.RWMutex
+}
+
+// NewBanGater create a gater of bans in front of allowlist, allowlist may be
+// nil to let every peer that is not banned connect
+func NewBanGater(allowlist *AllowlistGater) *BanGater {
+	return &BanGater{allowlist: allowlist, bans: make(map[peer.ID]time.Time)}
+}
+
+// Option libp2p option installing gater on host
+func (g *BanGater) Option() libp2p.Option {
+	return libp2p.ConnectionGater(g)
+}
+
+// Ban refuse connections of peer for given duration, 0 bans it until node
+// restarts. Banning a banned peer replaces its ban
+func (g *BanGater) Ban(id peer.ID, duration time.Duration) {
+	var until time.Time
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.bans[id] = until
+}
+
+// Unban let peer connect again
+func (g *BanGater) Unban(id peer.ID) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if _, ok := g.bans[id]; !ok {
+		return ErrNotBanned
+	}
+	delete(g.bans, id)
+	return nil
+}
+
+// Bans peers that are banned ordered by ID, expired bans are dropped
+func (g *BanGater) Bans() []Ban {
+	now := time.Now()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	bans := make([]Ban, 0, len(g.bans))
+	for id, until := range g.bans {
+		if !until.IsZero() && now.After(until) {
+			delete(g.bans, id)
+			continue
+		}
+		ban := Ban{Peer: id}
+		if !until.IsZero() {
+			expiry := until
+			ban.Until = &expiry
+		}
+		bans = append(bans, ban)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Peer < bans[j].Peer })
+	return bans
+}
+
+func (g *BanGater) isBanned(id peer.ID) bool {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	until, ok := g.bans[id]
+	return ok && (until.IsZero() || time.Now().Before(until))
+}
+
+// InterceptPeerDial refuse dialing banned peers
+func (g *BanGater) InterceptPeerDial(id peer.ID) bool {
+	return !g.isBanned(id) && (g.allowlist == nil || g.allowlist.InterceptPeerDial(id))
+}
+
+// InterceptAddrDial refuse dialing banned peers at any address
+func (g *BanGater) InterceptAddrDial(id peer.ID, addr multiaddr.Multiaddr) bool {
+	return !g.isBanned(id) && (g.allowlist == nil || g.allowlist.InterceptAddrDial(id, addr))
+}
+
+// InterceptAccept peer is not known before handshake, it's left to allowlist
+func (g *BanGater) InterceptAccept(addrs p2pNetwork.ConnMultiaddrs) bool {
+	return g.allowlist == nil || g.allowlist.InterceptAccept(addrs)
+}
+
+// InterceptSecured refuse connections of banned peers
+func (g *BanGater) InterceptSecured(direction p2pNetwork.Direction, id peer.ID, addrs p2pNetwork.ConnMultiaddrs) bool {
+	if g.isBanned(id) {
+		log.Debugf("Reject connection of banned peer %s from %s", id, addrs.RemoteMultiaddr())
+		return false
+	}
+	return g.allowlist == nil || g.allowlist.InterceptSecured(direction, id, addrs)
+}
+
+// InterceptUpgraded connection was already checked when it was secured
+func (g *BanGater) InterceptUpgraded(conn p2pNetwork.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+// BanPeer refuse connections of peer for given duration and close its
+// connections, 0 bans it until node restarts
+func (net *Network) BanPeer(id peer.ID, duration time.Duration) error {
+	if net.Bans == nil {
+		return errors.New("network does not ban peers")
+	}
+	if id == net.host.ID() {
+		return errors.New("node can not ban itself")
+	}
+	net.Bans.Ban(id, duration)
+	net.membersMutex.RLock()
+	grouped := len(net.members) > 0
+	net.membersMutex.RUnlock()
+	if grouped && net.isMember(id) {
+		log.Warnf("Banned peer %s is a member of group", id)
+	}
+	return net.host.Network().ClosePeer(id)
+}
+
+// UnbanPeer let a banned peer connect again, it's dialed in background if
+// its addresses are known
+func (net *Network) UnbanPeer(id peer.ID) error {
+	if net.Bans == nil {
+		return ErrNotBanned
+	}
+	if err := net.Bans.Unban(id); err != nil {
+		return err
+	}
+	info := net.host.Peerstore().PeerInfo(id)
+	if len(info.Addrs) > 0 {
+		go func() {
+			if err := net.host.Connect(net.context, info); err != nil {
+				log.Debugf("Unable to reconnect unbanned peer %s: %v", id, err)
+			}
+		}()
+	}
+	return nil
+}
+
+// BannedPeers peers that are banned
+func (net *Network) BannedPeers() []Ban {
+	if net.Bans == nil {
+		return []Ban{}
+	}
+	return net.Bans.Bans()
+}
@@ -0,0 +1,77 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MaxEnvelopeSize largest encoded envelope, pubsub drops bigger messages
+// before they are validated
+const MaxEnvelopeSize = 1 << 20
+
+// DefaultMaxPayload largest payload of message types without a limit of their own
+const DefaultMaxPayload = 64 << 10
+
+// Limits of envelope fields, peer IDs and signatures of supported keys are
+// far smaller
+const (
+	maxTypeLength      = 64
+	maxSenderLength    = 128
+	maxSignatureLength = 1024
+)
+
+var errOversized = errors.New("envelope exceeds size limit")
+
+// payloadLimits largest payload of each message type, see SetPayloadLimit
+var (
+	payloadLimits      = make(map[string]int)
+	payloadLimitsMutex sync.RWMutex
+)
+
+// SetPayloadLimit set largest payload of a message type, envelopes with a
+// bigger payload are rejected before their payload is decoded. Message types
+// share a limit across topics
+func SetPayloadLimit(msgType string, max int) {
+	payloadLimitsMutex.Lock()
+	defer payloadLimitsMutex.Unlock()
+	payloadLimits[msgType] = max
+}
+
+// PayloadLimit largest payload of a message type
+func PayloadLimit(msgType string) int {
+	payloadLimitsMutex.RLock()
+	defer payloadLimitsMutex.RUnlock()
+	if max, ok := payloadLimits[msgType]; ok {
+		return max
+	}
+	return DefaultMaxPayload
+}
+
+// checkLimits reject envelope fields over their limit
+func (e *Envelope) checkLimits() error {
+	if len(e.Type) == 0 || len(e.Type) > maxTypeLength || len(e.Sender) == 0 || len(e.Sender) > maxSenderLength || len(e.Signature) > maxSignatureLength {
+		return errOversized
+	}
+	if max := PayloadLimit(e.Type); len(e.Payload) > max {
+		return fmt.Errorf("payload of %s message has %d bytes, limit is %d", e.Type, len(e.Payload), max)
+	}
+	return nil
+}
+
+// DecodePayload decode a JSON payload strictly, unknown fields and data after
+// the value are rejected so a message has one accepted encoding
+func DecodePayload(payload []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after payload")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	swarm "github.com/libp2p/go-libp2p-swarm"
+)
+
+// directTag connection manager tag of direct peers
+const directTag = "direct"
+
+const (
+	// directCheckInterval how often connection to a direct peer is checked
+	directCheckInterval = 5 * time.Second
+	// directBackoffMax longest wait between reconnect attempts
+	directBackoffMax = time.Minute
+)
+
+// keepConnected connect to a direct peer and reconnect with backoff whenever
+// its connection drops
+func (net *Network) keepConnected(info peer.AddrInfo) {
+	net.host.ConnManager().Protect(info.ID, directTag)
+	net.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+	backoff := time.Second
+	for {
+		wait := directCheckInterval
+		if net.host.Network().Connectedness(info.ID) != p2pNetwork.Connected {
+			// Our own backoff replaces the one of swarm, which would hold
+			// back redials for minutes
+			if s, ok := net.host.Network().(*swarm.Swarm); ok {
+				s.Backoff().Clear(info.ID)
+			}
+			ctx, cancel := context.WithTimeout(net.context, 10*time.Second)
+			err := net.host.Connect(ctx, info)
+			cancel()
+			if err != nil {
+				log.Warnf("Unable to connect to direct peer %s, retry in %s: %v", info.ID, backoff, err)
+				wait = backoff
+				if backoff *= 2; backoff > directBackoffMax {
+					backoff = directBackoffMax
+				}
+			} else {
+				log.Infof("Connected to direct peer: %s", info.ID)
+				backoff = time.Second
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-net.context.Done():
+			return
+		}
+	}
+}
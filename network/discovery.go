@@ -0,0 +1,113 @@
+package network
+
+import (
+	"time"
+
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	discovery "github.com/libp2p/go-libp2p-discovery"
+)
+
+// discoverLoop query rendezvous again every discovery interval so peers that
+// join late or come back are found
+func (net *Network) discoverLoop(routingDiscovery *discovery.RoutingDiscovery) {
+	ticker := time.NewTicker(net.DiscoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Advertise re-advertises in background, this covers records
+			// that were lost while DHT had no peers
+			if _, err := routingDiscovery.Advertise(net.context, net.Identifier.Rendezvous()); err != nil {
+				log.Debugf("Unable to advertise: %v", err)
+			}
+			net.findPeers(routingDiscovery)
+		case <-net.context.Done():
+			return
+		}
+	}
+}
+
+// findPeers connect to announced peers that are not connected yet
+func (net *Network) findPeers(routingDiscovery *discovery.RoutingDiscovery) {
+	log.Debug("Searching for other peers...")
+	peerChan, err := routingDiscovery.FindPeers(net.context, net.Identifier.Rendezvous())
+	if err != nil {
+		log.Warnf("Unable to find peers: %v", err)
+		return
+	}
+	for curPeer := range peerChan {
+		net.connectFound(curPeer)
+	}
+}
+
+// connectFound connect to a discovered peer unless it's node itself, not a
+// member or already connected
+func (net *Network) connectFound(curPeer peer.AddrInfo) {
+	if curPeer.ID == net.host.ID() || len(curPeer.Addrs) == 0 || !net.isMember(curPeer.ID) {
+		return
+	}
+	if net.host.Network().Connectedness(curPeer.ID) == p2pNetwork.Connected {
+		return
+	}
+	log.Debugf("Connecting to: %s", curPeer.ID.Pretty())
+	if err := net.host.Connect(net.context, curPeer); err != nil {
+		log.Warnf("Connection failed: %v", err)
+		return
+	}
+	log.Infof("Connected to: %s", curPeer.ID.Pretty())
+}
+
+// SetMembers limit peers that discovery connects to, nil lifts the limit
+func (net *Network) SetMembers(members []peer.ID) {
+	net.SetGroupMembers("", members)
+}
+
+// SetGroupMembers set members of a named group when node takes part in
+// several groups, discovery connects to members of any group. Nil removes
+// the group
+func (net *Network) SetGroupMembers(name string, members []peer.ID) {
+	net.membersMutex.Lock()
+	defer net.membersMutex.Unlock()
+	if members == nil {
+		delete(net.members, name)
+		return
+	}
+	if net.members == nil {
+		net.members = make(map[string]map[peer.ID]bool)
+	}
+	group := make(map[peer.ID]bool, len(members))
+	for _, member := range members {
+		group[member] = true
+	}
+	net.members[name] = group
+	if net.Gater != nil {
+		net.Gater.Allow(members...)
+	}
+}
+
+func (net *Network) isMember(id peer.ID) bool {
+	net.membersMutex.RLock()
+	defer net.membersMutex.RUnlock()
+	if len(net.members) == 0 {
+		return true
+	}
+	for _, group := range net.members {
+		if group[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// ConnectedMembers number of connected group members, every connected peer
+// counts if members are not set
+func (net *Network) ConnectedMembers() int {
+	count := 0
+	for _, id := range net.Peers() {
+		if net.isMember(id) {
+			count++
+		}
+	}
+	return count
+}
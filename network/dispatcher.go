@@ -0,0 +1,99 @@
+package network
+
+import (
+	"errors"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// Handler handle an envelope whose signature was verified
+type Handler func(e *Envelope)
+
+// Send sign payload into an envelope of given type and publish it to topic
+func (net *Network) Send(topicName string, msgType string, round uint64, payload []byte) error {
+	e := &Envelope{Type: msgType, Round: round, Sender: net.NodeID, Payload: payload, Version: ProtocolVersion}
+	if err := e.checkLimits(); err != nil {
+		return err
+	}
+	signature, err := net.nodeKey.Sign(e.signingBytes())
+	if err != nil {
+		return err
+	}
+	e.Signature = signature
+	return net.Publish(topicName, e.Marshal())
+}
+
+// Handle route envelopes of given type received on topic to handler, topic is
+// subscribed when its first handler is registered
+func (net *Network) Handle(topicName string, msgType string, handler Handler) error {
+	net.handlerMutex.Lock()
+	defer net.handlerMutex.Unlock()
+	handlers, ok := net.handlers[topicName]
+	if !ok {
+		if err := net.topics.AddValidator(topicName, ValidatorEnvelope, ValidateEnvelope); err != nil {
+			return err
+		}
+		sub, err := net.Subscribe(topicName, func(msg *pubsub.Message) {
+			net.dispatch(topicName, msg)
+		})
+		if err != nil {
+			net.topics.RemoveValidator(topicName, ValidatorEnvelope)
+			return err
+		}
+		handlers = make(map[string]Handler)
+		net.handlers[topicName] = handlers
+		net.subscriptions[topicName] = sub
+	}
+	if _, ok := handlers[msgType]; ok {
+		return errors.New("handler of message type was already registered")
+	}
+	handlers[msgType] = handler
+	return nil
+}
+
+// RemoveHandler stop routing envelopes of given type received on topic, topic
+// is unsubscribed when its last handler is removed
+func (net *Network) RemoveHandler(topicName string, msgType string) {
+	net.handlerMutex.Lock()
+	handlers, ok := net.handlers[topicName]
+	if !ok {
+		net.handlerMutex.Unlock()
+		return
+	}
+	delete(handlers, msgType)
+	if len(handlers) > 0 {
+		net.handlerMutex.Unlock()
+		return
+	}
+	sub := net.subscriptions[topicName]
+	delete(net.handlers, topicName)
+	delete(net.subscriptions, topicName)
+	net.handlerMutex.Unlock()
+	// Cancel waits for dispatch to return, it must not hold handler mutex
+	sub.Cancel()
+	net.topics.RemoveValidator(topicName, ValidatorEnvelope)
+}
+
+// dispatch pass an envelope accepted by ValidateEnvelope to its handler
+func (net *Network) dispatch(topicName string, msg *pubsub.Message) {
+	e := EnvelopeOf(msg)
+	if e == nil {
+		log.Debugf("Drop message on %s from %s without a verified envelope", topicName, msg.GetFrom())
+		return
+	}
+	net.handlerMutex.Lock()
+	handler, ok := net.handlers[topicName][e.Type]
+	replays := net.replays[topicName]
+	net.handlerMutex.Unlock()
+	if !ok {
+		return
+	}
+	// Envelopes without a handler are not recorded, so a repeat reaches a
+	// handler registered later
+	if replays != nil && replays.Seen(topicName, e) {
+		log.Debugf("Drop replayed %s message of round %d from %s on %s", e.Type, e.Round, e.Sender, topicName)
+		replaysDropped.WithLabelValues(topicName).Inc()
+		return
+	}
+	handler(e)
+}
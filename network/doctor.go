@@ -0,0 +1,290 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	discovery "github.com/libp2p/go-libp2p-discovery"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	swarm "github.com/libp2p/go-libp2p-swarm"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// CheckStatus outcome of a diagnostic check
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// Check result of one diagnostic, advice tells operator what to do about a
+// warning or failure
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail"`
+	Advice string      `json:"advice,omitempty"`
+}
+
+// meshWait how long topics are subscribed before their peers are counted,
+// a few gossipsub heartbeats
+const meshWait = 3 * time.Second
+
+// Diagnose check listen addresses, NAT reachability, bootstrap and direct
+// peers, DHT and peers of each topic. Network must be announced, reachability
+// is awaited until context is done
+func (net *Network) Diagnose(ctx context.Context, topicNames []string) []Check {
+	checks := []Check{net.checkListen()}
+	for _, peerAddr := range net.BootstrapPeers {
+		checks = append(checks, net.checkPeer(ctx, "bootstrap", peerAddr))
+	}
+	for _, peerAddr := range net.DirectPeers {
+		checks = append(checks, net.checkPeer(ctx, "direct", peerAddr))
+	}
+	checks = append(checks, net.checkDHT(ctx))
+	checks = append(checks, net.checkTopics(ctx, topicNames)...)
+	return append(checks, net.checkReachability(ctx))
+}
+
+// checkListen classify addresses that host listens on
+func (net *Network) checkListen() Check {
+	check := Check{Name: "listen"}
+	addrs := net.host.Addrs()
+	if len(addrs) == 0 {
+		check.Status = CheckFail
+		check.Detail = "host listens on no address"
+		check.Advice = "check bind-host, bind-port and network-listen, another process may hold the port"
+		return check
+	}
+	public, private := 0, 0
+	list := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		list = append(list, addr.String())
+		switch {
+		case manet.IsPublicAddr(addr):
+			public++
+		case !manet.IsIPLoopback(addr):
+			private++
+		}
+	}
+	check.Detail = strings.Join(list, " ")
+	switch {
+	case public > 0:
+		check.Status = CheckOK
+	case private > 0:
+		check.Status = CheckWarn
+		check.Advice = fmt.Sprintf("only private addresses, peers outside local network reach node only if TCP port %d is forwarded to it", net.listenPort())
+	default:
+		check.Status = CheckFail
+		check.Advice = "only loopback addresses, set bind-host to 0.0.0.0 or an interface address so other machines can connect"
+	}
+	return check
+}
+
+// checkPeer connect to a configured peer and ping it
+func (net *Network) checkPeer(ctx context.Context, kind string, peerAddr multiaddr.Multiaddr) Check {
+	check := Check{Name: kind + " " + peerAddr.String()}
+	info, err := peer.AddrInfoFromP2pAddr(peerAddr)
+	if err != nil {
+		check.Status = CheckFail
+		check.Detail = err.Error()
+		check.Advice = "address must end with /p2p/<peer ID>"
+		return check
+	}
+	// A dial that failed while announcing must not hold this one back
+	if s, ok := net.host.Network().(*swarm.Swarm); ok {
+		s.Backoff().Clear(info.ID)
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := net.host.Connect(connectCtx, *info); err != nil {
+		check.Status = CheckFail
+		check.Detail = strings.Join(strings.Fields(err.Error()), " ")
+		check.Advice = "check that peer runs and its port is open, and that both nodes share pre-shared key and allow each other"
+		return check
+	}
+	result := <-ping.Ping(connectCtx, net.host, info.ID)
+	if result.Error != nil {
+		check.Status = CheckWarn
+		check.Detail = "connected, ping failed: " + strings.Join(strings.Fields(result.Error.Error()), " ")
+		check.Advice = "peer may have disabled ping, connection works otherwise"
+		return check
+	}
+	check.Status = CheckOK
+	check.Detail = fmt.Sprintf("connected, round trip %s", result.RTT.Round(time.Millisecond))
+	return check
+}
+
+// checkDHT count peers of routing table and peers that announced rendezvous
+// of network
+func (net *Network) checkDHT(ctx context.Context) Check {
+	check := Check{Name: "dht"}
+	if net.DHTMode == DHTOff {
+		check.Status = CheckOK
+		check.Detail = "DHT is off, peers come from bootstrap and direct peers"
+		if net.MDNS {
+			check.Detail = "DHT is off, peers come from bootstrap, direct peers and mDNS"
+		}
+		if len(net.BootstrapPeers) == 0 && len(net.DirectPeers) == 0 && !net.MDNS {
+			check.Status = CheckFail
+			check.Advice = "without DHT node finds no peers, configure direct peers or enable mDNS"
+		}
+		return check
+	}
+	if net.dht == nil {
+		check.Status = CheckFail
+		check.Detail = "network is not announced"
+		return check
+	}
+	tableSize := net.dht.RoutingTable().Size()
+	findCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	announced := 0
+	if peerChan, err := discovery.NewRoutingDiscovery(net.dht).FindPeers(findCtx, net.Identifier.Rendezvous()); err == nil {
+		for found := range peerChan {
+			if found.ID != net.host.ID() {
+				announced++
+			}
+		}
+	}
+	check.Detail = fmt.Sprintf("%d peers in routing table, %d peers announced network %s", tableSize, announced, net.Identifier.Domain())
+	switch {
+	case tableSize == 0 && len(net.Peers()) == 0:
+		check.Status = CheckFail
+		check.Advice = "node has no peers, configure bootstrap or direct peers that are reachable"
+	case tableSize == 0:
+		check.Status = CheckWarn
+		check.Advice = "no connected peer serves DHT, peers serve it once they are reachable from outside. Rendezvous discovery needs at least one such peer"
+	case announced == 0:
+		check.Status = CheckWarn
+		check.Advice = "no other node announced network, check that peers use the same network name and chain hash"
+	default:
+		check.Status = CheckOK
+	}
+	return check
+}
+
+// checkTopics subscribe topics for a few heartbeats and compare their peers
+// with group members
+func (net *Network) checkTopics(ctx context.Context, topicNames []string) []Check {
+	subs := make([]*Subscription, 0, len(topicNames))
+	for _, name := range topicNames {
+		if sub, err := net.Subscribe(name, func(*pubsub.Message) {}); err == nil {
+			subs = append(subs, sub)
+		}
+	}
+	defer func() {
+		for _, sub := range subs {
+			sub.Cancel()
+		}
+	}()
+	select {
+	case <-time.After(meshWait):
+	case <-ctx.Done():
+	}
+
+	members := net.memberList()
+	checks := make([]Check, 0, len(topicNames))
+	for _, name := range topicNames {
+		check := Check{Name: "topic " + name}
+		peers := net.pubsub.ListPeers(net.topics.TopicName(name))
+		subscribed := make(map[peer.ID]bool, len(peers))
+		for _, id := range peers {
+			subscribed[id] = true
+		}
+		var missing []string
+		for _, id := range members {
+			if id != net.host.ID() && !subscribed[id] {
+				missing = append(missing, id.String())
+			}
+		}
+		check.Detail = fmt.Sprintf("%d peers", len(peers))
+		switch {
+		case len(peers) == 0:
+			check.Status = CheckFail
+			check.Advice = "no peer subscribed the topic, messages are not delivered until peers connect"
+		case len(missing) > 0:
+			check.Status = CheckWarn
+			check.Detail += ", members not seen: " + strings.Join(missing, " ")
+			check.Advice = "members not seen are down or not connected to this node, check them with their operators"
+		default:
+			check.Status = CheckOK
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// checkReachability wait for AutoNAT to tell whether node is reachable from
+// outside, peers dial it back to find out
+func (net *Network) checkReachability(ctx context.Context) Check {
+	check := Check{Name: "nat"}
+	reachability := p2pNetwork.ReachabilityUnknown
+	sub, err := net.host.EventBus().Subscribe(new(event.EvtLocalReachabilityChanged))
+	if err == nil {
+		defer sub.Close()
+	wait:
+		for reachability == p2pNetwork.ReachabilityUnknown {
+			select {
+			case e := <-sub.Out():
+				reachability = e.(event.EvtLocalReachabilityChanged).Reachability
+			case <-ctx.Done():
+				break wait
+			}
+		}
+	}
+	check.Detail = "reachability " + strings.ToLower(reachability.String())
+	switch reachability {
+	case p2pNetwork.ReachabilityPublic:
+		check.Status = CheckOK
+	case p2pNetwork.ReachabilityPrivate:
+		check.Status = CheckWarn
+		check.Advice = fmt.Sprintf("peers could not dial node back, it is behind NAT or firewall. Forward TCP port %d or rely on peers that are reachable", net.listenPort())
+	default:
+		check.Status = CheckWarn
+		check.Advice = "not enough peers answered AutoNAT yet, run doctor with a longer timeout once node has several peers"
+	}
+	return check
+}
+
+// listenPort TCP port that host listens on, configured port may be 0. It's
+// 0 if host listens on no TCP address
+func (net *Network) listenPort() uint {
+	for _, addr := range net.host.Network().ListenAddresses() {
+		if port, err := addr.ValueForProtocol(multiaddr.P_TCP); err == nil {
+			if n, err := strconv.ParseUint(port, 10, 16); err == nil {
+				return uint(n)
+			}
+		}
+	}
+	return 0
+}
+
+// memberList members of all groups, sorted
+func (net *Network) memberList() []peer.ID {
+	net.membersMutex.RLock()
+	defer net.membersMutex.RUnlock()
+	seen := make(map[peer.ID]bool)
+	for _, group := range net.members {
+		for id := range group {
+			seen[id] = true
+		}
+	}
+	list := make([]peer.ID, 0, len(seen))
+	for id := range seen {
+		list = append(list, id)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
@@ -0,0 +1,160 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"math"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Envelope typed message signed by its sender, it's encoded in protobuf wire
+// format as:
+//
+//	message Envelope {
+//	  string type = 1;
+//	  uint64 round = 2;
+//	  bytes sender = 3;
+//	  bytes payload = 4;
+//	  bytes signature = 5;
+//	  uint32 version = 6;
+//	}
+//
+// signature covers encoding of fields 1 to 4. Version is left out of it so
+// nodes from before versions verify envelopes of newer nodes, pubsub signs
+// whole message anyway. Envelopes of those nodes have version 0.
+type Envelope struct {
+	Type      string
+	Round     uint64
+	Sender    peer.ID
+	Payload   []byte
+	Signature []byte
+	// Version protocol version of sender
+	Version uint32
+}
+
+// Field numbers of envelope
+const (
+	envelopeType      protowire.Number = 1
+	envelopeRound     protowire.Number = 2
+	envelopeSender    protowire.Number = 3
+	envelopePayload   protowire.Number = 4
+	envelopeSignature protowire.Number = 5
+	envelopeVersion   protowire.Number = 6
+)
+
+var errInvalidEnvelope = errors.New("invalid envelope")
+
+// signingBytes encoding of fields covered by signature
+func (e *Envelope) signingBytes() []byte {
+	var b []byte
+	b = protowire.AppendTag(b, envelopeType, protowire.BytesType)
+	b = protowire.AppendString(b, e.Type)
+	b = protowire.AppendTag(b, envelopeRound, protowire.VarintType)
+	b = protowire.AppendVarint(b, e.Round)
+	b = protowire.AppendTag(b, envelopeSender, protowire.BytesType)
+	b = protowire.AppendBytes(b, []byte(e.Sender))
+	b = protowire.AppendTag(b, envelopePayload, protowire.BytesType)
+	b = protowire.AppendBytes(b, e.Payload)
+	return b
+}
+
+// Marshal encode envelope
+func (e *Envelope) Marshal() []byte {
+	b := e.signingBytes()
+	b = protowire.AppendTag(b, envelopeSignature, protowire.BytesType)
+	b = protowire.AppendBytes(b, e.Signature)
+	if e.Version != 0 {
+		b = protowire.AppendTag(b, envelopeVersion, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.Version))
+	}
+	return b
+}
+
+// UnmarshalEnvelope decode an envelope in canonical encoding, which is the
+// encoding of Marshal optionally followed by fields of newer versions in
+// increasing order. Oversized envelopes and fields are rejected
+func UnmarshalEnvelope(b []byte) (*Envelope, error) {
+	if len(b) > MaxEnvelopeSize {
+		return nil, errOversized
+	}
+	e := new(Envelope)
+	data := b
+	var last protowire.Number
+	for len(b) > 0 {
+		number, wireType, n := protowire.ConsumeTag(b)
+		if n < 0 || number <= last {
+			return nil, errInvalidEnvelope
+		}
+		last = number
+		b = b[n:]
+		if number == envelopeRound || number == envelopeVersion {
+			if wireType != protowire.VarintType {
+				return nil, errInvalidEnvelope
+			}
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 || number == envelopeVersion && v > math.MaxUint32 {
+				return nil, errInvalidEnvelope
+			}
+			if number == envelopeRound {
+				e.Round = v
+			} else {
+				e.Version = uint32(v)
+			}
+			b = b[n:]
+			continue
+		}
+		if number > envelopeVersion {
+			n := protowire.ConsumeFieldValue(number, wireType, b)
+			if n < 0 {
+				return nil, errInvalidEnvelope
+			}
+			b = b[n:]
+			continue
+		}
+		if wireType != protowire.BytesType {
+			return nil, errInvalidEnvelope
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, errInvalidEnvelope
+		}
+		b = b[n:]
+		switch number {
+		case envelopeType:
+			e.Type = string(v)
+		case envelopeSender:
+			e.Sender = peer.ID(v)
+		case envelopePayload:
+			e.Payload = append([]byte(nil), v...)
+		case envelopeSignature:
+			e.Signature = append([]byte(nil), v...)
+		}
+	}
+	if err := e.checkLimits(); err != nil {
+		return nil, err
+	}
+	// Missing fields, padded varints and an explicit version 0 encode
+	// differently than Marshal
+	if !bytes.HasPrefix(data, e.Marshal()) {
+		return nil, errInvalidEnvelope
+	}
+	return e, nil
+}
+
+// Verify signature of envelope against public key of its sender
+func (e *Envelope) Verify() error {
+	pubKey, err := e.Sender.ExtractPublicKey()
+	if err != nil {
+		return err
+	}
+	ok, err := pubKey.Verify(e.signingBytes(), e.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid envelope signature")
+	}
+	return nil
+}
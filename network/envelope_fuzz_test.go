@@ -0,0 +1,99 @@
+//go:build go1.18
+// +build go1.18
+
+package network
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// fuzzSmallType message type with a payload limit small enough for the fuzzer
+// to cross
+const fuzzSmallType = "fuzz-small"
+
+// fuzzPayload shape of JSON payloads nodes exchange
+type fuzzPayload struct {
+	Session   string `json:"session"`
+	Round     uint64 `json:"round"`
+	Signature []byte `json:"signature"`
+}
+
+// seedEnvelopes add valid signed envelopes to corpus of f
+func seedEnvelopes(f *testing.F) {
+	SetPayloadLimit(fuzzSmallType, 16)
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		f.Fatal(err)
+	}
+	sender, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		f.Fatal(err)
+	}
+	envelopes := []*Envelope{
+		{Type: "partial", Round: 1, Payload: []byte(`{"session":"s","round":1,"signature":"AAEC"}`)},
+		{Type: "hello", Payload: []byte(`{}`), Version: 1},
+		{Type: fuzzSmallType, Round: 1 << 40, Payload: []byte(`{"round":7}`), Version: 3},
+	}
+	for _, e := range envelopes {
+		e.Sender = sender
+		if e.Signature, err = priv.Sign(e.signingBytes()); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(e.Marshal())
+	}
+}
+
+func FuzzUnmarshalEnvelope(f *testing.F) {
+	seedEnvelopes(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e, err := UnmarshalEnvelope(data)
+		if err != nil {
+			return
+		}
+		// Canonical encoding, nothing but fields of newer versions may
+		// follow the encoding of Marshal
+		encoded := e.Marshal()
+		if !bytes.HasPrefix(data, encoded) {
+			t.Fatalf("accepted %x, it does not start with its encoding %x", data, encoded)
+		}
+		decoded, err := UnmarshalEnvelope(encoded)
+		if err != nil {
+			t.Fatalf("encoding of accepted envelope is rejected: %v", err)
+		}
+		if !bytes.Equal(decoded.Marshal(), encoded) {
+			t.Fatalf("encoding does not round-trip: %x != %x", decoded.Marshal(), encoded)
+		}
+	})
+}
+
+func FuzzDecodePayload(f *testing.F) {
+	seedEnvelopes(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e, err := UnmarshalEnvelope(data)
+		if err != nil {
+			return
+		}
+		if len(data) > MaxEnvelopeSize {
+			t.Fatalf("accepted envelope of %d bytes", len(data))
+		}
+		if max := PayloadLimit(e.Type); len(e.Payload) > max {
+			t.Fatalf("accepted %s payload of %d bytes, limit is %d", e.Type, len(e.Payload), max)
+		}
+		if len(e.Type) > maxTypeLength || len(e.Sender) > maxSenderLength || len(e.Signature) > maxSignatureLength {
+			t.Fatalf("accepted oversized fields: type %d, sender %d, signature %d bytes", len(e.Type), len(e.Sender), len(e.Signature))
+		}
+		payload := new(fuzzPayload)
+		if DecodePayload(e.Payload, payload) != nil {
+			return
+		}
+		// A decoded payload has one accepted encoding
+		if DecodePayload(append(append([]byte(nil), e.Payload...), `{}`...), new(fuzzPayload)) == nil {
+			t.Fatalf("accepted data after payload %q", e.Payload)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/control"
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// AllowlistGater connection gater accepting only listed peers, or peers
+// connecting from listed CIDR ranges. Peers are checked on both inbound and
+// outbound connections, so DHT traffic of unknown peers is dropped before it
+// reaches the node
+type AllowlistGater struct {
+	peers  map[peer.ID]bool
+	ranges []*net.IPNet
+	mutex  sync.RWMutex
+}
+
+// NewAllowlistGater create a gater of peer IDs and CIDR ranges, e.g.
+// 12D3KooW... or 10.0.0.0/8
+func NewAllowlistGater(entries []string) (*AllowlistGater, error) {
+	g := &AllowlistGater{peers: make(map[peer.ID]bool)}
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowlist entry %s: %v", entry, err)
+			}
+			g.ranges = append(g.ranges, ipNet)
+			continue
+		}
+		id, err := peer.Decode(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist entry %s: %v", entry, err)
+		}
+		g.peers[id] = true
+	}
+	return g, nil
+}
+
+// Option libp2p option installing gater on host
+func (g *AllowlistGater) Option() libp2p.Option {
+	return libp2p.ConnectionGater(g)
+}
+
+// Allow add peers to allowlist, e.g. members of group
+func (g *AllowlistGater) Allow(ids ...peer.ID) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for _, id := range ids {
+		g.peers[id] = true
+	}
+}
+
+func (g *AllowlistGater) isAllowedPeer(id peer.ID) bool {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.peers[id]
+}
+
+func (g *AllowlistGater) isAllowedAddr(addr multiaddr.Multiaddr) bool {
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return false
+	}
+	for _, ipNet := range g.ranges {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// InterceptPeerDial address is not known yet, peers outside of list may still
+// be dialed at an allowed address
+func (g *AllowlistGater) InterceptPeerDial(id peer.ID) bool {
+	return g.isAllowedPeer(id) || len(g.ranges) > 0
+}
+
+// InterceptAddrDial allow dialing listed peers at any address, others only in listed ranges
+func (g *AllowlistGater) InterceptAddrDial(id peer.ID, addr multiaddr.Multiaddr) bool {
+	return g.isAllowedPeer(id) || g.isAllowedAddr(addr)
+}
+
+// InterceptAccept peer is not known before handshake, connections outside of
+// ranges are accepted only if some peers are listed by ID
+func (g *AllowlistGater) InterceptAccept(addrs p2pNetwork.ConnMultiaddrs) bool {
+	if g.isAllowedAddr(addrs.RemoteMultiaddr()) {
+		return true
+	}
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return len(g.peers) > 0
+}
+
+// InterceptSecured allow connections of listed peers or from listed ranges
+func (g *AllowlistGater) InterceptSecured(direction p2pNetwork.Direction, id peer.ID, addrs p2pNetwork.ConnMultiaddrs) bool {
+	if g.isAllowedPeer(id) || g.isAllowedAddr(addrs.RemoteMultiaddr()) {
+		return true
+	}
+	log.Debugf("Reject connection of %s from %s, it's not in allowlist", id, addrs.RemoteMultiaddr())
+	return false
+}
+
+// InterceptUpgraded connection was already checked when it was secured
+func (g *AllowlistGater) InterceptUpgraded(conn p2pNetwork.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,167 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// ProtocolVersion version of envelopes and protocols of this node, it's raised
+// whenever they change in a way older nodes do not understand
+const ProtocolVersion uint32 = 2
+
+// MinProtocolVersion oldest version this node works with. Version 2 widened
+// share index of partial signatures to 4 bytes, older nodes send partials
+// this node can not read
+const MinProtocolVersion uint32 = 2
+
+// handshakeProtocol stream protocol exchanging versions on first contact
+const handshakeProtocol = "/orochi/handshake/1.0.0"
+
+// handshakeTimeout how long a handshake may take
+const handshakeTimeout = 10 * time.Second
+
+// maxHelloSize largest hello that is read from a peer
+const maxHelloSize = 4 << 10
+
+// Hello versions and capabilities a node announces in handshake
+type Hello struct {
+	Version      uint32   `json:"version"`
+	MinVersion   uint32   `json:"minVersion"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// PeerVersion hello of a peer and whether this node works with it
+type PeerVersion struct {
+	Hello
+	Compatible bool   `json:"compatible"`
+	Error      string `json:"error,omitempty"`
+}
+
+// check whether a node saying hello works with a peer saying other
+func (hello Hello) check(other Hello) error {
+	if other.Version < hello.MinVersion {
+		return fmt.Errorf("peer speaks protocol version %d, this node needs at least version %d, upgrade the peer", other.Version, hello.MinVersion)
+	}
+	if hello.Version < other.MinVersion {
+		return fmt.Errorf("peer needs protocol version %d or newer, this node speaks version %d, upgrade this node", other.MinVersion, hello.Version)
+	}
+	return nil
+}
+
+// hello of this node
+func (net *Network) hello() Hello {
+	capabilities := net.Capabilities
+	if capabilities == nil {
+		capabilities = make([]string, 0)
+	}
+	return Hello{Version: ProtocolVersion, MinVersion: MinProtocolVersion, Capabilities: capabilities}
+}
+
+// serveHandshake exchange hellos with every peer that connects, the dialing
+// side opens the stream. Peers of an incompatible version are disconnected
+func (net *Network) serveHandshake() {
+	net.host.SetStreamHandler(handshakeProtocol, net.handleHandshake)
+	net.host.Network().Notify(&p2pNetwork.NotifyBundle{
+		ConnectedF: func(_ p2pNetwork.Network, conn p2pNetwork.Conn) {
+			if conn.Stat().Direction == p2pNetwork.DirOutbound && net.PeerVersion(conn.RemotePeer()) == nil {
+				go net.handshake(conn.RemotePeer())
+			}
+		},
+		DisconnectedF: func(n p2pNetwork.Network, conn p2pNetwork.Conn) {
+			// A peer may come back upgraded
+			if n.Connectedness(conn.RemotePeer()) != p2pNetwork.Connected {
+				net.versionsMutex.Lock()
+				delete(net.versions, conn.RemotePeer())
+				net.versionsMutex.Unlock()
+			}
+		},
+		ListenF:      func(p2pNetwork.Network, multiaddr.Multiaddr) {},
+		ListenCloseF: func(p2pNetwork.Network, multiaddr.Multiaddr) {},
+	})
+}
+
+// handshake send hello of this node to a peer and read its hello
+func (net *Network) handshake(id peer.ID) {
+	ctx, cancel := context.WithTimeout(net.context, handshakeTimeout)
+	defer cancel()
+	s, err := net.host.NewStream(ctx, id, handshakeProtocol)
+	if err != nil {
+		// Identify has finished once NewStream returns, so protocols of
+		// peer are known
+		if protocols, perr := net.host.Peerstore().SupportsProtocols(id, handshakeProtocol); perr == nil && len(protocols) == 0 {
+			net.recordVersion(id, Hello{})
+			return
+		}
+		log.Debugf("Unable to handshake with %s: %v", id, err)
+		return
+	}
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := json.NewEncoder(s).Encode(net.hello()); err != nil {
+		s.Reset()
+		log.Debugf("Unable to send hello to %s: %v", id, err)
+		return
+	}
+	hello := new(Hello)
+	if err := json.NewDecoder(io.LimitReader(s, maxHelloSize)).Decode(hello); err != nil {
+		s.Reset()
+		log.Debugf("Unable to read hello of %s: %v", id, err)
+		return
+	}
+	net.recordVersion(id, *hello)
+}
+
+// handleHandshake answer hello of a peer that dialed this node
+func (net *Network) handleHandshake(s p2pNetwork.Stream) {
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(handshakeTimeout))
+	hello := new(Hello)
+	if err := json.NewDecoder(io.LimitReader(s, maxHelloSize)).Decode(hello); err != nil {
+		s.Reset()
+		return
+	}
+	if err := json.NewEncoder(s).Encode(net.hello()); err != nil {
+		s.Reset()
+		return
+	}
+	net.recordVersion(s.Conn().RemotePeer(), *hello)
+}
+
+// recordVersion keep hello of a peer and disconnect it if it's incompatible
+func (net *Network) recordVersion(id peer.ID, hello Hello) {
+	version := &PeerVersion{Hello: hello, Compatible: true}
+	if version.Capabilities == nil {
+		version.Capabilities = make([]string, 0)
+	}
+	if err := net.hello().check(hello); err != nil {
+		version.Compatible = false
+		version.Error = err.Error()
+	}
+	net.versionsMutex.Lock()
+	if net.versions == nil {
+		net.versions = make(map[peer.ID]*PeerVersion)
+	}
+	net.versions[id] = version
+	net.versionsMutex.Unlock()
+	if !version.Compatible {
+		log.Errorf("Disconnect %s: %s", id, version.Error)
+		net.host.Network().ClosePeer(id)
+		return
+	}
+	log.Debugf("Peer %s speaks protocol version %d", id, hello.Version)
+}
+
+// PeerVersion hello that a connected peer sent in handshake, nil if there was
+// no handshake yet
+func (net *Network) PeerVersion(id peer.ID) *PeerVersion {
+	net.versionsMutex.Lock()
+	defer net.versionsMutex.Unlock()
+	return net.versions[id]
+}
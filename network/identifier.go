@@ -0,0 +1,58 @@
+package network
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+)
+
+// Well-known network names
+const (
+	Mainnet = "mainnet"
+	Testnet = "testnet"
+	Devnet  = "devnet"
+)
+
+// networkName lowercase letters, digits and dashes so it's safe in topic names
+var networkName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Identifier network that node belongs to, topic names and rendezvous string
+// are derived from it so nodes of different networks never exchange messages
+type Identifier struct {
+	// Name mainnet, testnet, devnet or name of a private network
+	Name string
+	// ChainHash hash of chain info of beacon, it tells apart chains that
+	// share a network name. Optional
+	ChainHash []byte
+}
+
+// ParseIdentifier identifier of network of given name and hex chain hash,
+// chain hash may be empty
+func ParseIdentifier(name string, chainHash string) (Identifier, error) {
+	if !networkName.MatchString(name) {
+		return Identifier{}, fmt.Errorf("invalid network name %q, use lowercase letters, digits and dashes", name)
+	}
+	id := Identifier{Name: name}
+	if chainHash != "" {
+		hash, err := hex.DecodeString(chainHash)
+		if err != nil || len(hash) != 32 {
+			return Identifier{}, fmt.Errorf("invalid chain hash %q, it must be 32 bytes in hex", chainHash)
+		}
+		id.ChainHash = hash
+	}
+	return id, nil
+}
+
+// Domain namespace of topic names, name of network followed by chain hash
+// if there is one
+func (id Identifier) Domain() string {
+	if len(id.ChainHash) == 0 {
+		return id.Name
+	}
+	return id.Name + "/" + hex.EncodeToString(id.ChainHash)
+}
+
+// Rendezvous string that nodes of network announce themselves under in DHT
+func (id Identifier) Rendezvous() string {
+	return "orochi/" + id.Domain()
+}
@@ -0,0 +1,168 @@
+package network
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// latencySamples number of recent round trips kept per member for percentiles
+const latencySamples = 100
+
+var (
+	memberRTT = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "member_rtt_seconds",
+		Help:      "Round trip time of pings to group members",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"peer"})
+	memberPingFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "member_ping_failures_total",
+		Help:      "Pings to connected group members that failed or timed out",
+	}, []string{"peer"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(memberRTT, memberPingFailures)
+}
+
+// LatencyStats round trips measured by pinging a member, percentiles are of
+// the latest samples
+type LatencyStats struct {
+	Last     time.Duration `json:"last"`
+	Min      time.Duration `json:"min"`
+	Max      time.Duration `json:"max"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	Samples  uint64        `json:"samples"`
+	Failures uint64        `json:"failures"`
+	Measured time.Time     `json:"measured"`
+}
+
+// latencyRecord recent round trips of a member, samples is a ring
+type latencyRecord struct {
+	stats   LatencyStats
+	samples []time.Duration
+	next    int
+}
+
+func (r *latencyRecord) add(rtt time.Duration, now time.Time) {
+	if r.stats.Samples == 0 || rtt < r.stats.Min {
+		r.stats.Min = rtt
+	}
+	if rtt > r.stats.Max {
+		r.stats.Max = rtt
+	}
+	r.stats.Last = rtt
+	r.stats.Samples++
+	r.stats.Measured = now
+	if len(r.samples) < latencySamples {
+		r.samples = append(r.samples, rtt)
+	} else {
+		r.samples[r.next] = rtt
+		r.next = (r.next + 1) % latencySamples
+	}
+}
+
+// snapshot copy of stats with percentiles of samples
+func (r *latencyRecord) snapshot() *LatencyStats {
+	stats := r.stats
+	if len(r.samples) > 0 {
+		sorted := append([]time.Duration(nil), r.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats.P50 = percentile(sorted, 0.50)
+		stats.P95 = percentile(sorted, 0.95)
+	}
+	return &stats
+}
+
+// percentile of sorted samples
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	index := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// latencyTracker round trips of each member
+type latencyTracker struct {
+	records map[peer.ID]*latencyRecord
+	mutex   sync.Mutex
+}
+
+func (t *latencyTracker) record(id peer.ID) *latencyRecord {
+	if t.records == nil {
+		t.records = make(map[peer.ID]*latencyRecord)
+	}
+	if t.records[id] == nil {
+		t.records[id] = new(latencyRecord)
+	}
+	return t.records[id]
+}
+
+// MeasureLatency ping connected group members every interval in background,
+// round trips are exported to metrics and listed by PeerInfos
+func (net *Network) MeasureLatency(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			net.pingMembers(interval)
+			select {
+			case <-ticker.C:
+			case <-net.context.Done():
+				return
+			}
+		}
+	}()
+}
+
+// pingMembers ping each connected member once, in parallel
+func (net *Network) pingMembers(timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, id := range net.memberList() {
+		if id == net.host.ID() || len(net.host.Network().ConnsToPeer(id)) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(id peer.ID) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(net.context, timeout)
+			defer cancel()
+			result := <-ping.Ping(ctx, net.host, id)
+			net.latency.mutex.Lock()
+			defer net.latency.mutex.Unlock()
+			record := net.latency.record(id)
+			if result.Error != nil {
+				record.stats.Failures++
+				memberPingFailures.WithLabelValues(id.String()).Inc()
+				log.Debugf("Unable to ping member %s: %v", id, result.Error)
+				return
+			}
+			record.add(result.RTT, time.Now())
+			memberRTT.WithLabelValues(id.String()).Observe(result.RTT.Seconds())
+		}(id)
+	}
+	wg.Wait()
+}
+
+// Latency round trips measured to a member, nil if it was never pinged
+func (net *Network) Latency(id peer.ID) *LatencyStats {
+	net.latency.mutex.Lock()
+	defer net.latency.mutex.Unlock()
+	if record := net.latency.records[id]; record != nil {
+		return record.snapshot()
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package network
+
+import (
+	"encoding/hex"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+)
+
+// MDNSService mDNS service that nodes of network announce themselves under on
+// the local network, a short chain hash keeps beacons of one network apart
+func (id Identifier) MDNSService() string {
+	if len(id.ChainHash) == 0 {
+		return "_orochi-" + id.Name + "._udp"
+	}
+	return "_orochi-" + id.Name + "-" + hex.EncodeToString(id.ChainHash[:4]) + "._udp"
+}
+
+// mdnsNotifee connect to peers found on the local network
+type mdnsNotifee struct {
+	net *Network
+}
+
+func (n *mdnsNotifee) HandlePeerFound(info peer.AddrInfo) {
+	go n.net.connectFound(info)
+}
+
+// startMDNS announce node and find peers on the local network over mDNS,
+// service is closed with host
+func (net *Network) startMDNS() {
+	service := mdns.NewMdnsService(net.host, net.Identifier.MDNSService(), &mdnsNotifee{net: net})
+	if err := service.Start(); err != nil {
+		log.Warnf("Unable to start mDNS discovery: %v", err)
+		return
+	}
+	log.Infof("Discovering peers on local network over mDNS: %s", net.Identifier.MDNSService())
+	go func() {
+		<-net.context.Done()
+		service.Close()
+	}()
+}
@@ -0,0 +1,212 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	meshPeers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "mesh_peers",
+		Help:      "Peers in gossipsub mesh of each topic, a topic whose mesh is empty does not deliver messages",
+	}, []string{"topic"})
+	meshGrafts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "mesh_grafts_total",
+		Help:      "Peers grafted onto gossipsub mesh of each topic",
+	}, []string{"topic"})
+	meshPrunes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "mesh_prunes_total",
+		Help:      "Peers pruned from gossipsub mesh of each topic",
+	}, []string{"topic"})
+	gossipIHave = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "gossip_ihave_total",
+		Help:      "Message IDs announced in IHAVE gossip, by direction and topic",
+	}, []string{"direction", "topic"})
+	gossipIWant = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "gossip_iwant_total",
+		Help:      "Message IDs requested in IWANT gossip, by direction. Many received IWANTs mean peers miss messages through the mesh",
+	}, []string{"direction"})
+	messageDelivery = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "message_delivery_seconds",
+		Help:      "Time from receiving a message until it's validated and delivered to handlers, by topic",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"topic"})
+	messagesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "network",
+		Name:      "messages_dropped_total",
+		Help:      "Messages that were not delivered, by topic and reason: duplicate, rejected or undeliverable",
+	}, []string{"topic", "reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(meshPeers, meshGrafts, meshPrunes, gossipIHave, gossipIWant, messageDelivery, messagesDropped)
+}
+
+// maxTracedMessages messages whose delivery latency is tracked at once,
+// older ones are forgotten beyond it
+const maxTracedMessages = 4096
+
+// meshTracer export gossipsub mesh and gossip events as metrics, so a topic
+// partition shows before rounds time out
+type meshTracer struct {
+	mesh  map[string]map[peer.ID]bool
+	seen  map[*pubsub.Message]time.Time
+	mutex sync.Mutex
+}
+
+func newMeshTracer() *meshTracer {
+	return &meshTracer{
+		mesh: make(map[string]map[peer.ID]bool),
+		seen: make(map[*pubsub.Message]time.Time),
+	}
+}
+
+func (t *meshTracer) AddPeer(peer.ID, protocol.ID) {}
+
+// RemovePeer peers leave meshes without a prune when they disconnect
+func (t *meshTracer) RemovePeer(p peer.ID) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for topic, peers := range t.mesh {
+		if peers[p] {
+			delete(peers, p)
+			meshPeers.WithLabelValues(topic).Set(float64(len(peers)))
+		}
+	}
+}
+
+func (t *meshTracer) Join(topic string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.mesh[topic] = make(map[peer.ID]bool)
+	meshPeers.WithLabelValues(topic).Set(0)
+}
+
+func (t *meshTracer) Leave(topic string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.mesh, topic)
+	meshPeers.DeleteLabelValues(topic)
+}
+
+func (t *meshTracer) Graft(p peer.ID, topic string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	meshGrafts.WithLabelValues(topic).Inc()
+	peers, ok := t.mesh[topic]
+	if !ok {
+		return
+	}
+	peers[p] = true
+	meshPeers.WithLabelValues(topic).Set(float64(len(peers)))
+}
+
+func (t *meshTracer) Prune(p peer.ID, topic string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	meshPrunes.WithLabelValues(topic).Inc()
+	peers, ok := t.mesh[topic]
+	if !ok {
+		return
+	}
+	delete(peers, p)
+	meshPeers.WithLabelValues(topic).Set(float64(len(peers)))
+}
+
+func (t *meshTracer) ValidateMessage(msg *pubsub.Message) {
+	now := time.Now()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.seen) >= maxTracedMessages {
+		for m, at := range t.seen {
+			if now.Sub(at) > time.Minute {
+				delete(t.seen, m)
+			}
+		}
+		if len(t.seen) >= maxTracedMessages {
+			return
+		}
+	}
+	t.seen[msg] = now
+}
+
+func (t *meshTracer) DeliverMessage(msg *pubsub.Message) {
+	t.mutex.Lock()
+	at, ok := t.seen[msg]
+	delete(t.seen, msg)
+	t.mutex.Unlock()
+	if ok {
+		messageDelivery.WithLabelValues(msg.GetTopic()).Observe(time.Since(at).Seconds())
+	}
+}
+
+func (t *meshTracer) RejectMessage(msg *pubsub.Message, reason string) {
+	t.mutex.Lock()
+	delete(t.seen, msg)
+	t.mutex.Unlock()
+	messagesDropped.WithLabelValues(msg.GetTopic(), "rejected").Inc()
+}
+
+func (t *meshTracer) DuplicateMessage(msg *pubsub.Message) {
+	messagesDropped.WithLabelValues(msg.GetTopic(), "duplicate").Inc()
+}
+
+func (t *meshTracer) ThrottlePeer(peer.ID) {}
+
+func (t *meshTracer) RecvRPC(rpc *pubsub.RPC) {
+	t.countGossip("in", rpc)
+}
+
+func (t *meshTracer) SendRPC(rpc *pubsub.RPC, _ peer.ID) {
+	t.countGossip("out", rpc)
+}
+
+func (t *meshTracer) DropRPC(*pubsub.RPC, peer.ID) {}
+
+func (t *meshTracer) UndeliverableMessage(msg *pubsub.Message) {
+	messagesDropped.WithLabelValues(msg.GetTopic(), "undeliverable").Inc()
+}
+
+// countGossip count message IDs of IHAVE and IWANT control messages of rpc
+func (t *meshTracer) countGossip(direction string, rpc *pubsub.RPC) {
+	control := rpc.GetControl()
+	if control == nil {
+		return
+	}
+	t.mutex.Lock()
+	for _, ihave := range control.GetIhave() {
+		// Peers may gossip any topic, only joined ones get a label of their own
+		topic := ihave.GetTopicID()
+		if _, ok := t.mesh[topic]; !ok {
+			topic = "other"
+		}
+		gossipIHave.WithLabelValues(direction, topic).Add(float64(len(ihave.GetMessageIDs())))
+	}
+	t.mutex.Unlock()
+	iwant := 0
+	for _, want := range control.GetIwant() {
+		iwant += len(want.GetMessageIDs())
+	}
+	if iwant > 0 {
+		gossipIWant.WithLabelValues(direction).Add(float64(iwant))
+	}
+}
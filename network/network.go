@@ -2,14 +2,15 @@ package network
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
+	connmgr "github.com/libp2p/go-libp2p-connmgr"
 	"github.com/libp2p/go-libp2p-core/host"
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
 	discovery "github.com/libp2p/go-libp2p-discovery"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -19,42 +20,104 @@ import (
 	"go.uber.org/zap"
 )
 
+// Connection manager trims connections down to connLow once there are more
+// than connHigh, connections younger than connGrace are kept
+const (
+	connLow   = 100
+	connHigh  = 400
+	connGrace = time.Minute
+)
+
 type Network struct {
-	BindHost string
-	BindPort uint
-	NodeID   peer.ID
-	Domain   string
-	context  context.Context
-	nodeKey  *keypair.KeyPair
-	host     host.Host
-	pubsub   *pubsub.PubSub
+	// ListenAddrs addresses given to New, port 0 picks a random port
+	ListenAddrs []multiaddr.Multiaddr
+	NodeID      peer.ID
+	// Identifier network that node belongs to, it namespaces topics and
+	// rendezvous
+	Identifier Identifier
+	// BootstrapPeers peers connected before announcing, none by default so
+	// private deployments stay isolated from public DHT
+	BootstrapPeers []multiaddr.Multiaddr
+	// DirectPeers peers that are protected from connection trimming and
+	// reconnected whenever their connection drops
+	DirectPeers []multiaddr.Multiaddr
+	// DHTMode auto, server, client or off, auto if it's empty
+	DHTMode string
+	// DiscoveryInterval how often rendezvous is queried again after
+	// announcing, discovery runs once if it's 0
+	DiscoveryInterval time.Duration
+	// MDNS also find peers on the local network over mDNS, it works without
+	// DHT and bootstrap peers
+	MDNS bool
+	// Capabilities features of node announced in handshake with peers
+	Capabilities []string
+	// Gater allowlist of connections given to New, group members are added
+	// to it by SetMembers and SetGroupMembers. Every peer may connect if it's nil
+	Gater *AllowlistGater
+	// Bans gater of banned peers given to New, BanPeer fails if it's nil
+	Bans *BanGater
+	// Transports security transports and muxers given to New, what each
+	// connection negotiated is listed by PeerInfos if it's set
+	Transports    *Transports
+	context       context.Context
+	nodeKey       keypair.Signer
+	host          host.Host
+	pubsub        *pubsub.PubSub
+	topics        *TopicManager
+	handlers      map[string]map[string]Handler
+	subscriptions map[string]*Subscription
+	replays       map[string]*ReplayCache
+	handlerMutex  sync.Mutex
+	// members peers of each group that discovery connects to, any peer if
+	// there is no group
+	members      map[string]map[peer.ID]bool
+	membersMutex sync.RWMutex
+	// dht routing table of discovery, set by Announce
+	dht *dht.IpfsDHT
+	// resources limits of inbound connections and streams, set by
+	// LimitResources
+	resources *resourceManager
+	// latency round trips to members, measured by MeasureLatency
+	latency latencyTracker
+	// versions hellos of connected peers, see serveHandshake
+	versions      map[peer.ID]*PeerVersion
+	versionsMutex sync.Mutex
 }
 
 var log *zap.SugaredLogger
 
 func init() {
-	log = logger.GetSugarLogger()
+	log = logger.Named("network")
 }
 
-func New(bindHost string, bindPort uint, domain string, nodeKey *keypair.KeyPair) *Network {
-	bindStr := fmt.Sprintf("/ip4/%s/tcp/%d", bindHost, bindPort)
-	log.Debugf("Bind address: %s", bindStr)
-	sourceMultiAddr, err := multiaddr.NewMultiaddr(bindStr)
-	if err != nil {
-		log.Panic(err)
-	}
+// New create host listening on given addresses and gossip pub sub of node,
+// extra options are applied to host
+func New(listenAddrs []multiaddr.Multiaddr, id Identifier, nodeKey keypair.Signer, opts ...libp2p.Option) *Network {
+	log.Debugf("Listen addresses: %v", listenAddrs)
 	nodeID, _ := nodeKey.GetID()
 	log.Debugf("Setup host with given private key, node ID: %s", nodeID)
 	prvKey := nodeKey.GetPrivateKey()
-	host, err := libp2p.New(
-		libp2p.ListenAddrs(sourceMultiAddr),
+	host, err := libp2p.New(append([]libp2p.Option{
+		libp2p.ListenAddrs(listenAddrs...),
 		libp2p.Identity(prvKey),
-	)
+		libp2p.ConnectionManager(connmgr.NewConnManager(connLow, connHigh, connGrace)),
+		// Dial back peers that ask whether they are reachable, so nodes
+		// behind NAT learn it from each other
+		libp2p.EnableNATService(),
+	}, opts...)...)
 
 	if err != nil {
 		log.Panic(err)
 	}
+	net := NewWithHost(host, id, nodeKey)
+	net.ListenAddrs = listenAddrs
+	return net
+}
 
+// NewWithHost create gossip pub sub of node on a host that was set up by
+// caller, e.g. an in-memory host of a simulated network
+func NewWithHost(host host.Host, id Identifier, nodeKey keypair.Signer) *Network {
+	nodeID, _ := nodeKey.GetID()
 	context := context.Background()
 
 	// Start new gossip pub sub
@@ -62,6 +125,8 @@ func New(bindHost string, bindPort uint, domain string, nodeKey *keypair.KeyPair
 		context,
 		host,
 		pubsub.WithPeerExchange(true),
+		pubsub.WithMaxMessageSize(MaxEnvelopeSize),
+		pubsub.WithRawTracer(newMeshTracer()),
 	)
 
 	if err != nil {
@@ -69,42 +134,86 @@ func New(bindHost string, bindPort uint, domain string, nodeKey *keypair.KeyPair
 	}
 
 	net := &Network{
-		BindHost: bindHost,
-		BindPort: bindPort,
-		Domain:   domain,
-		NodeID:   nodeID,
-		nodeKey:  nodeKey,
-		host:     host,
-		context:  context,
-		pubsub:   pubsubInstance,
+		Identifier:    id,
+		NodeID:        nodeID,
+		nodeKey:       nodeKey,
+		host:          host,
+		context:       context,
+		pubsub:        pubsubInstance,
+		topics:        NewTopicManager(context, pubsubInstance, id.Domain()),
+		handlers:      make(map[string]map[string]Handler),
+		subscriptions: make(map[string]*Subscription),
 	}
+	net.serveHandshake()
 
 	return net
 }
 
+// DHT modes, see Network.DHTMode
+const (
+	// DHTAuto serve DHT once node is reachable from outside
+	DHTAuto = "auto"
+	// DHTServer always serve DHT
+	DHTServer = "server"
+	// DHTClient query DHT without serving it
+	DHTClient = "client"
+	// DHTOff run no DHT, peers come from bootstrap and direct peers
+	DHTOff = "off"
+)
+
+// dhtModes option of each DHT mode that runs a DHT
+var dhtModes = map[string]dht.ModeOpt{
+	DHTAuto:   dht.ModeAuto,
+	DHTServer: dht.ModeServer,
+	DHTClient: dht.ModeClient,
+}
+
+// Announce connect to direct and bootstrap peers, then announce node on
+// rendezvous of network and connect to peers found there unless DHT is off
 func (net *Network) Announce() {
+	var routingDiscovery *discovery.RoutingDiscovery
+	if net.DHTMode != DHTOff {
+		mode, ok := dhtModes[net.DHTMode]
+		if !ok {
+			mode = dht.ModeAuto
+		}
+		// Start a DHT, for use in peer discovery. We can't just make a new DHT
+		// client because we want each peer to maintain its own local copy of the
+		// DHT, so that the bootstrapping node of the DHT can go down without
+		// inhibiting future peer discovery.
+		kademliaDHT, err := dht.New(net.context, net.host, dht.Mode(mode))
+		if err != nil {
+			log.Panic(err)
+		}
+		net.dht = kademliaDHT
 
-	// Start a DHT, for use in peer discovery. We can't just make a new DHT
-	// client because we want each peer to maintain its own local copy of the
-	// DHT, so that the bootstrapping node of the DHT can go down without
-	// inhibiting future peer discovery.
-	kademliaDHT, err := dht.New(net.context, net.host)
-	if err != nil {
-		log.Panic(err)
+		// Bootstrap the DHT. In the default configuration, this spawns a Background
+		// thread that will refresh the peer table every five minutes.
+		log.Debug("Bootstrapping the DHT")
+		if err = kademliaDHT.Bootstrap(net.context); err != nil {
+			log.Panic(err)
+		}
+		routingDiscovery = discovery.NewRoutingDiscovery(kademliaDHT)
 	}
 
-	// Bootstrap the DHT. In the default configuration, this spawns a Background
-	// thread that will refresh the peer table every five minutes.
-	log.Debug("Bootstrapping the DHT")
-	if err = kademliaDHT.Bootstrap(net.context); err != nil {
-		log.Panic(err)
+	for _, peerAddr := range net.DirectPeers {
+		peerinfo, err := peer.AddrInfoFromP2pAddr(peerAddr)
+		if err != nil {
+			log.Warnf("Invalid direct peer %s: %v", peerAddr, err)
+			continue
+		}
+		go net.keepConnected(*peerinfo)
 	}
 
 	// Let's connect to the bootstrap nodes first. They will tell us about the
 	// other nodes in the network.
 	var wg sync.WaitGroup
-	for _, peerAddr := range dht.DefaultBootstrapPeers {
-		peerinfo, _ := peer.AddrInfoFromP2pAddr(peerAddr)
+	for _, peerAddr := range net.BootstrapPeers {
+		peerinfo, err := peer.AddrInfoFromP2pAddr(peerAddr)
+		if err != nil {
+			log.Warnf("Invalid bootstrap peer %s: %v", peerAddr, err)
+			continue
+		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -117,61 +226,59 @@ func (net *Network) Announce() {
 	}
 	wg.Wait()
 
-	// We use a rendezvous point `domain` to announce our location.
+	if net.MDNS {
+		net.startMDNS()
+	}
+	if routingDiscovery == nil {
+		log.Info("DHT is off, peers come from bootstrap, direct peers and mDNS")
+		return
+	}
+
+	// We use a rendezvous point of network to announce our location.
 	// This is like telling your friends to meet you at the Eiffel Tower.
 	log.Info("Announcing ourselves...")
-	routingDiscovery := discovery.NewRoutingDiscovery(kademliaDHT)
-	discovery.Advertise(net.context, routingDiscovery, net.Domain)
+	discovery.Advertise(net.context, routingDiscovery, net.Identifier.Rendezvous())
 	log.Debug("Successfully announced!")
 
 	// Now, look for others who have announced
 	// This is like your friend telling you the location to meet you.
-	log.Debug("Searching for other peers...")
-	peerChan, err := routingDiscovery.FindPeers(net.context, net.Domain)
-	if err != nil {
-		log.Panic(err)
+	net.findPeers(routingDiscovery)
+	if net.DiscoveryInterval > 0 {
+		go net.discoverLoop(routingDiscovery)
 	}
+}
 
-	for curPeer := range peerChan {
-		if curPeer.ID == net.host.ID() {
-			continue
-		}
-
-		log.Debugf("Connecting to: %s", curPeer.ID.Pretty())
-		err := net.host.Connect(net.context, curPeer)
-
-		if err != nil {
-			log.Warnf("Connection failed: %v", err)
-			continue
-		}
-
-		log.Infof("Connected to: %s", curPeer.ID.Pretty())
-	}
+// Topics topic manager of network
+func (net *Network) Topics() *TopicManager {
+	return net.topics
 }
 
-func (net *Network) Join() {
-	net.pubsub.GetTopics()
+// Publish data to given topic, topic is joined on first use
+func (net *Network) Publish(topicName string, data []byte) error {
+	return net.topics.Publish(topicName, data)
+}
 
-	topic, err := net.pubsub.Join("hello")
+// Subscribe pass messages of given topic to handler until subscription is cancelled
+func (net *Network) Subscribe(topicName string, handler MessageHandler) (*Subscription, error) {
+	return net.topics.Subscribe(topicName, handler)
+}
 
-	if err != nil {
-		log.Panic(err)
-	}
+// SetStreamHandler handle incoming streams of given protocol
+func (net *Network) SetStreamHandler(protocolID string, handler p2pNetwork.StreamHandler) {
+	net.host.SetStreamHandler(protocol.ID(protocolID), handler)
+}
 
-	helloWorld, err := topic.Subscribe()
+// NewStream open a stream of given protocol to a peer
+func (net *Network) NewStream(ctx context.Context, peerID peer.ID, protocolID string) (p2pNetwork.Stream, error) {
+	return net.host.NewStream(ctx, peerID, protocol.ID(protocolID))
+}
 
-	if err != nil {
-		log.Panic(err)
-	}
+// Peers currently connected peers
+func (net *Network) Peers() []peer.ID {
+	return net.host.Network().Peers()
+}
 
-	for {
-		time.AfterFunc(time.Duration(rand.Intn(10))*time.Second, func() {
-			topic.Publish(net.context, []byte("Hello from:"+net.NodeID.Pretty()))
-		})
-		msg, err := helloWorld.Next(net.context)
-		if err != nil {
-			log.Panic(err)
-		}
-		log.Debugf("Topic: %s from: %s data: %s", topic.String(), msg.GetFrom().String(), string(msg.GetData()))
-	}
+// Close host, connections to all peers are closed
+func (net *Network) Close() error {
+	return net.host.Close()
 }
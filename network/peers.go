@@ -0,0 +1,138 @@
+package network
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// PeerInfo connected peer as seen by host and pubsub
+type PeerInfo struct {
+	ID    peer.ID  `json:"id"`
+	Addrs []string `json:"addrs"`
+	// Direction inbound or outbound, of the oldest connection
+	Direction string `json:"direction"`
+	// Latency moving average of round trips measured by host, 0 if there
+	// is no measurement yet
+	Latency      time.Duration `json:"latency"`
+	AgentVersion string        `json:"agentVersion,omitempty"`
+	// Topics joined topics that peer is subscribed to
+	Topics []string `json:"topics"`
+	// Member peer is a member of a group of node
+	Member bool `json:"member"`
+	// RTT round trips of pings to member, see MeasureLatency
+	RTT *LatencyStats `json:"rtt,omitempty"`
+	// Version protocol version and capabilities that peer sent in handshake
+	Version *PeerVersion `json:"version,omitempty"`
+	// Conns connections to peer, oldest first
+	Conns []ConnInfo `json:"conns"`
+}
+
+// ConnInfo connection to a peer, security and muxer are empty if they are
+// not known
+type ConnInfo struct {
+	Addr      string `json:"addr"`
+	Direction string `json:"direction"`
+	Security  string `json:"security,omitempty"`
+	Muxer     string `json:"muxer,omitempty"`
+}
+
+// PeerInfos connected peers ordered by ID
+func (net *Network) PeerInfos() []PeerInfo {
+	topics := net.topics.peerTopics()
+	ids := net.Peers()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	infos := make([]PeerInfo, 0, len(ids))
+	for _, id := range ids {
+		info := PeerInfo{
+			ID:      id,
+			Addrs:   make([]string, 0),
+			Latency: net.host.Peerstore().LatencyEWMA(id),
+			Topics:  topics[id],
+			Member:  net.inGroup(id),
+			RTT:     net.Latency(id),
+			Version: net.PeerVersion(id),
+		}
+		conns := net.host.Network().ConnsToPeer(id)
+		sort.Slice(conns, func(i, j int) bool { return conns[i].Stat().Opened.Before(conns[j].Stat().Opened) })
+		info.Conns = make([]ConnInfo, 0, len(conns))
+		for _, conn := range conns {
+			info.Addrs = append(info.Addrs, conn.RemoteMultiaddr().String())
+			connInfo := ConnInfo{
+				Addr:      conn.RemoteMultiaddr().String(),
+				Direction: strings.ToLower(conn.Stat().Direction.String()),
+			}
+			if net.Transports != nil {
+				if negotiated := net.Transports.Negotiated(conn); negotiated != nil {
+					connInfo.Security = negotiated.Security
+					connInfo.Muxer = negotiated.Muxer
+				}
+			}
+			info.Conns = append(info.Conns, connInfo)
+		}
+		if len(conns) > 0 {
+			info.Direction = strings.ToLower(conns[0].Stat().Direction.String())
+		}
+		if agent, err := net.host.Peerstore().Get(id, "AgentVersion"); err == nil {
+			info.AgentVersion, _ = agent.(string)
+		}
+		if info.Topics == nil {
+			info.Topics = make([]string, 0)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// inGroup peer is a member of some group, unlike isMember it's false when
+// there is no group
+func (net *Network) inGroup(id peer.ID) bool {
+	net.membersMutex.RLock()
+	defer net.membersMutex.RUnlock()
+	for _, group := range net.members {
+		if group[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// peerTopics joined topics that each peer is subscribed to, sorted
+func (m *TopicManager) peerTopics() map[peer.ID][]string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	topics := make(map[peer.ID][]string)
+	for name, topic := range m.topics {
+		for _, id := range topic.ListPeers() {
+			topics[id] = append(topics[id], name)
+		}
+	}
+	for _, names := range topics {
+		sort.Strings(names)
+	}
+	return topics
+}
+
+// NotifyPeers call connected when node opens its first connection to a peer,
+// with direction of that connection, and disconnected when its last
+// connection to a peer is closed
+func (net *Network) NotifyPeers(connected func(id peer.ID, direction string), disconnected func(id peer.ID)) {
+	net.host.Network().Notify(&p2pNetwork.NotifyBundle{
+		ConnectedF: func(n p2pNetwork.Network, conn p2pNetwork.Conn) {
+			if len(n.ConnsToPeer(conn.RemotePeer())) == 1 {
+				connected(conn.RemotePeer(), strings.ToLower(conn.Stat().Direction.String()))
+			}
+		},
+		DisconnectedF: func(n p2pNetwork.Network, conn p2pNetwork.Conn) {
+			if n.Connectedness(conn.RemotePeer()) != p2pNetwork.Connected {
+				disconnected(conn.RemotePeer())
+			}
+		},
+		ListenF:      func(p2pNetwork.Network, multiaddr.Multiaddr) {},
+		ListenCloseF: func(p2pNetwork.Network, multiaddr.Multiaddr) {},
+	})
+}
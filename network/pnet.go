@@ -0,0 +1,32 @@
+package network
+
+import (
+	"os"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/pnet"
+	tcp "github.com/libp2p/go-tcp-transport"
+)
+
+// PrivateNetwork options of a host that only talks to peers holding the same
+// pre-shared key. The key file uses the swarm.key format of IPFS:
+//
+//	/key/swarm/psk/1.0.0/
+//	/base16/
+//	<64 hex characters>
+func PrivateNetwork(pskFile string) ([]libp2p.Option, error) {
+	file, err := os.Open(pskFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	psk, err := pnet.DecodeV1PSK(file)
+	if err != nil {
+		return nil, err
+	}
+	// QUIC does not support private networks, TCP is the only transport
+	return []libp2p.Option{
+		libp2p.PrivateNetwork(psk),
+		libp2p.Transport(tcp.NewTCPTransport),
+	}, nil
+}
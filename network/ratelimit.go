@@ -0,0 +1,126 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// ValidatorRateLimit key of validator limiting message rate of publishers
+const ValidatorRateLimit = "rate-limit"
+
+// rateLimitTag connection manager tag lowered each time a peer exceeds its
+// limit, so it's among the first connections trimmed
+const rateLimitTag = "orochi-rate-limit"
+
+// minRateLimitScore lowest value of rate limit tag
+const minRateLimitScore = -100
+
+// RateLimiter token buckets of publishers, each publisher may send burst
+// messages at once and rate messages per second after that
+type RateLimiter struct {
+	rate    float64
+	burst   float64
+	buckets map[peer.ID]*bucket
+	mutex   sync.Mutex
+	// self messages published by this node, they are never limited
+	self peer.ID
+	// OnExceed called with publisher that exceeded its limit
+	OnExceed func(peer.ID)
+}
+
+// bucket tokens left to a publisher
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// warned whether exceeding was logged since bucket was last full
+	warned bool
+}
+
+// NewRateLimiter create a rate limiter allowing rate messages per second with
+// bursts of given size
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{rate: rate, burst: float64(burst), buckets: make(map[peer.ID]*bucket)}
+}
+
+// Allow take a token of publisher, false if it has none left
+func (r *RateLimiter) Allow(id peer.ID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	b, ok := r.buckets[id]
+	if !ok {
+		// Forget publishers that were idle long enough to refill their bucket
+		if len(r.buckets) >= 1024 {
+			r.prune(now)
+		}
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[id] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+		b.warned = false
+	}
+	b.last = now
+	if b.tokens < 1 {
+		if !b.warned {
+			b.warned = true
+			log.Warnf("Peer %s exceeds rate limit of %.0f messages per second, its messages are dropped", id, r.rate)
+		}
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune remove buckets that are full again, caller must hold mutex
+func (r *RateLimiter) prune(now time.Time) {
+	for id, b := range r.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*r.rate >= r.burst {
+			delete(r.buckets, id)
+		}
+	}
+}
+
+// validator drop messages of publishers over their limit. Message is rejected
+// only if the publisher sent it to us itself, so peers relaying a flood are
+// not penalized for it
+func (r *RateLimiter) validator(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	publisher := msg.GetFrom()
+	if publisher == r.self || r.Allow(publisher) {
+		return pubsub.ValidationAccept
+	}
+	if r.OnExceed != nil {
+		r.OnExceed(publisher)
+	}
+	if from == publisher {
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationIgnore
+}
+
+// RateLimit limit rate of messages each publisher may send on given topics.
+// Limiter should be added before other validators of topics so floods are
+// dropped before signatures are checked. Publishers over limit lose value in
+// connection manager
+func (net *Network) RateLimit(limiter *RateLimiter, topicNames ...string) error {
+	limiter.self = net.host.ID()
+	limiter.OnExceed = func(id peer.ID) {
+		net.host.ConnManager().UpsertTag(id, rateLimitTag, func(value int) int {
+			if value <= minRateLimitScore {
+				return minRateLimitScore
+			}
+			return value - 1
+		})
+	}
+	for _, topicName := range topicNames {
+		if err := net.topics.AddValidator(topicName, ValidatorRateLimit, limiter.validator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
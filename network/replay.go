@@ -0,0 +1,88 @@
+package network
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var replaysDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "network",
+	Name:      "replays_dropped_total",
+	Help:      "Envelopes dropped because the same envelope was handled before",
+}, []string{"topic"})
+
+func init() {
+	metrics.Registry.MustRegister(replaysDropped)
+}
+
+// replayKey identify an envelope regardless of pubsub message ID, which
+// changes each time the envelope is published again
+type replayKey struct {
+	topic   string
+	sender  peer.ID
+	msgType string
+	round   uint64
+	hash    [sha256.Size]byte
+}
+
+// ReplayCache envelopes handled within TTL. Gossipsub forgets message IDs
+// after a few minutes and a republished envelope gets a new ID, the cache
+// drops such envelopes before they reach handlers a second time
+type ReplayCache struct {
+	ttl    time.Duration
+	seen   map[replayKey]time.Time
+	pruned time.Time
+	mutex  sync.Mutex
+}
+
+// NewReplayCache create a replay cache remembering envelopes for ttl
+func NewReplayCache(ttl time.Duration) *ReplayCache {
+	return &ReplayCache{ttl: ttl, seen: make(map[replayKey]time.Time), pruned: time.Now()}
+}
+
+// Seen record envelope received on topic, true if it was already recorded
+// within TTL
+func (c *ReplayCache) Seen(topicName string, e *Envelope) bool {
+	key := replayKey{topic: topicName, sender: e.Sender, msgType: e.Type, round: e.Round, hash: sha256.Sum256(e.Payload)}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(c.pruned) > c.ttl {
+		c.prune(now)
+	}
+	if seen, ok := c.seen[key]; ok && now.Sub(seen) < c.ttl {
+		return true
+	}
+	c.seen[key] = now
+	return false
+}
+
+// prune forget envelopes older than TTL, caller must hold mutex
+func (c *ReplayCache) prune(now time.Time) {
+	for key, seen := range c.seen {
+		if now.Sub(seen) >= c.ttl {
+			delete(c.seen, key)
+		}
+	}
+	c.pruned = now
+}
+
+// DropReplays pass each envelope of given topics to its handler once within
+// TTL of cache. Protocols that answer repeated messages, such as share
+// recovery, must not use it
+func (net *Network) DropReplays(cache *ReplayCache, topicNames ...string) {
+	net.handlerMutex.Lock()
+	defer net.handlerMutex.Unlock()
+	if net.replays == nil {
+		net.replays = make(map[string]*ReplayCache)
+	}
+	for _, topicName := range topicNames {
+		net.replays[topicName] = cache
+	}
+}
@@ -0,0 +1,215 @@
+package network
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/orochi-network/orochimaru/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var resourcesRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "network",
+	Name:      "resources_rejected_total",
+	Help:      "Inbound connections and streams closed because a resource limit was reached",
+}, []string{"resource"})
+
+func init() {
+	metrics.Registry.MustRegister(resourcesRejected)
+}
+
+// memorySampleInterval how often heap size is read while a memory limit is set
+const memorySampleInterval = time.Second
+
+// ResourceLimits limits of peers that are not group members, 0 means no
+// limit. Group members are never rejected, so a node under attack keeps
+// producing rounds with its group
+type ResourceLimits struct {
+	// Conns open connections
+	Conns int `json:"conns"`
+	// ConnsPerPeer open connections to one peer
+	ConnsPerPeer int `json:"connsPerPeer"`
+	// Streams open streams
+	Streams int `json:"streams"`
+	// StreamsPerPeer open streams to one peer
+	StreamsPerPeer int `json:"streamsPerPeer"`
+	// Memory heap size in bytes above which inbound connections and streams
+	// are rejected
+	Memory uint64 `json:"memory"`
+}
+
+// ResourceUsage resources in use and how often limits were hit
+type ResourceUsage struct {
+	Conns         int            `json:"conns"`
+	InboundConns  int            `json:"inboundConns"`
+	OutboundConns int            `json:"outboundConns"`
+	Streams       int            `json:"streams"`
+	Peers         int            `json:"peers"`
+	Memory        uint64         `json:"memory"`
+	Limits        ResourceLimits `json:"limits"`
+	// Rejected inbound connections and streams closed since start
+	RejectedConns   uint64 `json:"rejectedConns"`
+	RejectedStreams uint64 `json:"rejectedStreams"`
+}
+
+// resourceManager enforces ResourceLimits on inbound connections and streams
+type resourceManager struct {
+	limits ResourceLimits
+	// memory heap size of last sample
+	memory          uint64
+	rejectedConns   uint64
+	rejectedStreams uint64
+	mutex           sync.Mutex
+}
+
+// LimitResources close inbound connections and streams of peers that are not
+// group members once a limit is reached. Unlike connection trimming, which
+// happens after the fact, this keeps a flood of peers or streams from
+// exhausting memory of node. It must be called once
+func (net *Network) LimitResources(limits ResourceLimits) {
+	rm := &resourceManager{limits: limits}
+	net.resources = rm
+	if limits.Memory > 0 {
+		rm.sampleMemory()
+		go func() {
+			ticker := time.NewTicker(memorySampleInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-net.context.Done():
+					return
+				case <-ticker.C:
+					rm.sampleMemory()
+				}
+			}
+		}()
+	}
+	net.host.Network().Notify(&p2pNetwork.NotifyBundle{
+		ConnectedF: func(n p2pNetwork.Network, conn p2pNetwork.Conn) {
+			if conn.Stat().Direction != p2pNetwork.DirInbound || net.inGroup(conn.RemotePeer()) {
+				return
+			}
+			if reason := rm.connExceeds(n, conn.RemotePeer()); reason != "" {
+				rm.reject("conns", &rm.rejectedConns)
+				log.Debugf("Close inbound connection of %s, %s", conn.RemotePeer(), reason)
+				go conn.Close()
+			}
+		},
+		OpenedStreamF: func(n p2pNetwork.Network, s p2pNetwork.Stream) {
+			id := s.Conn().RemotePeer()
+			if s.Stat().Direction != p2pNetwork.DirInbound || net.inGroup(id) {
+				return
+			}
+			if reason := rm.streamExceeds(n, id); reason != "" {
+				rm.reject("streams", &rm.rejectedStreams)
+				log.Debugf("Reset inbound stream of %s, %s", id, reason)
+				go s.Reset()
+			}
+		},
+		ListenF:      func(p2pNetwork.Network, multiaddr.Multiaddr) {},
+		ListenCloseF: func(p2pNetwork.Network, multiaddr.Multiaddr) {},
+	})
+}
+
+// ResourceUsage connections, streams and memory in use
+func (net *Network) ResourceUsage() ResourceUsage {
+	var usage ResourceUsage
+	for _, conn := range net.host.Network().Conns() {
+		usage.Conns++
+		if conn.Stat().Direction == p2pNetwork.DirInbound {
+			usage.InboundConns++
+		} else {
+			usage.OutboundConns++
+		}
+		usage.Streams += len(conn.GetStreams())
+	}
+	usage.Peers = len(net.host.Network().Peers())
+	if rm := net.resources; rm != nil {
+		rm.mutex.Lock()
+		usage.Limits = rm.limits
+		usage.Memory = rm.memory
+		usage.RejectedConns = rm.rejectedConns
+		usage.RejectedStreams = rm.rejectedStreams
+		rm.mutex.Unlock()
+	}
+	if usage.Memory == 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		usage.Memory = stats.HeapAlloc
+	}
+	return usage
+}
+
+// sampleMemory read heap size, reading it stops the world so it's sampled
+// instead of read for each connection
+func (rm *resourceManager) sampleMemory() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	rm.mutex.Lock()
+	rm.memory = stats.HeapAlloc
+	rm.mutex.Unlock()
+}
+
+// overMemory heap size exceeds memory limit
+func (rm *resourceManager) overMemory() bool {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	return rm.limits.Memory > 0 && rm.memory > rm.limits.Memory
+}
+
+// connExceeds reason why a new connection of peer exceeds limits, empty if
+// it does not
+func (rm *resourceManager) connExceeds(n p2pNetwork.Network, id peer.ID) string {
+	limits := rm.limits
+	if limits.Conns > 0 && len(n.Conns()) > limits.Conns {
+		return "connection limit reached"
+	}
+	if limits.ConnsPerPeer > 0 && len(n.ConnsToPeer(id)) > limits.ConnsPerPeer {
+		return "connection limit of peer reached"
+	}
+	if rm.overMemory() {
+		return "memory limit reached"
+	}
+	return ""
+}
+
+// streamExceeds reason why a new stream of peer exceeds limits, empty if it
+// does not
+func (rm *resourceManager) streamExceeds(n p2pNetwork.Network, id peer.ID) string {
+	limits := rm.limits
+	if limits.StreamsPerPeer > 0 {
+		streams := 0
+		for _, conn := range n.ConnsToPeer(id) {
+			streams += len(conn.GetStreams())
+		}
+		if streams > limits.StreamsPerPeer {
+			return "stream limit of peer reached"
+		}
+	}
+	if limits.Streams > 0 {
+		streams := 0
+		for _, conn := range n.Conns() {
+			streams += len(conn.GetStreams())
+		}
+		if streams > limits.Streams {
+			return "stream limit reached"
+		}
+	}
+	if rm.overMemory() {
+		return "memory limit reached"
+	}
+	return ""
+}
+
+// reject count a rejected connection or stream
+func (rm *resourceManager) reject(resource string, counter *uint64) {
+	rm.mutex.Lock()
+	*counter++
+	rm.mutex.Unlock()
+	resourcesRejected.WithLabelValues(resource).Inc()
+}
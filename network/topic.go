@@ -0,0 +1,137 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// MessageHandler handle a pubsub message of a subscribed topic
+type MessageHandler func(msg *pubsub.Message)
+
+// TopicManager join pubsub topics namespaced by domain, topic beacon of network
+// mainnet is orochi/mainnet/beacon so different networks do not mix
+type TopicManager struct {
+	domain        string
+	context       context.Context
+	pubsub        *pubsub.PubSub
+	topics        map[string]*pubsub.Topic
+	subscriptions map[string]int
+	mutex         sync.Mutex
+	// validators of each topic, in registration order
+	validators     map[string][]namedValidator
+	validatorMutex sync.RWMutex
+}
+
+// Subscription handler subscribed to a topic
+type Subscription struct {
+	name    string
+	manager *TopicManager
+	sub     *pubsub.Subscription
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewTopicManager create a topic manager of given domain
+func NewTopicManager(ctx context.Context, ps *pubsub.PubSub, domain string) *TopicManager {
+	return &TopicManager{
+		domain:        domain,
+		context:       ctx,
+		pubsub:        ps,
+		topics:        make(map[string]*pubsub.Topic),
+		subscriptions: make(map[string]int),
+		validators:    make(map[string][]namedValidator),
+	}
+}
+
+// TopicName full pubsub name of a topic
+func (m *TopicManager) TopicName(name string) string {
+	return fmt.Sprintf("orochi/%s/%s", m.domain, name)
+}
+
+// JoinTopic join a topic, a joined topic is reused
+func (m *TopicManager) JoinTopic(name string) (*pubsub.Topic, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.join(name)
+}
+
+// join topic, caller must hold mutex
+func (m *TopicManager) join(name string) (*pubsub.Topic, error) {
+	if topic, ok := m.topics[name]; ok {
+		return topic, nil
+	}
+	topic, err := m.pubsub.Join(m.TopicName(name))
+	if err != nil {
+		return nil, err
+	}
+	m.topics[name] = topic
+	return topic, nil
+}
+
+// Publish data to a topic, topic is joined on first use
+func (m *TopicManager) Publish(name string, data []byte) error {
+	topic, err := m.JoinTopic(name)
+	if err != nil {
+		return err
+	}
+	return topic.Publish(m.context, data)
+}
+
+// Subscribe pass messages of a topic to handler until subscription is cancelled,
+// handler is called from a single goroutine
+func (m *TopicManager) Subscribe(name string, handler MessageHandler) (*Subscription, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	topic, err := m.join(name)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := topic.Subscribe()
+	if err != nil {
+		return nil, err
+	}
+	m.subscriptions[name]++
+	ctx, cancel := context.WithCancel(m.context)
+	s := &Subscription{name: name, manager: m, sub: sub, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		for {
+			msg, err := sub.Next(ctx)
+			if err != nil {
+				return
+			}
+			handler(msg)
+		}
+	}()
+	return s, nil
+}
+
+// Cancel stop subscription and wait for its handler to return, topic is left
+// when it has no subscription left
+func (s *Subscription) Cancel() {
+	s.cancel()
+	s.sub.Cancel()
+	<-s.done
+	s.manager.unsubscribe(s.name)
+}
+
+func (m *TopicManager) unsubscribe(name string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.subscriptions[name]--
+	if m.subscriptions[name] > 0 {
+		return
+	}
+	delete(m.subscriptions, name)
+	if topic, ok := m.topics[name]; ok {
+		if err := topic.Close(); err != nil {
+			// Topic is still in use, it's reused on next join
+			log.Debugf("Unable to leave topic %s: %v", name, err)
+			return
+		}
+		delete(m.topics, name)
+	}
+}
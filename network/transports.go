@@ -0,0 +1,231 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	stdnet "net"
+	"sync"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/mux"
+	p2pNetwork "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/sec"
+	mplex "github.com/libp2p/go-libp2p-mplex"
+	noise "github.com/libp2p/go-libp2p-noise"
+	tls "github.com/libp2p/go-libp2p-tls"
+	yamux "github.com/libp2p/go-libp2p-yamux"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// Security transports and stream muxers that host may offer
+const (
+	SecurityNoise = "noise"
+	// SecurityTLS TLS 1.3
+	SecurityTLS = "tls"
+	MuxerYamux  = "yamux"
+	MuxerMplex  = "mplex"
+	// MuxerQUIC QUIC connections carry their own streams and always use TLS 1.3,
+	// whatever security transports and muxers are configured
+	MuxerQUIC = "quic"
+)
+
+// DefaultSecurity and DefaultMuxers are offered in this order unless
+// configured otherwise, like libp2p does
+var (
+	DefaultSecurity = []string{SecurityNoise, SecurityTLS}
+	DefaultMuxers   = []string{MuxerYamux, MuxerMplex}
+)
+
+// securityIDs protocol ID of each security transport
+var securityIDs = map[string]string{
+	SecurityNoise: noise.ID,
+	SecurityTLS:   tls.ID,
+}
+
+// muxers protocol ID and transport of each stream muxer
+var muxers = map[string]struct {
+	id        string
+	transport mux.Multiplexer
+}{
+	MuxerYamux: {"/yamux/1.0.0", yamux.DefaultTransport},
+	MuxerMplex: {"/mplex/6.7.0", mplex.DefaultTransport},
+}
+
+// Negotiated security transport and stream muxer of a connection
+type Negotiated struct {
+	Security string `json:"security"`
+	Muxer    string `json:"muxer"`
+}
+
+// Transports security transports and stream muxers offered by a host in
+// order of preference. It records what each connection negotiated
+type Transports struct {
+	Security []string
+	Muxers   []string
+	// conns negotiated of open connections by their local and remote address
+	conns map[string]*Negotiated
+	mutex sync.Mutex
+}
+
+// NewTransports check security transports and stream muxers, empty lists are
+// replaced by DefaultSecurity and DefaultMuxers
+func NewTransports(security []string, muxerNames []string) (*Transports, error) {
+	if len(security) == 0 {
+		security = DefaultSecurity
+	}
+	if len(muxerNames) == 0 {
+		muxerNames = DefaultMuxers
+	}
+	for _, name := range security {
+		if _, ok := securityIDs[name]; !ok {
+			return nil, fmt.Errorf("unsupported security transport %q, use %s or %s", name, SecurityNoise, SecurityTLS)
+		}
+	}
+	for _, name := range muxerNames {
+		if _, ok := muxers[name]; !ok {
+			return nil, fmt.Errorf("unsupported stream muxer %q, use %s or %s", name, MuxerYamux, MuxerMplex)
+		}
+	}
+	return &Transports{Security: security, Muxers: muxerNames, conns: make(map[string]*Negotiated)}, nil
+}
+
+// Option option of host that offers security transports and muxers of t
+// instead of the defaults of libp2p
+func (t *Transports) Option() libp2p.Option {
+	opts := make([]libp2p.Option, 0, len(t.Security)+len(t.Muxers))
+	for _, name := range t.Security {
+		name := name
+		opts = append(opts, libp2p.Security(securityIDs[name], func(key crypto.PrivKey) (sec.SecureTransport, error) {
+			var transport sec.SecureTransport
+			var err error
+			if name == SecurityTLS {
+				transport, err = tls.New(key)
+			} else {
+				transport, err = noise.New(key)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return &recordingSecurity{SecureTransport: transport, name: name, transports: t}, nil
+		}))
+	}
+	for _, name := range t.Muxers {
+		opts = append(opts, libp2p.Muxer(muxers[name].id, &recordingMuxer{Multiplexer: muxers[name].transport, name: name}))
+	}
+	return libp2p.ChainOptions(opts...)
+}
+
+// Negotiated security transport and muxer of a connection, nil if it was not
+// upgraded by t
+func (t *Transports) Negotiated(conn p2pNetwork.Conn) *Negotiated {
+	if _, err := conn.RemoteMultiaddr().ValueForProtocol(multiaddr.P_QUIC); err == nil {
+		return &Negotiated{Security: SecurityTLS, Muxer: MuxerQUIC}
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	negotiated, ok := t.conns[connKey(conn.LocalMultiaddr(), conn.RemoteMultiaddr())]
+	if !ok {
+		return nil
+	}
+	copied := *negotiated
+	return &copied
+}
+
+// connKey key of a connection in Transports, nil if its addresses are not
+// multiaddrs
+func connKey(local multiaddr.Multiaddr, remote multiaddr.Multiaddr) string {
+	if local == nil || remote == nil {
+		return ""
+	}
+	return local.String() + " " + remote.String()
+}
+
+// netConnKey key of a connection in Transports from its net addresses
+func netConnKey(conn stdnet.Conn) string {
+	local, err := manet.FromNetAddr(conn.LocalAddr())
+	if err != nil {
+		return ""
+	}
+	remote, err := manet.FromNetAddr(conn.RemoteAddr())
+	if err != nil {
+		return ""
+	}
+	return connKey(local, remote)
+}
+
+// recordingSecurity security transport that records connections it secured
+type recordingSecurity struct {
+	sec.SecureTransport
+	name       string
+	transports *Transports
+}
+
+func (s *recordingSecurity) SecureInbound(ctx context.Context, insecure stdnet.Conn, p peer.ID) (sec.SecureConn, error) {
+	conn, err := s.SecureTransport.SecureInbound(ctx, insecure, p)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(conn), nil
+}
+
+func (s *recordingSecurity) SecureOutbound(ctx context.Context, insecure stdnet.Conn, p peer.ID) (sec.SecureConn, error) {
+	conn, err := s.SecureTransport.SecureOutbound(ctx, insecure, p)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(conn), nil
+}
+
+// record keep security transport of conn until it's closed
+func (s *recordingSecurity) record(conn sec.SecureConn) sec.SecureConn {
+	key := netConnKey(conn)
+	if key == "" {
+		return conn
+	}
+	negotiated := &Negotiated{Security: s.name}
+	t := s.transports
+	t.mutex.Lock()
+	t.conns[key] = negotiated
+	t.mutex.Unlock()
+	return &recordedConn{SecureConn: conn, transports: t, key: key, negotiated: negotiated}
+}
+
+// recordedConn secured connection whose record is dropped once it's closed
+type recordedConn struct {
+	sec.SecureConn
+	transports *Transports
+	key        string
+	negotiated *Negotiated
+	closeOnce  sync.Once
+}
+
+func (c *recordedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.transports.mutex.Lock()
+		delete(c.transports.conns, c.key)
+		c.transports.mutex.Unlock()
+	})
+	return c.SecureConn.Close()
+}
+
+// recordingMuxer stream muxer that records connections it multiplexes
+type recordingMuxer struct {
+	mux.Multiplexer
+	name string
+}
+
+func (m *recordingMuxer) NewConn(conn stdnet.Conn, isServer bool) (mux.MuxedConn, error) {
+	muxed, err := m.Multiplexer.NewConn(conn, isServer)
+	if err != nil {
+		return nil, err
+	}
+	if recorded, ok := conn.(*recordedConn); ok {
+		recorded.transports.mutex.Lock()
+		recorded.negotiated.Muxer = m.name
+		recorded.transports.mutex.Unlock()
+	}
+	return muxed, nil
+}
@@ -0,0 +1,133 @@
+package network
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// ValidatorEnvelope key of validator checking envelope signatures
+const ValidatorEnvelope = "envelope"
+
+// ValidatorMembership key of validator restricting publishers to group members
+const ValidatorMembership = "membership"
+
+// namedValidator validator registered under a key
+type namedValidator struct {
+	key       string
+	validator pubsub.ValidatorEx
+}
+
+// AddValidator register a validator of a topic under given key, a message is
+// delivered and propagated only when all validators of its topic accept it
+func (m *TopicManager) AddValidator(name string, key string, validator pubsub.ValidatorEx) error {
+	m.validatorMutex.Lock()
+	defer m.validatorMutex.Unlock()
+	validators, ok := m.validators[name]
+	if !ok {
+		err := m.pubsub.RegisterTopicValidator(m.TopicName(name), func(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+			return m.validate(ctx, name, from, msg)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for i := range validators {
+		if validators[i].key == key {
+			validators[i].validator = validator
+			return nil
+		}
+	}
+	m.validators[name] = append(validators, namedValidator{key: key, validator: validator})
+	return nil
+}
+
+// RemoveValidator remove validator of a topic registered under given key
+func (m *TopicManager) RemoveValidator(name string, key string) {
+	m.validatorMutex.Lock()
+	defer m.validatorMutex.Unlock()
+	validators := m.validators[name]
+	for i := range validators {
+		if validators[i].key == key {
+			validators = append(validators[:i:i], validators[i+1:]...)
+			break
+		}
+	}
+	if len(validators) > 0 {
+		m.validators[name] = validators
+		return
+	}
+	if _, ok := m.validators[name]; ok {
+		delete(m.validators, name)
+		if err := m.pubsub.UnregisterTopicValidator(m.TopicName(name)); err != nil {
+			log.Debugf("Unable to unregister validator of %s: %v", name, err)
+		}
+	}
+}
+
+// validate run validators of a topic in registration order
+func (m *TopicManager) validate(ctx context.Context, name string, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	m.validatorMutex.RLock()
+	validators := m.validators[name]
+	m.validatorMutex.RUnlock()
+	for _, v := range validators {
+		if result := v.validator(ctx, from, msg); result != pubsub.ValidationAccept {
+			return result
+		}
+	}
+	return pubsub.ValidationAccept
+}
+
+// ValidateEnvelope reject messages that are not envelopes signed by their
+// publisher, decoded envelope is kept in ValidatorData of message
+func ValidateEnvelope(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	e, err := UnmarshalEnvelope(msg.GetData())
+	if err != nil {
+		return pubsub.ValidationReject
+	}
+	// Pubsub signing authenticates the peer that published the message
+	if e.Sender != msg.GetFrom() {
+		return pubsub.ValidationReject
+	}
+	if e.Version < MinProtocolVersion {
+		log.Debugf("Drop envelope of protocol version %d from %s, at least version %d is needed", e.Version, e.Sender, MinProtocolVersion)
+		return pubsub.ValidationReject
+	}
+	if err := e.Verify(); err != nil {
+		return pubsub.ValidationReject
+	}
+	msg.ValidatorData = e
+	return pubsub.ValidationAccept
+}
+
+// EnvelopeOf envelope of a message accepted by ValidateEnvelope, nil otherwise
+func EnvelopeOf(msg *pubsub.Message) *Envelope {
+	e, _ := msg.ValidatorData.(*Envelope)
+	return e
+}
+
+// MembershipValidator reject messages whose publisher is not one of members
+func MembershipValidator(members []peer.ID) pubsub.ValidatorEx {
+	allowed := make(map[peer.ID]bool, len(members))
+	for _, member := range members {
+		allowed[member] = true
+	}
+	return func(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+		if !allowed[msg.GetFrom()] {
+			return pubsub.ValidationReject
+		}
+		return pubsub.ValidationAccept
+	}
+}
+
+// RestrictTopics accept messages of given topics only from members
+func (net *Network) RestrictTopics(members []peer.ID, topicNames ...string) error {
+	validator := MembershipValidator(members)
+	for _, topicName := range topicNames {
+		if err := net.topics.AddValidator(topicName, ValidatorMembership, validator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package plan
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// LatencyProfile one-way latency between two nodes
+type LatencyProfile struct {
+	Name   string        `json:"name"`
+	Mean   time.Duration `json:"mean"`
+	Jitter time.Duration `json:"jitter"`
+}
+
+// Params deployment parameters to plan for
+type Params struct {
+	Nodes     int            `json:"nodes"`
+	Threshold int            `json:"threshold"`
+	Period    time.Duration  `json:"period"`
+	Profile   LatencyProfile `json:"profile"`
+	// MeshDegree gossipsub mesh degree, each node forwards a message to this many peers
+	MeshDegree int `json:"meshDegree"`
+	// VerifyCost time to verify one partial signature
+	VerifyCost time.Duration `json:"verifyCost"`
+	// MessageSize size of one partial signature message in bytes
+	MessageSize int   `json:"messageSize"`
+	Trials      int   `json:"trials"`
+	Seed        int64 `json:"seed"`
+}
+
+// Report result of a planning simulation
+type Report struct {
+	Params            Params        `json:"params"`
+	Hops              int           `json:"hops"`
+	MessagesPerRound  int           `json:"messagesPerRound"`
+	TransmitsPerRound int           `json:"transmitsPerRound"`
+	BytesPerRound     int           `json:"bytesPerRound"`
+	P50               time.Duration `json:"p50"`
+	P95               time.Duration `json:"p95"`
+	P99               time.Duration `json:"p99"`
+	Budget            time.Duration `json:"budget"`
+	Feasible          bool          `json:"feasible"`
+}
+
+// BudgetFraction fraction of period that threshold must be reached in to be feasible
+const BudgetFraction = 0.5
+
+var profiles = map[string]LatencyProfile{
+	"lan":    {Name: "lan", Mean: 2 * time.Millisecond, Jitter: time.Millisecond},
+	"region": {Name: "region", Mean: 20 * time.Millisecond, Jitter: 10 * time.Millisecond},
+	"global": {Name: "global", Mean: 120 * time.Millisecond, Jitter: 60 * time.Millisecond},
+}
+
+// GetProfile get a built-in latency profile by name
+func GetProfile(name string) (LatencyProfile, error) {
+	if p, ok := profiles[name]; ok {
+		return p, nil
+	}
+	return LatencyProfile{}, errors.New("unknown latency profile")
+}
+
+// Profiles list names of built-in latency profiles
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultParams parameters with default gossip and crypto costs
+func DefaultParams(nodes int, threshold int, period time.Duration, profile LatencyProfile) Params {
+	return Params{
+		Nodes:       nodes,
+		Threshold:   threshold,
+		Period:      period,
+		Profile:     profile,
+		MeshDegree:  6,
+		VerifyCost:  2 * time.Millisecond,
+		MessageSize: 256,
+		Trials:      1000,
+		Seed:        1,
+	}
+}
+
+// Simulate estimate message complexity and time to threshold of a deployment
+func Simulate(p Params) (*Report, error) {
+	if p.Nodes < 1 || p.Threshold < 1 || p.Threshold > p.Nodes {
+		return nil, errors.New("threshold must be between 1 and number of nodes")
+	}
+	if p.Period <= 0 || p.Trials < 1 || p.MeshDegree < 1 {
+		return nil, errors.New("period, trials and mesh degree must be positive")
+	}
+	degree := p.MeshDegree
+	if degree > p.Nodes-1 {
+		degree = p.Nodes - 1
+	}
+	hops := 1
+	if degree > 1 && p.Nodes > 2 {
+		hops = int(math.Ceil(math.Log(float64(p.Nodes)) / math.Log(float64(degree))))
+	}
+	report := &Report{
+		Params:            p,
+		Hops:              hops,
+		MessagesPerRound:  p.Nodes,
+		TransmitsPerRound: p.Nodes * p.Nodes * degree,
+		Budget:            time.Duration(float64(p.Period) * BudgetFraction),
+	}
+	report.BytesPerRound = report.TransmitsPerRound * p.MessageSize
+
+	random := rand.New(rand.NewSource(p.Seed))
+	samples := make([]time.Duration, p.Trials)
+	arrivals := make([]time.Duration, p.Nodes-1)
+	for trial := range samples {
+		for i := range arrivals {
+			var delay time.Duration
+			for hop := 0; hop < hops; hop++ {
+				delay += sampleLatency(random, p.Profile)
+			}
+			arrivals[i] = delay + p.VerifyCost
+		}
+		sort.Slice(arrivals, func(i, j int) bool { return arrivals[i] < arrivals[j] })
+		// Our own partial is available immediately
+		if p.Threshold > 1 {
+			samples[trial] = arrivals[p.Threshold-2]
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	report.P50 = percentile(samples, 0.50)
+	report.P95 = percentile(samples, 0.95)
+	report.P99 = percentile(samples, 0.99)
+	report.Feasible = report.P99 <= report.Budget
+	return report, nil
+}
+
+// sampleLatency draw one-way latency of a single hop
+func sampleLatency(random *rand.Rand, profile LatencyProfile) time.Duration {
+	delay := float64(profile.Mean) + math.Abs(random.NormFloat64())*float64(profile.Jitter)
+	return time.Duration(delay)
+}
+
+// percentile of sorted samples
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	index := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
@@ -0,0 +1,69 @@
+package relayer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/orochi-network/orochimaru/contracts"
+)
+
+// method contract method given by its signature, such as
+// "submitRound(uint64,bytes32,bytes,bytes)"
+type method struct {
+	selector []byte
+	inputs   abi.Arguments
+}
+
+// parseMethod parse a method signature, types are ABI types without names
+func parseMethod(signature string) (*method, error) {
+	open := strings.IndexByte(signature, '(')
+	if open < 1 || !strings.HasSuffix(signature, ")") {
+		return nil, fmt.Errorf("invalid method signature %q", signature)
+	}
+	m := &method{selector: crypto.Keccak256([]byte(signature))[:4]}
+	if params := signature[open+1 : len(signature)-1]; params != "" {
+		for _, param := range strings.Split(params, ",") {
+			typ, err := abi.NewType(param, "", nil)
+			if err != nil {
+				return nil, fmt.Errorf("method %s: %v", signature, err)
+			}
+			m.inputs = append(m.inputs, abi.Argument{Type: typ})
+		}
+	}
+	return m, nil
+}
+
+// beaconMethod method of OrochiBeacon contract, as described by the ABI of
+// its generated binding
+func beaconMethod(name string) (*method, error) {
+	parsed, err := contracts.OrochiBeaconMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	m, ok := parsed.Methods[name]
+	if !ok {
+		return nil, fmt.Errorf("OrochiBeacon has no method %s", name)
+	}
+	return &method{selector: m.ID, inputs: m.Inputs}, nil
+}
+
+// pack ABI encode a call of method, arguments have the Go types that
+// go-ethereum maps ABI types to
+func (m *method) pack(args ...interface{}) ([]byte, error) {
+	packed, err := m.inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), m.selector...), packed...), nil
+}
+
+// ParseAddress parse a 0x prefixed hex address
+func ParseAddress(s string) (common.Address, error) {
+	if !strings.HasPrefix(s, "0x") || !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	return common.HexToAddress(s), nil
+}
@@ -0,0 +1,58 @@
+package relayer
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// maxLogRange blocks queried for logs at once, nodes limit range of eth_getLogs
+const maxLogRange = 1000
+
+// WatchLogs poll logs matching query every interval and hand them to handle
+// in order, starting with the block after the latest one. Blocks are only read
+// once they have given number of confirmations. Block range of query is
+// ignored. It returns when ctx is done
+func WatchLogs(ctx context.Context, client *ethclient.Client, query ethereum.FilterQuery, confirmations uint64, interval time.Duration, handle func(types.Log)) {
+	next := uint64(0)
+	for {
+		latest, err := client.BlockNumber(ctx)
+		switch {
+		case err != nil:
+			log.Warnf("Unable to get latest block: %v", err)
+		case next == 0:
+			next = latest + 1
+		case latest >= next+confirmations:
+			to := latest - confirmations
+			if to-next >= maxLogRange {
+				to = next + maxLogRange - 1
+			}
+			query.FromBlock = new(big.Int).SetUint64(next)
+			query.ToBlock = new(big.Int).SetUint64(to)
+			logs, err := client.FilterLogs(ctx, query)
+			if err != nil {
+				log.Warnf("Unable to get logs of blocks %d to %d: %v", next, to, err)
+				break
+			}
+			for _, l := range logs {
+				if !l.Removed {
+					handle(l)
+				}
+			}
+			next = to + 1
+			if to < latest-confirmations {
+				// Catch up without waiting
+				continue
+			}
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package relayer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	p2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/events"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/logger"
+	"go.uber.org/zap"
+)
+
+// Relayer submits finalized beacon rounds to an EVM contract through
+// go-ethereum's ethclient. Transactions are legacy EIP-155 transactions signed
+// with a secp256k1 key pair by types.SignTx, a transaction
+// that is not mined in time is replaced by one with the same nonce and a
+// higher gas price.
+
+// Gas price strategies
+const (
+	// GasFixed use configured gas price
+	GasFixed = "fixed"
+	// GasNode use gas price suggested by node, capped by max gas price
+	GasNode = "node"
+)
+
+// DefaultMethod contract method called with round, randomness, signature and
+// previous signature, it's submitRound of the OrochiBeacon binding
+const DefaultMethod = "submitRound(uint64,bytes32,bytes,bytes)"
+
+// gasBumpPercent gas price increase of each replacement transaction
+const gasBumpPercent = 20
+
+// queueSize calls waiting to be relayed, newer calls are dropped when it's full
+const queueSize = 64
+
+var errReverted = errors.New("transaction was reverted")
+
+// Config relayer configuration
+type Config struct {
+	// Endpoint JSON-RPC endpoint of an EVM node
+	Endpoint string
+	Contract common.Address
+	// ChainID of network, it's queried from node if nil
+	ChainID *big.Int
+	// Method signature of contract method, DefaultMethod if empty
+	Method      string
+	GasLimit    uint64
+	GasStrategy string
+	// GasPrice gas price of fixed strategy in wei
+	GasPrice *big.Int
+	// MaxGasPrice cap of gas price in wei including bumps, no cap if nil
+	MaxGasPrice *big.Int
+	// MaxAttempts transactions sent per round before it's given up
+	MaxAttempts int
+	// Backoff wait after first failed attempt, it doubles after each failure
+	Backoff time.Duration
+	// ReceiptTimeout wait for a transaction to be mined before it's replaced
+	ReceiptTimeout time.Duration
+}
+
+// call contract call that relayer sends a transaction of
+type call struct {
+	// label names call in logs, e.g. "round 12"
+	label    string
+	contract common.Address
+	data     []byte
+}
+
+// Relayer relay rounds to contract in order of submission
+type Relayer struct {
+	conf       Config
+	method     *method
+	key        *ecdsa.PrivateKey
+	address    common.Address
+	client     *ethclient.Client
+	queue      chan call
+	chainID    *big.Int
+	nonce      uint64
+	nonceKnown bool
+	context    context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+	gasMutex   sync.Mutex
+	// paused 1 while calls are dropped instead of relayed
+	paused int32
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("relayer")
+}
+
+// New create relayer signing transactions with a secp256k1 key pair
+func New(conf Config, key *keypair.KeyPair) (*Relayer, error) {
+	if conf.Endpoint == "" {
+		return nil, errors.New("relayer needs a JSON-RPC endpoint")
+	}
+	if conf.GasStrategy != GasFixed && conf.GasStrategy != GasNode {
+		return nil, errors.New("unknown gas price strategy: " + conf.GasStrategy)
+	}
+	if conf.GasStrategy == GasFixed && (conf.GasPrice == nil || conf.GasPrice.Sign() <= 0) {
+		return nil, errors.New("fixed gas price strategy needs a gas price")
+	}
+	if conf.Method == "" {
+		conf.Method = DefaultMethod
+	}
+	var m *method
+	var err error
+	if conf.Method == DefaultMethod {
+		m, err = beaconMethod("submitRound")
+	} else {
+		m, err = parseMethod(conf.Method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if conf.MaxAttempts < 1 {
+		conf.MaxAttempts = 1
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = 2 * time.Second
+	}
+	if conf.ReceiptTimeout <= 0 {
+		conf.ReceiptTimeout = time.Minute
+	}
+	privKey, err := secp256k1Key(key)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ethclient.Dial(conf.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Relayer{
+		conf:    conf,
+		method:  m,
+		key:     privKey,
+		address: crypto.PubkeyToAddress(privKey.PublicKey),
+		client:  client,
+		queue:   make(chan call, queueSize),
+		chainID: conf.ChainID,
+		context: ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// SetMaxGasPrice change cap of gas price in wei, nil removes the cap
+func (r *Relayer) SetMaxGasPrice(maxGasPrice *big.Int) {
+	r.gasMutex.Lock()
+	defer r.gasMutex.Unlock()
+	r.conf.MaxGasPrice = maxGasPrice
+}
+
+// KeyAddress address of account of a secp256k1 key pair
+func KeyAddress(key *keypair.KeyPair) (common.Address, error) {
+	privKey, err := secp256k1Key(key)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(privKey.PublicKey), nil
+}
+
+func secp256k1Key(key *keypair.KeyPair) (*ecdsa.PrivateKey, error) {
+	if key.GetKeyType() != int(p2pCrypto.Secp256k1) {
+		return nil, errors.New("relayer key must be a secp256k1 key")
+	}
+	raw, err := key.GetPrivateKey().Raw()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.ToECDSA(raw)
+}
+
+// Address account that sends transactions
+func (r *Relayer) Address() common.Address {
+	return r.address
+}
+
+// Start relay submitted rounds in background until relayer is stopped
+func (r *Relayer) Start() {
+	log.Infof("Relay rounds from %s to contract %s", r.address, r.conf.Contract)
+	go func() {
+		defer close(r.done)
+		for {
+			select {
+			case <-r.context.Done():
+				return
+			case c := <-r.queue:
+				if r.Paused() {
+					log.Infof("Relayer is paused, %s is not relayed", c.label)
+					continue
+				}
+				r.relay(&c)
+			}
+		}
+	}()
+}
+
+// Stop relaying, pending calls are dropped
+func (r *Relayer) Stop() {
+	r.cancel()
+	<-r.done
+}
+
+// Pause drop submitted calls until relayer is resumed, a call being relayed
+// is finished
+func (r *Relayer) Pause() {
+	if atomic.CompareAndSwapInt32(&r.paused, 0, 1) {
+		log.Info("Relayer paused")
+	}
+}
+
+// Resume relay calls submitted after it's called
+func (r *Relayer) Resume() {
+	if atomic.CompareAndSwapInt32(&r.paused, 1, 0) {
+		log.Info("Relayer resumed")
+	}
+}
+
+// Paused check whether submitted calls are dropped
+func (r *Relayer) Paused() bool {
+	return atomic.LoadInt32(&r.paused) == 1
+}
+
+// Submit queue a finalized round to be relayed. BLS signatures of threshold
+// groups are relayed uncompressed, as contracts can't afford to decompress them
+func (r *Relayer) Submit(result *beacon.RoundResult) {
+	var randomness [32]byte
+	copy(randomness[:], result.Randomness)
+	signature := result.Signature
+	if len(signature) == keypair.BLSSignatureSize {
+		encoded, err := keypair.EncodeG1EVM(signature)
+		if err != nil {
+			log.Warnf("Round %d is not relayed: %v", result.Round, err)
+			return
+		}
+		signature = encoded
+	}
+	data, err := r.method.pack(result.Round, randomness, signature, result.PreviousSignature)
+	if err != nil {
+		log.Warnf("Round %d is not relayed: %v", result.Round, err)
+		return
+	}
+	r.SubmitCall(fmt.Sprintf("round %d", result.Round), r.conf.Contract, data)
+}
+
+// SubmitCall queue a call of contract with ABI encoded calldata, label names
+// call in logs. Calls share queue, nonces and gas price strategy with rounds
+func (r *Relayer) SubmitCall(label string, contract common.Address, data []byte) {
+	select {
+	case r.queue <- call{label: label, contract: contract, data: data}:
+	default:
+		log.Warnf("Relayer queue is full, %s is not relayed", label)
+	}
+}
+
+// Subscribe relay rounds of the default beacon of node finalized on bus
+func (r *Relayer) Subscribe(bus *events.Bus) *events.Subscription {
+	return bus.Subscribe("relayer", func(event events.Event) {
+		if finalized := event.(events.RoundFinalized); finalized.Beacon == "" {
+			r.Submit(&finalized.Result)
+		}
+	}, events.TypeRoundFinalized)
+}
+
+// relay a call, retrying with backoff until it's mined or attempts run out
+func (r *Relayer) relay(c *call) {
+	var hashes []common.Hash
+	nonce := uint64(0)
+	backoff := r.conf.Backoff
+	for attempt := 1; attempt <= r.conf.MaxAttempts; attempt++ {
+		hash, err := r.attempt(c, attempt, &nonce, &hashes)
+		if err == nil {
+			log.Infof("Relayed %s in transaction %s", c.label, hash)
+			return
+		}
+		if err == errReverted {
+			log.Errorf("Contract rejected %s in transaction %s", c.label, hash)
+			return
+		}
+		if r.context.Err() != nil {
+			return
+		}
+		log.Warnf("Relay %s attempt %d failed: %v", c.label, attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-r.context.Done():
+			return
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+	log.Errorf("Give up relaying %s after %d attempts", c.label, r.conf.MaxAttempts)
+}
+
+// attempt send one transaction of call and wait for it or an earlier
+// transaction of the same call to be mined
+func (r *Relayer) attempt(c *call, attempt int, nonce *uint64, hashes *[]common.Hash) (common.Hash, error) {
+	ctx := r.context
+	if r.chainID == nil {
+		chainID, err := r.client.ChainID(ctx)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		r.chainID = chainID
+	}
+	if !r.nonceKnown {
+		pending, err := r.client.PendingNonceAt(ctx, r.address)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		r.nonce, r.nonceKnown = pending, true
+	}
+	// Retries reuse nonce so they replace transactions that are still pending
+	if len(*hashes) == 0 {
+		*nonce = r.nonce
+	}
+	gasPrice, err := r.gasPrice(attempt)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		Nonce:    *nonce,
+		GasPrice: gasPrice,
+		Gas:      r.conf.GasLimit,
+		To:       &c.contract,
+		Value:    new(big.Int),
+		Data:     c.data,
+	}), types.NewEIP155Signer(r.chainID), r.key)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	err = r.client.SendTransaction(ctx, tx)
+	switch {
+	case err == nil:
+		*hashes = append(*hashes, tx.Hash())
+	case hasMessage(err, "nonce too low"):
+		// Nonce was used, either by an earlier transaction of this call or by
+		// another sender of the same account
+		if mined, minedErr := r.waitMined(*hashes, 0); minedErr == nil || minedErr == errReverted {
+			r.nonce = *nonce + 1
+			return mined, minedErr
+		}
+		r.nonceKnown = false
+		*hashes = nil
+		return common.Hash{}, err
+	case hasMessage(err, "already known") && len(*hashes) > 0:
+		// Gas price reached its cap, the same transaction is still pending
+	default:
+		return common.Hash{}, err
+	}
+	mined, err := r.waitMined(*hashes, r.conf.ReceiptTimeout)
+	if err == nil || err == errReverted {
+		r.nonce = *nonce + 1
+	}
+	return mined, err
+}
+
+// waitMined poll receipts of transactions until one of them is mined or timeout expires
+func (r *Relayer) waitMined(hashes []common.Hash, timeout time.Duration) (common.Hash, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, hash := range hashes {
+			receipt, err := r.client.TransactionReceipt(r.context, hash)
+			if err != nil {
+				if err != ethereum.NotFound {
+					log.Debugf("Unable to get receipt of %s: %v", hash, err)
+				}
+				continue
+			}
+			if receipt.Status == types.ReceiptStatusFailed {
+				return hash, errReverted
+			}
+			return hash, nil
+		}
+		if !time.Now().Before(deadline) {
+			return common.Hash{}, errors.New("transaction was not mined in time")
+		}
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.context.Done():
+			return common.Hash{}, r.context.Err()
+		}
+	}
+}
+
+// gasPrice gas price of given attempt, each attempt bumps price so it can
+// replace the previous transaction
+func (r *Relayer) gasPrice(attempt int) (*big.Int, error) {
+	price := r.conf.GasPrice
+	if r.conf.GasStrategy == GasNode {
+		suggested, err := r.client.SuggestGasPrice(r.context)
+		if err != nil {
+			return nil, err
+		}
+		price = suggested
+	}
+	price = new(big.Int).Mul(price, big.NewInt(int64(100+gasBumpPercent*(attempt-1))))
+	price.Div(price, big.NewInt(100))
+	r.gasMutex.Lock()
+	maxGasPrice := r.conf.MaxGasPrice
+	r.gasMutex.Unlock()
+	if maxGasPrice != nil && price.Cmp(maxGasPrice) > 0 {
+		price = new(big.Int).Set(maxGasPrice)
+	}
+	return price, nil
+}
+
+// hasMessage check whether node error contains message
+func hasMessage(err error, message string) bool {
+	return strings.Contains(strings.ToLower(err.Error()), message)
+}
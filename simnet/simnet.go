@@ -0,0 +1,236 @@
+// Package simnet runs a beacon group of several nodes in one process, nodes
+// talk over an in-memory transport so no ports or containers are needed
+package simnet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/clock"
+	"github.com/orochi-network/orochimaru/dkg"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"go.uber.org/zap"
+)
+
+// Config parameters of a simulated group
+type Config struct {
+	Nodes     int
+	Threshold int
+	Period    time.Duration
+	// Mode chained or unchained
+	Mode         string
+	PhaseTimeout time.Duration
+	// Clock of beacons, a shared mock clock makes rounds progress only when
+	// it's advanced. Real time if it's nil
+	Clock clock.Clock
+}
+
+// Node member of a simulated group, Share is nil until DKG finished
+type Node struct {
+	Key     *keypair.KeyPair
+	Network *network.Network
+	Beacon  *beacon.Beacon
+	Share   *dkg.Result
+}
+
+// Simnet simulated group, nodes are fully connected to each other
+type Simnet struct {
+	Config Config
+	// Nodes in share order
+	Nodes    []*Node
+	mocknet  mocknet.Mocknet
+	results  chan beacon.RoundResult
+	started  bool
+	delivery sync.WaitGroup
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("simnet")
+}
+
+// New create nodes of a simulated group and connect each of them to the others
+func New(conf Config) (*Simnet, error) {
+	if conf.Nodes < 1 {
+		return nil, errors.New("simnet needs at least one node")
+	}
+	if conf.Threshold < 1 || conf.Threshold > conf.Nodes {
+		return nil, fmt.Errorf("threshold must be between 1 and %d", conf.Nodes)
+	}
+	if err := beacon.CheckPeriod(conf.Period); err != nil {
+		return nil, err
+	}
+	if conf.Mode != beacon.ModeChained && conf.Mode != beacon.ModeUnchained {
+		return nil, fmt.Errorf("unsupported mode %q, use %s or %s", conf.Mode, beacon.ModeChained, beacon.ModeUnchained)
+	}
+	s := &Simnet{
+		Config:  conf,
+		mocknet: mocknet.New(context.Background()),
+		results: make(chan beacon.RoundResult, 16),
+	}
+	for i := 0; i < conf.Nodes; i++ {
+		key, err := keypair.NewEd25519()
+		if err != nil {
+			return nil, err
+		}
+		// Addresses of discard prefix only name hosts, links carry messages
+		addr, err := multiaddr.NewMultiaddr(fmt.Sprintf("/ip6/100::%x/tcp/4001", i+1))
+		if err != nil {
+			return nil, err
+		}
+		host, err := s.mocknet.AddPeer(key.GetPrivateKey(), addr)
+		if err != nil {
+			return nil, err
+		}
+		s.Nodes = append(s.Nodes, &Node{
+			Key:     key,
+			Network: network.NewWithHost(host, network.Identifier{Name: network.Devnet}, key),
+		})
+	}
+	if err := s.mocknet.LinkAll(); err != nil {
+		return nil, err
+	}
+	if err := s.mocknet.ConnectAllButSelf(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Members peer IDs of nodes in share order
+func (s *Simnet) Members() []peer.ID {
+	members := make([]peer.ID, 0, len(s.Nodes))
+	for _, node := range s.Nodes {
+		members = append(members, node.Network.NodeID)
+	}
+	return members
+}
+
+// RunDKG run a DKG ceremony among all nodes and keep share of each node
+func (s *Simnet) RunDKG(ctx context.Context) error {
+	conf := dkg.Config{
+		Participants: s.Members(),
+		Threshold:    s.Config.Threshold,
+		PhaseTimeout: s.Config.PhaseTimeout,
+	}
+	protocols := make([]*dkg.Protocol, len(s.Nodes))
+	for i, node := range s.Nodes {
+		protocol, err := dkg.New(node.Network, conf)
+		if err != nil {
+			return err
+		}
+		protocols[i] = protocol
+	}
+	errs := make([]error, len(s.Nodes))
+	var wg sync.WaitGroup
+	for i := range s.Nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Nodes[i].Share, errs[i] = protocols[i].Run(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("DKG of node %d: %w", i+1, err)
+		}
+	}
+	groupKey := s.Nodes[0].Share.GroupKey()
+	for i, node := range s.Nodes {
+		if !bytes.Equal(node.Share.GroupKey(), groupKey) {
+			return fmt.Errorf("node %d derived another group key", i+1)
+		}
+	}
+	return nil
+}
+
+// GroupKey public key of group, nil before DKG
+func (s *Simnet) GroupKey() []byte {
+	if len(s.Nodes) == 0 || s.Nodes[0].Share == nil {
+		return nil
+	}
+	return s.Nodes[0].Share.GroupKey()
+}
+
+// Start beacons of all nodes with genesis at given time, DKG must have
+// finished
+func (s *Simnet) Start(genesis time.Time) error {
+	if s.GroupKey() == nil {
+		return errors.New("DKG has not finished")
+	}
+	if s.started {
+		return errors.New("simnet is already started")
+	}
+	s.started = true
+	members := s.Members()
+	for _, node := range s.Nodes {
+		node.Beacon = beacon.New(node.Network, node.Key, s.Config.Period)
+		node.Beacon.Genesis = genesis
+		node.Beacon.Mode = s.Config.Mode
+		node.Beacon.Members = members
+		if s.Config.Clock != nil {
+			node.Beacon.Clock = s.Config.Clock
+		}
+		if err := node.Beacon.SetThreshold(node.Share.Share, node.Share.PubPoly); err != nil {
+			return err
+		}
+	}
+	delivered := make(map[uint64][]byte)
+	var mutex sync.Mutex
+	for i, node := range s.Nodes {
+		node.Beacon.Start()
+		s.delivery.Add(1)
+		go func(i int, results <-chan beacon.RoundResult) {
+			defer s.delivery.Done()
+			for result := range results {
+				mutex.Lock()
+				randomness, ok := delivered[result.Round]
+				if !ok {
+					delivered[result.Round] = result.Randomness
+				}
+				mutex.Unlock()
+				if !ok {
+					s.results <- result
+				} else if !bytes.Equal(randomness, result.Randomness) {
+					log.Errorf("Node %d has another randomness of round %d", i+1, result.Round)
+				}
+			}
+		}(i, node.Beacon.Results())
+	}
+	go func() {
+		s.delivery.Wait()
+		close(s.results)
+	}()
+	return nil
+}
+
+// Results rounds of group, each round once as soon as the first node has it.
+// It's closed after Stop
+func (s *Simnet) Results() <-chan beacon.RoundResult {
+	return s.results
+}
+
+// Stop beacons and close hosts of all nodes
+func (s *Simnet) Stop() {
+	for _, node := range s.Nodes {
+		if node.Beacon != nil {
+			node.Beacon.Stop()
+		}
+	}
+	for _, node := range s.Nodes {
+		if err := node.Network.Close(); err != nil {
+			log.Warnf("Unable to close host of %s: %v", node.Network.NodeID, err)
+		}
+	}
+}
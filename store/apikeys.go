@@ -0,0 +1,259 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrKeyNotFound API key does not exist in store
+var ErrKeyNotFound = errors.New("API key not found")
+
+// APIKey key of an API consumer with its limits and usage. Only the SHA-256
+// of its secret is kept
+type APIKey struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	SecretHash []byte `json:"secretHash"`
+	// Rate requests per second after Burst requests at once, no limit if 0
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+	// Quota requests the key may make in total, no quota if 0
+	Quota uint64 `json:"quota"`
+	// Requests served and Rejected over rate limit or quota
+	Requests uint64    `json:"requests"`
+	Rejected uint64    `json:"rejected"`
+	Disabled bool      `json:"disabled"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// Usage requests of a key counted since usage was last added to store
+type Usage struct {
+	Requests uint64
+	Rejected uint64
+	LastUsed time.Time
+}
+
+// KeyStore storage of API keys, drivers that keep keys implement it
+type KeyStore interface {
+	// PutKey create or replace a key, usage counters are replaced as well
+	PutKey(key *APIKey) error
+	// GetKey key by its ID, ErrKeyNotFound if it's not stored
+	GetKey(id string) (*APIKey, error)
+	// ListKeys keys ordered by ID
+	ListKeys() ([]*APIKey, error)
+	// DeleteKey remove a key, ErrKeyNotFound if it's not stored
+	DeleteKey(id string) error
+	// AddUsage add usage to counters of keys, keys that were deleted are skipped.
+	// Nodes that share a database add their usage to the same counters
+	AddUsage(usage map[string]Usage) error
+}
+
+// APIKeys key store of driver, false if driver does not keep keys
+func (s *Store) APIKeys() (KeyStore, bool) {
+	keys, ok := s.Driver.(KeyStore)
+	return keys, ok
+}
+
+// API keys of bolt driver are kept in bucket apikeys of store file, as JSON
+// keyed by key ID
+
+// getKey key by its ID from apikeys bucket
+func getKey(bucket *bolt.Bucket, id string) (*APIKey, error) {
+	value := bucket.Get([]byte(id))
+	if value == nil {
+		return nil, ErrKeyNotFound
+	}
+	key := new(APIKey)
+	if err := json.Unmarshal(value, key); err != nil {
+		return nil, fmt.Errorf("invalid API key %s: %v", id, err)
+	}
+	return key, nil
+}
+
+// putKey write key to apikeys bucket
+func putKey(bucket *bolt.Bucket, key *APIKey) error {
+	value, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key.ID), value)
+}
+
+func (s *boltDriver) PutKey(key *APIKey) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putKey(tx.Bucket(bucketAPIKeys), key)
+	})
+}
+
+func (s *boltDriver) GetKey(id string) (*APIKey, error) {
+	var key *APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		key, err = getKey(tx.Bucket(bucketAPIKeys), id)
+		return err
+	})
+	return key, err
+}
+
+func (s *boltDriver) ListKeys() ([]*APIKey, error) {
+	var keys []*APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// Keys of bucket sort by ID
+		return tx.Bucket(bucketAPIKeys).ForEach(func(id []byte, value []byte) error {
+			key := new(APIKey)
+			if err := json.Unmarshal(value, key); err != nil {
+				return fmt.Errorf("invalid API key %s: %v", id, err)
+			}
+			keys = append(keys, key)
+			return nil
+		})
+	})
+	return keys, err
+}
+
+func (s *boltDriver) DeleteKey(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAPIKeys)
+		if bucket.Get([]byte(id)) == nil {
+			return ErrKeyNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+func (s *boltDriver) AddUsage(usage map[string]Usage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAPIKeys)
+		for id, u := range usage {
+			key, err := getKey(bucket, id)
+			if err == ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			key.Requests += u.Requests
+			key.Rejected += u.Rejected
+			if u.LastUsed.After(key.LastUsed) {
+				key.LastUsed = u.LastUsed
+			}
+			if err := putKey(bucket, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// postgresKeysSchema table of API keys, it's created when store is opened
+const postgresKeysSchema = `CREATE TABLE IF NOT EXISTS %s (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	secret_hash BYTEA NOT NULL,
+	rate DOUBLE PRECISION NOT NULL,
+	burst INTEGER NOT NULL,
+	quota BIGINT NOT NULL,
+	requests BIGINT NOT NULL,
+	rejected BIGINT NOT NULL,
+	disabled BOOLEAN NOT NULL,
+	created TIMESTAMPTZ NOT NULL,
+	last_used TIMESTAMPTZ
+)`
+
+const postgresKeyColumns = `id, name, secret_hash, rate, burst, quota, requests, rejected, disabled, created, last_used`
+
+// keysQuery insert table name of API keys into query
+func (s *postgresDriver) keysQuery(query string) string {
+	return fmt.Sprintf(query, s.keysTable)
+}
+
+func (s *postgresDriver) PutKey(key *APIKey) error {
+	var lastUsed interface{}
+	if !key.LastUsed.IsZero() {
+		lastUsed = key.LastUsed
+	}
+	_, err := s.db.Exec(s.keysQuery(`INSERT INTO %s (`+postgresKeyColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (id) DO UPDATE SET name = $2, secret_hash = $3, rate = $4, burst = $5, quota = $6, requests = $7,
+		rejected = $8, disabled = $9, created = $10, last_used = $11`),
+		key.ID, key.Name, key.SecretHash, key.Rate, key.Burst, int64(key.Quota), int64(key.Requests), int64(key.Rejected),
+		key.Disabled, key.Created, lastUsed)
+	return err
+}
+
+func (s *postgresDriver) GetKey(id string) (*APIKey, error) {
+	rows, err := s.db.Query(s.keysQuery(`SELECT `+postgresKeyColumns+` FROM %s WHERE id = $1`), id)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := scanKeys(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return keys[0], nil
+}
+
+func (s *postgresDriver) ListKeys() ([]*APIKey, error) {
+	rows, err := s.db.Query(s.keysQuery(`SELECT ` + postgresKeyColumns + ` FROM %s ORDER BY id`))
+	if err != nil {
+		return nil, err
+	}
+	return scanKeys(rows)
+}
+
+func (s *postgresDriver) DeleteKey(id string) error {
+	result, err := s.db.Exec(s.keysQuery(`DELETE FROM %s WHERE id = $1`), id)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+func (s *postgresDriver) AddUsage(usage map[string]Usage) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for id, u := range usage {
+		_, err := tx.Exec(s.keysQuery(`UPDATE %s SET requests = requests + $2, rejected = rejected + $3,
+			last_used = GREATEST(COALESCE(last_used, $4), $4) WHERE id = $1`),
+			id, int64(u.Requests), int64(u.Rejected), u.LastUsed)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// scanKeys read keys selected with postgresKeyColumns and close rows
+func scanKeys(rows *sql.Rows) ([]*APIKey, error) {
+	defer rows.Close()
+	var keys []*APIKey
+	for rows.Next() {
+		key := new(APIKey)
+		var quota, requests, rejected int64
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, &key.SecretHash, &key.Rate, &key.Burst, &quota, &requests, &rejected,
+			&key.Disabled, &key.Created, &lastUsed); err != nil {
+			return nil, err
+		}
+		key.Quota, key.Requests, key.Rejected = uint64(quota), uint64(requests), uint64(rejected)
+		if lastUsed.Valid {
+			key.LastUsed = lastUsed.Time
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// An archive holds a range of rounds with the chain info they verify against:
+//
+//	magic line | JSON header line | records in store file format
+//
+// Header carries chain hash, so an archive is checked against a pinned chain
+// as well as a group key.
+
+// archiveMagic first line of archive files
+const archiveMagic = "orochi-drng-archive-v1\n"
+
+// ArchiveHeader chain info and range of archived rounds
+type ArchiveHeader struct {
+	PublicKey   string `json:"publicKey"`
+	Period      uint64 `json:"period"`
+	GenesisTime int64  `json:"genesisTime"`
+	Mode        string `json:"mode"`
+	Scheme      string `json:"scheme,omitempty"`
+	Hash        string `json:"hash"`
+	From        uint64 `json:"from"`
+	To          uint64 `json:"to"`
+}
+
+// ChainInfo decode chain info of header and check it against header hash
+func (h *ArchiveHeader) ChainInfo() (beacon.ChainInfo, error) {
+	publicKey, err := hex.DecodeString(h.PublicKey)
+	if err != nil {
+		return beacon.ChainInfo{}, err
+	}
+	info := beacon.ChainInfo{
+		PublicKey:   publicKey,
+		Period:      time.Duration(h.Period) * time.Second,
+		GenesisTime: time.Unix(h.GenesisTime, 0),
+		Mode:        h.Mode,
+		Scheme:      h.Scheme,
+	}
+	if info.Scheme == "" {
+		info.Scheme = keypair.SchemeBLS
+	}
+	if hex.EncodeToString(info.Hash()) != h.Hash {
+		return beacon.ChainInfo{}, errors.New("archive hash does not match its chain info")
+	}
+	return info, nil
+}
+
+// archiveBatch rounds that ExportPaced writes between calls of pace
+const archiveBatch = 1000
+
+// Export write rounds from round from to round to of chain into an archive,
+// return number of written rounds
+func (s *Store) Export(w io.Writer, info beacon.ChainInfo, from uint64, to uint64) (int, error) {
+	return s.ExportPaced(w, info, from, to, nil)
+}
+
+// ExportPaced Export that calls pace after every archiveBatch rounds, pace may
+// block to defer export and export stops with its error
+func (s *Store) ExportPaced(w io.Writer, info beacon.ChainInfo, from uint64, to uint64, pace func() error) (int, error) {
+	if from > to {
+		return 0, errors.New("first round is after last round")
+	}
+	header, err := json.Marshal(ArchiveHeader{
+		PublicKey:   hex.EncodeToString(info.PublicKey),
+		Period:      uint64(info.Period.Seconds()),
+		GenesisTime: info.GenesisTime.Unix(),
+		Mode:        info.Mode,
+		Scheme:      info.Scheme,
+		Hash:        hex.EncodeToString(info.Hash()),
+		From:        from,
+		To:          to,
+	})
+	if err != nil {
+		return 0, err
+	}
+	writer := bufio.NewWriter(w)
+	writer.WriteString(archiveMagic)
+	writer.Write(append(header, '\n'))
+	count := 0
+	cursor := s.Cursor()
+	for result, err := cursor.Seek(from); result != nil || err != nil; result, err = cursor.Next() {
+		if err != nil {
+			return count, err
+		}
+		if result.Round > to {
+			break
+		}
+		record, err := encodeRecord(result)
+		if err != nil {
+			return count, err
+		}
+		if _, err := writer.Write(record); err != nil {
+			return count, err
+		}
+		count++
+		if pace != nil && count%archiveBatch == 0 {
+			if err := writer.Flush(); err != nil {
+				return count, err
+			}
+			if err := pace(); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, writer.Flush()
+}
+
+// ArchiveReader read rounds of an archive in order
+type ArchiveReader struct {
+	Header *ArchiveHeader
+	reader *bufio.Reader
+}
+
+// OpenArchive read header of an archive
+func OpenArchive(r io.Reader) (*ArchiveReader, error) {
+	reader := bufio.NewReader(r)
+	magic, err := reader.ReadString('\n')
+	if err != nil || magic != archiveMagic {
+		return nil, errors.New("not an archive file")
+	}
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.New("archive header is truncated")
+	}
+	header := new(ArchiveHeader)
+	if err := json.Unmarshal(line, header); err != nil {
+		return nil, fmt.Errorf("invalid archive header: %v", err)
+	}
+	return &ArchiveReader{Header: header, reader: reader}, nil
+}
+
+// Next read next round, nil at the end of archive
+func (a *ArchiveReader) Next() (*beacon.RoundResult, error) {
+	result, _, err := readRecord(a.reader)
+	if err == io.EOF {
+		return nil, nil
+	}
+	return result, err
+}
+
+// VerifyArchive check every round of an archive against chain info of its
+// header. Rounds must be in range of header and in ascending order, and
+// consecutive chained rounds must link to each other. Group key of header
+// must equal given group key unless it's nil. Return header and number of
+// rounds
+func VerifyArchive(r io.Reader, groupKey []byte) (*ArchiveHeader, int, error) {
+	archive, err := OpenArchive(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	header := archive.Header
+	info, err := header.ChainInfo()
+	if err != nil {
+		return header, 0, err
+	}
+	if groupKey != nil && !bytes.Equal(groupKey, info.PublicKey) {
+		return header, 0, errors.New("archive belongs to another group")
+	}
+	count := 0
+	var last *beacon.RoundResult
+	for {
+		result, err := archive.Next()
+		if err != nil {
+			return header, count, fmt.Errorf("record after round %d: %v", lastRound(last), err)
+		}
+		if result == nil {
+			return header, count, nil
+		}
+		if result.Round < header.From || result.Round > header.To || last != nil && result.Round <= last.Round {
+			return header, count, fmt.Errorf("round %d is out of order", result.Round)
+		}
+		if info.Mode == beacon.ModeUnchained && len(result.PreviousSignature) != 0 {
+			return header, count, fmt.Errorf("unchained round %d carries previous signature", result.Round)
+		}
+		if info.Mode != beacon.ModeUnchained && last != nil && last.Round+1 == result.Round && !bytes.Equal(last.Signature, result.PreviousSignature) {
+			return header, count, fmt.Errorf("round %d does not link to round %d", result.Round, last.Round)
+		}
+		if err := beacon.Verify(info.Scheme, info.PublicKey, result); err != nil {
+			return header, count, fmt.Errorf("round %d is invalid: %v", result.Round, err)
+		}
+		last = result
+		count++
+	}
+}
+
+func lastRound(result *beacon.RoundResult) uint64 {
+	if result == nil {
+		return 0
+	}
+	return result.Round
+}
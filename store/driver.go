@@ -0,0 +1,173 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/orochi-network/orochimaru/beacon"
+)
+
+// Driver storage backend of rounds, implementations must be safe for
+// concurrent use
+type Driver interface {
+	// Put persist a round, a stored round is replaced
+	Put(result *beacon.RoundResult) error
+	// Get round by its number, ErrNotFound if it's not stored
+	Get(round uint64) (*beacon.RoundResult, error)
+	// Latest get round with the highest number
+	Latest() (*beacon.RoundResult, error)
+	// Seek get the first round that is greater than or equal to given round
+	Seek(round uint64) (*beacon.RoundResult, error)
+	// Prune remove rounds numbered below given round, return number of
+	// removed rounds
+	Prune(before uint64) (int, error)
+	// Len number of stored rounds
+	Len() (int, error)
+	// Writable check that backend still accepts writes
+	Writable() error
+	Close() error
+}
+
+// OpenFunc open a driver with its data source, e.g. a file name or a
+// connection string. Namespace separates chains kept in one data source, it's
+// empty for the default chain of a node
+type OpenFunc func(source string, namespace string) (Driver, error)
+
+// Names of drivers shipped with store
+const (
+	// DriverBolt embedded BoltDB file
+	DriverBolt = "bolt"
+	// DriverPostgres PostgreSQL database shared by several nodes
+	DriverPostgres = "postgres"
+)
+
+// validNamespace namespaces are used in file and table names
+var validNamespace = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+var (
+	drivers     = make(map[string]OpenFunc)
+	driverMutex sync.RWMutex
+)
+
+func init() {
+	Register(DriverBolt, openBolt)
+	Register(DriverPostgres, openPostgres)
+}
+
+// Register make a driver available by name, registering a name twice panics
+func Register(name string, open OpenFunc) {
+	driverMutex.Lock()
+	defer driverMutex.Unlock()
+	if _, ok := drivers[name]; ok {
+		panic("store driver registered twice: " + name)
+	}
+	drivers[name] = open
+}
+
+// Drivers names of registered drivers in alphabetical order
+func Drivers() []string {
+	driverMutex.RLock()
+	defer driverMutex.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Store persist beacon rounds with a driver
+type Store struct {
+	Driver
+}
+
+// Open open or create a store file
+func Open(fileName string) (*Store, error) {
+	return OpenDriver(DriverBolt, fileName)
+}
+
+// OpenDriver open store with a registered driver
+func OpenDriver(name string, source string) (*Store, error) {
+	return OpenNamespace(name, source, "")
+}
+
+// ValidNamespace check that namespace is 1 to 32 lowercase letters, digits
+// and underscores
+func ValidNamespace(namespace string) bool {
+	return validNamespace.MatchString(namespace)
+}
+
+// OpenNamespace open store of a namespace with a registered driver, stores of
+// different namespaces may share a data source
+func OpenNamespace(name string, source string, namespace string) (*Store, error) {
+	if namespace != "" && !ValidNamespace(namespace) {
+		return nil, fmt.Errorf("invalid store namespace: %s", namespace)
+	}
+	driverMutex.RLock()
+	open, ok := drivers[name]
+	driverMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown store driver: %s", name)
+	}
+	driver, err := open(source, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Driver: driver}, nil
+}
+
+// Retain prune all but the latest count rounds, rounds skipped by the group
+// count as well so retained rounds span count periods
+func (s *Store) Retain(count uint64) (int, error) {
+	latest, err := s.Latest()
+	if err == ErrNotFound || err == nil && latest.Round < count {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return s.Prune(latest.Round - count + 1)
+}
+
+// Cursor iterate stored rounds in ascending order
+func (s *Store) Cursor() *Cursor {
+	return &Cursor{store: s}
+}
+
+// Cursor iterate rounds of a store in ascending order, methods return nil
+// result when there is no more round
+type Cursor struct {
+	store *Store
+	round uint64
+	valid bool
+}
+
+// First move cursor to the first round
+func (c *Cursor) First() (*beacon.RoundResult, error) {
+	return c.Seek(0)
+}
+
+// Next move cursor to the next round
+func (c *Cursor) Next() (*beacon.RoundResult, error) {
+	if !c.valid {
+		return nil, nil
+	}
+	return c.Seek(c.round + 1)
+}
+
+// Seek move cursor to the first round that is greater than or equal to given round
+func (c *Cursor) Seek(round uint64) (*beacon.RoundResult, error) {
+	result, err := c.store.Driver.Seek(round)
+	if err == ErrNotFound {
+		c.valid = false
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.round = result.Round
+	c.valid = true
+	return result, nil
+}
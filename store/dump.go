@@ -0,0 +1,189 @@
+package store
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+)
+
+// Formats of Dump
+const (
+	// DumpCSV comma-separated values with a header row of field names
+	DumpCSV = "csv"
+	// DumpJSONL one JSON object per round and line
+	DumpJSONL = "jsonl"
+)
+
+// Fields of a round that Dump writes
+const (
+	FieldRound             = "round"
+	FieldRandomness        = "randomness"
+	FieldSignature         = "signature"
+	FieldPreviousSignature = "previous_signature"
+	// FieldTime start of round in RFC 3339, it needs DumpOptions.RoundTime
+	FieldTime = "time"
+)
+
+// DumpFields every field Dump can write, in default order
+var DumpFields = []string{FieldRound, FieldRandomness, FieldSignature, FieldPreviousSignature, FieldTime}
+
+// DumpOptions rounds and fields that Dump writes
+type DumpOptions struct {
+	Format string
+	// Fields written for each round in this order, bytes are hex
+	Fields []string
+	// From first round, To last round, the latest round if it's 0
+	From uint64
+	To   uint64
+	// RoundTime time a round starts, required by FieldTime
+	RoundTime func(round uint64) time.Time
+}
+
+// dumpWriter write rounds in one format
+type dumpWriter interface {
+	header(fields []string) error
+	row(fields []string, values []interface{}) error
+	flush() error
+}
+
+// Dump stream stored rounds from From to To to w for analysis, rounds are
+// written as read so history of any length fits in memory. Return number of
+// written rounds
+func (s *Store) Dump(w io.Writer, opts DumpOptions) (int, error) {
+	if len(opts.Fields) == 0 {
+		return 0, errors.New("no field to dump")
+	}
+	for _, field := range opts.Fields {
+		if !validDumpField(field) {
+			return 0, fmt.Errorf("unknown field %q", field)
+		}
+		if field == FieldTime && opts.RoundTime == nil {
+			return 0, errors.New("time field needs chain info of group")
+		}
+	}
+	if opts.To != 0 && opts.From > opts.To {
+		return 0, errors.New("first round is after last round")
+	}
+	var writer dumpWriter
+	switch opts.Format {
+	case DumpCSV:
+		writer = &csvDumpWriter{writer: csv.NewWriter(w)}
+	case DumpJSONL:
+		writer = &jsonlDumpWriter{writer: bufio.NewWriter(w)}
+	default:
+		return 0, fmt.Errorf("unknown dump format %q, use %s or %s", opts.Format, DumpCSV, DumpJSONL)
+	}
+	if err := writer.header(opts.Fields); err != nil {
+		return 0, err
+	}
+	count := 0
+	values := make([]interface{}, len(opts.Fields))
+	cursor := s.Cursor()
+	for result, err := cursor.Seek(opts.From); result != nil || err != nil; result, err = cursor.Next() {
+		if err != nil {
+			return count, err
+		}
+		if opts.To != 0 && result.Round > opts.To {
+			break
+		}
+		for i, field := range opts.Fields {
+			values[i] = dumpValue(result, field, opts.RoundTime)
+		}
+		if err := writer.row(opts.Fields, values); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, writer.flush()
+}
+
+func validDumpField(field string) bool {
+	for _, f := range DumpFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpValue value of a field, round number is uint64 and other fields strings
+func dumpValue(result *beacon.RoundResult, field string, roundTime func(uint64) time.Time) interface{} {
+	switch field {
+	case FieldRound:
+		return result.Round
+	case FieldRandomness:
+		return hex.EncodeToString(result.Randomness)
+	case FieldSignature:
+		return hex.EncodeToString(result.Signature)
+	case FieldPreviousSignature:
+		return hex.EncodeToString(result.PreviousSignature)
+	case FieldTime:
+		return roundTime(result.Round).UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+type csvDumpWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (c *csvDumpWriter) header(fields []string) error {
+	c.record = make([]string, len(fields))
+	return c.writer.Write(fields)
+}
+
+func (c *csvDumpWriter) row(fields []string, values []interface{}) error {
+	for i, value := range values {
+		if round, ok := value.(uint64); ok {
+			c.record[i] = strconv.FormatUint(round, 10)
+		} else {
+			c.record[i] = value.(string)
+		}
+	}
+	return c.writer.Write(c.record)
+}
+
+func (c *csvDumpWriter) flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonlDumpWriter write fields of each round in given order
+type jsonlDumpWriter struct {
+	writer *bufio.Writer
+}
+
+func (j *jsonlDumpWriter) header(fields []string) error {
+	return nil
+}
+
+func (j *jsonlDumpWriter) row(fields []string, values []interface{}) error {
+	j.writer.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			j.writer.WriteByte(',')
+		}
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		j.writer.WriteString(strconv.Quote(field))
+		j.writer.WriteByte(':')
+		j.writer.Write(value)
+	}
+	_, err := j.writer.WriteString("}\n")
+	return err
+}
+
+func (j *jsonlDumpWriter) flush() error {
+	return j.writer.Flush()
+}
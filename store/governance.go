@@ -0,0 +1,92 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/orochi-network/orochimaru/governance"
+	bolt "go.etcd.io/bbolt"
+)
+
+// GovernanceStore storage of applied governance messages as part of chain
+// history, drivers that keep them implement it
+type GovernanceStore interface {
+	// PutGovernance record an applied message under its proposal nonce
+	PutGovernance(m *governance.Message) error
+	// GovernanceHistory recorded messages in nonce order
+	GovernanceHistory() ([]*governance.Message, error)
+}
+
+// Governance governance store of driver, false if driver does not keep
+// governance messages
+func (s *Store) Governance() (GovernanceStore, bool) {
+	history, ok := s.Driver.(GovernanceStore)
+	return history, ok
+}
+
+// Governance messages of bolt driver are kept in bucket governance of store
+// file, as JSON keyed by big-endian proposal nonce
+
+func (s *boltDriver) PutGovernance(m *governance.Message) error {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketGovernance).Put(roundKey(m.Proposal.Nonce), value)
+	})
+}
+
+func (s *boltDriver) GovernanceHistory() ([]*governance.Message, error) {
+	var history []*governance.Message
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketGovernance).ForEach(func(key []byte, value []byte) error {
+			m, err := governance.Decode(value)
+			if err != nil {
+				return fmt.Errorf("invalid governance message %x: %v", key, err)
+			}
+			history = append(history, m)
+			return nil
+		})
+	})
+	return history, err
+}
+
+// postgresGovernanceSchema table of governance messages, it's created when
+// store is opened
+const postgresGovernanceSchema = `CREATE TABLE IF NOT EXISTS %s (
+	nonce BIGINT PRIMARY KEY,
+	message BYTEA NOT NULL
+)`
+
+func (s *postgresDriver) PutGovernance(m *governance.Message) error {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf(`INSERT INTO %s (nonce, message) VALUES ($1, $2)
+		ON CONFLICT (nonce) DO UPDATE SET message = $2`, s.governanceTable), int64(m.Proposal.Nonce), value)
+	return err
+}
+
+func (s *postgresDriver) GovernanceHistory() ([]*governance.Message, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`SELECT message FROM %s ORDER BY nonce`, s.governanceTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []*governance.Message
+	for rows.Next() {
+		var value sql.RawBytes
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		m, err := governance.Decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid governance message: %v", err)
+		}
+		history = append(history, m)
+	}
+	return history, rows.Err()
+}
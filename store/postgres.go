@@ -0,0 +1,139 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/orochi-network/orochimaru/beacon"
+)
+
+// ErrNoPostgres binary was built without a PostgreSQL driver
+var ErrNoPostgres = errors.New("PostgreSQL support is not built in, rebuild with -tags postgres")
+
+// postgresSchema table of rounds, it's created when store is opened
+const postgresSchema = `CREATE TABLE IF NOT EXISTS %s (
+	round BIGINT PRIMARY KEY,
+	randomness BYTEA NOT NULL,
+	signature BYTEA NOT NULL,
+	previous_signature BYTEA
+)`
+
+// postgresDriver driver keeping rounds in a PostgreSQL table, several nodes
+// may share it to serve the same chain
+type postgresDriver struct {
+	db *sql.DB
+	// table of rounds, rounds_<namespace> for a namespace
+	table string
+	// keysTable table of API keys, api_keys_<namespace> for a namespace
+	keysTable string
+	// governanceTable table of governance messages, governance_<namespace>
+	// for a namespace
+	governanceTable string
+}
+
+// openPostgres connect to database of given connection string, e.g.
+// postgres://drng:secret@db:5432/drng?sslmode=disable
+func openPostgres(source string, namespace string) (Driver, error) {
+	if !hasSQLDriver("postgres") {
+		return nil, ErrNoPostgres
+	}
+	db, err := sql.Open("postgres", source)
+	if err != nil {
+		return nil, err
+	}
+	table, keysTable, governanceTable := "rounds", "api_keys", "governance"
+	if namespace != "" {
+		table += "_" + namespace
+		keysTable += "_" + namespace
+		governanceTable += "_" + namespace
+	}
+	schemas := []string{
+		fmt.Sprintf(postgresSchema, table),
+		fmt.Sprintf(postgresKeysSchema, keysTable),
+		fmt.Sprintf(postgresGovernanceSchema, governanceTable),
+	}
+	for _, schema := range schemas {
+		if _, err := db.Exec(schema); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return &postgresDriver{db: db, table: table, keysTable: keysTable, governanceTable: governanceTable}, nil
+}
+
+func hasSQLDriver(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *postgresDriver) Put(result *beacon.RoundResult) error {
+	_, err := s.db.Exec(s.query(`INSERT INTO %s (round, randomness, signature, previous_signature) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (round) DO UPDATE SET randomness = $2, signature = $3, previous_signature = $4`),
+		int64(result.Round), result.Randomness, result.Signature, result.PreviousSignature)
+	return err
+}
+
+func (s *postgresDriver) Get(round uint64) (*beacon.RoundResult, error) {
+	return s.queryRound(s.query(`SELECT round, randomness, signature, previous_signature FROM %s WHERE round = $1`), int64(round))
+}
+
+func (s *postgresDriver) Latest() (*beacon.RoundResult, error) {
+	return s.queryRound(s.query(`SELECT round, randomness, signature, previous_signature FROM %s ORDER BY round DESC LIMIT 1`))
+}
+
+func (s *postgresDriver) Seek(round uint64) (*beacon.RoundResult, error) {
+	return s.queryRound(s.query(`SELECT round, randomness, signature, previous_signature FROM %s WHERE round >= $1 ORDER BY round LIMIT 1`), int64(round))
+}
+
+// query insert table name of driver into query
+func (s *postgresDriver) query(query string) string {
+	return fmt.Sprintf(query, s.table)
+}
+
+// queryRound scan the single round selected by query
+func (s *postgresDriver) queryRound(query string, args ...interface{}) (*beacon.RoundResult, error) {
+	var round int64
+	result := new(beacon.RoundResult)
+	err := s.db.QueryRow(query, args...).Scan(&round, &result.Randomness, &result.Signature, &result.PreviousSignature)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Round = uint64(round)
+	// Empty fields are nil, as in file store
+	if len(result.PreviousSignature) == 0 {
+		result.PreviousSignature = nil
+	}
+	return result, nil
+}
+
+func (s *postgresDriver) Prune(before uint64) (int, error) {
+	result, err := s.db.Exec(s.query(`DELETE FROM %s WHERE round < $1`), int64(before))
+	if err != nil {
+		return 0, err
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
+
+func (s *postgresDriver) Len() (int, error) {
+	var count int
+	err := s.db.QueryRow(s.query(`SELECT COUNT(*) FROM %s`)).Scan(&count)
+	return count, err
+}
+
+// Writable check that database is reachable
+func (s *postgresDriver) Writable() error {
+	return s.db.Ping()
+}
+
+func (s *postgresDriver) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,7 @@
+//go:build postgres
+// +build postgres
+
+package store
+
+// PostgreSQL driver of database/sql
+import _ "github.com/lib/pq"
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/logger"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// Rounds are kept in a BoltDB file, in bucket rounds keyed by big-endian round
+// number. Each value is a record:
+//
+//	round (8) | randomness length (2) | randomness | signature length (2) | signature
+//	| previous signature length (2) | previous signature | crc32 of previous fields (4)
+//
+// Archives use the same record format.
+
+// ErrNotFound round does not exist in store
+var ErrNotFound = errors.New("round not found")
+
+var errCorruptRecord = errors.New("corrupt round record")
+
+// maxFieldSize largest field that fits the 2 bytes length prefix
+const maxFieldSize = 0xffff
+
+// lockTimeout how long opening a store waits for another process to release it
+const lockTimeout = time.Second
+
+var (
+	bucketRounds     = []byte("rounds")
+	bucketAPIKeys    = []byte("apikeys")
+	bucketGovernance = []byte("governance")
+)
+
+// boltDriver embedded driver keeping rounds in a BoltDB file, it needs no
+// external service
+type boltDriver struct {
+	db *bolt.DB
+}
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("store")
+}
+
+// openBolt open or create a store file, a namespace is kept in a file next
+// to it, e.g. rounds-fast.db
+func openBolt(fileName string, namespace string) (Driver, error) {
+	if namespace != "" {
+		ext := filepath.Ext(fileName)
+		fileName = strings.TrimSuffix(fileName, ext) + "-" + namespace + ext
+	}
+	db, err := bolt.Open(fileName, 0644, &bolt.Options{Timeout: lockTimeout})
+	if err == bolt.ErrTimeout {
+		return nil, fmt.Errorf("store %s is in use by another process", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open store %s: %v", fileName, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketRounds, bucketAPIKeys, bucketGovernance} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltDriver{db: db}, nil
+}
+
+// roundKey key of a round in rounds bucket, keys sort in round order
+func roundKey(round uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, round)
+	return key
+}
+
+// decodeValue decode a record read from rounds bucket, value is only valid
+// during its transaction so it's copied
+func decodeValue(value []byte) (*beacon.RoundResult, error) {
+	result, _, err := readRecord(bufio.NewReader(bytes.NewReader(value)))
+	return result, err
+}
+
+// Put persist a round, a stored round is replaced
+func (s *boltDriver) Put(result *beacon.RoundResult) error {
+	record, err := encodeRecord(result)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRounds).Put(roundKey(result.Round), record)
+	})
+}
+
+// Get round by its number
+func (s *boltDriver) Get(round uint64) (*beacon.RoundResult, error) {
+	var result *beacon.RoundResult
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucketRounds).Get(roundKey(round))
+		if value == nil {
+			return ErrNotFound
+		}
+		var err error
+		result, err = decodeValue(value)
+		return err
+	})
+	return result, err
+}
+
+// Latest get round with the highest number
+func (s *boltDriver) Latest() (*beacon.RoundResult, error) {
+	var result *beacon.RoundResult
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, value := tx.Bucket(bucketRounds).Cursor().Last()
+		if value == nil {
+			return ErrNotFound
+		}
+		var err error
+		result, err = decodeValue(value)
+		return err
+	})
+	return result, err
+}
+
+// Len number of stored rounds
+func (s *boltDriver) Len() (int, error) {
+	var count int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(bucketRounds).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// Writable check that store file still accepts writes, it commits an empty
+// transaction which syncs the file
+func (s *boltDriver) Writable() error {
+	return s.db.Update(func(tx *bolt.Tx) error { return nil })
+}
+
+// Seek get the first round that is greater than or equal to given round
+func (s *boltDriver) Seek(round uint64) (*beacon.RoundResult, error) {
+	var result *beacon.RoundResult
+	err := s.db.View(func(tx *bolt.Tx) error {
+		_, value := tx.Bucket(bucketRounds).Cursor().Seek(roundKey(round))
+		if value == nil {
+			return ErrNotFound
+		}
+		var err error
+		result, err = decodeValue(value)
+		return err
+	})
+	return result, err
+}
+
+// Prune remove rounds numbered below given round in one transaction
+func (s *boltDriver) Prune(before uint64) (int, error) {
+	pruned := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketRounds).Cursor()
+		end := roundKey(before)
+		for key, _ := cursor.First(); key != nil && bytes.Compare(key, end) < 0; key, _ = cursor.First() {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+			pruned++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return pruned, nil
+}
+
+// Close store file
+func (s *boltDriver) Close() error {
+	return s.db.Close()
+}
+
+// encodeRecord encode round as a store record
+func encodeRecord(result *beacon.RoundResult) ([]byte, error) {
+	fields := [][]byte{result.Randomness, result.Signature, result.PreviousSignature}
+	size := 8 + 4
+	for _, field := range fields {
+		if len(field) > maxFieldSize {
+			return nil, errors.New("round field is too large")
+		}
+		size += 2 + len(field)
+	}
+	record := make([]byte, 8, size)
+	binary.BigEndian.PutUint64(record, result.Round)
+	for _, field := range fields {
+		record = append(record, byte(len(field)>>8), byte(len(field)))
+		record = append(record, field...)
+	}
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(record))
+	return append(record, checksum...), nil
+}
+
+// readRecord read one record, return io.EOF only when reader is at a record boundary
+func readRecord(reader *bufio.Reader) (*beacon.RoundResult, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, errCorruptRecord
+	}
+	record := header
+	fields := make([][]byte, 3)
+	for i := range fields {
+		length := make([]byte, 2)
+		if _, err := io.ReadFull(reader, length); err != nil {
+			return nil, 0, errCorruptRecord
+		}
+		field := make([]byte, binary.BigEndian.Uint16(length))
+		if _, err := io.ReadFull(reader, field); err != nil {
+			return nil, 0, errCorruptRecord
+		}
+		record = append(record, length...)
+		record = append(record, field...)
+		if len(field) > 0 {
+			fields[i] = field
+		}
+	}
+	checksum := make([]byte, 4)
+	if _, err := io.ReadFull(reader, checksum); err != nil {
+		return nil, 0, errCorruptRecord
+	}
+	if binary.BigEndian.Uint32(checksum) != crc32.ChecksumIEEE(record) {
+		return nil, 0, errCorruptRecord
+	}
+	return &beacon.RoundResult{
+		Round:             binary.BigEndian.Uint64(header),
+		Randomness:        fields[0],
+		Signature:         fields[1],
+		PreviousSignature: fields[2],
+	}, int64(len(record) + 4), nil
+}
@@ -0,0 +1,145 @@
+package tlock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/keypair"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Timelock encryption toward rounds of an unchained beacon. The signature of a
+// round is a BLS signature over a message known in advance, so it serves as
+// the private key of identity based encryption where the round is the
+// identity. Data is encrypted with XChaCha20-Poly1305 under a key derived
+// from the pairing of the round message and group public key
+
+// Cipher name of cipher of ciphertexts
+const Cipher = "bls12-381-ibe+xchacha20-poly1305"
+
+// keyDomain domain separation tag of derived keys
+var keyDomain = []byte("OROCHI-TLOCK-BLS12381")
+
+// ErrRoundMismatch round does not match round that data was encrypted to
+var ErrRoundMismatch = errors.New("round does not match round of ciphertext")
+
+// Ciphertext data encrypted toward a round
+type Ciphertext struct {
+	Cipher     string `json:"cipher"`
+	Round      uint64 `json:"round"`
+	Ephemeral  []byte `json:"ephemeral"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt plaintext so it can only be decrypted with signature of given round
+// of group, group must run in unchained mode
+func Encrypt(groupKey []byte, round uint64, plaintext []byte) (*Ciphertext, error) {
+	if round == 0 {
+		return nil, errors.New("round must be greater than 0")
+	}
+	public, err := keypair.DecodeG2(groupKey)
+	if err != nil || bls12381.NewG2().IsZero(public) {
+		return nil, errors.New("invalid group public key")
+	}
+	hashed, err := roundPoint(round)
+	if err != nil {
+		return nil, err
+	}
+	r, err := keypair.RandomScalar(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	gt := bls12381.NewGT()
+	shared := gt.New()
+	gt.Exp(shared, bls12381.NewEngine().AddPair(hashed, public).Result(), r)
+	c := &Ciphertext{
+		Cipher:    Cipher,
+		Round:     round,
+		Ephemeral: bls12381.NewG2().ToCompressed(keypair.G2Base(r)),
+		Nonce:     make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(c.Nonce); err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(deriveKey(shared, c.Ephemeral))
+	if err != nil {
+		return nil, err
+	}
+	c.Ciphertext = aead.Seal(nil, c.Nonce, plaintext, additionalData(c))
+	return c, nil
+}
+
+// Decrypt ciphertext with published round, round is verified against group key
+func Decrypt(groupKey []byte, result *beacon.RoundResult, c *Ciphertext) ([]byte, error) {
+	if c.Cipher != Cipher {
+		return nil, fmt.Errorf("unsupported cipher: %s", c.Cipher)
+	}
+	if result.Round != c.Round {
+		return nil, ErrRoundMismatch
+	}
+	if len(result.PreviousSignature) != 0 {
+		return nil, errors.New("round is chained, timelock needs an unchained beacon")
+	}
+	if err := beacon.Verify(keypair.SchemeBLS, groupKey, result); err != nil {
+		return nil, err
+	}
+	signature, err := keypair.DecodeG1(result.Signature)
+	if err != nil {
+		return nil, errors.New("invalid round signature")
+	}
+	ephemeral, err := keypair.DecodeG2(c.Ephemeral)
+	if err != nil || bls12381.NewG2().IsZero(ephemeral) {
+		return nil, errors.New("invalid ephemeral key")
+	}
+	shared := bls12381.NewEngine().AddPair(signature, ephemeral).Result()
+	aead, err := chacha20poly1305.NewX(deriveKey(shared, c.Ephemeral))
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+	plaintext, err := aead.Open(nil, c.Nonce, c.Ciphertext, additionalData(c))
+	if err != nil {
+		return nil, errors.New("corrupt ciphertext or wrong group")
+	}
+	return plaintext, nil
+}
+
+// roundPoint point of G1 that group signs in given round
+func roundPoint(round uint64) (*bls12381.PointG1, error) {
+	encoded, err := keypair.BLSHashToPoint(beacon.Message(round, nil))
+	if err != nil {
+		return nil, err
+	}
+	point, err := keypair.DecodeG1(encoded)
+	if err != nil {
+		return nil, errors.New("invalid round point")
+	}
+	return point, nil
+}
+
+// deriveKey symmetric key from shared pairing value and ephemeral key
+func deriveKey(shared *bls12381.E, ephemeral []byte) []byte {
+	h := sha256.New()
+	h.Write(keyDomain)
+	h.Write(bls12381.NewGT().ToBytes(shared))
+	h.Write(ephemeral)
+	return h.Sum(nil)
+}
+
+// additionalData bind ciphertext to cipher, round and ephemeral key
+func additionalData(c *Ciphertext) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(c.Cipher)
+	binary.Write(buf, binary.BigEndian, c.Round)
+	buf.Write(c.Ephemeral)
+	return buf.Bytes()
+}
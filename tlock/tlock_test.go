@@ -0,0 +1,52 @@
+package tlock
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/keypair"
+)
+
+// signRound unchained round of a group whose key is k
+func signRound(t *testing.T, k *keypair.BLSKeyPair, round uint64) *beacon.RoundResult {
+	signature, err := k.Sign(beacon.Message(round, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &beacon.RoundResult{Round: round, Randomness: beacon.Randomness(signature), Signature: signature}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	group, err := keypair.NewBLS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("sealed until round 42")
+	c, err := Encrypt(group.PublicKey(), 42, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := Decrypt(group.PublicKey(), signRound(t, group, 42), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("decrypted %q, want %q", decrypted, plaintext)
+	}
+
+	if _, err := Decrypt(group.PublicKey(), signRound(t, group, 41), c); err != ErrRoundMismatch {
+		t.Fatalf("decrypted with another round: %v", err)
+	}
+	other, err := keypair.NewBLS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(other.PublicKey(), signRound(t, other, 42), c); err == nil {
+		t.Fatal("decrypted with round of another group")
+	}
+	c.Ciphertext[0] ^= 1
+	if _, err := Decrypt(group.PublicKey(), signRound(t, group, 42), c); err == nil {
+		t.Fatal("decrypted a corrupt ciphertext")
+	}
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/orochi-network/orochimaru/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Tracing traces round production with OpenTelemetry spans and exports them
+// over OTLP/HTTP, which Jaeger, Tempo and the OpenTelemetry collector accept.
+// Spans are no-ops until Setup is called.
+
+// DefaultServiceName service name of spans unless configured otherwise
+const DefaultServiceName = "drng"
+
+// tracesPath path of OTLP/HTTP traces endpoint, used if endpoint has no path
+const tracesPath = "/v1/traces"
+
+// batchTimeout longest wait before finished spans are exported
+const batchTimeout = 2 * time.Second
+
+// instrumentationName name of tracer of node
+const instrumentationName = "github.com/orochi-network/orochimaru"
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("tracing")
+}
+
+// Config tracing configuration
+type Config struct {
+	// Endpoint OTLP/HTTP endpoint, e.g. http://localhost:4318, spans are
+	// posted to /v1/traces unless it has a path of its own
+	Endpoint string
+	// ServiceName service of spans, DefaultServiceName if empty
+	ServiceName string
+	// NodeID identifies node among nodes of the same service
+	NodeID string
+	// SampleRatio fraction of rounds that are traced, between 0 and 1
+	SampleRatio float64
+}
+
+// Setup export spans to endpoint of conf. Returned function exports spans that
+// are not exported yet and stops exporting, it's called before node exits
+func Setup(conf Config) (func(context.Context) error, error) {
+	endpoint, err := TracesURL(conf.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if conf.SampleRatio < 0 || conf.SampleRatio > 1 {
+		return nil, errors.New("sample ratio must be between 0 and 1")
+	}
+	serviceName := conf.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	attrs := []resource.Option{resource.WithAttributes(semconv.ServiceNameKey.String(serviceName))}
+	if conf.NodeID != "" {
+		attrs = append(attrs, resource.WithAttributes(semconv.ServiceInstanceIDKey.String(conf.NodeID)))
+	}
+	res, err := resource.New(context.Background(), append(attrs, resource.WithSchemaURL(semconv.SchemaURL))...)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := NewExporter(context.Background(), endpoint)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(exporter, sdktrace.WithBatchTimeout(batchTimeout))),
+		sdktrace.WithResource(res),
+		// Spans of a round follow the sampling decision of the round
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warnf("Tracing error: %v", err)
+	}))
+	otel.SetTracerProvider(provider)
+	log.Infof("Spans are exported to %s, sample ratio: %g", endpoint, conf.SampleRatio)
+	return provider.Shutdown, nil
+}
+
+// TracesURL URL that spans are posted to from a configured endpoint
+func TracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("tracing endpoint %q is not an http or https URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+	return u.String(), nil
+}
+
+// Start span with given name, it's a child of span in ctx if there is one
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End span, a non-nil err marks span failed
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Run fn in a span with given name, an error returned by fn marks span failed
+func Run(ctx context.Context, name string, fn func() error) error {
+	_, span := Start(ctx, name)
+	err := fn()
+	End(span, err)
+	return err
+}
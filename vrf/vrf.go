@@ -0,0 +1,425 @@
+package vrf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/orochi-network/orochimaru/beacon"
+	"github.com/orochi-network/orochimaru/keypair"
+	"github.com/orochi-network/orochimaru/logger"
+	"github.com/orochi-network/orochimaru/network"
+	"go.uber.org/zap"
+)
+
+// On-demand randomness lets clients ask the group for randomness of a seed of
+// their own instead of waiting for the next beacon round. A member that gets
+// a request broadcasts the seed, every member signs a partial BLS signature of
+// it with its share and the group signature is recovered from threshold
+// partials. BLS signatures are unique, so the group signature is a VRF output
+// of the seed that anyone holding the group key can verify, and no member
+// learns it before threshold members sign.
+
+// Topic pubsub topic of randomness requests
+const Topic = "vrf"
+
+// Message types of randomness requests
+const (
+	MessageRequest = "vrf-request"
+	MessagePartial = "vrf-partial"
+)
+
+// MaxSeedSize largest seed of a request
+const MaxSeedSize = 256
+
+// DefaultTimeout time a request waits for threshold partials
+const DefaultTimeout = 30 * time.Second
+
+// IDSize size of request ID
+const IDSize = sha256.Size
+
+// maxPending requests waiting for partials, new requests are refused when
+// it's reached
+const maxPending = 1024
+
+// maxResults outputs kept for lookup, oldest are dropped first
+const maxResults = 4096
+
+// domain separates messages signed for requests from beacon rounds
+var domain = []byte("OROCHI-VRF")
+
+var (
+	errPending   = errors.New("too many pending requests")
+	errSeedSize  = errors.New("seed must be between 1 and 256 bytes")
+	errStopped   = errors.New("service is stopped")
+	errExpired   = errors.New("request expired before threshold partials arrived")
+	errNoRequest = errors.New("unknown request")
+)
+
+var log *zap.SugaredLogger
+
+func init() {
+	log = logger.Named("vrf")
+	network.SetPayloadLimit(MessageRequest, 1024)
+	network.SetPayloadLimit(MessagePartial, 512)
+}
+
+type requestPayload struct {
+	Seed []byte `json:"seed"`
+}
+
+type partialPayload struct {
+	ID      []byte `json:"id"`
+	Partial []byte `json:"partial"`
+}
+
+// Output randomness of a request
+type Output struct {
+	// ID message signed by group, SHA-256 of domain and seed
+	ID         []byte
+	Seed       []byte
+	Randomness []byte
+	Signature  []byte
+}
+
+// Message message that group signs for seed, it's also the ID of request
+func Message(seed []byte) []byte {
+	h := sha256.New()
+	h.Write(domain)
+	h.Write(seed)
+	return h.Sum(nil)
+}
+
+// Verify check output against group public key
+func Verify(groupKey []byte, output *Output) error {
+	if !bytes.Equal(output.ID, Message(output.Seed)) {
+		return errors.New("request ID does not match seed")
+	}
+	randomness := sha256.Sum256(output.Signature)
+	if !bytes.Equal(output.Randomness, randomness[:]) {
+		return errors.New("randomness does not match signature")
+	}
+	if ok, err := keypair.BLSVerify(groupKey, output.ID, output.Signature); err != nil || !ok {
+		return errors.New("signature is not a group signature of request")
+	}
+	return nil
+}
+
+// request state of a request waiting for partials
+type request struct {
+	// key of request in aggregator
+	key uint64
+	// seed nil while only partials of request were received
+	seed     []byte
+	deadline time.Time
+	// done closed once request is fulfilled or expired
+	done chan struct{}
+}
+
+// Service answer randomness requests of clients and peers with threshold
+// signatures of group
+type Service struct {
+	// Timeout time a request waits for partials, DefaultTimeout if it's 0
+	Timeout time.Duration
+	// OnOutput called with output of every fulfilled request, whether it was
+	// requested by this node or a peer
+	OnOutput func(*Output)
+
+	net        *network.Network
+	topic      string
+	share      *keypair.PriShare
+	pubPoly    *keypair.PubPoly
+	aggregator *beacon.Aggregator
+	requests   map[[IDSize]byte]*request
+	results    map[[IDSize]byte]*Output
+	// order IDs of results oldest first
+	order   [][IDSize]byte
+	nextKey uint64
+	stop    chan struct{}
+	mutex   sync.Mutex
+}
+
+// New create a service signing requests with share of group on topic, topic
+// is namespaced like topics of beacon
+func New(net *network.Network, topic string, share *keypair.PriShare, pubPoly *keypair.PubPoly) (*Service, error) {
+	if share == nil || pubPoly == nil {
+		return nil, errors.New("on-demand randomness needs a threshold beacon")
+	}
+	scheme, err := keypair.GetThresholdScheme(keypair.SchemeBLS)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		net:        net,
+		topic:      topic,
+		share:      share,
+		pubPoly:    pubPoly,
+		aggregator: beacon.NewAggregator(scheme, pubPoly, nil),
+		requests:   make(map[[IDSize]byte]*request),
+		results:    make(map[[IDSize]byte]*Output),
+		stop:       make(chan struct{}),
+	}, nil
+}
+
+// Start answer requests of peers until service is stopped
+func (s *Service) Start() error {
+	if s.Timeout <= 0 {
+		s.Timeout = DefaultTimeout
+	}
+	if err := s.net.Handle(s.topic, MessageRequest, s.handleRequest); err != nil {
+		return err
+	}
+	if err := s.net.Handle(s.topic, MessagePartial, s.handlePartial); err != nil {
+		s.net.RemoveHandler(s.topic, MessageRequest)
+		return err
+	}
+	go s.expireLoop()
+	return nil
+}
+
+// Stop answering requests, pending requests are left unfulfilled
+func (s *Service) Stop() {
+	s.net.RemoveHandler(s.topic, MessageRequest)
+	s.net.RemoveHandler(s.topic, MessagePartial)
+	close(s.stop)
+}
+
+// GroupKey public key that outputs are verified with
+func (s *Service) GroupKey() []byte {
+	return s.pubPoly.PublicKey()
+}
+
+// Request ask group for randomness of seed and return ID of request. Output
+// is looked up with ID once threshold members signed, a seed that was
+// requested before returns the same ID and output
+func (s *Service) Request(seed []byte) ([]byte, error) {
+	id, err := s.accept(seed)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(&requestPayload{Seed: seed})
+	if err != nil {
+		return nil, err
+	}
+	return id, s.net.Send(s.topic, MessageRequest, 0, payload)
+}
+
+// Lookup output of request with given ID, pending is true while request waits
+// for partials. Both are empty for unknown requests
+func (s *Service) Lookup(id []byte) (output *Output, pending bool) {
+	key, ok := requestID(id)
+	if !ok {
+		return nil, false
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if output, ok := s.results[key]; ok {
+		return output, false
+	}
+	req, ok := s.requests[key]
+	return nil, ok && req.seed != nil
+}
+
+// Wait wait for output of request with given ID until it's fulfilled, it
+// expires or ctx is done
+func (s *Service) Wait(ctx context.Context, id []byte) (*Output, error) {
+	key, ok := requestID(id)
+	if !ok {
+		return nil, errNoRequest
+	}
+	s.mutex.Lock()
+	output, fulfilled := s.results[key]
+	req, pending := s.requests[key]
+	s.mutex.Unlock()
+	if fulfilled {
+		return output, nil
+	}
+	if !pending {
+		return nil, errNoRequest
+	}
+	select {
+	case <-req.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.stop:
+		return nil, errStopped
+	}
+	s.mutex.Lock()
+	output, fulfilled = s.results[key]
+	s.mutex.Unlock()
+	if !fulfilled {
+		return nil, errExpired
+	}
+	return output, nil
+}
+
+// accept start signing seed if it's not known yet and broadcast partial of
+// this node
+func (s *Service) accept(seed []byte) ([]byte, error) {
+	if len(seed) == 0 || len(seed) > MaxSeedSize {
+		return nil, errSeedSize
+	}
+	message := Message(seed)
+	key, _ := requestID(message)
+	s.mutex.Lock()
+	if _, ok := s.results[key]; ok {
+		s.mutex.Unlock()
+		return message, nil
+	}
+	req, err := s.get(key)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+	if req.seed != nil {
+		s.mutex.Unlock()
+		return message, nil
+	}
+	req.seed = append([]byte(nil), seed...)
+	req.deadline = time.Now().Add(s.Timeout)
+	done := s.aggregator.Expect(req.key, message)
+	s.mutex.Unlock()
+	go s.await(key, req, done)
+
+	partial := keypair.SignPartial(s.share, message)
+	s.aggregator.Add(req.key, partial)
+	payload, err := json.Marshal(&partialPayload{ID: message, Partial: partial})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.net.Send(s.topic, MessagePartial, 0, payload); err != nil {
+		log.Warnf("Unable to send partial of request %x: %v", message, err)
+	}
+	return message, nil
+}
+
+// get pending request of ID, it's created if it's not pending. Caller must
+// hold mutex
+func (s *Service) get(key [IDSize]byte) (*request, error) {
+	if req, ok := s.requests[key]; ok {
+		return req, nil
+	}
+	if len(s.requests) >= maxPending {
+		return nil, errPending
+	}
+	s.nextKey++
+	req := &request{key: s.nextKey, deadline: time.Now().Add(s.Timeout), done: make(chan struct{})}
+	s.requests[key] = req
+	return req, nil
+}
+
+// await store output of request once its group signature is recovered
+func (s *Service) await(key [IDSize]byte, req *request, done <-chan []byte) {
+	var signature []byte
+	select {
+	case signature = <-done:
+	case <-req.done:
+		return
+	case <-s.stop:
+		return
+	}
+	randomness := sha256.Sum256(signature)
+	output := &Output{ID: key[:], Seed: req.seed, Randomness: randomness[:], Signature: signature}
+	s.mutex.Lock()
+	if s.requests[key] != req {
+		s.mutex.Unlock()
+		return
+	}
+	s.remove(key, req)
+	s.results[key] = output
+	s.order = append(s.order, key)
+	if len(s.order) > maxResults {
+		delete(s.results, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.mutex.Unlock()
+	log.Infof("Request %x fulfilled, randomness: %x", key, output.Randomness)
+	if s.OnOutput != nil {
+		s.OnOutput(output)
+	}
+}
+
+// remove pending request and wake up its waiters. Caller must hold mutex
+func (s *Service) remove(key [IDSize]byte, req *request) {
+	delete(s.requests, key)
+	s.aggregator.Remove(req.key)
+	close(req.done)
+}
+
+// expireLoop drop requests that were not fulfilled in time
+func (s *Service) expireLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.mutex.Lock()
+			oldest := s.nextKey + 1
+			for key, req := range s.requests {
+				if now.After(req.deadline) {
+					if req.seed != nil {
+						log.Warnf("Request %x expired with partials of shares %v", key, s.aggregator.Received(req.key))
+					}
+					s.remove(key, req)
+				} else if req.key < oldest {
+					oldest = req.key
+				}
+			}
+			// Keys only grow, partials added after their request was removed
+			// are dropped with keys older than every pending request
+			s.aggregator.Forget(oldest)
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// handleRequest sign seed requested by a peer
+func (s *Service) handleRequest(e *network.Envelope) {
+	payload := new(requestPayload)
+	if err := network.DecodePayload(e.Payload, payload); err != nil {
+		return
+	}
+	if _, err := s.accept(payload.Seed); err != nil {
+		log.Debugf("Drop request of %s: %v", e.Sender, err)
+	}
+}
+
+// handlePartial feed partials of peers to aggregator, partials that arrive
+// before their request wait for it until request expires
+func (s *Service) handlePartial(e *network.Envelope) {
+	payload := new(partialPayload)
+	if err := network.DecodePayload(e.Payload, payload); err != nil || len(payload.Partial) != keypair.PartialSignatureSize {
+		return
+	}
+	key, ok := requestID(payload.ID)
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	if _, ok := s.results[key]; ok {
+		s.mutex.Unlock()
+		return
+	}
+	req, err := s.get(key)
+	s.mutex.Unlock()
+	if err != nil {
+		return
+	}
+	s.aggregator.Add(req.key, payload.Partial)
+}
+
+// requestID fixed size ID, false if id has the wrong size
+func requestID(id []byte) ([IDSize]byte, bool) {
+	var key [IDSize]byte
+	if len(id) != IDSize {
+		return key, false
+	}
+	copy(key[:], id)
+	return key, true
+}